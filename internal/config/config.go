@@ -0,0 +1,214 @@
+// Package config contains server configuration and its loading from file, environment and flags.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"nova/pkg/resp"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// envPrefix is a prefix of environment variables overriding config parameters,
+	// e.g. NOVA_CLEANUP_INTERVAL overrides "cleanup-interval".
+	envPrefix = "NOVA_"
+
+	// configFlag is the name of command-line flag with path to config file.
+	configFlag = "config"
+)
+
+var (
+	ErrUnknownParam = errors.New("unknown configuration parameter")
+)
+
+// SaveRule means that snapshot should be saved if at least Changes keys were changed
+// in Seconds seconds.
+type SaveRule struct {
+	Seconds int
+	Changes int
+}
+
+// Config is a set of server tunables.
+type Config struct {
+	// File is a path to configuration file the config was loaded from (if any).
+	File string
+
+	Bind            string
+	Port            int
+	MaxClients      int
+	Timeout         time.Duration
+	ShutdownTimeout time.Duration
+
+	CleanupInterval time.Duration
+
+	LogLevel  string
+	LogFormat string
+
+	Dir        string
+	DBFilename string
+	Save       []SaveRule
+}
+
+// Default returns config with default values of all parameters.
+func Default() *Config {
+	return &Config{
+		Bind:            "localhost",
+		Port:            6379,
+		MaxClients:      10000,
+		Timeout:         0,
+		ShutdownTimeout: 10 * time.Second,
+
+		CleanupInterval: 1 * time.Minute,
+
+		LogLevel:  "info",
+		LogFormat: "json",
+
+		Dir:        ".",
+		DBFilename: "dump.rdb",
+		Save: []SaveRule{
+			{Seconds: 3600, Changes: 1},
+			{Seconds: 300, Changes: 100},
+			{Seconds: 60, Changes: 10000},
+		},
+	}
+}
+
+// Addr returns address the server should listen on.
+func (c *Config) Addr() string {
+	return net.JoinHostPort(c.Bind, strconv.Itoa(c.Port))
+}
+
+// Load builds config from command-line arguments (without program name).
+// Values are applied in the following order, each one overriding the previous:
+// defaults, config file (given via -config flag), environment variables, flags.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("nova", flag.ContinueOnError)
+	configFile := fs.String(configFlag, "", "path to configuration file")
+
+	// flag values are remembered and applied after config file and environment
+	type flagValue struct{ name, value string }
+	flagValues := []flagValue{}
+	for _, p := range params {
+		fs.Func(p.name, p.usage, func(value string) error {
+			flagValues = append(flagValues, flagValue{name: p.name, value: value})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range params {
+		envName := envPrefix + strings.ToUpper(strings.ReplaceAll(p.name, "-", "_"))
+		if value, ok := os.LookupEnv(envName); ok {
+			if err := cfg.Set(p.name, value); err != nil {
+				return nil, fmt.Errorf("environment variable %s: %w", envName, err)
+			}
+		}
+	}
+
+	for _, fv := range flagValues {
+		if err := cfg.Set(fv.name, fv.value); err != nil {
+			return nil, fmt.Errorf("flag -%s: %w", fv.name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadFile reads redis.conf-like file where every line is "<param> <value>".
+// Empty lines and lines starting with '#' are ignored.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	if err := c.parse(f); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	c.File = path
+
+	return nil
+}
+
+// parse applies parameters read from config file.
+// As in Redis, every "save" line adds rules to the ones from previous lines
+// (the first one replaces defaults), while "save" with empty value removes all of them.
+func (c *Config) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	saveSeen := false
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// quoting rules are the same as for inline commands
+		args, err := resp.SplitArgs(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		prevRules := c.Save
+		if err := c.Set(args[0], strings.Join(args[1:], " ")); err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if strings.EqualFold(args[0], "save") {
+			if saveSeen && len(c.Save) > 0 {
+				c.Save = slices.Concat(prevRules, c.Save)
+			}
+			saveSeen = true
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Set validates value and assigns it to the parameter with given name.
+func (c *Config) Set(name, value string) error {
+	p, ok := lookupParam(name)
+	if !ok {
+		return fmt.Errorf("%w '%s'", ErrUnknownParam, name)
+	}
+
+	if err := p.set(c, value); err != nil {
+		return fmt.Errorf("invalid value '%s' for '%s': %w", value, p.name, err)
+	}
+
+	return nil
+}
+
+// Get returns current value of the parameter with given name.
+func (c *Config) Get(name string) (string, bool) {
+	p, ok := lookupParam(name)
+	if !ok {
+		return "", false
+	}
+
+	return p.get(c), true
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSave(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  []SaveRule
+	}{
+		{
+			name:  "Default",
+			input: "port 6380\n",
+			want:  Default().Save,
+		},
+		{
+			name:  "Single line replaces defaults",
+			input: "save 900 1\n",
+			want:  []SaveRule{{Seconds: 900, Changes: 1}},
+		},
+		{
+			name:  "Multiple lines",
+			input: "save 900 1\nsave 300 10\n# comment\nsave 60 10000\n",
+			want:  []SaveRule{{Seconds: 900, Changes: 1}, {Seconds: 300, Changes: 10}, {Seconds: 60, Changes: 10000}},
+		},
+		{
+			name:  "Several rules in line",
+			input: "save \"900 1 300 10\"\nsave 60 10000\n",
+			want:  []SaveRule{{Seconds: 900, Changes: 1}, {Seconds: 300, Changes: 10}, {Seconds: 60, Changes: 10000}},
+		},
+		{
+			name:  "Empty disables",
+			input: "save 900 1\nsave \"\"\n",
+			want:  []SaveRule{},
+		},
+		{
+			name:  "Rules after empty",
+			input: "save \"\"\nsave 60 10\n",
+			want:  []SaveRule{{Seconds: 60, Changes: 10}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Default()
+			require.NoError(t, cfg.parse(strings.NewReader(test.input)))
+			assert.Equal(t, test.want, cfg.Save)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := `
+# network
+bind 0.0.0.0
+  port   6380
+timeout 30
+DIR "/var/lib/my nova"
+dbfilename 'dump "1".rdb'
+`
+	cfg := Default()
+	require.NoError(t, cfg.parse(strings.NewReader(input)))
+
+	assert.Equal(t, "0.0.0.0", cfg.Bind)
+	assert.Equal(t, 6380, cfg.Port)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Equal(t, "/var/lib/my nova", cfg.Dir)
+	assert.Equal(t, `dump "1".rdb`, cfg.DBFilename)
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Unknown parameter", input: "port 6380\nno-such-param yes\n", wantErr: "line 2: unknown configuration parameter 'no-such-param'"},
+		{name: "Invalid value", input: "port abc\n", wantErr: "line 1: invalid value 'abc' for 'port': not an integer"},
+		{name: "Unbalanced quotes", input: "dir \"/tmp\n", wantErr: "line 1: "},
+		{name: "Odd save rule", input: "save 900\n", wantErr: "line 1: invalid value '900' for 'save'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Default().parse(strings.NewReader(test.input))
+			require.Error(t, err)
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+
+	err := Default().parse(strings.NewReader("no-such-param yes\n"))
+	assert.ErrorIs(t, err, ErrUnknownParam)
+}
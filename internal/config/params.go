@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// param describes single configuration parameter.
+type param struct {
+	name  string
+	usage string
+	// immutable parameters cannot be changed while server is running
+	immutable bool
+
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+// params is a list of all supported parameters in the order they are written to config file.
+var params = []param{
+	{
+		name:      "bind",
+		immutable: true,
+		usage:     "interface to listen on",
+		get:       func(c *Config) string { return c.Bind },
+		set: func(c *Config, value string) error {
+			if value == "" {
+				return errors.New("must not be empty")
+			}
+			c.Bind = value
+			return nil
+		},
+	},
+	{
+		name:      "port",
+		immutable: true,
+		usage:     "port to listen on",
+		get:       func(c *Config) string { return strconv.Itoa(c.Port) },
+		set: func(c *Config, value string) error {
+			return setInt(&c.Port, value, 0, 65535)
+		},
+	},
+	{
+		name:  "maxclients",
+		usage: "max number of simultaneously connected clients",
+		get:   func(c *Config) string { return strconv.Itoa(c.MaxClients) },
+		set: func(c *Config, value string) error {
+			return setInt(&c.MaxClients, value, 1, 1<<31-1)
+		},
+	},
+	{
+		name:  "timeout",
+		usage: "close connection after client is idle for N seconds (0 to disable)",
+		get:   func(c *Config) string { return strconv.Itoa(int(c.Timeout / time.Second)) },
+		set: func(c *Config, value string) error {
+			var seconds int
+			if err := setInt(&seconds, value, 0, 1<<31-1); err != nil {
+				return err
+			}
+			c.Timeout = time.Duration(seconds) * time.Second
+			return nil
+		},
+	},
+	{
+		name:  "shutdown-timeout",
+		usage: "max time to wait for clients on shutdown (e.g. 10s)",
+		get:   func(c *Config) string { return c.ShutdownTimeout.String() },
+		set: func(c *Config, value string) error {
+			return setDuration(&c.ShutdownTimeout, value)
+		},
+	},
+	{
+		name:  "cleanup-interval",
+		usage: "interval between removals of expired keys (e.g. 1m)",
+		get:   func(c *Config) string { return c.CleanupInterval.String() },
+		set: func(c *Config, value string) error {
+			return setDuration(&c.CleanupInterval, value)
+		},
+	},
+	{
+		name:  "loglevel",
+		usage: "log level: debug, info, warn or error",
+		get:   func(c *Config) string { return c.LogLevel },
+		set: func(c *Config, value string) error {
+			level, err := zapcore.ParseLevel(value)
+			if err != nil {
+				return errors.New("unknown log level")
+			}
+			c.LogLevel = level.String()
+			return nil
+		},
+	},
+	{
+		name:      "logformat",
+		immutable: true,
+		usage:     "log format: json or console",
+		get:       func(c *Config) string { return c.LogFormat },
+		set: func(c *Config, value string) error {
+			value = strings.ToLower(value)
+			if value != "json" && value != "console" {
+				return errors.New("must be json or console")
+			}
+			c.LogFormat = value
+			return nil
+		},
+	},
+	{
+		name:  "dir",
+		usage: "directory where snapshot is stored",
+		get:   func(c *Config) string { return c.Dir },
+		set: func(c *Config, value string) error {
+			if value == "" {
+				return errors.New("must not be empty")
+			}
+			c.Dir = value
+			return nil
+		},
+	},
+	{
+		name:  "dbfilename",
+		usage: "name of snapshot file",
+		get:   func(c *Config) string { return c.DBFilename },
+		set: func(c *Config, value string) error {
+			if value == "" || filepath.Base(value) != value {
+				return errors.New("must be a file name without directory")
+			}
+			c.DBFilename = value
+			return nil
+		},
+	},
+	{
+		name:  "save",
+		usage: `snapshot rules as "<seconds> <changes>" pairs (empty to disable)`,
+		get:   func(c *Config) string { return formatSaveRules(c.Save) },
+		set: func(c *Config, value string) error {
+			rules, err := parseSaveRules(value)
+			if err != nil {
+				return err
+			}
+			c.Save = rules
+			return nil
+		},
+	},
+}
+
+func lookupParam(name string) (param, bool) {
+	name = strings.ToLower(name)
+	for _, p := range params {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return param{}, false
+}
+
+func setInt(dst *int, value string, minVal, maxVal int) error {
+	num, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("not an integer")
+	}
+	if num < minVal || num > maxVal {
+		return fmt.Errorf("must be in range [%d, %d]", minVal, maxVal)
+	}
+
+	*dst = num
+	return nil
+}
+
+// setDuration parses duration either in Go format (e.g. "1m30s") or as number of seconds.
+func setDuration(dst *time.Duration, value string) error {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		value = strconv.Itoa(seconds) + "s"
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.New("not a duration")
+	}
+	if d <= 0 {
+		return errors.New("must be positive")
+	}
+
+	*dst = d
+	return nil
+}
+
+func parseSaveRules(value string) ([]SaveRule, error) {
+	fields := strings.Fields(value)
+	if len(fields)%2 != 0 {
+		return nil, errors.New("must be pairs of seconds and changes")
+	}
+
+	rules := make([]SaveRule, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		var rule SaveRule
+		if err := setInt(&rule.Seconds, fields[i], 1, 1<<31-1); err != nil {
+			return nil, err
+		}
+		if err := setInt(&rule.Changes, fields[i+1], 0, 1<<31-1); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func formatSaveRules(rules []SaveRule) string {
+	parts := make([]string, 0, len(rules)*2)
+	for _, rule := range rules {
+		parts = append(parts, strconv.Itoa(rule.Seconds), strconv.Itoa(rule.Changes))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"nova/pkg/glob"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrImmutableParam = errors.New("can't set immutable config")
+	ErrNoConfigFile   = errors.New("the server is running without a config file")
+
+	rewriteHeader = "# Generated by CONFIG REWRITE"
+)
+
+// Runtime is a config shared by running server components.
+// It allows to read and change parameters while server is running.
+type Runtime struct {
+	mu  sync.RWMutex
+	cfg *Config
+
+	// hooks are called after parameter with corresponding name is changed
+	hooks map[string][]func(*Config)
+}
+
+// NewRuntime is a constructor for Runtime.
+func NewRuntime(cfg *Config) *Runtime {
+	return &Runtime{
+		cfg:   cfg.clone(),
+		hooks: map[string][]func(*Config){},
+	}
+}
+
+func (c *Config) clone() *Config {
+	cfg := *c
+	cfg.Save = slices.Clone(c.Save)
+	return &cfg
+}
+
+// Current returns a copy of current config.
+func (r *Runtime) Current() *Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cfg.clone()
+}
+
+// OnChange registers hook which applies new value of the parameter to a running component.
+func (r *Runtime) OnChange(name string, hook func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks[name] = append(r.hooks[name], hook)
+}
+
+// Get returns names and values of parameters matching any of glob patterns.
+// Result is a flat list of name-value pairs.
+func (r *Runtime) Get(patterns []string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []string{}
+	for _, p := range params {
+		for _, pattern := range patterns {
+			if glob.Match(pattern, p.name, true) {
+				result = append(result, p.name, p.get(r.cfg))
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// Set changes parameters given as a flat list of name-value pairs.
+// Either all parameters are changed or none of them.
+func (r *Runtime) Set(pairs []string) error {
+	if len(pairs)%2 != 0 {
+		return errors.New("parameters must be name-value pairs")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// values are validated on a copy, so that failure doesn't leave config half-changed
+	cfg := r.cfg.clone()
+	changed := []string{}
+	for i := 0; i < len(pairs); i += 2 {
+		p, ok := lookupParam(pairs[i])
+		if !ok {
+			return fmt.Errorf("%w '%s'", ErrUnknownParam, pairs[i])
+		}
+		if slices.Contains(changed, p.name) {
+			return fmt.Errorf("duplicate parameter '%s'", p.name)
+		}
+		if p.immutable {
+			return fmt.Errorf("%w '%s'", ErrImmutableParam, p.name)
+		}
+		if err := cfg.Set(p.name, pairs[i+1]); err != nil {
+			return err
+		}
+		changed = append(changed, p.name)
+	}
+
+	r.cfg = cfg
+	for _, name := range changed {
+		for _, hook := range r.hooks[name] {
+			hook(cfg.clone())
+		}
+	}
+
+	return nil
+}
+
+// Rewrite writes current config to the file it was loaded from.
+// Lines with known parameters are updated in place, comments and order are preserved.
+// Parameters which are absent in file and differ from default values are appended.
+func (r *Runtime) Rewrite() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.cfg.File == "" {
+		return ErrNoConfigFile
+	}
+
+	content, err := os.ReadFile(r.cfg.File)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	lines := []string{}
+	written := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == rewriteHeader {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			lines = append(lines, line)
+			continue
+		}
+
+		p, ok := lookupParam(fields[0])
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		// duplicates of already written parameter are dropped
+		if written[p.name] {
+			continue
+		}
+		lines = append(lines, formatParam(p, r.cfg))
+		written[p.name] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	defaults := Default()
+	header := false
+	for _, p := range params {
+		if written[p.name] || p.get(r.cfg) == p.get(defaults) {
+			continue
+		}
+		if !header {
+			lines = append(lines, rewriteHeader)
+			header = true
+		}
+		lines = append(lines, formatParam(p, r.cfg))
+	}
+
+	return writeFileAtomic(r.cfg.File, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// formatParam formats parameter as config file line.
+func formatParam(p param, c *Config) string {
+	return p.name + " " + quote(p.get(c))
+}
+
+// quote wraps value in double quotes if it cannot be written as is.
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'\\#") {
+		return value
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// writeFileAtomic writes data to temporary file and renames it,
+// so that config file is never left partially written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// temporary file is created with 0600 permissions, original ones are kept
+	if info, err := os.Stat(path); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to set config permissions: %w", err)
+		}
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close config: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+
+	return nil
+}
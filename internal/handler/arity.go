@@ -0,0 +1,175 @@
+package handler
+
+// cmdArity is the number of arguments of every command including its name.
+// Positive arity is the exact number, negative one is the minimum number (as in Redis).
+// Arity is checked before command is executed or queued in transaction,
+// handlers validate their syntax further.
+var cmdArity = map[string]int{
+	cmdPing:   -1,
+	cmdEcho:   2,
+	cmdHello:  -1,
+	cmdConfig: -2,
+	cmdInfo:   -1,
+
+	cmdSave:     1,
+	cmdBGSave:   -1,
+	cmdLastSave: 1,
+
+	cmdGet:    2,
+	cmdSet:    -3,
+	cmdDelete: -2,
+
+	cmdIncr:        2,
+	cmdDecr:        2,
+	cmdIncrBy:      3,
+	cmdDecrBy:      3,
+	cmdIncrByFloat: 3,
+	cmdAppend:      3,
+	cmdStrLen:      2,
+	cmdGetRange:    4,
+	cmdSetRange:    4,
+	cmdGetDel:      2,
+	cmdGetEx:       -2,
+	cmdGetSet:      3,
+	cmdMGet:        -2,
+	cmdMSet:        -3,
+	cmdMSetNX:      -3,
+
+	cmdExists:    -2,
+	cmdType:      2,
+	cmdKeys:      2,
+	cmdDBSize:    1,
+	cmdRandomKey: 1,
+	cmdScan:      -2,
+	cmdRename:    3,
+	cmdRenameNX:  3,
+	cmdCopy:      -3,
+	cmdMove:      3,
+	cmdTouch:     -2,
+	cmdUnlink:    -2,
+
+	cmdExpire:      -3,
+	cmdPExpire:     -3,
+	cmdExpireAt:    -3,
+	cmdPExpireAt:   -3,
+	cmdTTL:         2,
+	cmdPTTL:        2,
+	cmdExpireTime:  2,
+	cmdPExpireTime: 2,
+	cmdPersist:     2,
+
+	cmdRPush:  -3,
+	cmdLPush:  -3,
+	cmdLRange: 4,
+	cmdLPop:   -2,
+	cmdLLen:   2,
+
+	cmdRPop:      -2,
+	cmdLIndex:    3,
+	cmdLSet:      4,
+	cmdLInsert:   5,
+	cmdLRem:      4,
+	cmdLTrim:     4,
+	cmdLPos:      -3,
+	cmdLPushX:    -3,
+	cmdRPushX:    -3,
+	cmdLMove:     5,
+	cmdRPopLPush: 3,
+	cmdLMPop:     -4,
+
+	cmdBLPop:      -3,
+	cmdBRPop:      -3,
+	cmdBLMove:     6,
+	cmdBRPopLPush: 4,
+	cmdBLMPop:     -5,
+
+	cmdHSet:         -4,
+	cmdHMSet:        -4,
+	cmdHSetNX:       4,
+	cmdHGet:         3,
+	cmdHMGet:        -3,
+	cmdHDel:         -3,
+	cmdHExists:      3,
+	cmdHLen:         2,
+	cmdHKeys:        2,
+	cmdHVals:        2,
+	cmdHGetAll:      2,
+	cmdHIncrBy:      4,
+	cmdHIncrByFloat: 4,
+	cmdHStrLen:      3,
+	cmdHRandField:   -2,
+	cmdHScan:        -3,
+
+	cmdSAdd:        -3,
+	cmdSRem:        -3,
+	cmdSIsMember:   3,
+	cmdSMIsMember:  -3,
+	cmdSMembers:    2,
+	cmdSCard:       2,
+	cmdSPop:        -2,
+	cmdSRandMember: -2,
+	cmdSMove:       4,
+	cmdSInter:      -2,
+	cmdSUnion:      -2,
+	cmdSDiff:       -2,
+	cmdSInterStore: -3,
+	cmdSUnionStore: -3,
+	cmdSDiffStore:  -3,
+	cmdSInterCard:  -3,
+
+	cmdZAdd:        -4,
+	cmdZRem:        -3,
+	cmdZScore:      3,
+	cmdZMScore:     -3,
+	cmdZIncrBy:     4,
+	cmdZCard:       2,
+	cmdZCount:      4,
+	cmdZRank:       -3,
+	cmdZRevRank:    -3,
+	cmdZRange:      -4,
+	cmdZRangeStore: -5,
+	cmdZPopMin:     -2,
+	cmdZPopMax:     -2,
+	cmdZUnionStore: -4,
+	cmdZInterStore: -4,
+
+	cmdXAdd:       -5,
+	cmdXRange:     -4,
+	cmdXRevRange:  -4,
+	cmdXLen:       2,
+	cmdXRead:      -4,
+	cmdXDel:       -3,
+	cmdXTrim:      -4,
+	cmdXGroup:     -2,
+	cmdXReadGroup: -7,
+	cmdXAck:       -4,
+	cmdXPending:   -3,
+	cmdXClaim:     -6,
+	cmdXAutoClaim: -6,
+
+	cmdSubscribe:    -2,
+	cmdUnsubscribe:  -1,
+	cmdPSubscribe:   -2,
+	cmdPUnsubscribe: -1,
+	cmdSSubscribe:   -2,
+	cmdSUnsubscribe: -1,
+	cmdPublish:      3,
+	cmdSPublish:     3,
+	cmdPubSub:       -2,
+
+	cmdMulti:   1,
+	cmdExec:    1,
+	cmdDiscard: 1,
+	cmdWatch:   -2,
+	cmdUnwatch: 1,
+}
+
+// validArity reports whether number of arguments matches arity of the command.
+// Command MUST BE known.
+func validArity(cmd string, args []string) bool {
+	arity := cmdArity[cmd]
+	if arity < 0 {
+		return len(args) >= -arity
+	}
+	return len(args) == arity
+}
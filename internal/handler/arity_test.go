@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArityDefined(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	for cmd := range h.dict {
+		assert.Contains(t, cmdArity, cmd)
+	}
+	for cmd := range cmdArity {
+		assert.Contains(t, h.dict, cmd)
+	}
+}
+
+func TestArity(t *testing.T) {
+	h, ctx := newTestHandler(t)
+
+	var tests = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "Exact", args: []string{"GET", "key"}, want: "$-1\r\n"},
+		{name: "Exact too many", args: []string{"GET", "key", "extra"}, want: "-Wrong number of arguments for 'get' command\r\n"},
+		{name: "Exact too few", args: []string{"HGET", "key"}, want: "-Wrong number of arguments for 'hget' command\r\n"},
+		{name: "Minimum", args: []string{"DEL", "a", "b", "c"}, want: ":0\r\n"},
+		{name: "Below minimum", args: []string{"RPUSH", "key"}, want: "-Wrong number of arguments for 'rpush' command\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"nova/internal/pubsub"
+	"nova/internal/storage"
+	"nova/pkg/resp"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	protocolRESP2 = 2
+	protocolRESP3 = 3
+)
+
+// client holds state of a single connection.
+type client struct {
+	id   uint64
+	name string
+
+	// protocol is a version of RESP negotiated via HELLO command.
+	// It's read by publishers of messages, so it's accessed atomically.
+	protocol atomic.Int64
+
+	// push writes message to the connection out of request-response order,
+	// it's nil if command is executed outside of connection.
+	push func([]byte)
+
+	// channels, patterns and shardChannels are pub/sub subscriptions of the client.
+	channels      map[string]struct{}
+	patterns      map[string]struct{}
+	shardChannels map[string]struct{}
+
+	// tx is a transaction started with MULTI, it's nil outside of transaction.
+	tx *transaction
+	// executing is true while queued commands of transaction are executed.
+	executing bool
+	// watches are keys watched for modifications by WATCH commands.
+	watches []storage.Watch
+}
+
+// newClient is a constructor for client. It uses RESP2 until another version is negotiated.
+func newClient(id uint64, push func([]byte)) *client {
+	c := &client{
+		id:            id,
+		push:          push,
+		channels:      map[string]struct{}{},
+		patterns:      map[string]struct{}{},
+		shardChannels: map[string]struct{}{},
+	}
+	c.protocol.Store(int64(protocolRESP2))
+
+	return c
+}
+
+// protocolVersion returns RESP version used by the client.
+func (c *client) protocolVersion() int {
+	return int(c.protocol.Load())
+}
+
+// resp3 reports whether the client uses RESP3.
+func (c *client) resp3() bool {
+	return c.protocolVersion() == protocolRESP3
+}
+
+// subscriptions returns number of channels and patterns the client is subscribed to.
+// Shard channels are counted separately.
+func (c *client) subscriptions() int {
+	return len(c.channels) + len(c.patterns)
+}
+
+// subscribed reports whether the client has any pub/sub subscription.
+func (c *client) subscribed() bool {
+	return c.subscriptions()+len(c.shardChannels) > 0
+}
+
+// watchedModified reports whether any of watched keys was modified.
+func (c *client) watchedModified() bool {
+	for _, w := range c.watches {
+		if w.Modified() {
+			return true
+		}
+	}
+	return false
+}
+
+// unwatch forgets all watched keys.
+func (c *client) unwatch() {
+	for _, w := range c.watches {
+		w.Release()
+	}
+	c.watches = nil
+}
+
+// Message implements pubsub.Subscriber. Message is pushed as RESP3 push type
+// or as an ordinary array to RESP2 clients, which can't issue commands while subscribed.
+func (c *client) Message(kind pubsub.Kind, pattern, channel, message string) {
+	if c.push == nil {
+		return
+	}
+
+	elems := []string{string(kind), channel, message}
+	if kind == pubsub.KindPMessage {
+		elems = []string{string(kind), pattern, channel, message}
+	}
+	c.push(c.encodePush(elems))
+}
+
+// encodePush encodes out-of-band message according to client's protocol version.
+func (c *client) encodePush(elems []string) []byte {
+	encoded := make([][]byte, len(elems))
+	for i, elem := range elems {
+		encoded[i] = resp.EncodeString(elem)
+	}
+	return c.encodePushRaw(encoded)
+}
+
+// encodePushRaw encodes already encoded elements of out-of-band message.
+func (c *client) encodePushRaw(elems [][]byte) []byte {
+	if c.resp3() {
+		return resp.EncodePush(elems)
+	}
+	return resp.EncodeRawArray(elems)
+}
+
+type clientKey struct{}
+
+func withClient(ctx context.Context, c *client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// clientFromContext returns connection state stored in context.
+// If there is no such state (e.g. command is executed outside of connection),
+// state of a new RESP2 client is returned.
+func clientFromContext(ctx context.Context) *client {
+	c, ok := ctx.Value(clientKey{}).(*client)
+	if !ok {
+		return newClient(0, nil)
+	}
+	return c
+}
+
+// encodeNull encodes null bulk string according to client's protocol version.
+func (c *client) encodeNull() []byte {
+	if c.resp3() {
+		return resp.Null
+	}
+	return resp.NullString
+}
+
+// encodeNilArray encodes null array according to client's protocol version.
+func (c *client) encodeNilArray() []byte {
+	if c.resp3() {
+		return resp.Null
+	}
+	return resp.NilArray
+}
+
+// encodeMap encodes map as RESP3 map or as flat array for RESP2 clients.
+func (c *client) encodeMap(pairs [][]byte) []byte {
+	if c.resp3() {
+		return resp.EncodeMap(pairs)
+	}
+	return resp.EncodeRawArray(pairs)
+}
+
+// encodeSet encodes elements as RESP3 set or as array for RESP2 clients.
+func (c *client) encodeSet(elems [][]byte) []byte {
+	if c.resp3() {
+		return resp.EncodeSet(elems)
+	}
+	return resp.EncodeRawArray(elems)
+}
+
+// encodeDouble encodes number as RESP3 double or as bulk string for RESP2 clients.
+func (c *client) encodeDouble(num float64) []byte {
+	if c.resp3() {
+		return resp.EncodeDouble(num)
+	}
+	return resp.EncodeString(formatFloat(num))
+}
+
+// formatFloat formats float number the same way as Redis does in replies.
+func formatFloat(num float64) string {
+	switch {
+	case math.IsInf(num, 1):
+		return "inf"
+	case math.IsInf(num, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(num, 'f', -1, 64)
+	}
+}
@@ -17,8 +17,6 @@ import (
 type handlerFunc func(context.Context, []string) []byte
 
 var (
-	// TODO: implement info command
-	// cmdInfo   = "info"
 	cmdPing   = "ping"
 	cmdEcho   = "echo"
 	cmdGet    = "get"
@@ -35,7 +33,6 @@ var (
 	ErrUnknownCmd        = "Unknown command"
 	ErrWrongNumberOfArgs = "Wrong number of arguments for '%s' command"
 	ErrSyntax            = "syntax error"
-	ErrProtocol          = "Protocol error"
 	ErrWrongType         = "WRONGTYPE Operation against a key holding the wrong kind of value"
 	ErrInvalidInt        = "Value is not an integer or out of range"
 	ErrNegativeVal       = "value is out of range, must be positive"
@@ -52,6 +49,17 @@ func (h *Handler) pingHandler(ctx context.Context, args []string) []byte {
 	response := "PONG"
 
 	log := l.FromContext(ctx)
+
+	// subscribed RESP2 client gets reply in the same shape as pushed messages
+	if c := clientFromContext(ctx); c.subscribed() && !c.resp3() {
+		msg := ""
+		if len(args) > 1 {
+			msg = args[1]
+		}
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeArray([]string{strings.ToLower(response), msg})
+	}
+
 	log.Info(responseMsg, zap.String("response", response))
 	return resp.EncodeSimpleString(response)
 }
@@ -85,7 +93,7 @@ func (h *Handler) getHandler(ctx context.Context, args []string) []byte {
 	switch err {
 	case storage.ErrKeyNotFound:
 		log.Info(responseMsg, zap.String("response", nullString))
-		return resp.NullString
+		return clientFromContext(ctx).encodeNull()
 	case storage.ErrWrongType:
 		log.Info(responseMsg, zap.String("response", ErrWrongType))
 		return resp.EncodeError(ErrWrongType)
@@ -95,46 +103,97 @@ func (h *Handler) getHandler(ctx context.Context, args []string) []byte {
 	}
 }
 
+// setHandler sets string value of the key.
+// Syntax: SET key value [NX | XX] [GET] [EX seconds | PX milliseconds |
+// EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]
 func (h *Handler) setHandler(ctx context.Context, args []string) []byte {
 	log := l.FromContext(ctx)
 
-	switch {
-	case len(args) == 3:
-		key, value := args[1], args[2]
-		h.storage.Set(key, value, 0)
-
-		log.Info(responseMsg, zap.String("response", "OK"))
-		return resp.EncodeSimpleString("OK")
-
-	case len(args) < 3:
-		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmdGet)
+	if len(args) < 3 {
+		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmdSet)
 		log.Info(responseMsg, zap.String("response", response))
 		return resp.EncodeError(response)
+	}
+	key, value := args[1], args[2]
+
+	var (
+		opts storage.SetOptions
+		// expiry is set when one of EX, PX, EXAT, PXAT or KEEPTTL options is met
+		expiry string
+	)
+	for i := 3; i < len(args); i++ {
+		option := strings.ToLower(args[i])
+		switch option {
+		case "nx", "xx":
+			if opts.NX || opts.XX {
+				log.Info(responseMsg, zap.String("response", ErrSyntax))
+				return resp.EncodeError(ErrSyntax)
+			}
+			opts.NX, opts.XX = option == "nx", option == "xx"
+
+		case "get":
+			opts.Get = true
 
-	case len(args) > 3:
-		if strings.ToLower(args[3]) == "px" {
-			if len(args) > 5 {
+		case "keepttl":
+			if expiry != "" {
 				log.Info(responseMsg, zap.String("response", ErrSyntax))
 				return resp.EncodeError(ErrSyntax)
 			}
+			expiry = option
+			opts.KeepTTL = true
 
-			ttl, err := strconv.Atoi(args[4])
-			if err != nil {
-				// TODO: should be completely another error. I'll definitely fix it
+		case "ex", "px", "exat", "pxat":
+			if expiry != "" || i+1 == len(args) {
 				log.Info(responseMsg, zap.String("response", ErrSyntax))
 				return resp.EncodeError(ErrSyntax)
 			}
+			expiry = option
+			i++
+
+			num, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+				return resp.EncodeError(ErrInvalidInt)
+			}
+
+			unit := time.Second
+			if option == "px" || option == "pxat" {
+				unit = time.Millisecond
+			}
+			expiresAt, ok := expireTime(num, unit, option == "exat" || option == "pxat")
+			if num <= 0 || !ok {
+				response := fmt.Sprintf(ErrInvalidExpire, cmdSet)
+				log.Info(responseMsg, zap.String("response", response))
+				return resp.EncodeError(response)
+			}
+			opts.ExpiresAt = expiresAt
 
-			key, value := args[1], args[2]
-			h.storage.Set(key, value, time.Duration(ttl)*time.Millisecond)
-			log.Info(responseMsg, zap.String("response", "OK"))
-			return resp.EncodeSimpleString("OK")
+		default:
+			log.Info(responseMsg, zap.String("response", ErrSyntax))
+			return resp.EncodeError(ErrSyntax)
 		}
+	}
 
-		log.Info(responseMsg, zap.String("response", ErrSyntax))
-		return resp.EncodeError(ErrSyntax)
+	result, err := h.storage.SetWithOptions(key, value, opts)
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
 	}
 
+	c := clientFromContext(ctx)
+	switch {
+	case opts.Get && !result.Existed:
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNull()
+	case opts.Get:
+		log.Info(responseMsg, zap.String("response", result.Old))
+		return resp.EncodeString(result.Old)
+	case !result.Set:
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNull()
+	}
+
+	log.Info(responseMsg, zap.String("response", "OK"))
 	return resp.EncodeSimpleString("OK")
 }
 
@@ -228,49 +287,7 @@ func (h *Handler) lRangeHandler(ctx context.Context, args []string) []byte {
 }
 
 func (h *Handler) lPopHandler(ctx context.Context, args []string) []byte {
-	log := l.FromContext(ctx)
-
-	if len(args) < 2 || len(args) > 3 {
-		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmdLPop)
-		log.Info(responseMsg, zap.String("response", response))
-		return resp.EncodeError(response)
-	}
-
-	key := args[1]
-
-	if len(args) == 2 {
-		value, err := h.storage.LPop(key, 1)
-		if errors.Is(err, storage.ErrWrongType) {
-			log.Info(responseMsg, zap.String("response", ErrWrongType))
-			return resp.EncodeError(ErrWrongType)
-		}
-		if errors.Is(err, storage.ErrKeyNotFound) {
-			log.Info(responseMsg, zap.String("response", nullString))
-			return resp.NullString
-		}
-
-		log.Info(responseMsg, zap.String("response", value[0]))
-		return resp.EncodeString(value[0])
-	}
-
-	// len(args) == 3
-	n, err := strconv.Atoi(args[2])
-	if err != nil {
-		log.Info(responseMsg, zap.String("response", ErrNegativeVal))
-		return resp.EncodeError(ErrNegativeVal)
-	}
-
-	values, err := h.storage.LPop(key, n)
-	if errors.Is(err, storage.ErrWrongType) {
-		log.Info(responseMsg, zap.String("response", ErrWrongType))
-		return resp.EncodeError(ErrWrongType)
-	}
-	if errors.Is(err, storage.ErrKeyNotFound) {
-		log.Info(responseMsg, zap.String("response", nullString))
-		return resp.EncodeError(nullString)
-	}
-	log.Info(responseMsg, zap.Strings("response", values))
-	return resp.EncodeArray(values)
+	return h.popGeneric(ctx, args, cmdLPop, true)
 }
 
 func (h *Handler) lLenHandler(ctx context.Context, args []string) []byte {
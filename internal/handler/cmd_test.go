@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	var tests = []struct {
+		name  string
+		setup [][]string
+		args  []string
+		want  string
+		// check is a command checking the result of SET
+		check     []string
+		wantCheck string
+	}{
+		{name: "Set", args: []string{"SET", "key", "value"}, want: "+OK\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "Overwrite", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value"}, want: "+OK\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "Overwrite other type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"SET", "key", "value"}, want: "+OK\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "Overwrite clears TTL", setup: [][]string{{"SET", "key", "old", "EX", "100"}}, args: []string{"SET", "key", "value"}, want: "+OK\r\n", check: []string{"TTL", "key"}, wantCheck: ":-1\r\n"},
+
+		{name: "NX missing key", args: []string{"SET", "key", "value", "NX"}, want: "+OK\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "NX existing key", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value", "nx"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$3\r\nold\r\n"},
+		{name: "XX missing key", args: []string{"SET", "key", "value", "XX"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "XX existing key", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value", "XX"}, want: "+OK\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+
+		{name: "GET missing key", args: []string{"SET", "key", "value", "GET"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "GET existing key", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value", "GET"}, want: "$3\r\nold\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "GET other type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"SET", "key", "value", "GET"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"LLEN", "key"}, wantCheck: ":1\r\n"},
+		{name: "NX GET existing key", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value", "NX", "GET"}, want: "$3\r\nold\r\n", check: []string{"GET", "key"}, wantCheck: "$3\r\nold\r\n"},
+		{name: "XX GET missing key", args: []string{"SET", "key", "value", "GET", "XX"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+
+		{name: "EX", args: []string{"SET", "key", "value", "EX", "100"}, want: "+OK\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "PX", args: []string{"SET", "key", "value", "px", "100000"}, want: "+OK\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "EXAT", args: []string{"SET", "key", "value", "EXAT", "32503680000"}, want: "+OK\r\n", check: []string{"EXPIRETIME", "key"}, wantCheck: ":32503680000\r\n"},
+		{name: "PXAT", args: []string{"SET", "key", "value", "PXAT", "32503680000123"}, want: "+OK\r\n", check: []string{"PEXPIRETIME", "key"}, wantCheck: ":32503680000123\r\n"},
+		{name: "KEEPTTL", setup: [][]string{{"SET", "key", "old", "EX", "100"}}, args: []string{"SET", "key", "value", "KEEPTTL"}, want: "+OK\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "XX with EX", setup: [][]string{{"SET", "key", "old"}}, args: []string{"SET", "key", "value", "XX", "EX", "100"}, want: "+OK\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "NX not set keeps TTL", setup: [][]string{{"SET", "key", "old", "EX", "100"}}, args: []string{"SET", "key", "value", "NX", "EX", "200"}, want: "$-1\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+
+		{name: "NX and XX", args: []string{"SET", "key", "value", "NX", "XX"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX and PX", args: []string{"SET", "key", "value", "EX", "100", "PX", "100"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX and KEEPTTL", args: []string{"SET", "key", "value", "EX", "100", "KEEPTTL"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "KEEPTTL and PXAT", args: []string{"SET", "key", "value", "KEEPTTL", "PXAT", "100"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX without value", args: []string{"SET", "key", "value", "EX"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "Unknown option", args: []string{"SET", "key", "value", "FOREVER"}, want: "-syntax error\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX not an integer", args: []string{"SET", "key", "value", "EX", "1.5"}, want: "-Value is not an integer or out of range\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX zero", args: []string{"SET", "key", "value", "EX", "0"}, want: "-invalid expire time in 'set' command\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "PX negative", args: []string{"SET", "key", "value", "PX", "-100"}, want: "-invalid expire time in 'set' command\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "EX overflow", args: []string{"SET", "key", "value", "EX", "9223372036854775"}, want: "-invalid expire time in 'set' command\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "Wrong number of arguments", args: []string{"SET", "key"}, want: "-Wrong number of arguments for 'set' command\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			for _, args := range test.setup {
+				serve(ctx, h, args...)
+			}
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+			assert.Equal(t, test.wantCheck, serve(ctx, h, test.check...))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdHello = "hello"
+)
+
+var (
+	ErrNoProto         = "NOPROTO unsupported protocol version"
+	ErrInvalidProtover = "Protocol version is not an integer or out of range"
+	ErrWrongPass       = "WRONGPASS invalid username-password pair or user is disabled."
+
+	// defaultUser is the only user, it doesn't require password.
+	defaultUser = "default"
+)
+
+var (
+	serverName    = "nova"
+	serverVersion = "0.1.0"
+)
+
+// helloHandler switches protocol version of the connection and returns server info.
+// Syntax: HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (h *Handler) helloHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+	c := clientFromContext(ctx)
+
+	protocol := c.protocolVersion()
+	if len(args) > 1 {
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			log.Info(responseMsg, zap.String("response", ErrInvalidProtover))
+			return resp.EncodeError(ErrInvalidProtover)
+		}
+		if version != protocolRESP2 && version != protocolRESP3 {
+			log.Info(responseMsg, zap.String("response", ErrNoProto))
+			return resp.EncodeError(ErrNoProto)
+		}
+		protocol = version
+	}
+
+	name, nameSet := c.name, false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "auth":
+			if i+2 >= len(args) {
+				log.Info(responseMsg, zap.String("response", ErrSyntax))
+				return resp.EncodeError(ErrSyntax)
+			}
+			// there is no ACL yet, so only default user without password is allowed
+			if args[i+1] != defaultUser {
+				log.Info(responseMsg, zap.String("response", ErrWrongPass))
+				return resp.EncodeError(ErrWrongPass)
+			}
+			i += 2
+		case "setname":
+			if i+1 >= len(args) {
+				log.Info(responseMsg, zap.String("response", ErrSyntax))
+				return resp.EncodeError(ErrSyntax)
+			}
+			name, nameSet = args[i+1], true
+			i++
+		default:
+			log.Info(responseMsg, zap.String("response", ErrSyntax))
+			return resp.EncodeError(ErrSyntax)
+		}
+	}
+
+	// connection state is changed only if the whole command is valid
+	c.protocol.Store(int64(protocol))
+	if nameSet {
+		c.name = name
+	}
+
+	log.Info(responseMsg, zap.Int("protocol", protocol))
+	return c.encodeMap([][]byte{
+		resp.EncodeString("server"), resp.EncodeString(serverName),
+		resp.EncodeString("version"), resp.EncodeString(serverVersion),
+		resp.EncodeString("proto"), resp.EncodeInt(protocol),
+		resp.EncodeString("id"), resp.EncodeInt(int(c.id)),
+		resp.EncodeString("mode"), resp.EncodeString("standalone"),
+		resp.EncodeString("role"), resp.EncodeString("master"),
+		resp.EncodeString("modules"), resp.EncodeArray([]string{}),
+	})
+}
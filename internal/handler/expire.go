@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdExpire      = "expire"
+	cmdPExpire     = "pexpire"
+	cmdExpireAt    = "expireat"
+	cmdPExpireAt   = "pexpireat"
+	cmdTTL         = "ttl"
+	cmdPTTL        = "pttl"
+	cmdExpireTime  = "expiretime"
+	cmdPExpireTime = "pexpiretime"
+	cmdPersist     = "persist"
+)
+
+var (
+	ErrInvalidExpire     = "invalid expire time in '%s' command"
+	ErrUnsupportedOption = "Unsupported option %s"
+	ErrNXAndXXGTLT       = "NX and XX, GT or LT options at the same time are not compatible"
+	ErrGTAndLT           = "GT and LT options at the same time are not compatible"
+)
+
+var (
+	// ttlNoKey and ttlNoExpire are replies of TTL family for missing keys and keys without expiry.
+	ttlNoKey    = -2
+	ttlNoExpire = -1
+)
+
+func (h *Handler) expireHandler(ctx context.Context, args []string) []byte {
+	return h.expireGeneric(ctx, args, cmdExpire, time.Second, false)
+}
+
+func (h *Handler) pExpireHandler(ctx context.Context, args []string) []byte {
+	return h.expireGeneric(ctx, args, cmdPExpire, time.Millisecond, false)
+}
+
+func (h *Handler) expireAtHandler(ctx context.Context, args []string) []byte {
+	return h.expireGeneric(ctx, args, cmdExpireAt, time.Second, true)
+}
+
+func (h *Handler) pExpireAtHandler(ctx context.Context, args []string) []byte {
+	return h.expireGeneric(ctx, args, cmdPExpireAt, time.Millisecond, true)
+}
+
+// expireGeneric implements EXPIRE, PEXPIRE, EXPIREAT and PEXPIREAT.
+// Syntax: <cmd> key time [NX | XX] [GT | LT]
+// unit is a unit of time argument, absolute shows whether it's a unix timestamp or TTL.
+func (h *Handler) expireGeneric(ctx context.Context, args []string, cmd string, unit time.Duration, absolute bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmd)
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeError(response)
+	}
+
+	key := args[1]
+	num, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+
+	var opts storage.ExpireOptions
+	for _, arg := range args[3:] {
+		switch strings.ToLower(arg) {
+		case "nx":
+			opts.NX = true
+		case "xx":
+			opts.XX = true
+		case "gt":
+			opts.GT = true
+		case "lt":
+			opts.LT = true
+		default:
+			response := fmt.Sprintf(ErrUnsupportedOption, arg)
+			log.Info(responseMsg, zap.String("response", response))
+			return resp.EncodeError(response)
+		}
+	}
+	if opts.NX && (opts.XX || opts.GT || opts.LT) {
+		log.Info(responseMsg, zap.String("response", ErrNXAndXXGTLT))
+		return resp.EncodeError(ErrNXAndXXGTLT)
+	}
+	if opts.GT && opts.LT {
+		log.Info(responseMsg, zap.String("response", ErrGTAndLT))
+		return resp.EncodeError(ErrGTAndLT)
+	}
+
+	expiresAt, ok := expireTime(num, unit, absolute)
+	if !ok {
+		response := fmt.Sprintf(ErrInvalidExpire, cmd)
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeError(response)
+	}
+
+	result := 0
+	if h.storage.Expire(key, expiresAt, opts) {
+		result = 1
+	}
+
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// expireTime converts TTL or unix timestamp in given unit to expiration time.
+// It returns false if result doesn't fit into int64 milliseconds.
+func expireTime(num int64, unit time.Duration, absolute bool) (time.Time, bool) {
+	ms := num
+	if unit == time.Second {
+		if num > math.MaxInt64/1000 || num < math.MinInt64/1000 {
+			return time.Time{}, false
+		}
+		ms = num * 1000
+	}
+
+	if !absolute {
+		now := time.Now().UnixMilli()
+		if ms > math.MaxInt64-now {
+			return time.Time{}, false
+		}
+		ms += now
+	}
+
+	return time.UnixMilli(ms), true
+}
+
+func (h *Handler) ttlHandler(ctx context.Context, args []string) []byte {
+	return h.ttlGeneric(ctx, args, cmdTTL, time.Second, false)
+}
+
+func (h *Handler) pTTLHandler(ctx context.Context, args []string) []byte {
+	return h.ttlGeneric(ctx, args, cmdPTTL, time.Millisecond, false)
+}
+
+func (h *Handler) expireTimeHandler(ctx context.Context, args []string) []byte {
+	return h.ttlGeneric(ctx, args, cmdExpireTime, time.Second, true)
+}
+
+func (h *Handler) pExpireTimeHandler(ctx context.Context, args []string) []byte {
+	return h.ttlGeneric(ctx, args, cmdPExpireTime, time.Millisecond, true)
+}
+
+// ttlGeneric implements TTL, PTTL, EXPIRETIME and PEXPIRETIME.
+// It returns -2 if key doesn't exist and -1 if key has no expiration time.
+func (h *Handler) ttlGeneric(ctx context.Context, args []string, cmd string, unit time.Duration, absolute bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmd)
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeError(response)
+	}
+
+	expiresAt, err := h.storage.ExpiresAt(args[1])
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		log.Info(responseMsg, zap.Int("response", ttlNoKey))
+		return resp.EncodeInt(ttlNoKey)
+	}
+	if expiresAt.IsZero() {
+		log.Info(responseMsg, zap.Int("response", ttlNoExpire))
+		return resp.EncodeInt(ttlNoExpire)
+	}
+
+	var result int64
+	remaining := max(expiresAt.UnixMilli()-time.Now().UnixMilli(), 0)
+	switch {
+	case absolute && unit == time.Second:
+		result = expiresAt.Unix()
+	case absolute:
+		result = expiresAt.UnixMilli()
+	case unit == time.Second:
+		// remaining TTL is rounded like in Redis
+		result = (remaining + 500) / 1000
+	default:
+		result = remaining
+	}
+
+	log.Info(responseMsg, zap.Int64("response", result))
+	return resp.EncodeInt(int(result))
+}
+
+// persistHandler removes expiration time of the key.
+// Syntax: PERSIST key
+func (h *Handler) persistHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		response := fmt.Sprintf(ErrWrongNumberOfArgs, cmdPersist)
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeError(response)
+	}
+
+	result := 0
+	if h.storage.Persist(args[1]) {
+		result = 1
+	}
+
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
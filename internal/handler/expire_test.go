@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpire(t *testing.T) {
+	var tests = []struct {
+		name string
+		// ttl is TTL of the key in milliseconds before the command, "" means no expiration
+		ttl  string
+		args []string
+		want string
+		// check is a command checking the result, it's TTL of the key by default
+		check     []string
+		wantCheck string
+	}{
+		{name: "Set TTL", args: []string{"EXPIRE", "key", "50"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "Replace TTL", ttl: "100000", args: []string{"EXPIRE", "key", "50"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "Missing key", args: []string{"EXPIRE", "nokey", "50"}, want: ":0\r\n", wantCheck: ":-1\r\n"},
+		{name: "PEXPIRE", args: []string{"PEXPIRE", "key", "50000"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "EXPIREAT", args: []string{"EXPIREAT", "key", "32503680000"}, want: ":1\r\n", check: []string{"EXPIRETIME", "key"}, wantCheck: ":32503680000\r\n"},
+		{name: "PEXPIREAT", args: []string{"PEXPIREAT", "key", "32503680000123"}, want: ":1\r\n", check: []string{"EXPIRETIME", "key"}, wantCheck: ":32503680000\r\n"},
+		{name: "Negative TTL deletes key", args: []string{"EXPIRE", "key", "-1"}, want: ":1\r\n", wantCheck: ":-2\r\n"},
+		{name: "Time in the past deletes key", args: []string{"EXPIREAT", "key", "1"}, want: ":1\r\n", wantCheck: ":-2\r\n"},
+
+		{name: "NX without TTL", args: []string{"EXPIRE", "key", "50", "NX"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "NX with TTL", ttl: "100000", args: []string{"EXPIRE", "key", "50", "nx"}, want: ":0\r\n", wantCheck: ":100\r\n"},
+		{name: "XX without TTL", args: []string{"EXPIRE", "key", "50", "XX"}, want: ":0\r\n", wantCheck: ":-1\r\n"},
+		{name: "XX with TTL", ttl: "100000", args: []string{"EXPIRE", "key", "50", "XX"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "GT without TTL", args: []string{"EXPIRE", "key", "50", "GT"}, want: ":0\r\n", wantCheck: ":-1\r\n"},
+		{name: "GT greater", ttl: "100000", args: []string{"EXPIRE", "key", "200", "GT"}, want: ":1\r\n", wantCheck: ":200\r\n"},
+		{name: "GT less", ttl: "100000", args: []string{"EXPIRE", "key", "50", "GT"}, want: ":0\r\n", wantCheck: ":100\r\n"},
+		{name: "LT without TTL", args: []string{"EXPIRE", "key", "50", "LT"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "LT less", ttl: "100000", args: []string{"EXPIRE", "key", "50", "LT"}, want: ":1\r\n", wantCheck: ":50\r\n"},
+		{name: "LT greater", ttl: "100000", args: []string{"EXPIRE", "key", "200", "LT"}, want: ":0\r\n", wantCheck: ":100\r\n"},
+		{name: "XX and GT", ttl: "100000", args: []string{"EXPIRE", "key", "200", "XX", "GT"}, want: ":1\r\n", wantCheck: ":200\r\n"},
+
+		{name: "NX and XX", args: []string{"EXPIRE", "key", "50", "NX", "XX"}, want: "-NX and XX, GT or LT options at the same time are not compatible\r\n", wantCheck: ":-1\r\n"},
+		{name: "NX and GT", args: []string{"EXPIRE", "key", "50", "NX", "GT"}, want: "-NX and XX, GT or LT options at the same time are not compatible\r\n", wantCheck: ":-1\r\n"},
+		{name: "GT and LT", args: []string{"EXPIRE", "key", "50", "GT", "LT"}, want: "-GT and LT options at the same time are not compatible\r\n", wantCheck: ":-1\r\n"},
+		{name: "Unsupported option", args: []string{"EXPIRE", "key", "50", "KEEPTTL"}, want: "-Unsupported option KEEPTTL\r\n", wantCheck: ":-1\r\n"},
+		{name: "Not an integer", args: []string{"EXPIRE", "key", "1.5"}, want: "-Value is not an integer or out of range\r\n", wantCheck: ":-1\r\n"},
+		{name: "Overflow", args: []string{"EXPIRE", "key", "9223372036854775"}, want: "-invalid expire time in 'expire' command\r\n", wantCheck: ":-1\r\n"},
+		{name: "PEXPIRE overflow", args: []string{"PEXPIRE", "key", "9223372036854775807"}, want: "-invalid expire time in 'pexpire' command\r\n", wantCheck: ":-1\r\n"},
+		{name: "Wrong number of arguments", args: []string{"EXPIRE", "key"}, want: "-Wrong number of arguments for 'expire' command\r\n", wantCheck: ":-1\r\n"},
+
+		{name: "PERSIST with TTL", ttl: "100000", args: []string{"PERSIST", "key"}, want: ":1\r\n", wantCheck: ":-1\r\n"},
+		{name: "PERSIST without TTL", args: []string{"PERSIST", "key"}, want: ":0\r\n", wantCheck: ":-1\r\n"},
+		{name: "PERSIST missing key", args: []string{"PERSIST", "nokey"}, want: ":0\r\n", wantCheck: ":-1\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			if test.ttl == "" {
+				serve(ctx, h, "SET", "key", "value")
+			} else {
+				serve(ctx, h, "SET", "key", "value", "PX", test.ttl)
+			}
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+
+			check := test.check
+			if check == nil {
+				check = []string{"TTL", "key"}
+			}
+			assert.Equal(t, test.wantCheck, serve(ctx, h, check...))
+		})
+	}
+}
+
+func TestTTL(t *testing.T) {
+	var tests = []struct {
+		name string
+		// ttl is TTL of the key in milliseconds, "" means no expiration
+		ttl  string
+		args []string
+		want string
+	}{
+		{name: "TTL of missing key", args: []string{"TTL", "nokey"}, want: ":-2\r\n"},
+		{name: "TTL without expiration", args: []string{"TTL", "key"}, want: ":-1\r\n"},
+		{name: "TTL is rounded down", ttl: "1300", args: []string{"TTL", "key"}, want: ":1\r\n"},
+		{name: "TTL is rounded up", ttl: "1700", args: []string{"TTL", "key"}, want: ":2\r\n"},
+		{name: "PTTL of missing key", args: []string{"PTTL", "nokey"}, want: ":-2\r\n"},
+		{name: "PTTL without expiration", args: []string{"PTTL", "key"}, want: ":-1\r\n"},
+		{name: "EXPIRETIME of missing key", args: []string{"EXPIRETIME", "nokey"}, want: ":-2\r\n"},
+		{name: "PEXPIRETIME without expiration", args: []string{"PEXPIRETIME", "key"}, want: ":-1\r\n"},
+		{name: "Wrong number of arguments", args: []string{"TTL", "key", "key"}, want: "-Wrong number of arguments for 'ttl' command\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			if test.ttl == "" {
+				serve(ctx, h, "SET", "key", "value")
+			} else {
+				serve(ctx, h, "SET", "key", "value", "PX", test.ttl)
+			}
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
+
+func TestPTTL(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "SET", "key", "value")
+	assert.Equal(t, ":1\r\n", serve(ctx, h, "PEXPIREAT", "key", "32503680000123"))
+
+	assert.Equal(t, ":32503680000123\r\n", serve(ctx, h, "PEXPIRETIME", "key"))
+	assert.Equal(t, ":32503680000\r\n", serve(ctx, h, "EXPIRETIME", "key"))
+
+	assert.Equal(t, ":1\r\n", serve(ctx, h, "PEXPIRE", "key", "5000"))
+	pttl, err := strconv.Atoi(strings.Trim(serve(ctx, h, "PTTL", "key"), ":\r\n"))
+	require.NoError(t, err)
+	assert.InDelta(t, 5000, pttl, 100)
+}
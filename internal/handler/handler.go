@@ -3,9 +3,14 @@ package handler
 import (
 	"context"
 	"fmt"
+	"nova/internal/pubsub"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
 	l "nova/pkg/logger"
 	"nova/pkg/resp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -13,59 +18,425 @@ import (
 
 type Storage interface {
 	Set(key, value string, ttl time.Duration)
+	SetWithOptions(key, value string, opts storage.SetOptions) (storage.SetResult, error)
 	Get(key string) (string, error)
 	DeleteMany(keys []string) int
 
+	IncrBy(key string, delta int) (int, error)
+	IncrByFloat(key string, delta float64) (string, error)
+	Append(key, value string) (int, error)
+	StrLen(key string) (int, error)
+	GetRange(key string, start, end int) (string, error)
+	SetRange(key string, offset int, value string) (int, error)
+	GetDel(key string) (string, error)
+	GetEx(key string, opts storage.GetExOptions) (string, error)
+	MGet(keys []string) (values []string, ok []bool)
+	MSet(pairs []string)
+	MSetNX(pairs []string) bool
+
 	RPush(key string, values []string) (int, error)
 	LPush(key string, values []string) (int, error)
 	LRange(key string, start, stop int) ([]string, error)
 	LPop(key string, n int) ([]string, error)
 	ListLen(key string) (int, error)
+	RPop(key string, n int) ([]string, error)
+	LIndex(key string, index int) (string, error)
+	LSet(key string, index int, value string) error
+	LInsert(key string, before bool, pivot, value string) (int, error)
+	LRem(key string, count int, value string) (int, error)
+	LTrim(key string, start, stop int) error
+	LPos(key, element string, opts storage.LPosOptions) ([]int, error)
+	PushX(key string, values []string, left bool) (int, error)
+	LMove(src, dst string, srcLeft, dstLeft bool) (string, error)
+	LMPop(keys []string, left bool, count int) (string, []string, error)
+	// BLMPop and BLMove block until ctx is done if there are no elements to pop.
+	BLMPop(ctx context.Context, keys []string, left bool, count int) (string, []string, error)
+	BLMove(ctx context.Context, src, dst string, srcLeft, dstLeft bool) (string, error)
+	BlockedClients() int
+	// ServeBlocked serves clients blocked on keys which were modified since the last call.
+	ServeBlocked()
+
+	HSet(key string, pairs []string) (int, error)
+	HSetNX(key, field, value string) (bool, error)
+	HGet(key, field string) (string, error)
+	HMGet(key string, fields []string) (values []string, ok []bool, err error)
+	HDel(key string, fields []string) (int, error)
+	HExists(key, field string) (bool, error)
+	HLen(key string) (int, error)
+	HGetAll(key string) (fields, values []string, err error)
+	HIncrBy(key, field string, delta int) (int, error)
+	HIncrByFloat(key, field string, delta float64) (string, error)
+	HStrLen(key, field string) (int, error)
+	HRandField(key string, count int) (fields, values []string, err error)
+	HScan(key string, cursor uint64, count int, pattern string) (uint64, []string, error)
+
+	SAdd(key string, members []string) (int, error)
+	SRem(key string, members []string) (int, error)
+	SMIsMember(key string, members []string) ([]bool, error)
+	SMembers(key string) ([]string, error)
+	SCard(key string) (int, error)
+	SPop(key string, count int) ([]string, error)
+	SRandMember(key string, count int) ([]string, error)
+	SMove(src, dst, member string) (bool, error)
+	SetAlgebra(op storage.SetOp, keys []string) ([]string, error)
+	SetAlgebraStore(op storage.SetOp, dst string, keys []string) (int, error)
+	SInterCard(keys []string, limit int) (int, error)
+
+	ZAdd(key string, members []ds.ScoredMember, opts storage.ZAddOptions) (int, error)
+	ZIncrBy(key, member string, delta float64, opts storage.ZAddOptions) (float64, bool, error)
+	ZRem(key string, members []string) (int, error)
+	ZMScore(key string, members []string) (scores []float64, ok []bool, err error)
+	ZCard(key string) (int, error)
+	ZCount(key string, min, max ds.ScoreBound) (int, error)
+	ZRank(key, member string, reverse bool) (int, float64, error)
+	ZRange(key string, query storage.ZRangeQuery) ([]ds.ScoredMember, error)
+	ZRangeStore(dst, src string, query storage.ZRangeQuery) (int, error)
+	ZPop(key string, count int, max bool) ([]ds.ScoredMember, error)
+	ZStore(op storage.SetOp, dst string, keys []string, weights []float64, agg storage.Aggregate) (int, error)
+
+	XAdd(key string, fields []string, opts storage.XAddOptions) (ds.StreamID, error)
+	XLen(key string) (int, error)
+	XRange(key string, start, end ds.StreamID, reverse bool, count int) ([]ds.StreamEntry, error)
+	XDel(key string, ids []ds.StreamID) (int, error)
+	XTrim(key string, opts storage.XTrimOptions) (int, error)
+	XRead(streams []storage.StreamRead, count int) ([]storage.StreamEntries, error)
+	XGroupCreate(key, group string, id ds.StreamID, fromLast, mkStream bool) error
+	XGroupSetID(key, group string, id ds.StreamID, fromLast bool) error
+	XGroupDestroy(key, group string) (bool, error)
+	XGroupCreateConsumer(key, group, consumer string) (bool, error)
+	XGroupDelConsumer(key, group, consumer string) (int, error)
+	XReadGroup(streams []storage.StreamRead, opts storage.XReadGroupOptions) ([]storage.StreamEntries, error)
+	// XReadBlock and XReadGroupBlock block until ctx is done if there are no entries to read.
+	XReadBlock(ctx context.Context, streams []storage.StreamRead, count int) ([]storage.StreamEntries, error)
+	XReadGroupBlock(ctx context.Context, streams []storage.StreamRead, opts storage.XReadGroupOptions) ([]storage.StreamEntries, error)
+	XAck(key, group string, ids []ds.StreamID) (int, error)
+	XPendingSummary(key, group string) (storage.XPendingSummary, error)
+	XPending(key, group string, query storage.XPendingQuery) ([]ds.PendingEntry, error)
+	XClaim(key, group, consumer string, ids []ds.StreamID, opts storage.XClaimOptions) ([]ds.StreamEntry, error)
+	XAutoClaim(key, group, consumer string, minIdle time.Duration, start ds.StreamID, count int, justID bool) (ds.StreamID, []ds.StreamEntry, []ds.StreamID, error)
+
+	// Watch starts watching keys for modifications until watch is released.
+	Watch(keys []string) storage.Watch
+
+	Expire(key string, expiresAt time.Time, opts storage.ExpireOptions) bool
+	Persist(key string) bool
+	ExpiresAt(key string) (time.Time, error)
+
+	KeyspaceStats() (keys, expires int)
+	Exists(keys []string) int
+	Type(key string) string
+	Keys(pattern string) []string
+	DBSize() int
+	RandomKey() (string, bool)
+	Scan(cursor uint64, count int, pattern, valueType string) (uint64, []string)
+	Rename(src, dst string, nx bool) (bool, error)
+	Copy(src, dst string, replace bool) (bool, error)
+	Touch(keys []string) int
+	Unlink(keys []string) int
+}
+
+// Config provides access to server configuration at runtime.
+type Config interface {
+	// Get returns flat list of name-value pairs of parameters matching patterns.
+	Get(patterns []string) []string
+	// Set atomically changes parameters given as flat list of name-value pairs.
+	Set(pairs []string) error
+	// Rewrite saves current configuration to the config file.
+	Rewrite() error
 }
 
 type Handler struct {
-	storage Storage
-	dict    map[string]handlerFunc
+	storage     Storage
+	config      Config
+	server      Server
+	persistence Persistence
+	pubsub      *pubsub.Broker
+	dict        map[string]handlerFunc
+
+	// execMu makes transactions atomic: EXEC holds it exclusively, while
+	// other commands share it. Blocking commands release it while they
+	// wait for other clients (see blockingContext).
+	execMu sync.RWMutex
+
+	// stats are created for every command once and never modified later,
+	// so map can be read concurrently
+	stats     map[string]*cmdStats
+	startTime time.Time
+
+	// clientCounter is used to assign unique ids to connections
+	clientCounter atomic.Uint64
 }
 
-func NewHandler(storage Storage) *Handler {
+func NewHandler(storage Storage, opts ...Option) *Handler {
 	h := &Handler{
-		storage: storage,
+		storage:   storage,
+		pubsub:    pubsub.NewBroker(),
+		startTime: time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
 
 	dict := map[string]handlerFunc{
-		cmdPing: h.pingHandler,
-		cmdEcho: h.echoHandler,
+		cmdPing:  h.pingHandler,
+		cmdEcho:  h.echoHandler,
+		cmdHello: h.helloHandler,
+
+		cmdConfig: h.configHandler,
+		cmdInfo:   h.infoHandler,
+
+		cmdSave:     h.saveHandler,
+		cmdBGSave:   h.bgSaveHandler,
+		cmdLastSave: h.lastSaveHandler,
 
 		cmdGet:    h.getHandler,
 		cmdSet:    h.setHandler,
 		cmdDelete: h.deleteHandler,
 
+		cmdIncr:        h.incrHandler,
+		cmdDecr:        h.decrHandler,
+		cmdIncrBy:      h.incrByHandler,
+		cmdDecrBy:      h.decrByHandler,
+		cmdIncrByFloat: h.incrByFloatHandler,
+		cmdAppend:      h.appendHandler,
+		cmdStrLen:      h.strLenHandler,
+		cmdGetRange:    h.getRangeHandler,
+		cmdSetRange:    h.setRangeHandler,
+		cmdGetDel:      h.getDelHandler,
+		cmdGetEx:       h.getExHandler,
+		cmdGetSet:      h.getSetHandler,
+		cmdMGet:        h.mGetHandler,
+		cmdMSet:        h.mSetHandler,
+		cmdMSetNX:      h.mSetNXHandler,
+
+		cmdExists:    h.existsHandler,
+		cmdType:      h.typeHandler,
+		cmdKeys:      h.keysHandler,
+		cmdDBSize:    h.dbSizeHandler,
+		cmdRandomKey: h.randomKeyHandler,
+		cmdScan:      h.scanHandler,
+		cmdRename:    h.renameHandler,
+		cmdRenameNX:  h.renameNXHandler,
+		cmdCopy:      h.copyHandler,
+		cmdMove:      h.moveHandler,
+		cmdTouch:     h.touchHandler,
+		cmdUnlink:    h.unlinkHandler,
+
+		cmdExpire:      h.expireHandler,
+		cmdPExpire:     h.pExpireHandler,
+		cmdExpireAt:    h.expireAtHandler,
+		cmdPExpireAt:   h.pExpireAtHandler,
+		cmdTTL:         h.ttlHandler,
+		cmdPTTL:        h.pTTLHandler,
+		cmdExpireTime:  h.expireTimeHandler,
+		cmdPExpireTime: h.pExpireTimeHandler,
+		cmdPersist:     h.persistHandler,
+
 		cmdRPush:  h.rPushHandler,
 		cmdLPush:  h.lPushHandler,
 		cmdLRange: h.lRangeHandler,
 		cmdLPop:   h.lPopHandler,
 		cmdLLen:   h.lLenHandler,
+
+		cmdRPop:      h.rPopHandler,
+		cmdLIndex:    h.lIndexHandler,
+		cmdLSet:      h.lSetHandler,
+		cmdLInsert:   h.lInsertHandler,
+		cmdLRem:      h.lRemHandler,
+		cmdLTrim:     h.lTrimHandler,
+		cmdLPos:      h.lPosHandler,
+		cmdLPushX:    h.lPushXHandler,
+		cmdRPushX:    h.rPushXHandler,
+		cmdLMove:     h.lMoveHandler,
+		cmdRPopLPush: h.rPopLPushHandler,
+		cmdLMPop:     h.lMPopHandler,
+
+		cmdBLPop:      h.bLPopHandler,
+		cmdBRPop:      h.bRPopHandler,
+		cmdBLMove:     h.bLMoveHandler,
+		cmdBRPopLPush: h.bRPopLPushHandler,
+		cmdBLMPop:     h.bLMPopHandler,
+
+		cmdHSet:         h.hSetHandler,
+		cmdHMSet:        h.hMSetHandler,
+		cmdHSetNX:       h.hSetNXHandler,
+		cmdHGet:         h.hGetHandler,
+		cmdHMGet:        h.hMGetHandler,
+		cmdHDel:         h.hDelHandler,
+		cmdHExists:      h.hExistsHandler,
+		cmdHLen:         h.hLenHandler,
+		cmdHKeys:        h.hKeysHandler,
+		cmdHVals:        h.hValsHandler,
+		cmdHGetAll:      h.hGetAllHandler,
+		cmdHIncrBy:      h.hIncrByHandler,
+		cmdHIncrByFloat: h.hIncrByFloatHandler,
+		cmdHStrLen:      h.hStrLenHandler,
+		cmdHRandField:   h.hRandFieldHandler,
+		cmdHScan:        h.hScanHandler,
+
+		cmdSAdd:        h.sAddHandler,
+		cmdSRem:        h.sRemHandler,
+		cmdSIsMember:   h.sIsMemberHandler,
+		cmdSMIsMember:  h.sMIsMemberHandler,
+		cmdSMembers:    h.sMembersHandler,
+		cmdSCard:       h.sCardHandler,
+		cmdSPop:        h.sPopHandler,
+		cmdSRandMember: h.sRandMemberHandler,
+		cmdSMove:       h.sMoveHandler,
+		cmdSInter:      h.sInterHandler,
+		cmdSUnion:      h.sUnionHandler,
+		cmdSDiff:       h.sDiffHandler,
+		cmdSInterStore: h.sInterStoreHandler,
+		cmdSUnionStore: h.sUnionStoreHandler,
+		cmdSDiffStore:  h.sDiffStoreHandler,
+		cmdSInterCard:  h.sInterCardHandler,
+
+		cmdZAdd:        h.zAddHandler,
+		cmdZRem:        h.zRemHandler,
+		cmdZScore:      h.zScoreHandler,
+		cmdZMScore:     h.zMScoreHandler,
+		cmdZIncrBy:     h.zIncrByHandler,
+		cmdZCard:       h.zCardHandler,
+		cmdZCount:      h.zCountHandler,
+		cmdZRank:       h.zRankHandler,
+		cmdZRevRank:    h.zRevRankHandler,
+		cmdZRange:      h.zRangeHandler,
+		cmdZRangeStore: h.zRangeStoreHandler,
+		cmdZPopMin:     h.zPopMinHandler,
+		cmdZPopMax:     h.zPopMaxHandler,
+		cmdZUnionStore: h.zUnionStoreHandler,
+		cmdZInterStore: h.zInterStoreHandler,
+
+		cmdXAdd:       h.xAddHandler,
+		cmdXRange:     h.xRangeHandler,
+		cmdXRevRange:  h.xRevRangeHandler,
+		cmdXLen:       h.xLenHandler,
+		cmdXRead:      h.xReadHandler,
+		cmdXDel:       h.xDelHandler,
+		cmdXTrim:      h.xTrimHandler,
+		cmdXGroup:     h.xGroupHandler,
+		cmdXReadGroup: h.xReadGroupHandler,
+		cmdXAck:       h.xAckHandler,
+		cmdXPending:   h.xPendingHandler,
+		cmdXClaim:     h.xClaimHandler,
+		cmdXAutoClaim: h.xAutoClaimHandler,
+
+		cmdSubscribe:    h.subscribeHandler,
+		cmdUnsubscribe:  h.unsubscribeHandler,
+		cmdPSubscribe:   h.pSubscribeHandler,
+		cmdPUnsubscribe: h.pUnsubscribeHandler,
+		cmdSSubscribe:   h.sSubscribeHandler,
+		cmdSUnsubscribe: h.sUnsubscribeHandler,
+		cmdPublish:      h.publishHandler,
+		cmdSPublish:     h.sPublishHandler,
+		cmdPubSub:       h.pubSubHandler,
+
+		cmdMulti:   h.multiHandler,
+		cmdExec:    h.execHandler,
+		cmdDiscard: h.discardHandler,
+		cmdWatch:   h.watchHandler,
+		cmdUnwatch: h.unwatchHandler,
 	}
 
 	h.dict = dict
+	h.stats = make(map[string]*cmdStats, len(dict))
+	for cmd := range dict {
+		h.stats[cmd] = &cmdStats{}
+	}
+
 	return h
 }
 
-func (h *Handler) Serve(ctx context.Context, input []byte) []byte {
+// SetServer provides network server statistics for INFO command.
+// It must be called before server starts accepting connections.
+func (h *Handler) SetServer(server Server) {
+	h.server = server
+}
+
+// Connect creates state for a new connection.
+// Returned context must be used as a parent for every request of this connection.
+// push is used to deliver pub/sub messages to the connection.
+func (h *Handler) Connect(ctx context.Context, push func([]byte)) context.Context {
+	c := newClient(h.clientCounter.Add(1), push)
+
+	return withClient(ctx, c)
+}
+
+// Disconnect releases state of the closed connection.
+func (h *Handler) Disconnect(ctx context.Context) {
+	c := clientFromContext(ctx)
+	h.unsubscribeAll(c)
+	c.unwatch()
+}
+
+// Serve executes command with given arguments and returns encoded response.
+// First argument is a command name, the rest are its arguments.
+func (h *Handler) Serve(ctx context.Context, args []string) []byte {
 	log := l.FromContext(ctx)
 
-	args, err := resp.Decode(input)
-	if err != nil {
-		return resp.EncodeError(fmt.Sprintf(ErrProtocol, err.Error()))
-	}
 	log.Info("decoded request", zap.Strings("args", args))
 
+	c := clientFromContext(ctx)
 	cmd := strings.ToLower(args[0])
-	handler, ok := h.dict[cmd]
-	if !ok {
+	if _, ok := h.dict[cmd]; !ok {
+		// transaction with command which can't be queued is discarded on EXEC
+		if c.tx != nil {
+			c.tx.aborted = true
+		}
 		return resp.EncodeError(ErrUnknownCmd)
 	}
+	if !validArity(cmd, args) {
+		if c.tx != nil {
+			c.tx.aborted = true
+		}
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	// replies of RESP2 client can't be told apart from pushed messages
+	if _, ok := subscribedContextCmds[cmd]; !ok && c.subscribed() && !c.resp3() {
+		response := fmt.Sprintf(ErrSubscribedContext, cmd)
+		log.Info(responseMsg, zap.String("response", response))
+		return resp.EncodeError(response)
+	}
+
+	if _, ok := notQueuedCmds[cmd]; ok && c.tx != nil {
+		c.tx.aborted = true
+		log.Info(responseMsg, zap.String("response", ErrNotAllowedInMulti))
+		return resp.EncodeError(ErrNotAllowedInMulti)
+	}
+	if _, ok := txControlCmds[cmd]; !ok && c.tx != nil {
+		c.tx.queue = append(c.tx.queue, args)
+		log.Info(responseMsg, zap.String("response", "QUEUED"))
+		return resp.EncodeSimpleString("QUEUED")
+	}
+
+	// EXEC acquires the lock exclusively by itself and serves blocked clients under it
+	if cmd == cmdExec {
+		return h.call(ctx, args)
+	}
+
+	h.execMu.RLock()
+	defer h.execMu.RUnlock()
+
+	response := h.call(ctx, args)
+	// clients blocked on keys are served once the command is complete,
+	// so they never run in the middle of it
+	h.storage.ServeBlocked()
+	return response
+}
+
+// call executes command with its handler and updates command statistics.
+// Command MUST BE known.
+func (h *Handler) call(ctx context.Context, args []string) []byte {
+	cmd := strings.ToLower(args[0])
+
+	start := time.Now()
+	response := h.dict[cmd](ctx, args)
+
+	stats := h.stats[cmd]
+	stats.calls.Add(1)
+	stats.usec.Add(uint64(time.Since(start).Microseconds()))
 
-	return handler(ctx, args)
+	return response
 }
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	mapstorage "nova/internal/storage/map"
+	l "nova/pkg/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// newTestHandler returns handler backed by a new storage and context of a connected client.
+func newTestHandler(t *testing.T, opts ...Option) (*Handler, context.Context) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	h := NewHandler(mapstorage.New(ctx), opts...)
+	return h, connect(t, h)
+}
+
+// connect returns context of another client connected to the handler.
+func connect(t *testing.T, h *Handler) context.Context {
+	t.Helper()
+
+	connCtx := l.WithLogger(h.Connect(t.Context(), nil), zap.NewNop())
+	t.Cleanup(func() { h.Disconnect(connCtx) })
+
+	return connCtx
+}
+
+// serve executes command given as separate arguments.
+func serve(ctx context.Context, h *Handler, args ...string) string {
+	return string(h.Serve(ctx, args))
+}
+
+// commandTest is a test case of command run after setup commands.
+// If check is not empty, it's run after the command and its reply is compared with wantCheck.
+type commandTest struct {
+	name      string
+	setup     [][]string
+	args      []string
+	want      string
+	check     []string
+	wantCheck string
+}
+
+func runCommandTests(t *testing.T, tests []commandTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			for _, args := range test.setup {
+				serve(ctx, h, args...)
+			}
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+			if test.check != nil {
+				assert.Equal(t, test.wantCheck, serve(ctx, h, test.check...))
+			}
+		})
+	}
+}
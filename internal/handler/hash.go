@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdHSet         = "hset"
+	cmdHMSet        = "hmset"
+	cmdHSetNX       = "hsetnx"
+	cmdHGet         = "hget"
+	cmdHMGet        = "hmget"
+	cmdHDel         = "hdel"
+	cmdHExists      = "hexists"
+	cmdHLen         = "hlen"
+	cmdHKeys        = "hkeys"
+	cmdHVals        = "hvals"
+	cmdHGetAll      = "hgetall"
+	cmdHIncrBy      = "hincrby"
+	cmdHIncrByFloat = "hincrbyfloat"
+	cmdHStrLen      = "hstrlen"
+	cmdHRandField   = "hrandfield"
+	cmdHScan        = "hscan"
+)
+
+var (
+	ErrHashNotInteger = "hash value is not an integer"
+	ErrHashNotFloat   = "hash value is not a float"
+	ErrValueRange     = "value is out of range"
+)
+
+// maxRandCount limits negative count of HRANDFIELD and SRANDMEMBER. Elements may repeat
+// in that case, so reply size depends on count only and must be bounded.
+const maxRandCount = 1 << 24
+
+// hSetHandler sets fields of the hash and returns number of added ones.
+// Syntax: HSET key field value [field value ...]
+func (h *Handler) hSetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 || len(args)%2 != 0 {
+		return h.wrongNumberOfArgs(ctx, cmdHSet)
+	}
+
+	added, err := h.storage.HSet(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", added))
+	return resp.EncodeInt(added)
+}
+
+// hMSetHandler is a deprecated form of HSET which replies with OK.
+// Syntax: HMSET key field value [field value ...]
+func (h *Handler) hMSetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 || len(args)%2 != 0 {
+		return h.wrongNumberOfArgs(ctx, cmdHMSet)
+	}
+
+	if _, err := h.storage.HSet(args[1], args[2:]); errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// hSetNXHandler sets field of the hash only if it doesn't exist.
+// Syntax: HSETNX key field value
+func (h *Handler) hSetNXHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdHSetNX)
+	}
+
+	set, err := h.storage.HSetNX(args[1], args[2], args[3])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	result := 0
+	if set {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// hGetHandler returns value of the hash field.
+// Syntax: HGET key field
+func (h *Handler) hGetHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHGet)
+	}
+
+	value, err := h.storage.HGet(args[1], args[2])
+	return h.stringReply(ctx, value, err)
+}
+
+// hMGetHandler returns values of the hash fields. Missing fields are returned as nulls.
+// Syntax: HMGET key field [field ...]
+func (h *Handler) hMGetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHMGet)
+	}
+
+	values, ok, err := h.storage.HMGet(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	c := clientFromContext(ctx)
+	elems := make([][]byte, len(values))
+	for i, value := range values {
+		if !ok[i] {
+			elems[i] = c.encodeNull()
+			continue
+		}
+		elems[i] = resp.EncodeString(value)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", values))
+	return resp.EncodeRawArray(elems)
+}
+
+// hDelHandler deletes fields of the hash and returns number of deleted ones.
+// Syntax: HDEL key field [field ...]
+func (h *Handler) hDelHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHDel)
+	}
+
+	deleted, err := h.storage.HDel(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", deleted))
+	return resp.EncodeInt(deleted)
+}
+
+// hExistsHandler reports whether the hash has a field.
+// Syntax: HEXISTS key field
+func (h *Handler) hExistsHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHExists)
+	}
+
+	exists, err := h.storage.HExists(args[1], args[2])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	result := 0
+	if exists {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// hLenHandler returns number of fields in the hash.
+// Syntax: HLEN key
+func (h *Handler) hLenHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdHLen)
+	}
+
+	length, err := h.storage.HLen(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// hKeysHandler returns all fields of the hash.
+// Syntax: HKEYS key
+func (h *Handler) hKeysHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdHKeys)
+	}
+
+	fields, _, err := h.storage.HGetAll(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", fields))
+	return resp.EncodeArray(fields)
+}
+
+// hValsHandler returns all values of the hash.
+// Syntax: HVALS key
+func (h *Handler) hValsHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdHVals)
+	}
+
+	_, values, err := h.storage.HGetAll(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", values))
+	return resp.EncodeArray(values)
+}
+
+// hGetAllHandler returns all fields of the hash with their values.
+// Syntax: HGETALL key
+func (h *Handler) hGetAllHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdHGetAll)
+	}
+
+	fields, values, err := h.storage.HGetAll(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", fields))
+	return clientFromContext(ctx).encodeMap(encodePairs(fields, values))
+}
+
+// encodePairs encodes fields and their values as key1, value1, key2, value2, ...
+func encodePairs(fields, values []string) [][]byte {
+	elems := make([][]byte, 0, 2*len(fields))
+	for i := range fields {
+		elems = append(elems, resp.EncodeString(fields[i]), resp.EncodeString(values[i]))
+	}
+	return elems
+}
+
+// hIncrByHandler increments integer value of the hash field.
+// Syntax: HINCRBY key field increment
+func (h *Handler) hIncrByHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdHIncrBy)
+	}
+
+	delta, err := strconv.Atoi(args[3])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+
+	result, err := h.storage.HIncrBy(args[1], args[2], delta)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrNotInteger):
+		return h.errorReply(ctx, ErrHashNotInteger)
+	case errors.Is(err, storage.ErrOverflow):
+		return h.errorReply(ctx, ErrOverflow)
+	}
+
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// hIncrByFloatHandler increments numeric value of the hash field by float number.
+// Syntax: HINCRBYFLOAT key field increment
+func (h *Handler) hIncrByFloatHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdHIncrByFloat)
+	}
+
+	delta, err := strconv.ParseFloat(args[3], 64)
+	if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+		return h.errorReply(ctx, ErrInvalidFloat)
+	}
+
+	result, err := h.storage.HIncrByFloat(args[1], args[2], delta)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrNotFloat):
+		return h.errorReply(ctx, ErrHashNotFloat)
+	case errors.Is(err, storage.ErrNaNOrInf):
+		return h.errorReply(ctx, ErrNaNOrInfinity)
+	}
+
+	log.Info(responseMsg, zap.String("response", result))
+	return resp.EncodeString(result)
+}
+
+// hStrLenHandler returns length of value of the hash field.
+// Syntax: HSTRLEN key field
+func (h *Handler) hStrLenHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHStrLen)
+	}
+
+	length, err := h.storage.HStrLen(args[1], args[2])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// hRandFieldHandler returns random fields of the hash.
+// Without count a single field is returned. Positive count means distinct fields,
+// negative one allows the same field to be returned several times.
+// Syntax: HRANDFIELD key [count [WITHVALUES]]
+func (h *Handler) hRandFieldHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 || len(args) > 4 {
+		return h.wrongNumberOfArgs(ctx, cmdHRandField)
+	}
+
+	if len(args) == 2 {
+		fields, _, err := h.storage.HRandField(args[1], 1)
+		if err == nil && len(fields) == 0 {
+			err = storage.ErrKeyNotFound
+		}
+		if err != nil {
+			return h.stringReply(ctx, "", err)
+		}
+		return h.stringReply(ctx, fields[0], nil)
+	}
+
+	count, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	if count < -maxRandCount {
+		return h.errorReply(ctx, ErrValueRange)
+	}
+	withValues := false
+	if len(args) == 4 {
+		if !strings.EqualFold(args[3], "withvalues") {
+			return h.errorReply(ctx, ErrSyntax)
+		}
+		withValues = true
+	}
+
+	fields, values, err := h.storage.HRandField(args[1], count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", fields))
+	if !withValues {
+		return resp.EncodeArray(fields)
+	}
+
+	c := clientFromContext(ctx)
+	if c.resp3() {
+		// RESP3 clients get array of field-value pairs
+		elems := make([][]byte, len(fields))
+		for i := range fields {
+			elems[i] = resp.EncodeArray([]string{fields[i], values[i]})
+		}
+		return resp.EncodeRawArray(elems)
+	}
+	return resp.EncodeRawArray(encodePairs(fields, values))
+}
+
+// hScanHandler incrementally iterates over fields of the hash.
+// Syntax: HSCAN key cursor [MATCH pattern] [COUNT count]
+func (h *Handler) hScanHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdHScan)
+	}
+
+	opts, errReply := parseScanArgs(args[2:], false)
+	if errReply != nil {
+		log.Info(responseMsg, zap.ByteString("response", errReply))
+		return errReply
+	}
+
+	cursor, pairs, err := h.storage.HScan(args[1], opts.cursor, opts.count, opts.pattern)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Uint64("cursor", cursor), zap.Strings("response", pairs))
+	return encodeScanReply(cursor, pairs)
+}
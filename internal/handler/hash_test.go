@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fv is a setup of hash "hash" with fields f1 and f2.
+var fv = [][]string{{"HSET", "hash", "f1", "v1", "f2", "v2"}}
+
+func TestHashSet(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "HSET counts only new fields", setup: fv, args: []string{"HSET", "hash", "f2", "new", "f3", "v3"}, want: ":1\r\n", check: []string{"HMGET", "hash", "f2", "f3", "f4"}, wantCheck: "*3\r\n$3\r\nnew\r\n$2\r\nv3\r\n$-1\r\n"},
+		{name: "HSET odd pairs", args: []string{"HSET", "hash", "f1", "v1", "f2"}, want: "-Wrong number of arguments for 'hset' command\r\n", check: []string{"EXISTS", "hash"}, wantCheck: ":0\r\n"},
+		{name: "HSET string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"HSET", "key", "f", "v"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "HMSET", args: []string{"HMSET", "hash", "f1", "v1"}, want: "+OK\r\n", check: []string{"HGET", "hash", "f1"}, wantCheck: "$2\r\nv1\r\n"},
+		{name: "HSETNX new field", setup: fv, args: []string{"HSETNX", "hash", "f3", "v3"}, want: ":1\r\n"},
+		{name: "HSETNX existing field", setup: fv, args: []string{"HSETNX", "hash", "f1", "new"}, want: ":0\r\n", check: []string{"HGET", "hash", "f1"}, wantCheck: "$2\r\nv1\r\n"},
+
+		{name: "HDEL", setup: fv, args: []string{"HDEL", "hash", "f1", "f3"}, want: ":1\r\n", check: []string{"HLEN", "hash"}, wantCheck: ":1\r\n"},
+		{name: "HDEL last field deletes key", setup: fv, args: []string{"HDEL", "hash", "f1", "f2"}, want: ":2\r\n", check: []string{"TYPE", "hash"}, wantCheck: "+none\r\n"},
+		{name: "HDEL missing key", args: []string{"HDEL", "hash", "f1"}, want: ":0\r\n"},
+	})
+}
+
+func TestHashGet(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "HGET", setup: fv, args: []string{"HGET", "hash", "f2"}, want: "$2\r\nv2\r\n"},
+		{name: "HGET missing field", setup: fv, args: []string{"HGET", "hash", "f3"}, want: "$-1\r\n"},
+		{name: "HGET string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"HGET", "key", "f"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "HMGET missing key", args: []string{"HMGET", "hash", "f1", "f2"}, want: "*2\r\n$-1\r\n$-1\r\n"},
+		{name: "HEXISTS", setup: fv, args: []string{"HEXISTS", "hash", "f1"}, want: ":1\r\n"},
+		{name: "HEXISTS missing field", setup: fv, args: []string{"HEXISTS", "hash", "f3"}, want: ":0\r\n"},
+		{name: "HLEN", setup: fv, args: []string{"HLEN", "hash"}, want: ":2\r\n"},
+		{name: "HLEN missing key", args: []string{"HLEN", "hash"}, want: ":0\r\n"},
+		{name: "HSTRLEN", setup: [][]string{{"HSET", "hash", "f", "hello"}}, args: []string{"HSTRLEN", "hash", "f"}, want: ":5\r\n"},
+		{name: "HSTRLEN missing field", setup: fv, args: []string{"HSTRLEN", "hash", "f3"}, want: ":0\r\n"},
+		{name: "HKEYS missing key", args: []string{"HKEYS", "hash"}, want: "*0\r\n"},
+		{name: "HGETALL", setup: [][]string{{"HSET", "hash", "f", "v"}}, args: []string{"HGETALL", "hash"}, want: "*2\r\n$1\r\nf\r\n$1\r\nv\r\n"},
+		{name: "HGETALL missing key", args: []string{"HGETALL", "hash"}, want: "*0\r\n"},
+	})
+}
+
+func TestHIncrBy(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "HINCRBY missing field", setup: fv, args: []string{"HINCRBY", "hash", "n", "5"}, want: ":5\r\n"},
+		{name: "HINCRBY", setup: [][]string{{"HSET", "hash", "n", "10"}}, args: []string{"HINCRBY", "hash", "n", "-15"}, want: ":-5\r\n", check: []string{"HGET", "hash", "n"}, wantCheck: "$2\r\n-5\r\n"},
+		{name: "HINCRBY not integer field", setup: fv, args: []string{"HINCRBY", "hash", "f1", "1"}, want: "-hash value is not an integer\r\n"},
+		{name: "HINCRBY not integer increment", setup: fv, args: []string{"HINCRBY", "hash", "n", "1.5"}, want: "-Value is not an integer or out of range\r\n", check: []string{"HEXISTS", "hash", "n"}, wantCheck: ":0\r\n"},
+		{name: "HINCRBY overflow", setup: [][]string{{"HSET", "hash", "n", "9223372036854775807"}}, args: []string{"HINCRBY", "hash", "n", "1"}, want: "-increment or decrement would overflow\r\n", check: []string{"HGET", "hash", "n"}, wantCheck: "$19\r\n9223372036854775807\r\n"},
+	})
+}
+
+func TestHIncrByFloat(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "Missing key", args: []string{"HINCRBYFLOAT", "hash", "n", "1.5"}, want: "$3\r\n1.5\r\n"},
+		{name: "Integer field", setup: [][]string{{"HSET", "hash", "n", "10"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "0.1"}, want: "$4\r\n10.1\r\n"},
+		{name: "Result is integer", setup: [][]string{{"HSET", "hash", "n", "1.5"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "1.5"}, want: "$1\r\n3\r\n", check: []string{"HGET", "hash", "n"}, wantCheck: "$1\r\n3\r\n"},
+		{name: "Exponent", setup: [][]string{{"HSET", "hash", "n", "5.0e3"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "2.0e2"}, want: "$4\r\n5200\r\n"},
+
+		// increments are added with long double precision like in Redis
+		{name: "No binary rounding error", setup: [][]string{{"HSET", "hash", "n", "0.1"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "0.2"}, want: "$3\r\n0.3\r\n"},
+		{name: "Small increment", setup: [][]string{{"HSET", "hash", "n", "1"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "0.00000000000000001"}, want: "$19\r\n1.00000000000000001\r\n"},
+		{name: "Tiny increment", setup: [][]string{{"HSET", "hash", "n", "1"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "1e-20"}, want: "$1\r\n1\r\n"},
+		{name: "Negative zero", setup: [][]string{{"HSET", "hash", "n", "-0.5"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "0.5"}, want: "$1\r\n0\r\n"},
+
+		{name: "Not float field", setup: fv, args: []string{"HINCRBYFLOAT", "hash", "f1", "1"}, want: "-hash value is not a float\r\n"},
+		{name: "Not float increment", setup: fv, args: []string{"HINCRBYFLOAT", "hash", "n", "abc"}, want: "-value is not a valid float\r\n"},
+		{name: "Infinite increment", setup: fv, args: []string{"HINCRBYFLOAT", "hash", "n", "inf"}, want: "-value is not a valid float\r\n"},
+		{name: "Infinite result", setup: [][]string{{"HSET", "hash", "n", "1.7e308"}}, args: []string{"HINCRBYFLOAT", "hash", "n", "1.7e308"}, want: "-increment would produce NaN or Infinity\r\n", check: []string{"HGET", "hash", "n"}, wantCheck: "$7\r\n1.7e308\r\n"},
+		{name: "String", setup: [][]string{{"SET", "key", "1"}}, args: []string{"HINCRBYFLOAT", "key", "n", "1"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestHRandField(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "HSET", "hash", "f1", "v1", "f2", "v2", "f3", "v3")
+
+	t.Run("Single", func(t *testing.T) {
+		assert.Regexp(t, `^\$2\r\nf[123]\r\n$`, serve(ctx, h, "HRANDFIELD", "hash"))
+		assert.Equal(t, "$-1\r\n", serve(ctx, h, "HRANDFIELD", "nokey"))
+	})
+
+	t.Run("Positive count gives distinct fields", func(t *testing.T) {
+		for range 20 {
+			fields := parseArray(t, serve(ctx, h, "HRANDFIELD", "hash", "2"))
+			require.Len(t, fields, 2)
+			assert.NotEqual(t, fields[0], fields[1])
+		}
+		assert.Equal(t, []string{"f1", "f2", "f3"}, parseArray(t, serve(ctx, h, "HRANDFIELD", "hash", "10")))
+	})
+
+	t.Run("Negative count may repeat fields", func(t *testing.T) {
+		seen := map[string]int{}
+		for range 20 {
+			fields := parseArray(t, serve(ctx, h, "HRANDFIELD", "hash", "-10"))
+			require.Len(t, fields, 10)
+			for _, field := range fields {
+				seen[field]++
+			}
+		}
+		assert.Len(t, seen, 3)
+	})
+
+	t.Run("Zero count", func(t *testing.T) {
+		assert.Equal(t, "*0\r\n", serve(ctx, h, "HRANDFIELD", "hash", "0"))
+		assert.Equal(t, "*0\r\n", serve(ctx, h, "HRANDFIELD", "nokey", "-5"))
+	})
+
+	t.Run("WITHVALUES", func(t *testing.T) {
+		reply := serve(ctx, h, "HRANDFIELD", "hash", "-5", "WITHVALUES")
+		lines := strings.Split(strings.TrimSuffix(reply, "\r\n"), "\r\n")
+		require.Equal(t, "*10", lines[0])
+		for i := 2; i < len(lines); i += 4 {
+			// every field is followed by its own value
+			assert.Equal(t, "v"+strings.TrimPrefix(lines[i], "f"), lines[i+2])
+		}
+		assert.Equal(t, "-syntax error\r\n", serve(ctx, h, "HRANDFIELD", "hash", "1", "VALUES"))
+	})
+
+	t.Run("Invalid count", func(t *testing.T) {
+		var tests = []struct {
+			name  string
+			count string
+			want  string
+		}{
+			{name: "Huge negative", count: "-1125899906842624", want: "-" + ErrValueRange + "\r\n"},
+			{name: "Min int", count: "-9223372036854775808", want: "-" + ErrValueRange + "\r\n"},
+			{name: "Not integer", count: "abc", want: "-" + ErrInvalidInt + "\r\n"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				assert.Equal(t, test.want, serve(ctx, h, "HRANDFIELD", "hash", test.count))
+			})
+		}
+	})
+}
+
+func TestHScan(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "HSET", "hash", "name", "nova", "nick", "n", "age", "1")
+
+	assert.Equal(t, "*2\r\n$1\r\n0\r\n*0\r\n", serve(ctx, h, "HSCAN", "nokey", "0"))
+	assert.Equal(t, "-syntax error\r\n", serve(ctx, h, "HSCAN", "hash", "0", "TYPE", "string"))
+
+	reply := serve(ctx, h, "HSCAN", "hash", "0", "MATCH", "n*", "COUNT", "100")
+	assert.True(t, strings.HasPrefix(reply, "*2\r\n$1\r\n0\r\n*4\r\n"), reply)
+	assert.Contains(t, reply, "$4\r\nname\r\n$4\r\nnova\r\n")
+	assert.Contains(t, reply, "$4\r\nnick\r\n$1\r\nn\r\n")
+}
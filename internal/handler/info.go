@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdInfo = "info"
+)
+
+// Server provides statistics of the network server.
+type Server interface {
+	ConnectedClients() int
+	TotalConnections() uint64
+	TotalCommands() uint64
+}
+
+// infoField is a single "name:value" line of INFO reply.
+type infoField struct {
+	name  string
+	value string
+}
+
+// infoSection is a named group of fields in INFO reply.
+type infoSection struct {
+	name string
+	// sections which are not default are returned only if requested explicitly or by "all"
+	isDefault bool
+	fields    func(h *Handler) []infoField
+}
+
+// cmdStats holds statistics of a single command.
+type cmdStats struct {
+	calls atomic.Uint64
+	usec  atomic.Uint64
+}
+
+var infoSections = []infoSection{
+	{name: "server", isDefault: true, fields: (*Handler).serverInfo},
+	{name: "clients", isDefault: true, fields: (*Handler).clientsInfo},
+	{name: "memory", isDefault: true, fields: (*Handler).memoryInfo},
+	{name: "persistence", isDefault: true, fields: (*Handler).persistenceInfo},
+	{name: "stats", isDefault: true, fields: (*Handler).statsInfo},
+	{name: "commandstats", isDefault: false, fields: (*Handler).commandStatsInfo},
+	{name: "keyspace", isDefault: true, fields: (*Handler).keyspaceInfo},
+}
+
+// infoHandler returns information and statistics about the server.
+// Syntax: INFO [section [section ...]]
+// Special sections are "default", "all" and "everything".
+func (h *Handler) infoHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	requested := []string{}
+	for _, arg := range args[1:] {
+		requested = append(requested, strings.ToLower(arg))
+	}
+	if len(requested) == 0 {
+		requested = []string{"default"}
+	}
+
+	var b strings.Builder
+	for _, section := range infoSections {
+		include := slices.Contains(requested, section.name) ||
+			slices.Contains(requested, "all") ||
+			slices.Contains(requested, "everything") ||
+			(section.isDefault && slices.Contains(requested, "default"))
+		if !include {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString("# ")
+		b.WriteString(strings.ToUpper(section.name[:1]) + section.name[1:])
+		b.WriteString("\r\n")
+		for _, field := range section.fields(h) {
+			b.WriteString(field.name)
+			b.WriteByte(':')
+			b.WriteString(field.value)
+			b.WriteString("\r\n")
+		}
+	}
+
+	log.Info(responseMsg, zap.Strings("sections", requested))
+	if clientFromContext(ctx).resp3() {
+		return resp.EncodeVerbatimString("txt", b.String())
+	}
+	return resp.EncodeString(b.String())
+}
+
+func (h *Handler) serverInfo() []infoField {
+	uptime := time.Since(h.startTime)
+
+	fields := []infoField{
+		{"nova_version", serverVersion},
+		{"redis_mode", "standalone"},
+		{"os", runtime.GOOS},
+		{"arch_bits", strconv.Itoa(strconv.IntSize)},
+		{"go_version", runtime.Version()},
+		{"process_id", strconv.Itoa(os.Getpid())},
+		{"uptime_in_seconds", strconv.Itoa(int(uptime.Seconds()))},
+		{"uptime_in_days", strconv.Itoa(int(uptime.Hours() / 24))},
+	}
+	if port, ok := h.configValue("port"); ok {
+		fields = append(fields, infoField{"tcp_port", port})
+	}
+
+	return fields
+}
+
+func (h *Handler) clientsInfo() []infoField {
+	fields := []infoField{}
+	if h.server != nil {
+		fields = append(fields, infoField{"connected_clients", strconv.Itoa(h.server.ConnectedClients())})
+	}
+	if maxClients, ok := h.configValue("maxclients"); ok {
+		fields = append(fields, infoField{"maxclients", maxClients})
+	}
+	fields = append(fields, infoField{"blocked_clients", strconv.Itoa(h.storage.BlockedClients())})
+
+	return fields
+}
+
+func (h *Handler) memoryInfo() []infoField {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return []infoField{
+		{"used_memory", strconv.FormatUint(stats.HeapAlloc, 10)},
+		{"used_memory_human", bytesToHuman(stats.HeapAlloc)},
+		{"used_memory_heap_sys", strconv.FormatUint(stats.HeapSys, 10)},
+		{"used_memory_heap_sys_human", bytesToHuman(stats.HeapSys)},
+		{"used_memory_sys", strconv.FormatUint(stats.Sys, 10)},
+		{"used_memory_sys_human", bytesToHuman(stats.Sys)},
+		{"gc_cycles", strconv.FormatUint(uint64(stats.NumGC), 10)},
+	}
+}
+
+func (h *Handler) statsInfo() []infoField {
+	if h.server == nil {
+		return []infoField{}
+	}
+
+	return []infoField{
+		{"total_connections_received", strconv.FormatUint(h.server.TotalConnections(), 10)},
+		{"total_commands_processed", strconv.FormatUint(h.server.TotalCommands(), 10)},
+	}
+}
+
+func (h *Handler) commandStatsInfo() []infoField {
+	names := make([]string, 0, len(h.stats))
+	for name := range h.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := []infoField{}
+	for _, name := range names {
+		calls := h.stats[name].calls.Load()
+		if calls == 0 {
+			continue
+		}
+		usec := h.stats[name].usec.Load()
+
+		fields = append(fields, infoField{
+			"cmdstat_" + name,
+			fmt.Sprintf("calls=%d,usec=%d,usec_per_call=%.2f", calls, usec, float64(usec)/float64(calls)),
+		})
+	}
+
+	return fields
+}
+
+func (h *Handler) keyspaceInfo() []infoField {
+	keys, expires := h.storage.KeyspaceStats()
+	if keys == 0 {
+		return []infoField{}
+	}
+
+	return []infoField{
+		{"db0", fmt.Sprintf("keys=%d,expires=%d", keys, expires)},
+	}
+}
+
+// configValue returns value of configuration parameter if config is available.
+func (h *Handler) configValue(name string) (string, bool) {
+	if h.config == nil {
+		return "", false
+	}
+
+	pairs := h.config.Get([]string{name})
+	if len(pairs) != 2 {
+		return "", false
+	}
+	return pairs[1], true
+}
+
+// bytesToHuman formats number of bytes the same way as Redis does, e.g. "1.50M".
+func bytesToHuman(n uint64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.2f%s", value, units[unit])
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"nova/internal/config"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a network server with fixed statistics.
+type testServer struct{}
+
+func (testServer) ConnectedClients() int    { return 2 }
+func (testServer) TotalConnections() uint64 { return 5 }
+func (testServer) TotalCommands() uint64    { return 42 }
+
+// infoHeaders returns names of sections of INFO reply.
+func infoHeaders(reply string) []string {
+	headers := []string{}
+	for _, m := range regexp.MustCompile(`# (\w+)\r\n`).FindAllStringSubmatch(reply, -1) {
+		headers = append(headers, m[1])
+	}
+	return headers
+}
+
+func TestInfoSections(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "Default", args: []string{"INFO"}, want: []string{"Server", "Clients", "Memory", "Persistence", "Stats", "Keyspace"}},
+		{name: "Explicit default", args: []string{"INFO", "default"}, want: []string{"Server", "Clients", "Memory", "Persistence", "Stats", "Keyspace"}},
+		{name: "All", args: []string{"INFO", "all"}, want: []string{"Server", "Clients", "Memory", "Persistence", "Stats", "Commandstats", "Keyspace"}},
+		{name: "Everything", args: []string{"INFO", "everything"}, want: []string{"Server", "Clients", "Memory", "Persistence", "Stats", "Commandstats", "Keyspace"}},
+		{name: "Not default section", args: []string{"INFO", "commandstats"}, want: []string{"Commandstats"}},
+		{name: "Several sections in server order", args: []string{"INFO", "KEYSPACE", "server"}, want: []string{"Server", "Keyspace"}},
+		{name: "Unknown section", args: []string{"INFO", "nosuchsection"}, want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+
+			assert.Equal(t, test.want, infoHeaders(serve(ctx, h, test.args...)))
+		})
+	}
+}
+
+func TestInfoFields(t *testing.T) {
+	h, ctx := newTestHandler(t, WithConfig(config.NewRuntime(config.Default())))
+	h.SetServer(testServer{})
+
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "SET", "a", "1"))
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "SET", "b", "2", "PX", "60000"))
+	serve(ctx, h, "GET", "a")
+	serve(ctx, h, "GET", "b")
+
+	info := serve(ctx, h, "INFO", "all")
+	for _, field := range []string{
+		"tcp_port:6379",
+		"connected_clients:2",
+		"maxclients:10000",
+		"blocked_clients:0",
+		"total_connections_received:5",
+		"total_commands_processed:42",
+		`cmdstat_get:calls=2,usec=\d+,usec_per_call=\d+\.\d{2}`,
+		`cmdstat_set:calls=2,`,
+		"db0:keys=2,expires=1",
+	} {
+		assert.Regexp(t, "\r\n"+field, info)
+	}
+	// commands never called are not reported
+	assert.NotContains(t, info, "cmdstat_lpush")
+}
+
+func TestInfoEmpty(t *testing.T) {
+	h, ctx := newTestHandler(t)
+
+	// sections depending on server, config and data have only storage fields then
+	info := serve(ctx, h, "INFO", "clients", "stats", "keyspace")
+	assert.Equal(t, "$55\r\n# Clients\r\nblocked_clients:0\r\n\r\n# Stats\r\n\r\n# Keyspace\r\n\r\n", info)
+}
+
+func TestInfoRESP3(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	require.Contains(t, serve(ctx, h, "HELLO", "3"), "proto")
+
+	assert.Regexp(t, `^=\d+\r\ntxt:# Server\r\n`, serve(ctx, h, "INFO", "server"))
+}
+
+func TestBytesToHuman(t *testing.T) {
+	var tests = []struct {
+		n    uint64
+		want string
+	}{
+		{n: 0, want: "0B"},
+		{n: 1023, want: "1023B"},
+		{n: 1024, want: "1.00K"},
+		{n: 1536 * 1024, want: "1.50M"},
+		{n: 3 << 30, want: "3.00G"},
+		{n: 5 << 50, want: "5120.00T"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, bytesToHuman(test.n))
+		})
+	}
+}
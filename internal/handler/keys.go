@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdExists    = "exists"
+	cmdType      = "type"
+	cmdKeys      = "keys"
+	cmdDBSize    = "dbsize"
+	cmdRandomKey = "randomkey"
+	cmdRename    = "rename"
+	cmdRenameNX  = "renamenx"
+	cmdCopy      = "copy"
+	cmdMove      = "move"
+	cmdTouch     = "touch"
+	cmdUnlink    = "unlink"
+)
+
+var (
+	ErrNoSuchKey  = "no such key"
+	ErrDBIndex    = "DB index is out of range"
+	ErrSameObject = "source and destination objects are the same"
+)
+
+var (
+	// Nova has a single database, so the only valid DB index is 0
+	defaultDB = 0
+)
+
+// existsHandler returns number of existing keys among given ones.
+// Syntax: EXISTS key [key ...]
+func (h *Handler) existsHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdExists)
+	}
+
+	count := h.storage.Exists(args[1:])
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
+
+// typeHandler returns type of value stored via key.
+// Syntax: TYPE key
+func (h *Handler) typeHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdType)
+	}
+
+	valueType := h.storage.Type(args[1])
+
+	log.Info(responseMsg, zap.String("response", valueType))
+	return resp.EncodeSimpleString(valueType)
+}
+
+// keysHandler returns all keys matching glob-style pattern.
+// Syntax: KEYS pattern
+func (h *Handler) keysHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdKeys)
+	}
+
+	keys := h.storage.Keys(args[1])
+
+	log.Info(responseMsg, zap.Int("response", len(keys)))
+	return resp.EncodeArray(keys)
+}
+
+// dbSizeHandler returns number of keys.
+// Syntax: DBSIZE
+func (h *Handler) dbSizeHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdDBSize)
+	}
+
+	size := h.storage.DBSize()
+
+	log.Info(responseMsg, zap.Int("response", size))
+	return resp.EncodeInt(size)
+}
+
+// randomKeyHandler returns random key.
+// Syntax: RANDOMKEY
+func (h *Handler) randomKeyHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdRandomKey)
+	}
+
+	key, ok := h.storage.RandomKey()
+	if !ok {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNull()
+	}
+
+	log.Info(responseMsg, zap.String("response", key))
+	return resp.EncodeString(key)
+}
+
+func (h *Handler) renameHandler(ctx context.Context, args []string) []byte {
+	return h.renameGeneric(ctx, args, cmdRename, false)
+}
+
+func (h *Handler) renameNXHandler(ctx context.Context, args []string) []byte {
+	return h.renameGeneric(ctx, args, cmdRenameNX, true)
+}
+
+// renameGeneric implements RENAME and RENAMENX.
+// Syntax: <cmd> key newkey
+func (h *Handler) renameGeneric(ctx context.Context, args []string, cmd string, nx bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	renamed, err := h.storage.Rename(args[1], args[2], nx)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		log.Info(responseMsg, zap.String("response", ErrNoSuchKey))
+		return resp.EncodeError(ErrNoSuchKey)
+	}
+
+	if !nx {
+		log.Info(responseMsg, zap.String("response", "OK"))
+		return resp.EncodeSimpleString("OK")
+	}
+
+	result := 0
+	if renamed {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// copyHandler copies value of the key to another key.
+// Syntax: COPY source destination [DB destination-db] [REPLACE]
+func (h *Handler) copyHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdCopy)
+	}
+
+	replace := false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "replace":
+			replace = true
+		case "db":
+			if i+1 == len(args) {
+				log.Info(responseMsg, zap.String("response", ErrSyntax))
+				return resp.EncodeError(ErrSyntax)
+			}
+			i++
+			if errReply := checkDBIndex(args[i]); errReply != nil {
+				log.Info(responseMsg, zap.ByteString("response", errReply))
+				return errReply
+			}
+		default:
+			log.Info(responseMsg, zap.String("response", ErrSyntax))
+			return resp.EncodeError(ErrSyntax)
+		}
+	}
+
+	copied, err := h.storage.Copy(args[1], args[2], replace)
+	if errors.Is(err, storage.ErrSameObject) {
+		log.Info(responseMsg, zap.String("response", ErrSameObject))
+		return resp.EncodeError(ErrSameObject)
+	}
+
+	result := 0
+	if copied {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// moveHandler moves key to another database.
+// There is only one database, so the command can only fail.
+// Syntax: MOVE key db
+func (h *Handler) moveHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdMove)
+	}
+
+	if errReply := checkDBIndex(args[2]); errReply != nil {
+		log.Info(responseMsg, zap.ByteString("response", errReply))
+		return errReply
+	}
+
+	// destination DB is always the current one
+	log.Info(responseMsg, zap.String("response", ErrSameObject))
+	return resp.EncodeError(ErrSameObject)
+}
+
+// checkDBIndex validates database index. It returns encoded error reply for invalid ones.
+func checkDBIndex(arg string) []byte {
+	db, err := strconv.Atoi(arg)
+	if err != nil {
+		return resp.EncodeError(ErrInvalidInt)
+	}
+	if db != defaultDB {
+		return resp.EncodeError(ErrDBIndex)
+	}
+
+	return nil
+}
+
+// touchHandler returns number of existing keys among given ones.
+// Syntax: TOUCH key [key ...]
+func (h *Handler) touchHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdTouch)
+	}
+
+	count := h.storage.Touch(args[1:])
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
+
+// unlinkHandler deletes keys freeing memory of big values in background.
+// Syntax: UNLINK key [key ...]
+func (h *Handler) unlinkHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdUnlink)
+	}
+
+	count := h.storage.Unlink(args[1:])
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupKeys fills storage with keys of different types and one expired key.
+func setupKeys(t *testing.T) (*Handler, context.Context) {
+	t.Helper()
+
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "MSET", "hello", "1", "hallo", "2", "world", "3")
+	serve(ctx, h, "RPUSH", "list", "a")
+	serve(ctx, h, "SET", "expired", "x", "PX", "1")
+	time.Sleep(5 * time.Millisecond)
+
+	return h, ctx
+}
+
+func TestKeysCommands(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "EXISTS", args: []string{"EXISTS", "hello", "nokey", "list"}, want: ":2\r\n"},
+		{name: "EXISTS the same key twice", args: []string{"EXISTS", "hello", "hello"}, want: ":2\r\n"},
+		{name: "EXISTS expired key", args: []string{"EXISTS", "expired"}, want: ":0\r\n"},
+		{name: "EXISTS wrong number of arguments", args: []string{"EXISTS"}, want: "-Wrong number of arguments for 'exists' command\r\n"},
+
+		{name: "TYPE string", args: []string{"TYPE", "world"}, want: "+string\r\n"},
+		{name: "TYPE list", args: []string{"TYPE", "list"}, want: "+list\r\n"},
+		{name: "TYPE missing key", args: []string{"TYPE", "nokey"}, want: "+none\r\n"},
+		{name: "TYPE expired key", args: []string{"TYPE", "expired"}, want: "+none\r\n"},
+
+		{name: "DBSIZE", args: []string{"DBSIZE"}, want: ":4\r\n"},
+		{name: "DBSIZE wrong number of arguments", args: []string{"DBSIZE", "x"}, want: "-Wrong number of arguments for 'dbsize' command\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := setupKeys(t)
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "All", pattern: "*", want: []string{"hallo", "hello", "list", "world"}},
+		{name: "Prefix", pattern: "h*", want: []string{"hallo", "hello"}},
+		{name: "Single character", pattern: "h?llo", want: []string{"hallo", "hello"}},
+		{name: "Character class", pattern: "h[ae]llo", want: []string{"hallo", "hello"}},
+		{name: "Negated class", pattern: "h[^e]llo", want: []string{"hallo"}},
+		{name: "Exact", pattern: "list", want: []string{"list"}},
+		{name: "No matches", pattern: "nokey*", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := setupKeys(t)
+			assert.Equal(t, test.want, parseArray(t, serve(ctx, h, "KEYS", test.pattern)))
+		})
+	}
+}
+
+func TestRandomKey(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	assert.Equal(t, "$-1\r\n", serve(ctx, h, "RANDOMKEY"))
+
+	serve(ctx, h, "SET", "expired", "x", "PX", "1")
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, "$-1\r\n", serve(ctx, h, "RANDOMKEY"))
+
+	serve(ctx, h, "MSET", "a", "1", "b", "2")
+	seen := map[string]bool{}
+	for range 100 {
+		seen[serve(ctx, h, "RANDOMKEY")] = true
+	}
+	assert.Equal(t, map[string]bool{"$1\r\na\r\n": true, "$1\r\nb\r\n": true}, seen)
+}
+
+// parseArray returns sorted elements of RESP array of bulk strings.
+func parseArray(t *testing.T, reply string) []string {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSuffix(reply, "\r\n"), "\r\n")
+	elems := []string{}
+	for i := 2; i < len(lines); i += 2 {
+		elems = append(elems, lines[i])
+	}
+	sort.Strings(elems)
+
+	return elems
+}
+
+func TestRenameCopy(t *testing.T) {
+	var tests = []struct {
+		name      string
+		setup     [][]string
+		args      []string
+		want      string
+		check     []string
+		wantCheck string
+	}{
+		{name: "RENAME", setup: [][]string{{"SET", "src", "value"}}, args: []string{"RENAME", "src", "dst"}, want: "+OK\r\n", check: []string{"MGET", "src", "dst"}, wantCheck: "*2\r\n$-1\r\n$5\r\nvalue\r\n"},
+		{name: "RENAME overwrites", setup: [][]string{{"SET", "src", "value"}, {"RPUSH", "dst", "a"}}, args: []string{"RENAME", "src", "dst"}, want: "+OK\r\n", check: []string{"GET", "dst"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "RENAME keeps TTL", setup: [][]string{{"SET", "src", "value", "EX", "100"}}, args: []string{"RENAME", "src", "dst"}, want: "+OK\r\n", check: []string{"TTL", "dst"}, wantCheck: ":100\r\n"},
+		{name: "RENAME drops TTL of destination", setup: [][]string{{"SET", "src", "value"}, {"SET", "dst", "old", "EX", "100"}}, args: []string{"RENAME", "src", "dst"}, want: "+OK\r\n", check: []string{"TTL", "dst"}, wantCheck: ":-1\r\n"},
+		{name: "RENAME to itself", setup: [][]string{{"SET", "src", "value"}}, args: []string{"RENAME", "src", "src"}, want: "+OK\r\n", check: []string{"GET", "src"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "RENAME missing key", args: []string{"RENAME", "src", "dst"}, want: "-no such key\r\n"},
+		{name: "RENAME expired key", setup: [][]string{{"SET", "src", "value", "PX", "1"}, {"SLEEP"}}, args: []string{"RENAME", "src", "dst"}, want: "-no such key\r\n"},
+
+		{name: "RENAMENX", setup: [][]string{{"SET", "src", "value", "EX", "100"}}, args: []string{"RENAMENX", "src", "dst"}, want: ":1\r\n", check: []string{"TTL", "dst"}, wantCheck: ":100\r\n"},
+		{name: "RENAMENX existing destination", setup: [][]string{{"SET", "src", "value"}, {"SET", "dst", "old"}}, args: []string{"RENAMENX", "src", "dst"}, want: ":0\r\n", check: []string{"MGET", "src", "dst"}, wantCheck: "*2\r\n$5\r\nvalue\r\n$3\r\nold\r\n"},
+		{name: "RENAMENX to itself", setup: [][]string{{"SET", "src", "value"}}, args: []string{"RENAMENX", "src", "src"}, want: ":0\r\n"},
+		{name: "RENAMENX missing key", args: []string{"RENAMENX", "src", "dst"}, want: "-no such key\r\n"},
+
+		{name: "COPY", setup: [][]string{{"SET", "src", "value", "EX", "100"}}, args: []string{"COPY", "src", "dst"}, want: ":1\r\n", check: []string{"TTL", "dst"}, wantCheck: ":100\r\n"},
+		{name: "COPY list", setup: [][]string{{"RPUSH", "src", "a", "b"}}, args: []string{"COPY", "src", "dst"}, want: ":1\r\n", check: []string{"LRANGE", "dst", "0", "-1"}, wantCheck: "*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{name: "COPY existing destination", setup: [][]string{{"SET", "src", "value"}, {"SET", "dst", "old"}}, args: []string{"COPY", "src", "dst"}, want: ":0\r\n", check: []string{"GET", "dst"}, wantCheck: "$3\r\nold\r\n"},
+		{name: "COPY REPLACE", setup: [][]string{{"SET", "src", "value"}, {"SET", "dst", "old"}}, args: []string{"COPY", "src", "dst", "REPLACE"}, want: ":1\r\n", check: []string{"GET", "dst"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "COPY missing key", args: []string{"COPY", "src", "dst", "REPLACE"}, want: ":0\r\n", check: []string{"EXISTS", "dst"}, wantCheck: ":0\r\n"},
+		{name: "COPY to itself", setup: [][]string{{"SET", "src", "value"}}, args: []string{"COPY", "src", "src"}, want: "-source and destination objects are the same\r\n"},
+		{name: "COPY DB 0", setup: [][]string{{"SET", "src", "value"}}, args: []string{"COPY", "src", "dst", "DB", "0", "REPLACE"}, want: ":1\r\n", check: []string{"GET", "dst"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "COPY DB out of range", setup: [][]string{{"SET", "src", "value"}}, args: []string{"COPY", "src", "dst", "DB", "1"}, want: "-DB index is out of range\r\n", check: []string{"EXISTS", "dst"}, wantCheck: ":0\r\n"},
+		{name: "COPY DB not integer", args: []string{"COPY", "src", "dst", "DB", "x"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "COPY DB without index", args: []string{"COPY", "src", "dst", "DB"}, want: "-syntax error\r\n"},
+		{name: "COPY unknown option", args: []string{"COPY", "src", "dst", "FORCE"}, want: "-syntax error\r\n"},
+
+		{name: "MOVE", setup: [][]string{{"SET", "key", "value"}}, args: []string{"MOVE", "key", "1"}, want: "-DB index is out of range\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "MOVE to current DB", setup: [][]string{{"SET", "key", "value"}}, args: []string{"MOVE", "key", "0"}, want: "-source and destination objects are the same\r\n"},
+		{name: "MOVE DB not integer", args: []string{"MOVE", "key", "x"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "MOVE wrong number of arguments", args: []string{"MOVE", "key"}, want: "-Wrong number of arguments for 'move' command\r\n"},
+
+		{name: "TOUCH", setup: [][]string{{"SET", "a", "1"}, {"RPUSH", "b", "x"}}, args: []string{"TOUCH", "a", "b", "c", "a"}, want: ":3\r\n"},
+		{name: "TOUCH wrong number of arguments", args: []string{"TOUCH"}, want: "-Wrong number of arguments for 'touch' command\r\n"},
+
+		{name: "UNLINK", setup: [][]string{{"SET", "a", "1"}, {"RPUSH", "b", "x"}}, args: []string{"UNLINK", "a", "b", "c", "a"}, want: ":2\r\n", check: []string{"DBSIZE"}, wantCheck: ":0\r\n"},
+		{name: "UNLINK wrong number of arguments", args: []string{"UNLINK"}, want: "-Wrong number of arguments for 'unlink' command\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			for _, args := range test.setup {
+				if args[0] == "SLEEP" {
+					time.Sleep(5 * time.Millisecond)
+					continue
+				}
+				serve(ctx, h, args...)
+			}
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+			if test.check != nil {
+				assert.Equal(t, test.wantCheck, serve(ctx, h, test.check...))
+			}
+		})
+	}
+}
+
+// TestRenameBlueGreen checks cache warming workflow: new version is built in a temporary key
+// and then renamed over the live one, so readers see either old or new version completely.
+func TestRenameBlueGreen(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	writerCtx := connect(t, h)
+	serve(ctx, h, "RPUSH", "cache", "v0", "v0")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 200; i++ {
+			version := "v" + strconv.Itoa(i)
+			serve(writerCtx, h, "DEL", "cache:tmp")
+			serve(writerCtx, h, "RPUSH", "cache:tmp", version)
+			serve(writerCtx, h, "RPUSH", "cache:tmp", version)
+			assert.Equal(t, "+OK\r\n", serve(writerCtx, h, "RENAME", "cache:tmp", "cache"))
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			assert.Equal(t, "*2\r\n$4\r\nv200\r\n$4\r\nv200\r\n", serve(ctx, h, "LRANGE", "cache", "0", "-1"))
+			assert.Equal(t, ":0\r\n", serve(ctx, h, "EXISTS", "cache:tmp"))
+			return
+		default:
+		}
+
+		elems := parseArray(t, serve(ctx, h, "LRANGE", "cache", "0", "-1"))
+		require.Len(t, elems, 2, "live key is missing or incomplete")
+		require.Equal(t, elems[0], elems[1], "live key mixes versions")
+	}
+}
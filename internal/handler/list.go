@@ -0,0 +1,576 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdRPop      = "rpop"
+	cmdLIndex    = "lindex"
+	cmdLSet      = "lset"
+	cmdLInsert   = "linsert"
+	cmdLRem      = "lrem"
+	cmdLTrim     = "ltrim"
+	cmdLPos      = "lpos"
+	cmdLPushX    = "lpushx"
+	cmdRPushX    = "rpushx"
+	cmdLMove     = "lmove"
+	cmdRPopLPush = "rpoplpush"
+	cmdLMPop     = "lmpop"
+
+	cmdBLPop      = "blpop"
+	cmdBRPop      = "brpop"
+	cmdBLMove     = "blmove"
+	cmdBRPopLPush = "brpoplpush"
+	cmdBLMPop     = "blmpop"
+)
+
+var (
+	ErrIndexOutOfRange = "index out of range"
+	ErrRankZero        = "RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the last match"
+	ErrCountNegative   = "COUNT can't be negative"
+	ErrMaxLenNegative  = "MAXLEN can't be negative"
+	ErrNumKeys         = "numkeys should be greater than 0"
+	ErrCountPositive   = "count should be greater than 0"
+	ErrTimeoutInvalid  = "timeout is not a float or out of range"
+	ErrTimeoutNegative = "timeout is negative"
+)
+
+func (h *Handler) rPopHandler(ctx context.Context, args []string) []byte {
+	return h.popGeneric(ctx, args, cmdRPop, false)
+}
+
+// popGeneric implements LPOP and RPOP.
+// Syntax: <cmd> key [count]
+func (h *Handler) popGeneric(ctx context.Context, args []string, cmd string, left bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 || len(args) > 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	pop := h.storage.RPop
+	if left {
+		pop = h.storage.LPop
+	}
+	key := args[1]
+
+	if len(args) == 2 {
+		values, err := pop(key, 1)
+		if err != nil {
+			return h.stringReply(ctx, "", err)
+		}
+		return h.stringReply(ctx, values[0], nil)
+	}
+
+	// len(args) == 3
+	n, err := strconv.Atoi(args[2])
+	if err != nil || n < 0 {
+		return h.errorReply(ctx, ErrNegativeVal)
+	}
+
+	values, err := pop(key, n)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNilArray()
+	}
+
+	log.Info(responseMsg, zap.Strings("response", values))
+	return resp.EncodeArray(values)
+}
+
+// lIndexHandler returns element of the list by its index.
+// Syntax: LINDEX key index
+func (h *Handler) lIndexHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdLIndex)
+	}
+
+	index, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+
+	value, err := h.storage.LIndex(args[1], index)
+	return h.stringReply(ctx, value, err)
+}
+
+// lSetHandler sets value of the list element by its index.
+// Syntax: LSET key index element
+func (h *Handler) lSetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdLSet)
+	}
+
+	index, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+
+	err = h.storage.LSet(args[1], index, args[3])
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return h.errorReply(ctx, ErrNoSuchKey)
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrOutOfRange):
+		return h.errorReply(ctx, ErrIndexOutOfRange)
+	}
+
+	log.Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// lInsertHandler inserts element before or after pivot element.
+// Syntax: LINSERT key BEFORE|AFTER pivot element
+func (h *Handler) lInsertHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 5 {
+		return h.wrongNumberOfArgs(ctx, cmdLInsert)
+	}
+
+	var before bool
+	switch strings.ToLower(args[2]) {
+	case "before":
+		before = true
+	case "after":
+		before = false
+	default:
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	length, err := h.storage.LInsert(args[1], before, args[3], args[4])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// lRemHandler removes elements equal to given one.
+// Positive count removes first matches, negative one removes last matches, zero removes all.
+// Syntax: LREM key count element
+func (h *Handler) lRemHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdLRem)
+	}
+
+	count, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+
+	removed, err := h.storage.LRem(args[1], count, args[3])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", removed))
+	return resp.EncodeInt(removed)
+}
+
+// lTrimHandler leaves only elements in the given range of indexes.
+// Syntax: LTRIM key start stop
+func (h *Handler) lTrimHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdLTrim)
+	}
+
+	start, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	stop, err := strconv.Atoi(args[3])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+
+	if err := h.storage.LTrim(args[1], start, stop); errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// lPosHandler returns indexes of elements equal to given one.
+// Without COUNT a single index is returned.
+// Syntax: LPOS key element [RANK rank] [COUNT num-matches] [MAXLEN len]
+func (h *Handler) lPosHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdLPos)
+	}
+
+	opts := storage.LPosOptions{Rank: 1}
+	withCount := false
+	for i := 3; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return h.errorReply(ctx, ErrSyntax)
+		}
+		num, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			return h.errorReply(ctx, ErrInvalidInt)
+		}
+
+		switch strings.ToLower(args[i]) {
+		case "rank":
+			if num == 0 {
+				return h.errorReply(ctx, ErrRankZero)
+			}
+			// rank is negated to search from the tail, so the min value cannot be used
+			if num == math.MinInt {
+				return h.errorReply(ctx, ErrInvalidInt)
+			}
+			opts.Rank = num
+		case "count":
+			if num < 0 {
+				return h.errorReply(ctx, ErrCountNegative)
+			}
+			opts.Count = num
+			withCount = true
+		case "maxlen":
+			if num < 0 {
+				return h.errorReply(ctx, ErrMaxLenNegative)
+			}
+			opts.MaxLen = num
+		default:
+			return h.errorReply(ctx, ErrSyntax)
+		}
+	}
+	if !withCount {
+		opts.Count = 1
+	}
+
+	positions, err := h.storage.LPos(args[1], args[2], opts)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	if withCount {
+		log.Info(responseMsg, zap.Ints("response", positions))
+		elems := make([][]byte, 0, len(positions))
+		for _, pos := range positions {
+			elems = append(elems, resp.EncodeInt(pos))
+		}
+		return resp.EncodeRawArray(elems)
+	}
+
+	if len(positions) == 0 {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNull()
+	}
+	log.Info(responseMsg, zap.Int("response", positions[0]))
+	return resp.EncodeInt(positions[0])
+}
+
+func (h *Handler) lPushXHandler(ctx context.Context, args []string) []byte {
+	return h.pushXGeneric(ctx, args, cmdLPushX, true)
+}
+
+func (h *Handler) rPushXHandler(ctx context.Context, args []string) []byte {
+	return h.pushXGeneric(ctx, args, cmdRPushX, false)
+}
+
+// pushXGeneric implements LPUSHX and RPUSHX, which push elements only to existing lists.
+// Syntax: <cmd> key element [element ...]
+func (h *Handler) pushXGeneric(ctx context.Context, args []string, cmd string, left bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	length, err := h.storage.PushX(args[1], args[2:], left)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// lMoveHandler atomically moves element from one list to another.
+// Syntax: LMOVE source destination LEFT|RIGHT LEFT|RIGHT
+func (h *Handler) lMoveHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 5 {
+		return h.wrongNumberOfArgs(ctx, cmdLMove)
+	}
+
+	srcLeft, ok := parseListEnd(args[3])
+	if !ok {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+	dstLeft, ok := parseListEnd(args[4])
+	if !ok {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	value, err := h.storage.LMove(args[1], args[2], srcLeft, dstLeft)
+	return h.stringReply(ctx, value, err)
+}
+
+// rPopLPushHandler moves the last element of one list to the head of another.
+// It is the same as LMOVE source destination RIGHT LEFT.
+// Syntax: RPOPLPUSH source destination
+func (h *Handler) rPopLPushHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdRPopLPush)
+	}
+
+	value, err := h.storage.LMove(args[1], args[2], false, true)
+	return h.stringReply(ctx, value, err)
+}
+
+// lMPopHandler pops elements from the first non-empty list among given keys.
+// Syntax: LMPOP numkeys key [key ...] LEFT|RIGHT [COUNT count]
+func (h *Handler) lMPopHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdLMPop)
+	}
+
+	keys, left, count, errReply := parseMPopArgs(args[1:])
+	if errReply != nil {
+		log.Info(responseMsg, zap.ByteString("response", errReply))
+		return errReply
+	}
+
+	key, values, err := h.storage.LMPop(keys, left, count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNilArray()
+	}
+
+	log.Info(responseMsg, zap.String("key", key), zap.Strings("response", values))
+	return resp.EncodeRawArray([][]byte{resp.EncodeString(key), resp.EncodeArray(values)})
+}
+
+// parseMPopArgs parses arguments of LMPOP starting from numkeys.
+// It returns encoded error reply for invalid arguments.
+func parseMPopArgs(args []string) (keys []string, left bool, count int, errReply []byte) {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, false, 0, resp.EncodeError(ErrInvalidInt)
+	}
+	if numKeys <= 0 {
+		return nil, false, 0, resp.EncodeError(ErrNumKeys)
+	}
+	// numkeys is followed by keys and direction at least
+	if numKeys > len(args)-2 {
+		return nil, false, 0, resp.EncodeError(ErrSyntax)
+	}
+	keys = args[1 : numKeys+1]
+
+	left, ok := parseListEnd(args[numKeys+1])
+	if !ok {
+		return nil, false, 0, resp.EncodeError(ErrSyntax)
+	}
+
+	count = 1
+	rest := args[numKeys+2:]
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.EqualFold(rest[0], "count"):
+		count, err = strconv.Atoi(rest[1])
+		if err != nil || count <= 0 {
+			return nil, false, 0, resp.EncodeError(ErrCountPositive)
+		}
+	default:
+		return nil, false, 0, resp.EncodeError(ErrSyntax)
+	}
+
+	return keys, left, count, nil
+}
+
+// parseListEnd parses LEFT or RIGHT argument. It returns true for LEFT.
+func parseListEnd(arg string) (left bool, ok bool) {
+	switch strings.ToLower(arg) {
+	case "left":
+		return true, true
+	case "right":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func (h *Handler) bLPopHandler(ctx context.Context, args []string) []byte {
+	return h.bPopGeneric(ctx, args, cmdBLPop, true)
+}
+
+func (h *Handler) bRPopHandler(ctx context.Context, args []string) []byte {
+	return h.bPopGeneric(ctx, args, cmdBRPop, false)
+}
+
+// bPopGeneric implements BLPOP and BRPOP, which pop one element
+// from the first non-empty list or block until there is one.
+// Syntax: <cmd> key [key ...] timeout
+func (h *Handler) bPopGeneric(ctx context.Context, args []string, cmd string, left bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	timeout, errMsg := parseTimeout(args[len(args)-1])
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	ctx, cancel := h.blockingContext(ctx, timeout)
+	defer cancel()
+
+	key, values, err := h.storage.BLMPop(ctx, args[1:len(args)-1], left, 1)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNilArray()
+	}
+
+	log.Info(responseMsg, zap.String("key", key), zap.String("response", values[0]))
+	return resp.EncodeArray([]string{key, values[0]})
+}
+
+// bLMoveHandler is a blocking variant of LMOVE.
+// Syntax: BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout
+func (h *Handler) bLMoveHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 6 {
+		return h.wrongNumberOfArgs(ctx, cmdBLMove)
+	}
+
+	srcLeft, ok := parseListEnd(args[3])
+	if !ok {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+	dstLeft, ok := parseListEnd(args[4])
+	if !ok {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	return h.bLMove(ctx, args[1], args[2], srcLeft, dstLeft, args[5])
+}
+
+// bRPopLPushHandler is a blocking variant of RPOPLPUSH.
+// Syntax: BRPOPLPUSH source destination timeout
+func (h *Handler) bRPopLPushHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdBRPopLPush)
+	}
+
+	return h.bLMove(ctx, args[1], args[2], false, true, args[3])
+}
+
+func (h *Handler) bLMove(ctx context.Context, src, dst string, srcLeft, dstLeft bool, timeoutArg string) []byte {
+	timeout, errMsg := parseTimeout(timeoutArg)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	ctx, cancel := h.blockingContext(ctx, timeout)
+	defer cancel()
+
+	value, err := h.storage.BLMove(ctx, src, dst, srcLeft, dstLeft)
+	if err != nil && !errors.Is(err, storage.ErrWrongType) {
+		// timeout is reported as null reply
+		err = storage.ErrKeyNotFound
+	}
+
+	return h.stringReply(ctx, value, err)
+}
+
+// bLMPopHandler is a blocking variant of LMPOP.
+// Syntax: BLMPOP timeout numkeys key [key ...] LEFT|RIGHT [COUNT count]
+func (h *Handler) bLMPopHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 5 {
+		return h.wrongNumberOfArgs(ctx, cmdBLMPop)
+	}
+
+	timeout, errMsg := parseTimeout(args[1])
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+	keys, left, count, errReply := parseMPopArgs(args[2:])
+	if errReply != nil {
+		log.Info(responseMsg, zap.ByteString("response", errReply))
+		return errReply
+	}
+
+	ctx, cancel := h.blockingContext(ctx, timeout)
+	defer cancel()
+
+	key, values, err := h.storage.BLMPop(ctx, keys, left, count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNilArray()
+	}
+
+	log.Info(responseMsg, zap.String("key", key), zap.Strings("response", values))
+	return resp.EncodeRawArray([][]byte{resp.EncodeString(key), resp.EncodeArray(values)})
+}
+
+// blockingContext returns context for blocking command, which is done after timeout.
+// Zero timeout means blocking until the client disconnects or server shuts down.
+// Inside transaction commands can't block, so returned context is done already.
+func (h *Handler) blockingContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if clientFromContext(ctx).executing {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		return ctx, cancel
+	}
+	// exec lock is held for the non-blocking attempt only, so it doesn't
+	// prevent transactions from being executed while client waits
+	ctx = storage.WithParking(ctx, h.execMu.RUnlock, h.execMu.RLock)
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// parseTimeout parses timeout of blocking command given in seconds.
+// It returns error message for invalid timeouts.
+func parseTimeout(arg string) (time.Duration, string) {
+	seconds, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) ||
+		seconds > float64(math.MaxInt64)/float64(time.Second) {
+		return 0, ErrTimeoutInvalid
+	}
+	if seconds < 0 {
+		return 0, ErrTimeoutNegative
+	}
+
+	return time.Duration(seconds * float64(time.Second)), ""
+}
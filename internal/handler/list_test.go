@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// abc is a setup of list "list" with elements a, b, c.
+var abc = [][]string{{"RPUSH", "list", "a", "b", "c"}}
+
+func TestListPush(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "RPUSH", setup: abc, args: []string{"RPUSH", "list", "d", "e"}, want: ":5\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*5\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\nd\r\n$1\r\ne\r\n"},
+		{name: "LPUSH", setup: abc, args: []string{"LPUSH", "list", "d", "e"}, want: ":5\r\n", check: []string{"LRANGE", "list", "0", "1"}, wantCheck: "*2\r\n$1\r\ne\r\n$1\r\nd\r\n"},
+		{name: "RPUSH to string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"RPUSH", "key", "a"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "LPUSH to string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"LPUSH", "key", "a"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+
+		{name: "LPUSHX", setup: abc, args: []string{"LPUSHX", "list", "z"}, want: ":4\r\n", check: []string{"LINDEX", "list", "0"}, wantCheck: "$1\r\nz\r\n"},
+		{name: "RPUSHX", setup: abc, args: []string{"RPUSHX", "list", "z", "y"}, want: ":5\r\n", check: []string{"LINDEX", "list", "-1"}, wantCheck: "$1\r\ny\r\n"},
+		{name: "LPUSHX missing key", args: []string{"LPUSHX", "list", "z"}, want: ":0\r\n", check: []string{"EXISTS", "list"}, wantCheck: ":0\r\n"},
+		{name: "RPUSHX string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"RPUSHX", "key", "z"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestListPop(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "LPOP", setup: abc, args: []string{"LPOP", "list"}, want: "$1\r\na\r\n"},
+		{name: "RPOP", setup: abc, args: []string{"RPOP", "list"}, want: "$1\r\nc\r\n"},
+		{name: "RPOP with count", setup: abc, args: []string{"RPOP", "list", "2"}, want: "*2\r\n$1\r\nc\r\n$1\r\nb\r\n"},
+		{name: "RPOP count exceeds length", setup: abc, args: []string{"RPOP", "list", "5"}, want: "*3\r\n$1\r\nc\r\n$1\r\nb\r\n$1\r\na\r\n", check: []string{"EXISTS", "list"}, wantCheck: ":0\r\n"},
+		{name: "RPOP zero count", setup: abc, args: []string{"RPOP", "list", "0"}, want: "*0\r\n"},
+		{name: "RPOP negative count", setup: abc, args: []string{"RPOP", "list", "-1"}, want: "-value is out of range, must be positive\r\n"},
+		{name: "RPOP missing key", args: []string{"RPOP", "list"}, want: "$-1\r\n"},
+		{name: "RPOP missing key with count", args: []string{"RPOP", "list", "2"}, want: "*-1\r\n"},
+		{name: "RPOP string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"RPOP", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "RPOP last element deletes key", setup: [][]string{{"RPUSH", "list", "a"}}, args: []string{"RPOP", "list"}, want: "$1\r\na\r\n", check: []string{"TYPE", "list"}, wantCheck: "+none\r\n"},
+	})
+}
+
+func TestListIndex(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "LINDEX", setup: abc, args: []string{"LINDEX", "list", "1"}, want: "$1\r\nb\r\n"},
+		{name: "LINDEX negative", setup: abc, args: []string{"LINDEX", "list", "-1"}, want: "$1\r\nc\r\n"},
+		{name: "LINDEX out of range", setup: abc, args: []string{"LINDEX", "list", "3"}, want: "$-1\r\n"},
+		{name: "LINDEX not integer", setup: abc, args: []string{"LINDEX", "list", "x"}, want: "-Value is not an integer or out of range\r\n"},
+
+		{name: "LSET", setup: abc, args: []string{"LSET", "list", "-1", "z"}, want: "+OK\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nz\r\n"},
+		{name: "LSET out of range", setup: abc, args: []string{"LSET", "list", "3", "z"}, want: "-index out of range\r\n"},
+		{name: "LSET missing key", args: []string{"LSET", "list", "0", "z"}, want: "-no such key\r\n"},
+
+		{name: "LINSERT BEFORE", setup: abc, args: []string{"LINSERT", "list", "BEFORE", "b", "z"}, want: ":4\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*4\r\n$1\r\na\r\n$1\r\nz\r\n$1\r\nb\r\n$1\r\nc\r\n"},
+		{name: "LINSERT AFTER last", setup: abc, args: []string{"LINSERT", "list", "after", "c", "z"}, want: ":4\r\n", check: []string{"LINDEX", "list", "-1"}, wantCheck: "$1\r\nz\r\n"},
+		{name: "LINSERT missing pivot", setup: abc, args: []string{"LINSERT", "list", "BEFORE", "x", "z"}, want: ":-1\r\n"},
+		{name: "LINSERT missing key", args: []string{"LINSERT", "list", "BEFORE", "x", "z"}, want: ":0\r\n"},
+		{name: "LINSERT syntax error", setup: abc, args: []string{"LINSERT", "list", "AROUND", "b", "z"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestListRemTrim(t *testing.T) {
+	abab := [][]string{{"RPUSH", "list", "a", "b", "a", "b", "a"}}
+
+	runCommandTests(t, []commandTest{
+		{name: "LREM first matches", setup: abab, args: []string{"LREM", "list", "2", "a"}, want: ":2\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*3\r\n$1\r\nb\r\n$1\r\nb\r\n$1\r\na\r\n"},
+		{name: "LREM last matches", setup: abab, args: []string{"LREM", "list", "-2", "a"}, want: ":2\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nb\r\n"},
+		{name: "LREM all matches", setup: abab, args: []string{"LREM", "list", "0", "a"}, want: ":3\r\n", check: []string{"LLEN", "list"}, wantCheck: ":2\r\n"},
+		{name: "LREM every element", setup: [][]string{{"RPUSH", "list", "a", "a"}}, args: []string{"LREM", "list", "0", "a"}, want: ":2\r\n", check: []string{"EXISTS", "list"}, wantCheck: ":0\r\n"},
+		{name: "LREM missing key", args: []string{"LREM", "list", "0", "a"}, want: ":0\r\n"},
+
+		{name: "LTRIM", setup: abc, args: []string{"LTRIM", "list", "1", "-1"}, want: "+OK\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*2\r\n$1\r\nb\r\n$1\r\nc\r\n"},
+		{name: "LTRIM out of range", setup: abc, args: []string{"LTRIM", "list", "1", "100"}, want: "+OK\r\n", check: []string{"LLEN", "list"}, wantCheck: ":2\r\n"},
+		{name: "LTRIM empty range deletes key", setup: abc, args: []string{"LTRIM", "list", "2", "1"}, want: "+OK\r\n", check: []string{"EXISTS", "list"}, wantCheck: ":0\r\n"},
+		{name: "LTRIM not integer", setup: abc, args: []string{"LTRIM", "list", "0", "x"}, want: "-Value is not an integer or out of range\r\n"},
+	})
+}
+
+func TestLPos(t *testing.T) {
+	abab := [][]string{{"RPUSH", "list", "a", "b", "a", "b", "a"}}
+
+	runCommandTests(t, []commandTest{
+		{name: "First match", setup: abab, args: []string{"LPOS", "list", "b"}, want: ":1\r\n"},
+		{name: "No match", setup: abab, args: []string{"LPOS", "list", "z"}, want: "$-1\r\n"},
+		{name: "RANK", setup: abab, args: []string{"LPOS", "list", "a", "RANK", "2"}, want: ":2\r\n"},
+		{name: "Negative RANK", setup: abab, args: []string{"LPOS", "list", "a", "RANK", "-1"}, want: ":4\r\n"},
+		{name: "COUNT", setup: abab, args: []string{"LPOS", "list", "a", "COUNT", "2"}, want: "*2\r\n:0\r\n:2\r\n"},
+		{name: "COUNT 0 returns all", setup: abab, args: []string{"LPOS", "list", "a", "COUNT", "0"}, want: "*3\r\n:0\r\n:2\r\n:4\r\n"},
+		{name: "COUNT with negative RANK", setup: abab, args: []string{"LPOS", "list", "a", "RANK", "-1", "COUNT", "2"}, want: "*2\r\n:4\r\n:2\r\n"},
+		{name: "COUNT without matches", setup: abab, args: []string{"LPOS", "list", "z", "COUNT", "1"}, want: "*0\r\n"},
+		{name: "MAXLEN", setup: abab, args: []string{"LPOS", "list", "a", "COUNT", "0", "MAXLEN", "3"}, want: "*2\r\n:0\r\n:2\r\n"},
+		{name: "RANK zero", setup: abab, args: []string{"LPOS", "list", "a", "RANK", "0"}, want: "-RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the last match\r\n"},
+		{name: "RANK min int", setup: abab, args: []string{"LPOS", "list", "a", "RANK", "-9223372036854775808"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Negative COUNT", setup: abab, args: []string{"LPOS", "list", "a", "COUNT", "-1"}, want: "-COUNT can't be negative\r\n"},
+		{name: "Negative MAXLEN", setup: abab, args: []string{"LPOS", "list", "a", "MAXLEN", "-1"}, want: "-MAXLEN can't be negative\r\n"},
+		{name: "Option without value", setup: abab, args: []string{"LPOS", "list", "a", "RANK"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestListMove(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "LMOVE RIGHT LEFT", setup: abc, args: []string{"LMOVE", "list", "dst", "RIGHT", "LEFT"}, want: "$1\r\nc\r\n", check: []string{"LRANGE", "dst", "0", "-1"}, wantCheck: "*1\r\n$1\r\nc\r\n"},
+		{name: "LMOVE to the same list", setup: abc, args: []string{"LMOVE", "list", "list", "LEFT", "RIGHT"}, want: "$1\r\na\r\n", check: []string{"LRANGE", "list", "0", "-1"}, wantCheck: "*3\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\na\r\n"},
+		{name: "LMOVE missing source", args: []string{"LMOVE", "list", "dst", "LEFT", "LEFT"}, want: "$-1\r\n", check: []string{"EXISTS", "dst"}, wantCheck: ":0\r\n"},
+		{name: "LMOVE destination of other type", setup: [][]string{{"RPUSH", "list", "a"}, {"SET", "dst", "value"}}, args: []string{"LMOVE", "list", "dst", "LEFT", "LEFT"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"LLEN", "list"}, wantCheck: ":1\r\n"},
+		{name: "LMOVE syntax error", setup: abc, args: []string{"LMOVE", "list", "dst", "UP", "LEFT"}, want: "-syntax error\r\n"},
+		{name: "RPOPLPUSH", setup: abc, args: []string{"RPOPLPUSH", "list", "dst"}, want: "$1\r\nc\r\n", check: []string{"LINDEX", "dst", "0"}, wantCheck: "$1\r\nc\r\n"},
+
+		{name: "LMPOP", setup: [][]string{{"RPUSH", "second", "a", "b"}}, args: []string{"LMPOP", "2", "first", "second", "LEFT"}, want: "*2\r\n$6\r\nsecond\r\n*1\r\n$1\r\na\r\n"},
+		{name: "LMPOP COUNT", setup: abc, args: []string{"LMPOP", "1", "list", "RIGHT", "COUNT", "5"}, want: "*2\r\n$4\r\nlist\r\n*3\r\n$1\r\nc\r\n$1\r\nb\r\n$1\r\na\r\n"},
+		{name: "LMPOP empty", args: []string{"LMPOP", "2", "first", "second", "LEFT"}, want: "*-1\r\n"},
+		{name: "LMPOP zero numkeys", args: []string{"LMPOP", "0", "list", "LEFT"}, want: "-numkeys should be greater than 0\r\n"},
+		{name: "LMPOP numkeys exceeds keys", args: []string{"LMPOP", "3", "list", "LEFT"}, want: "-syntax error\r\n"},
+		{name: "LMPOP zero COUNT", args: []string{"LMPOP", "1", "list", "LEFT", "COUNT", "0"}, want: "-count should be greater than 0\r\n"},
+		{name: "LMPOP wrong direction", args: []string{"LMPOP", "1", "list", "UP"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestBlockingImmediate(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "BLPOP", setup: [][]string{{"RPUSH", "second", "a", "b"}}, args: []string{"BLPOP", "first", "second", "0"}, want: "*2\r\n$6\r\nsecond\r\n$1\r\na\r\n"},
+		{name: "BRPOP", setup: abc, args: []string{"BRPOP", "list", "0"}, want: "*2\r\n$4\r\nlist\r\n$1\r\nc\r\n"},
+		{name: "BLMOVE", setup: abc, args: []string{"BLMOVE", "list", "dst", "LEFT", "RIGHT", "0"}, want: "$1\r\na\r\n", check: []string{"LRANGE", "dst", "0", "-1"}, wantCheck: "*1\r\n$1\r\na\r\n"},
+		{name: "BRPOPLPUSH", setup: abc, args: []string{"BRPOPLPUSH", "list", "dst", "0"}, want: "$1\r\nc\r\n"},
+		{name: "BLMPOP", setup: abc, args: []string{"BLMPOP", "0", "1", "list", "LEFT", "COUNT", "2"}, want: "*2\r\n$4\r\nlist\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+
+		{name: "BLPOP timeout", args: []string{"BLPOP", "list", "0.01"}, want: "*-1\r\n"},
+		{name: "BLMOVE timeout", args: []string{"BLMOVE", "list", "dst", "LEFT", "LEFT", "0.01"}, want: "$-1\r\n"},
+		{name: "BLMPOP timeout", args: []string{"BLMPOP", "0.01", "1", "list", "LEFT"}, want: "*-1\r\n"},
+		{name: "BLPOP string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"BLPOP", "key", "0"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+
+		{name: "Negative timeout", args: []string{"BLPOP", "list", "-1"}, want: "-timeout is negative\r\n"},
+		{name: "Invalid timeout", args: []string{"BRPOP", "list", "x"}, want: "-timeout is not a float or out of range\r\n"},
+		{name: "Infinite timeout", args: []string{"BLMPOP", "inf", "1", "list", "LEFT"}, want: "-timeout is not a float or out of range\r\n"},
+		{name: "BLMOVE syntax error", args: []string{"BLMOVE", "list", "dst", "LEFT", "UP", "0"}, want: "-syntax error\r\n"},
+		{name: "BLMPOP zero numkeys", args: []string{"BLMPOP", "0", "0", "list", "LEFT"}, want: "-numkeys should be greater than 0\r\n"},
+	})
+}
+
+// TestBlockingServedInOrder checks that clients blocked on the key are served in order of their arrival
+// and that a single push serves only as many clients as there are elements.
+func TestBlockingServedInOrder(t *testing.T) {
+	h, ctx := newTestHandler(t)
+
+	replies := make([]chan string, 3)
+	for i := range replies {
+		replies[i] = make(chan string, 1)
+		clientCtx := connect(t, h)
+		go func() {
+			replies[i] <- serve(clientCtx, h, "BLPOP", "list", "0")
+		}()
+		require.Eventually(t, func() bool {
+			return h.storage.BlockedClients() == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	assert.Equal(t, ":2\r\n", serve(ctx, h, "RPUSH", "list", "a", "b"))
+	for i, want := range []string{"a", "b"} {
+		select {
+		case got := <-replies[i]:
+			assert.Equal(t, "*2\r\n$4\r\nlist\r\n$1\r\n"+want+"\r\n", got)
+		case <-time.After(time.Second):
+			t.Fatalf("client %d wasn't served", i)
+		}
+	}
+
+	// the last client is still blocked
+	assert.Equal(t, 1, h.storage.BlockedClients())
+	assert.Equal(t, ":0\r\n", serve(ctx, h, "EXISTS", "list"))
+	assert.Equal(t, ":1\r\n", serve(ctx, h, "LPUSH", "list", "c"))
+	assert.Equal(t, "*2\r\n$4\r\nlist\r\n$1\r\nc\r\n", <-replies[2])
+}
+
+// TestBlockingCancelled checks that blocked client is unblocked when its connection is closed.
+func TestBlockingCancelled(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	connCtx, cancel := context.WithCancel(connect(t, h))
+	reply := make(chan string, 1)
+	go func() {
+		reply <- serve(connCtx, h, "BLMOVE", "list", "dst", "LEFT", "LEFT", "0")
+	}()
+	require.Eventually(t, func() bool {
+		return h.storage.BlockedClients() == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case got := <-reply:
+		assert.Equal(t, "$-1\r\n", got)
+	case <-time.After(time.Second):
+		t.Fatal("client wasn't unblocked")
+	}
+	assert.Equal(t, 0, h.storage.BlockedClients())
+}
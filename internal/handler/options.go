@@ -0,0 +1,17 @@
+package handler
+
+type Option func(*Handler)
+
+// WithConfig enables CONFIG command family backed by given runtime config.
+func WithConfig(config Config) Option {
+	return func(h *Handler) {
+		h.config = config
+	}
+}
+
+// WithPersistence enables SAVE, BGSAVE and LASTSAVE commands.
+func WithPersistence(persistence Persistence) Option {
+	return func(h *Handler) {
+		h.persistence = persistence
+	}
+}
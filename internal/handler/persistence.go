@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nova/internal/persistence"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdSave     = "save"
+	cmdBGSave   = "bgsave"
+	cmdLastSave = "lastsave"
+)
+
+var (
+	ErrPersistenceDisabled = "Persistence is not available"
+	ErrSaveInProgress      = "Background save already in progress"
+	ErrSaveFailed          = "Failed to save snapshot: %s"
+)
+
+// Persistence saves snapshots of storage to disk.
+type Persistence interface {
+	// Save writes snapshot synchronously.
+	Save() error
+	// BGSave writes snapshot in background.
+	BGSave() error
+	Status() persistence.Status
+}
+
+// saveHandler synchronously saves snapshot of all keys.
+// Syntax: SAVE
+func (h *Handler) saveHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdSave)
+	}
+	if h.persistence == nil {
+		return h.errorReply(ctx, ErrPersistenceDisabled)
+	}
+
+	if err := h.persistence.Save(); err != nil {
+		return h.saveErrorReply(ctx, err)
+	}
+
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// bgSaveHandler saves snapshot of all keys in background.
+// Syntax: BGSAVE
+func (h *Handler) bgSaveHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdBGSave)
+	}
+	if h.persistence == nil {
+		return h.errorReply(ctx, ErrPersistenceDisabled)
+	}
+
+	if err := h.persistence.BGSave(); err != nil {
+		return h.saveErrorReply(ctx, err)
+	}
+
+	response := "Background saving started"
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", response))
+	return resp.EncodeSimpleString(response)
+}
+
+func (h *Handler) saveErrorReply(ctx context.Context, err error) []byte {
+	if errors.Is(err, persistence.ErrSaveInProgress) {
+		return h.errorReply(ctx, ErrSaveInProgress)
+	}
+	return h.errorReply(ctx, fmt.Sprintf(ErrSaveFailed, err))
+}
+
+// lastSaveHandler returns unix time of the last successful save.
+// Syntax: LASTSAVE
+func (h *Handler) lastSaveHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdLastSave)
+	}
+	if h.persistence == nil {
+		return h.errorReply(ctx, ErrPersistenceDisabled)
+	}
+
+	lastSave := h.persistence.Status().LastSave.Unix()
+
+	l.FromContext(ctx).Info(responseMsg, zap.Int64("response", lastSave))
+	return resp.EncodeInt(int(lastSave))
+}
+
+func (h *Handler) persistenceInfo() []infoField {
+	if h.persistence == nil {
+		return []infoField{}
+	}
+
+	status := h.persistence.Status()
+	inProgress, lastStatus := "0", "ok"
+	if status.SaveInProgress {
+		inProgress = "1"
+	}
+	if status.LastErr != nil {
+		lastStatus = "err"
+	}
+
+	return []infoField{
+		{"rdb_changes_since_last_save", strconv.FormatUint(status.ChangesSinceSave, 10)},
+		{"rdb_bgsave_in_progress", inProgress},
+		{"rdb_last_save_time", strconv.FormatInt(status.LastSave.Unix(), 10)},
+		{"rdb_last_bgsave_status", lastStatus},
+	}
+}
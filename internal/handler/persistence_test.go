@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"nova/internal/persistence"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPersistence returns configured errors instead of saving snapshots.
+type testPersistence struct {
+	saveErr   error
+	bgSaveErr error
+	status    persistence.Status
+}
+
+func (p *testPersistence) Save() error                { return p.saveErr }
+func (p *testPersistence) BGSave() error              { return p.bgSaveErr }
+func (p *testPersistence) Status() persistence.Status { return p.status }
+
+func TestPersistenceCommands(t *testing.T) {
+	var tests = []struct {
+		name        string
+		persistence *testPersistence
+		args        []string
+		want        string
+	}{
+		{
+			name:        "SAVE",
+			persistence: &testPersistence{},
+			args:        []string{"SAVE"},
+			want:        "+OK\r\n",
+		},
+		{
+			name:        "SAVE failed",
+			persistence: &testPersistence{saveErr: errors.New("disk is full")},
+			args:        []string{"SAVE"},
+			want:        "-Failed to save snapshot: disk is full\r\n",
+		},
+		{
+			name:        "SAVE during BGSAVE",
+			persistence: &testPersistence{saveErr: persistence.ErrSaveInProgress},
+			args:        []string{"SAVE"},
+			want:        "-Background save already in progress\r\n",
+		},
+		{
+			name:        "BGSAVE",
+			persistence: &testPersistence{},
+			args:        []string{"BGSAVE"},
+			want:        "+Background saving started\r\n",
+		},
+		{
+			name:        "BGSAVE during BGSAVE",
+			persistence: &testPersistence{bgSaveErr: persistence.ErrSaveInProgress},
+			args:        []string{"BGSAVE"},
+			want:        "-Background save already in progress\r\n",
+		},
+		{
+			name:        "LASTSAVE",
+			persistence: &testPersistence{status: persistence.Status{LastSave: time.Unix(1700000000, 0)}},
+			args:        []string{"LASTSAVE"},
+			want:        ":1700000000\r\n",
+		},
+		{
+			name:        "SAVE with arguments",
+			persistence: &testPersistence{},
+			args:        []string{"SAVE", "now"},
+			want:        "-Wrong number of arguments for 'save' command\r\n",
+		},
+		{
+			name: "SAVE without persistence",
+			args: []string{"SAVE"},
+			want: "-Persistence is not available\r\n",
+		},
+		{
+			name: "BGSAVE without persistence",
+			args: []string{"BGSAVE"},
+			want: "-Persistence is not available\r\n",
+		},
+		{
+			name: "LASTSAVE without persistence",
+			args: []string{"LASTSAVE"},
+			want: "-Persistence is not available\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var opts []Option
+			if test.persistence != nil {
+				opts = append(opts, WithPersistence(test.persistence))
+			}
+			h, ctx := newTestHandler(t, opts...)
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
+
+func TestPersistenceInfo(t *testing.T) {
+	h, ctx := newTestHandler(t, WithPersistence(&testPersistence{status: persistence.Status{
+		ChangesSinceSave: 3,
+		SaveInProgress:   true,
+		LastSave:         time.Unix(1700000000, 0),
+		LastErr:          errors.New("disk is full"),
+	}}))
+
+	info := serve(ctx, h, "INFO", "persistence")
+	for _, field := range []string{
+		"rdb_changes_since_last_save:3",
+		"rdb_bgsave_in_progress:1",
+		"rdb_last_save_time:1700000000",
+		"rdb_last_bgsave_status:err",
+	} {
+		assert.Contains(t, info, "\r\n"+field+"\r\n")
+	}
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"nova/internal/pubsub"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdSubscribe    = "subscribe"
+	cmdUnsubscribe  = "unsubscribe"
+	cmdPSubscribe   = "psubscribe"
+	cmdPUnsubscribe = "punsubscribe"
+	cmdSSubscribe   = "ssubscribe"
+	cmdSUnsubscribe = "sunsubscribe"
+	cmdPublish      = "publish"
+	cmdSPublish     = "spublish"
+	cmdPubSub       = "pubsub"
+)
+
+var (
+	ErrSubscribedContext = "Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING are allowed in this context"
+)
+
+var pubsubHelp = []string{
+	"PUBSUB <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+	"CHANNELS [<pattern>]",
+	"    Return the currently active channels matching a <pattern> (default: '*').",
+	"NUMPAT",
+	"    Return number of subscriptions to patterns.",
+	"NUMSUB [<channel> ...]",
+	"    Return the number of subscribers for the specified channels, excluding",
+	"    pattern subscriptions(default: no channels).",
+	"SHARDCHANNELS [<pattern>]",
+	"    Return the currently active shard level channels matching a <pattern> (default: '*').",
+	"SHARDNUMSUB [<shardchannel> ...]",
+	"    Return the number of subscribers for the specified shard level channel(s)",
+	"HELP",
+	"    Prints this help.",
+}
+
+// subscribedContextCmds are commands RESP2 client can execute while subscribed,
+// since its replies can't be distinguished from pushed messages otherwise.
+var subscribedContextCmds = map[string]struct{}{
+	cmdSubscribe:    {},
+	cmdUnsubscribe:  {},
+	cmdPSubscribe:   {},
+	cmdPUnsubscribe: {},
+	cmdSSubscribe:   {},
+	cmdSUnsubscribe: {},
+	cmdPing:         {},
+}
+
+// subscription describes one kind of pub/sub subscriptions of the client.
+type subscription struct {
+	subscribeKind   string
+	unsubscribeKind string
+	// names are subscribed channels or patterns of the client
+	names func(c *client) map[string]struct{}
+	// count returns number of subscriptions reported in confirmations
+	count       func(c *client) int
+	subscribe   func(b *pubsub.Broker, sub pubsub.Subscriber, name string, confirm func(ok bool)) bool
+	unsubscribe func(b *pubsub.Broker, sub pubsub.Subscriber, name string, confirm func(ok bool)) bool
+}
+
+var (
+	channelSubscription = subscription{
+		subscribeKind:   "subscribe",
+		unsubscribeKind: "unsubscribe",
+		names:           func(c *client) map[string]struct{} { return c.channels },
+		count:           (*client).subscriptions,
+		subscribe:       (*pubsub.Broker).Subscribe,
+		unsubscribe:     (*pubsub.Broker).Unsubscribe,
+	}
+	patternSubscription = subscription{
+		subscribeKind:   "psubscribe",
+		unsubscribeKind: "punsubscribe",
+		names:           func(c *client) map[string]struct{} { return c.patterns },
+		count:           (*client).subscriptions,
+		subscribe:       (*pubsub.Broker).PSubscribe,
+		unsubscribe:     (*pubsub.Broker).PUnsubscribe,
+	}
+	shardSubscription = subscription{
+		subscribeKind:   "ssubscribe",
+		unsubscribeKind: "sunsubscribe",
+		names:           func(c *client) map[string]struct{} { return c.shardChannels },
+		count:           func(c *client) int { return len(c.shardChannels) },
+		subscribe:       (*pubsub.Broker).SSubscribe,
+		unsubscribe:     (*pubsub.Broker).SUnsubscribe,
+	}
+)
+
+// confirmations collect replies to subscription changes.
+// Client connected to the server gets them pushed right away, before any message
+// published after the change. Otherwise, they're returned as a response.
+type confirmations struct {
+	c       *client
+	replies []byte
+}
+
+func (cf *confirmations) add(kind string, name []byte, count int) {
+	msg := cf.c.encodePushRaw([][]byte{
+		resp.EncodeString(kind),
+		name,
+		resp.EncodeInt(count),
+	})
+
+	if cf.c.push != nil {
+		cf.c.push(msg)
+		return
+	}
+	cf.replies = append(cf.replies, msg...)
+}
+
+// subscribe subscribes the client to every name from args.
+func (h *Handler) subscribe(ctx context.Context, args []string, s subscription) []byte {
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, args[0])
+	}
+
+	log := l.FromContext(ctx)
+	c := clientFromContext(ctx)
+
+	cf := &confirmations{c: c}
+	for _, name := range args[1:] {
+		s.subscribe(h.pubsub, c, name, func(bool) {
+			s.names(c)[name] = struct{}{}
+			cf.add(s.subscribeKind, resp.EncodeString(name), s.count(c))
+		})
+	}
+
+	log.Info(responseMsg, zap.Strings(s.subscribeKind, args[1:]))
+	return cf.replies
+}
+
+// unsubscribe unsubscribes the client from every name from args or from all of them if there is no names.
+func (h *Handler) unsubscribe(ctx context.Context, args []string, s subscription) []byte {
+	log := l.FromContext(ctx)
+	c := clientFromContext(ctx)
+
+	names := args[1:]
+	if len(names) == 0 {
+		for name := range s.names(c) {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+
+	cf := &confirmations{c: c}
+	for _, name := range names {
+		s.unsubscribe(h.pubsub, c, name, func(bool) {
+			delete(s.names(c), name)
+			cf.add(s.unsubscribeKind, resp.EncodeString(name), s.count(c))
+		})
+	}
+	// client is notified even if it wasn't subscribed to anything
+	if len(names) == 0 {
+		cf.add(s.unsubscribeKind, c.encodeNull(), s.count(c))
+	}
+
+	log.Info(responseMsg, zap.Strings(s.unsubscribeKind, names))
+	return cf.replies
+}
+
+// unsubscribeAll removes all pub/sub subscriptions of the client without confirmations.
+func (h *Handler) unsubscribeAll(c *client) {
+	for _, s := range []subscription{channelSubscription, patternSubscription, shardSubscription} {
+		for name := range s.names(c) {
+			s.unsubscribe(h.pubsub, c, name, func(bool) {})
+			delete(s.names(c), name)
+		}
+	}
+}
+
+// subscribeHandler subscribes the client to channels.
+// Syntax: SUBSCRIBE channel [channel ...]
+func (h *Handler) subscribeHandler(ctx context.Context, args []string) []byte {
+	return h.subscribe(ctx, args, channelSubscription)
+}
+
+// unsubscribeHandler unsubscribes the client from channels.
+// Syntax: UNSUBSCRIBE [channel [channel ...]]
+func (h *Handler) unsubscribeHandler(ctx context.Context, args []string) []byte {
+	return h.unsubscribe(ctx, args, channelSubscription)
+}
+
+// pSubscribeHandler subscribes the client to channels matching glob-style patterns.
+// Syntax: PSUBSCRIBE pattern [pattern ...]
+func (h *Handler) pSubscribeHandler(ctx context.Context, args []string) []byte {
+	return h.subscribe(ctx, args, patternSubscription)
+}
+
+// pUnsubscribeHandler unsubscribes the client from patterns.
+// Syntax: PUNSUBSCRIBE [pattern [pattern ...]]
+func (h *Handler) pUnsubscribeHandler(ctx context.Context, args []string) []byte {
+	return h.unsubscribe(ctx, args, patternSubscription)
+}
+
+// sSubscribeHandler subscribes the client to shard channels.
+// Syntax: SSUBSCRIBE shardchannel [shardchannel ...]
+func (h *Handler) sSubscribeHandler(ctx context.Context, args []string) []byte {
+	return h.subscribe(ctx, args, shardSubscription)
+}
+
+// sUnsubscribeHandler unsubscribes the client from shard channels.
+// Syntax: SUNSUBSCRIBE [shardchannel [shardchannel ...]]
+func (h *Handler) sUnsubscribeHandler(ctx context.Context, args []string) []byte {
+	return h.unsubscribe(ctx, args, shardSubscription)
+}
+
+// publishHandler posts message to channel and returns number of clients that received it.
+// Syntax: PUBLISH channel message
+func (h *Handler) publishHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, args[0])
+	}
+
+	receivers := h.pubsub.Publish(args[1], args[2])
+
+	l.FromContext(ctx).Info(responseMsg, zap.Int("response", receivers))
+	return resp.EncodeInt(receivers)
+}
+
+// sPublishHandler posts message to shard channel and returns number of clients that received it.
+// Syntax: SPUBLISH shardchannel message
+func (h *Handler) sPublishHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, args[0])
+	}
+
+	receivers := h.pubsub.SPublish(args[1], args[2])
+
+	l.FromContext(ctx).Info(responseMsg, zap.Int("response", receivers))
+	return resp.EncodeInt(receivers)
+}
+
+// pubSubHandler inspects state of pub/sub subsystem.
+// Syntax: PUBSUB CHANNELS|SHARDCHANNELS [pattern] | NUMSUB|SHARDNUMSUB [channel ...] | NUMPAT | HELP
+func (h *Handler) pubSubHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+	c := clientFromContext(ctx)
+
+	subcmd := ""
+	if len(args) > 1 {
+		subcmd = strings.ToLower(args[1])
+	}
+
+	switch {
+	case (subcmd == "channels" || subcmd == "shardchannels") && len(args) <= 3:
+		pattern := ""
+		if len(args) == 3 {
+			pattern = args[2]
+		}
+
+		channels := h.pubsub.Channels(pattern, subcmd == "shardchannels")
+		log.Info(responseMsg, zap.Strings("response", channels))
+		return resp.EncodeArray(channels)
+
+	case subcmd == "numsub" || subcmd == "shardnumsub":
+		channels := args[2:]
+		counts := h.pubsub.NumSub(channels, subcmd == "shardnumsub")
+
+		pairs := make([][]byte, 0, 2*len(channels))
+		for i, channel := range channels {
+			pairs = append(pairs, resp.EncodeString(channel), resp.EncodeInt(counts[i]))
+		}
+		log.Info(responseMsg, zap.Ints("response", counts))
+		return c.encodeMap(pairs)
+
+	case subcmd == "numpat" && len(args) == 2:
+		patterns := h.pubsub.NumPat()
+		log.Info(responseMsg, zap.Int("response", patterns))
+		return resp.EncodeInt(patterns)
+
+	case subcmd == "help" && len(args) == 2:
+		log.Info(responseMsg, zap.Strings("response", pubsubHelp))
+		elems := make([][]byte, 0, len(pubsubHelp))
+		for _, line := range pubsubHelp {
+			elems = append(elems, resp.EncodeSimpleString(line))
+		}
+		return resp.EncodeRawArray(elems)
+	}
+
+	response := fmt.Sprintf(ErrUnknownSubcmd, subcmd, strings.ToUpper(cmdPubSub))
+	log.Info(responseMsg, zap.String("response", response))
+	return resp.EncodeError(response)
+}
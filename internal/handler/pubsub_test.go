@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	l "nova/pkg/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// subscriber connects a client whose pushed messages are collected into returned slice.
+func subscriber(t *testing.T, h *Handler) (context.Context, *[]string) {
+	t.Helper()
+
+	var pushed []string
+	ctx := l.WithLogger(h.Connect(t.Context(), func(msg []byte) {
+		pushed = append(pushed, string(msg))
+	}), zap.NewNop())
+	t.Cleanup(func() { h.Disconnect(ctx) })
+
+	return ctx, &pushed
+}
+
+func TestPublish(t *testing.T) {
+	var tests = []struct {
+		name       string
+		subscribe  []string
+		publish    []string
+		want       string
+		wantPushed []string
+	}{
+		{
+			name:      "channel",
+			subscribe: []string{"SUBSCRIBE", "news", "sport"},
+			publish:   []string{"PUBLISH", "news", "hello"},
+			want:      ":1\r\n",
+			wantPushed: []string{
+				"*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n",
+				"*3\r\n$9\r\nsubscribe\r\n$5\r\nsport\r\n:2\r\n",
+				"*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+			},
+		},
+		{
+			name:      "other channel",
+			subscribe: []string{"SUBSCRIBE", "news"},
+			publish:   []string{"PUBLISH", "sport", "hello"},
+			want:      ":0\r\n",
+			wantPushed: []string{
+				"*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n",
+			},
+		},
+		{
+			name:      "pattern",
+			subscribe: []string{"PSUBSCRIBE", "n*s"},
+			publish:   []string{"PUBLISH", "news", "hello"},
+			want:      ":1\r\n",
+			wantPushed: []string{
+				"*3\r\n$10\r\npsubscribe\r\n$3\r\nn*s\r\n:1\r\n",
+				"*4\r\n$8\r\npmessage\r\n$3\r\nn*s\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+			},
+		},
+		{
+			name:      "shard channel",
+			subscribe: []string{"SSUBSCRIBE", "news"},
+			publish:   []string{"SPUBLISH", "news", "hello"},
+			want:      ":1\r\n",
+			wantPushed: []string{
+				"*3\r\n$10\r\nssubscribe\r\n$4\r\nnews\r\n:1\r\n",
+				"*3\r\n$8\r\nsmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+			},
+		},
+		{
+			name:      "shard channel is not channel",
+			subscribe: []string{"SSUBSCRIBE", "news"},
+			publish:   []string{"PUBLISH", "news", "hello"},
+			want:      ":0\r\n",
+			wantPushed: []string{
+				"*3\r\n$10\r\nssubscribe\r\n$4\r\nnews\r\n:1\r\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			ctx, pushed := subscriber(t, h)
+
+			assert.Empty(t, serve(ctx, h, test.subscribe...))
+			assert.Equal(t, test.want, serve(connect(t, h), h, test.publish...))
+			assert.Equal(t, test.wantPushed, *pushed)
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx, pushed := subscriber(t, h)
+
+	serve(ctx, h, "SUBSCRIBE", "news", "sport")
+	serve(ctx, h, "PSUBSCRIBE", "n*")
+	*pushed = nil
+
+	assert.Empty(t, serve(ctx, h, "UNSUBSCRIBE"))
+	assert.Equal(t, []string{
+		"*3\r\n$11\r\nunsubscribe\r\n$4\r\nnews\r\n:2\r\n",
+		"*3\r\n$11\r\nunsubscribe\r\n$5\r\nsport\r\n:1\r\n",
+	}, *pushed)
+	assert.Equal(t, ":1\r\n", serve(connect(t, h), h, "PUBLISH", "news", "hello"))
+
+	*pushed = nil
+	assert.Empty(t, serve(ctx, h, "PUNSUBSCRIBE", "n*"))
+	assert.Empty(t, serve(ctx, h, "PUNSUBSCRIBE"))
+	assert.Equal(t, []string{
+		"*3\r\n$12\r\npunsubscribe\r\n$2\r\nn*\r\n:0\r\n",
+		"*3\r\n$12\r\npunsubscribe\r\n$-1\r\n:0\r\n",
+	}, *pushed)
+	assert.Equal(t, ":0\r\n", serve(connect(t, h), h, "PUBLISH", "news", "hello"))
+}
+
+func TestSubscribeWithoutConnection(t *testing.T) {
+	h, ctx := newTestHandler(t)
+
+	assert.Equal(t, "*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n", serve(ctx, h, "SUBSCRIBE", "news"))
+	assert.Equal(t, "-Wrong number of arguments for 'subscribe' command\r\n", serve(ctx, h, "SUBSCRIBE"))
+}
+
+func TestSubscribedContext(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx, pushed := subscriber(t, h)
+
+	serve(ctx, h, "SUBSCRIBE", "news")
+	assert.Equal(t,
+		"-Can't execute 'get': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING are allowed in this context\r\n",
+		serve(ctx, h, "GET", "key"))
+	assert.Equal(t, "*2\r\n$4\r\npong\r\n$0\r\n\r\n", serve(ctx, h, "PING"))
+
+	serve(ctx, h, "UNSUBSCRIBE")
+	assert.Equal(t, "$-1\r\n", serve(ctx, h, "GET", "key"))
+
+	// RESP3 client can execute any command, since messages are pushed with distinct type
+	serve(ctx, h, "HELLO", "3")
+	*pushed = nil
+	serve(ctx, h, "SUBSCRIBE", "news")
+	assert.Equal(t, "_\r\n", serve(ctx, h, "GET", "key"))
+	assert.Equal(t, ":1\r\n", serve(connect(t, h), h, "PUBLISH", "news", "hello"))
+	assert.Equal(t, []string{
+		">3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n",
+		">3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n",
+	}, *pushed)
+}
+
+func TestPubSubIntrospection(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx, _ := subscriber(t, h)
+	serve(ctx, h, "SUBSCRIBE", "news", "sport")
+	serve(ctx, h, "PSUBSCRIBE", "n*")
+	serve(ctx, h, "SSUBSCRIBE", "shard")
+
+	var tests = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "CHANNELS",
+			args: []string{"PUBSUB", "CHANNELS"},
+			want: "*2\r\n$4\r\nnews\r\n$5\r\nsport\r\n",
+		},
+		{
+			name: "CHANNELS pattern",
+			args: []string{"PUBSUB", "CHANNELS", "s*"},
+			want: "*1\r\n$5\r\nsport\r\n",
+		},
+		{
+			name: "SHARDCHANNELS",
+			args: []string{"PUBSUB", "SHARDCHANNELS"},
+			want: "*1\r\n$5\r\nshard\r\n",
+		},
+		{
+			name: "NUMSUB",
+			args: []string{"PUBSUB", "NUMSUB", "news", "other"},
+			want: "*4\r\n$4\r\nnews\r\n:1\r\n$5\r\nother\r\n:0\r\n",
+		},
+		{
+			name: "SHARDNUMSUB",
+			args: []string{"PUBSUB", "SHARDNUMSUB", "shard"},
+			want: "*2\r\n$5\r\nshard\r\n:1\r\n",
+		},
+		{
+			name: "NUMPAT",
+			args: []string{"PUBSUB", "NUMPAT"},
+			want: ":1\r\n",
+		},
+		{
+			name: "unknown subcommand",
+			args: []string{"PUBSUB", "foo"},
+			want: "-Unknown subcommand or wrong number of arguments for 'foo'. Try PUBSUB HELP.\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, serve(connect(t, h), h, test.args...))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdScan = "scan"
+)
+
+var (
+	ErrInvalidCursor = "invalid cursor"
+)
+
+var (
+	defaultScanCount = 10
+)
+
+// scanOptions are options of SCAN family commands.
+type scanOptions struct {
+	cursor    uint64
+	count     int
+	pattern   string
+	valueType string
+}
+
+// parseScanArgs parses "cursor [MATCH pattern] [COUNT count] [TYPE type]".
+// TYPE option is allowed only if withType is true.
+// In case of error, encoded error reply is returned.
+func parseScanArgs(args []string, withType bool) (scanOptions, []byte) {
+	opts := scanOptions{count: defaultScanCount}
+
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return opts, resp.EncodeError(ErrInvalidCursor)
+	}
+	opts.cursor = cursor
+
+	for i := 1; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return opts, resp.EncodeError(ErrSyntax)
+		}
+
+		value := args[i+1]
+		switch strings.ToLower(args[i]) {
+		case "match":
+			// "*" matches everything, so there is no need to check keys at all
+			if value != "*" {
+				opts.pattern = value
+			}
+		case "count":
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return opts, resp.EncodeError(ErrInvalidInt)
+			}
+			if count < 1 {
+				return opts, resp.EncodeError(ErrSyntax)
+			}
+			opts.count = count
+		case "type":
+			if !withType {
+				return opts, resp.EncodeError(ErrSyntax)
+			}
+			opts.valueType = strings.ToLower(value)
+		default:
+			return opts, resp.EncodeError(ErrSyntax)
+		}
+	}
+
+	return opts, nil
+}
+
+// encodeScanReply encodes reply of SCAN family commands: next cursor and list of elements.
+func encodeScanReply(cursor uint64, elems []string) []byte {
+	return resp.EncodeRawArray([][]byte{
+		resp.EncodeString(strconv.FormatUint(cursor, 10)),
+		resp.EncodeArray(elems),
+	})
+}
+
+// scanHandler incrementally iterates over keys.
+// Keys which exist during the whole iteration are returned at least once.
+// Syntax: SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]
+func (h *Handler) scanHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdScan)
+	}
+
+	opts, errReply := parseScanArgs(args[1:], true)
+	if errReply != nil {
+		log.Info(responseMsg, zap.ByteString("response", errReply))
+		return errReply
+	}
+
+	cursor, keys := h.storage.Scan(opts.cursor, opts.count, opts.pattern, opts.valueType)
+
+	log.Info(responseMsg, zap.Uint64("cursor", cursor), zap.Strings("response", keys))
+	return encodeScanReply(cursor, keys)
+}
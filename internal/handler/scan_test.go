@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanAll iterates over keys with SCAN until cursor is 0 and returns sorted unique keys.
+// Function between is called after every call of SCAN.
+func scanAll(t *testing.T, ctx context.Context, h *Handler, between func(), opts ...string) []string {
+	t.Helper()
+
+	seen := map[string]struct{}{}
+	cursor := "0"
+	for range 10000 {
+		reply := serve(ctx, h, append([]string{"SCAN", cursor}, opts...)...)
+		lines := strings.Split(strings.TrimSuffix(reply, "\r\n"), "\r\n")
+		require.Greater(t, len(lines), 3, reply)
+		require.Equal(t, "*2", lines[0], reply)
+
+		cursor = lines[2]
+		for i := 5; i < len(lines); i += 2 {
+			seen[lines[i]] = struct{}{}
+		}
+		if between != nil {
+			between()
+		}
+		if cursor == "0" {
+			keys := []string{}
+			for key := range seen {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return keys
+		}
+	}
+
+	t.Fatal("SCAN didn't finish iteration")
+	return nil
+}
+
+// fillKeys sets n string keys "key:<i>" and returns their sorted names.
+func fillKeys(ctx context.Context, h *Handler, n int) []string {
+	keys := make([]string, 0, n)
+	for i := range n {
+		key := "key:" + strconv.Itoa(i)
+		serve(ctx, h, "SET", key, "value")
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestScan(t *testing.T) {
+	var tests = []struct {
+		name string
+		opts []string
+		want []string
+	}{
+		{name: "All keys", want: []string{"hello", "hallo", "list", "world"}},
+		{name: "COUNT", opts: []string{"COUNT", "1"}, want: []string{"hello", "hallo", "list", "world"}},
+		{name: "MATCH", opts: []string{"MATCH", "h*llo"}, want: []string{"hello", "hallo"}},
+		{name: "MATCH all", opts: []string{"MATCH", "*"}, want: []string{"hello", "hallo", "list", "world"}},
+		{name: "MATCH nothing", opts: []string{"MATCH", "nokey*"}, want: []string{}},
+		{name: "TYPE", opts: []string{"TYPE", "list"}, want: []string{"list"}},
+		{name: "TYPE case insensitive", opts: []string{"type", "STRING"}, want: []string{"hello", "hallo", "world"}},
+		{name: "MATCH and TYPE", opts: []string{"MATCH", "h*", "TYPE", "string", "COUNT", "2"}, want: []string{"hello", "hallo"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := setupKeys(t)
+			sort.Strings(test.want)
+			assert.Equal(t, test.want, scanAll(t, ctx, h, nil, test.opts...))
+		})
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "Wrong number of arguments", args: []string{"SCAN"}, want: "-Wrong number of arguments for 'scan' command\r\n"},
+		{name: "Invalid cursor", args: []string{"SCAN", "abc"}, want: "-invalid cursor\r\n"},
+		{name: "Negative cursor", args: []string{"SCAN", "-1"}, want: "-invalid cursor\r\n"},
+		{name: "Option without value", args: []string{"SCAN", "0", "MATCH"}, want: "-syntax error\r\n"},
+		{name: "Unknown option", args: []string{"SCAN", "0", "LIMIT", "1"}, want: "-syntax error\r\n"},
+		{name: "Not integer COUNT", args: []string{"SCAN", "0", "COUNT", "x"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Zero COUNT", args: []string{"SCAN", "0", "COUNT", "0"}, want: "-syntax error\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
+
+// TestScanGuarantees checks that keys existing during the whole iteration are returned
+// even if other keys are added and deleted meanwhile.
+func TestScanGuarantees(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	want := fillKeys(ctx, h, 100)
+
+	i := 0
+	modify := func() {
+		serve(ctx, h, "SET", "new:"+strconv.Itoa(i), "value")
+		serve(ctx, h, "DEL", "new:"+strconv.Itoa(i/2))
+		i++
+	}
+
+	got := scanAll(t, ctx, h, modify, "MATCH", "key:*", "COUNT", "7")
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdConfig = "config"
+)
+
+var (
+	ErrUnknownSubcmd   = "Unknown subcommand or wrong number of arguments for '%s'. Try %s HELP."
+	ErrConfigDisabled  = "CONFIG is not available"
+	ErrConfigSetFailed = "CONFIG SET failed - %s"
+	ErrConfigRewrite   = "Rewriting config file: %s"
+)
+
+var (
+	configHelp = []string{
+		"CONFIG <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+		"GET <pattern> [<pattern> ...]",
+		"    Return parameters matching the glob-like <pattern> and their values.",
+		"SET <directive> <value> [<directive> <value> ...]",
+		"    Set the configuration <directive> to <value>.",
+		"REWRITE",
+		"    Rewrite the configuration file.",
+		"HELP",
+		"    Print this help.",
+	}
+)
+
+// configHandler executes CONFIG subcommands: GET, SET, REWRITE and HELP.
+func (h *Handler) configHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if h.config == nil {
+		log.Info(responseMsg, zap.String("response", ErrConfigDisabled))
+		return resp.EncodeError(ErrConfigDisabled)
+	}
+
+	subcmd := ""
+	if len(args) > 1 {
+		subcmd = strings.ToLower(args[1])
+	}
+
+	switch {
+	case subcmd == "get" && len(args) > 2:
+		pairs := h.config.Get(args[2:])
+
+		elems := make([][]byte, 0, len(pairs))
+		for _, s := range pairs {
+			elems = append(elems, resp.EncodeString(s))
+		}
+		log.Info(responseMsg, zap.Strings("response", pairs))
+		return clientFromContext(ctx).encodeMap(elems)
+
+	case subcmd == "set" && len(args) > 3 && len(args)%2 == 0:
+		if err := h.config.Set(args[2:]); err != nil {
+			response := fmt.Sprintf(ErrConfigSetFailed, err.Error())
+			log.Info(responseMsg, zap.String("response", response))
+			return resp.EncodeError(response)
+		}
+
+		log.Info(responseMsg, zap.String("response", "OK"))
+		return resp.EncodeSimpleString("OK")
+
+	case subcmd == "rewrite" && len(args) == 2:
+		if err := h.config.Rewrite(); err != nil {
+			response := fmt.Sprintf(ErrConfigRewrite, err.Error())
+			log.Info(responseMsg, zap.String("response", response))
+			return resp.EncodeError(response)
+		}
+
+		log.Info(responseMsg, zap.String("response", "OK"))
+		return resp.EncodeSimpleString("OK")
+
+	case subcmd == "help" && len(args) == 2:
+		log.Info(responseMsg, zap.Strings("response", configHelp))
+		elems := make([][]byte, 0, len(configHelp))
+		for _, line := range configHelp {
+			elems = append(elems, resp.EncodeSimpleString(line))
+		}
+		return resp.EncodeRawArray(elems)
+	}
+
+	response := fmt.Sprintf(ErrUnknownSubcmd, subcmd, strings.ToUpper(cmdConfig))
+	log.Info(responseMsg, zap.String("response", response))
+	return resp.EncodeError(response)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"nova/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "GET single parameter",
+			args: []string{"CONFIG", "GET", "maxclients"},
+			want: "*2\r\n$10\r\nmaxclients\r\n$5\r\n10000\r\n",
+		},
+		{
+			name: "GET by pattern",
+			args: []string{"CONFIG", "GET", "*port*"},
+			want: "*2\r\n$4\r\nport\r\n$4\r\n6379\r\n",
+		},
+		{
+			name: "GET several patterns",
+			args: []string{"CONFIG", "GET", "db*", "DIR", "bind"},
+			want: "*6\r\n$4\r\nbind\r\n$9\r\nlocalhost\r\n$3\r\ndir\r\n$1\r\n.\r\n$10\r\ndbfilename\r\n$8\r\ndump.rdb\r\n",
+		},
+		{
+			name: "GET unknown parameter",
+			args: []string{"CONFIG", "GET", "nosuchparam"},
+			want: "*0\r\n",
+		},
+		{
+			name: "SET",
+			args: []string{"CONFIG", "SET", "maxclients", "100", "timeout", "30"},
+			want: "+OK\r\n",
+		},
+		{
+			name: "SET invalid value",
+			args: []string{"CONFIG", "SET", "maxclients", "0"},
+			want: "-CONFIG SET failed - invalid value '0' for 'maxclients': must be in range [1, 2147483647]\r\n",
+		},
+		{
+			name: "SET immutable parameter",
+			args: []string{"CONFIG", "SET", "port", "6380"},
+			want: "-CONFIG SET failed - can't set immutable config 'port'\r\n",
+		},
+		{
+			name: "SET unknown parameter",
+			args: []string{"CONFIG", "SET", "nosuchparam", "1"},
+			want: "-CONFIG SET failed - unknown configuration parameter 'nosuchparam'\r\n",
+		},
+		{
+			name: "SET duplicate parameter",
+			args: []string{"CONFIG", "SET", "timeout", "1", "TIMEOUT", "2"},
+			want: "-CONFIG SET failed - duplicate parameter 'timeout'\r\n",
+		},
+		{
+			name: "SET without value",
+			args: []string{"CONFIG", "SET", "timeout"},
+			want: "-Unknown subcommand or wrong number of arguments for 'set'. Try CONFIG HELP.\r\n",
+		},
+		{
+			name: "REWRITE without config file",
+			args: []string{"CONFIG", "REWRITE"},
+			want: "-Rewriting config file: the server is running without a config file\r\n",
+		},
+		{
+			name: "Unknown subcommand",
+			args: []string{"CONFIG", "RESETSTAT"},
+			want: "-Unknown subcommand or wrong number of arguments for 'resetstat'. Try CONFIG HELP.\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t, WithConfig(config.NewRuntime(config.Default())))
+
+			assert.Equal(t, test.want, serve(ctx, h, test.args...))
+		})
+	}
+}
+
+func TestConfigSetApplied(t *testing.T) {
+	runtime := config.NewRuntime(config.Default())
+	applied := []int{}
+	runtime.OnChange("maxclients", func(cfg *config.Config) {
+		applied = append(applied, cfg.MaxClients)
+	})
+	h, ctx := newTestHandler(t, WithConfig(runtime))
+
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "CONFIG", "SET", "maxclients", "100"))
+	assert.Equal(t, "*2\r\n$10\r\nmaxclients\r\n$3\r\n100\r\n", serve(ctx, h, "CONFIG", "GET", "maxclients"))
+
+	// none of parameters is changed if any of them is invalid
+	assert.Equal(t, "-CONFIG SET failed - invalid value 'x' for 'timeout': not an integer\r\n",
+		serve(ctx, h, "CONFIG", "SET", "maxclients", "200", "timeout", "x"))
+	assert.Equal(t, "*2\r\n$10\r\nmaxclients\r\n$3\r\n100\r\n", serve(ctx, h, "CONFIG", "GET", "maxclients"))
+
+	assert.Equal(t, []int{100}, applied)
+}
+
+func TestConfigRewrite(t *testing.T) {
+	cfg := config.Default()
+	cfg.File = filepath.Join(t.TempDir(), "nova.conf")
+	require.NoError(t, os.WriteFile(cfg.File, []byte("# limits\nmaxclients 10\n"), 0o644))
+	h, ctx := newTestHandler(t, WithConfig(config.NewRuntime(cfg)))
+
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "CONFIG", "SET", "maxclients", "20", "timeout", "30"))
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "CONFIG", "REWRITE"))
+
+	content, err := os.ReadFile(cfg.File)
+	require.NoError(t, err)
+	assert.Equal(t, "# limits\nmaxclients 20\n# Generated by CONFIG REWRITE\ntimeout 30\n", string(content))
+}
+
+func TestConfigDisabled(t *testing.T) {
+	h, ctx := newTestHandler(t)
+
+	assert.Equal(t, "-CONFIG is not available\r\n", serve(ctx, h, "CONFIG", "GET", "*"))
+}
@@ -0,0 +1,364 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdSAdd        = "sadd"
+	cmdSRem        = "srem"
+	cmdSIsMember   = "sismember"
+	cmdSMIsMember  = "smismember"
+	cmdSMembers    = "smembers"
+	cmdSCard       = "scard"
+	cmdSPop        = "spop"
+	cmdSRandMember = "srandmember"
+	cmdSMove       = "smove"
+	cmdSInter      = "sinter"
+	cmdSUnion      = "sunion"
+	cmdSDiff       = "sdiff"
+	cmdSInterStore = "sinterstore"
+	cmdSUnionStore = "sunionstore"
+	cmdSDiffStore  = "sdiffstore"
+	cmdSInterCard  = "sintercard"
+)
+
+var (
+	ErrLimitNegative = "LIMIT can't be negative"
+)
+
+// sAddHandler adds members to the set and returns number of added ones.
+// Syntax: SADD key member [member ...]
+func (h *Handler) sAddHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSAdd)
+	}
+
+	added, err := h.storage.SAdd(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", added))
+	return resp.EncodeInt(added)
+}
+
+// sRemHandler removes members from the set and returns number of removed ones.
+// Syntax: SREM key member [member ...]
+func (h *Handler) sRemHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSRem)
+	}
+
+	removed, err := h.storage.SRem(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", removed))
+	return resp.EncodeInt(removed)
+}
+
+// sIsMemberHandler reports whether member is in the set.
+// Syntax: SISMEMBER key member
+func (h *Handler) sIsMemberHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSIsMember)
+	}
+
+	found, err := h.storage.SMIsMember(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	result := 0
+	if found[0] {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// sMIsMemberHandler reports for every member whether it is in the set.
+// Syntax: SMISMEMBER key member [member ...]
+func (h *Handler) sMIsMemberHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSMIsMember)
+	}
+
+	found, err := h.storage.SMIsMember(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	elems := make([][]byte, len(found))
+	for i, ok := range found {
+		result := 0
+		if ok {
+			result = 1
+		}
+		elems[i] = resp.EncodeInt(result)
+	}
+
+	log.Info(responseMsg, zap.Bools("response", found))
+	return resp.EncodeRawArray(elems)
+}
+
+// sMembersHandler returns all members of the set.
+// Syntax: SMEMBERS key
+func (h *Handler) sMembersHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdSMembers)
+	}
+
+	members, err := h.storage.SMembers(args[1])
+	return h.setReply(ctx, members, err)
+}
+
+// sCardHandler returns number of members in the set.
+// Syntax: SCARD key
+func (h *Handler) sCardHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdSCard)
+	}
+
+	card, err := h.storage.SCard(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", card))
+	return resp.EncodeInt(card)
+}
+
+// sPopHandler removes random members from the set and returns them.
+// Without count a single member is returned.
+// Syntax: SPOP key [count]
+func (h *Handler) sPopHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 || len(args) > 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSPop)
+	}
+
+	if len(args) == 2 {
+		members, err := h.storage.SPop(args[1], 1)
+		if err == nil && len(members) == 0 {
+			err = storage.ErrKeyNotFound
+		}
+		if err != nil {
+			return h.stringReply(ctx, "", err)
+		}
+		return h.stringReply(ctx, members[0], nil)
+	}
+
+	count, err := strconv.Atoi(args[2])
+	if err != nil || count < 0 {
+		return h.errorReply(ctx, ErrNegativeVal)
+	}
+
+	members, err := h.storage.SPop(args[1], count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", members))
+	return clientFromContext(ctx).encodeSet(encodeStrings(members))
+}
+
+// sRandMemberHandler returns random members of the set.
+// Without count a single member is returned. Positive count means distinct members,
+// negative one allows the same member to be returned several times.
+// Syntax: SRANDMEMBER key [count]
+func (h *Handler) sRandMemberHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 || len(args) > 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSRandMember)
+	}
+
+	if len(args) == 2 {
+		members, err := h.storage.SRandMember(args[1], 1)
+		if err == nil && len(members) == 0 {
+			err = storage.ErrKeyNotFound
+		}
+		if err != nil {
+			return h.stringReply(ctx, "", err)
+		}
+		return h.stringReply(ctx, members[0], nil)
+	}
+
+	count, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	if count < -maxRandCount {
+		return h.errorReply(ctx, ErrValueRange)
+	}
+
+	members, err := h.storage.SRandMember(args[1], count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Strings("response", members))
+	return resp.EncodeArray(members)
+}
+
+// sMoveHandler moves member from one set to another.
+// Syntax: SMOVE source destination member
+func (h *Handler) sMoveHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdSMove)
+	}
+
+	moved, err := h.storage.SMove(args[1], args[2], args[3])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	result := 0
+	if moved {
+		result = 1
+	}
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+func (h *Handler) sInterHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraGeneric(ctx, args, cmdSInter, storage.SetOpInter)
+}
+
+func (h *Handler) sUnionHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraGeneric(ctx, args, cmdSUnion, storage.SetOpUnion)
+}
+
+func (h *Handler) sDiffHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraGeneric(ctx, args, cmdSDiff, storage.SetOpDiff)
+}
+
+// setAlgebraGeneric implements SINTER, SUNION and SDIFF.
+// Syntax: <cmd> key [key ...]
+func (h *Handler) setAlgebraGeneric(ctx context.Context, args []string, cmd string, op storage.SetOp) []byte {
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	members, err := h.storage.SetAlgebra(op, args[1:])
+	return h.setReply(ctx, members, err)
+}
+
+func (h *Handler) sInterStoreHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraStoreGeneric(ctx, args, cmdSInterStore, storage.SetOpInter)
+}
+
+func (h *Handler) sUnionStoreHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraStoreGeneric(ctx, args, cmdSUnionStore, storage.SetOpUnion)
+}
+
+func (h *Handler) sDiffStoreHandler(ctx context.Context, args []string) []byte {
+	return h.setAlgebraStoreGeneric(ctx, args, cmdSDiffStore, storage.SetOpDiff)
+}
+
+// setAlgebraStoreGeneric implements SINTERSTORE, SUNIONSTORE and SDIFFSTORE.
+// Syntax: <cmd> destination key [key ...]
+func (h *Handler) setAlgebraStoreGeneric(ctx context.Context, args []string, cmd string, op storage.SetOp) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	card, err := h.storage.SetAlgebraStore(op, args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", card))
+	return resp.EncodeInt(card)
+}
+
+// sInterCardHandler returns cardinality of intersection of sets.
+// Syntax: SINTERCARD numkeys key [key ...] [LIMIT limit]
+func (h *Handler) sInterCardHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdSInterCard)
+	}
+
+	numKeys, err := strconv.Atoi(args[1])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	if numKeys <= 0 {
+		return h.errorReply(ctx, ErrNumKeys)
+	}
+	if numKeys > len(args)-2 {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+	keys := args[2 : numKeys+2]
+
+	limit := 0
+	rest := args[numKeys+2:]
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.EqualFold(rest[0], "limit"):
+		limit, err = strconv.Atoi(rest[1])
+		if err != nil {
+			return h.errorReply(ctx, ErrInvalidInt)
+		}
+		if limit < 0 {
+			return h.errorReply(ctx, ErrLimitNegative)
+		}
+	default:
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	card, err := h.storage.SInterCard(keys, limit)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", card))
+	return resp.EncodeInt(card)
+}
+
+// setReply encodes members of the set.
+func (h *Handler) setReply(ctx context.Context, members []string, err error) []byte {
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	l.FromContext(ctx).Info(responseMsg, zap.Strings("response", members))
+	return clientFromContext(ctx).encodeSet(encodeStrings(members))
+}
+
+// encodeStrings encodes every string as bulk string.
+func encodeStrings(strs []string) [][]byte {
+	elems := make([][]byte, len(strs))
+	for i, str := range strs {
+		elems[i] = resp.EncodeString(str)
+	}
+	return elems
+}
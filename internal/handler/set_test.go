@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sets is a setup of sets with integer (intset) and string members.
+var sets = [][]string{
+	{"SADD", "odd", "1", "3", "5", "7"},
+	{"SADD", "small", "1", "2", "3"},
+	{"SADD", "letters", "a", "b", "c", "1"},
+}
+
+func TestSetCommands(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "SADD counts only new members", setup: sets, args: []string{"SADD", "small", "3", "4", "4"}, want: ":1\r\n", check: []string{"SCARD", "small"}, wantCheck: ":4\r\n"},
+		{name: "SADD string to intset", setup: sets, args: []string{"SADD", "odd", "x"}, want: ":1\r\n", check: []string{"SISMEMBER", "odd", "7"}, wantCheck: ":1\r\n"},
+		{name: "SADD to string", setup: [][]string{{"SET", "key", "value"}}, args: []string{"SADD", "key", "a"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "SREM", setup: sets, args: []string{"SREM", "letters", "a", "1", "z"}, want: ":2\r\n", check: []string{"SCARD", "letters"}, wantCheck: ":2\r\n"},
+		{name: "SREM last member deletes key", setup: sets, args: []string{"SREM", "small", "1", "2", "3"}, want: ":3\r\n", check: []string{"EXISTS", "small"}, wantCheck: ":0\r\n"},
+
+		// non-canonical integers are different members
+		{name: "SISMEMBER non-canonical integer", setup: sets, args: []string{"SISMEMBER", "odd", "03"}, want: ":0\r\n"},
+		{name: "SISMEMBER missing key", args: []string{"SISMEMBER", "set", "a"}, want: ":0\r\n"},
+		{name: "SMISMEMBER", setup: sets, args: []string{"SMISMEMBER", "letters", "a", "z", "1"}, want: "*3\r\n:1\r\n:0\r\n:1\r\n"},
+		{name: "SCARD missing key", args: []string{"SCARD", "set"}, want: ":0\r\n"},
+
+		{name: "SMOVE", setup: sets, args: []string{"SMOVE", "small", "letters", "2"}, want: ":1\r\n", check: []string{"SMISMEMBER", "letters", "2"}, wantCheck: "*1\r\n:1\r\n"},
+		{name: "SMOVE missing member", setup: sets, args: []string{"SMOVE", "small", "letters", "9"}, want: ":0\r\n"},
+		{name: "SMOVE to string", setup: [][]string{{"SADD", "set", "a"}, {"SET", "key", "value"}}, args: []string{"SMOVE", "set", "key", "a"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"SCARD", "set"}, wantCheck: ":1\r\n"},
+
+		{name: "SPOP missing key", args: []string{"SPOP", "set"}, want: "$-1\r\n"},
+		{name: "SPOP negative count", setup: sets, args: []string{"SPOP", "small", "-1"}, want: "-value is out of range, must be positive\r\n"},
+		{name: "SPOP whole set", setup: sets, args: []string{"SPOP", "small", "5"}, want: "*3\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n", check: []string{"EXISTS", "small"}, wantCheck: ":0\r\n"},
+		{name: "SRANDMEMBER missing key", args: []string{"SRANDMEMBER", "set", "-3"}, want: "*0\r\n"},
+	})
+}
+
+func TestSetAlgebra(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "SINTER", args: []string{"SINTER", "odd", "small"}, want: []string{"1", "3"}},
+		{name: "SINTER intset and hash table", args: []string{"SINTER", "letters", "small"}, want: []string{"1"}},
+		{name: "SINTER missing key", args: []string{"SINTER", "odd", "nokey"}, want: []string{}},
+		{name: "SUNION", args: []string{"SUNION", "small", "letters", "nokey"}, want: []string{"1", "2", "3", "a", "b", "c"}},
+		{name: "SDIFF", args: []string{"SDIFF", "odd", "small"}, want: []string{"5", "7"}},
+		{name: "SDIFF missing first key", args: []string{"SDIFF", "nokey", "small"}, want: []string{}},
+		{name: "SDIFF order matters", args: []string{"SDIFF", "small", "odd", "letters"}, want: []string{"2"}},
+
+		{name: "SINTERSTORE", args: []string{"SINTERSTORE", "dst", "odd", "small"}, want: []string{"1", "3"}},
+		{name: "SUNIONSTORE source as destination", args: []string{"SUNIONSTORE", "small", "small", "odd"}, want: []string{"1", "2", "3", "5", "7"}},
+		{name: "SDIFFSTORE", args: []string{"SDIFFSTORE", "dst", "letters", "small"}, want: []string{"a", "b", "c"}},
+		{name: "SINTERSTORE empty result deletes destination", args: []string{"SINTERSTORE", "letters", "odd", "nokey"}, want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			for _, args := range sets {
+				serve(ctx, h, args...)
+			}
+
+			reply := serve(ctx, h, test.args...)
+			if strings.HasSuffix(test.args[0], "STORE") {
+				// store variants reply with cardinality, result is checked in destination
+				require.Equal(t, ":"+strconv.Itoa(len(test.want))+"\r\n", reply)
+				reply = serve(ctx, h, "SMEMBERS", test.args[1])
+			}
+			assert.Equal(t, test.want, parseArray(t, reply))
+		})
+	}
+}
+
+func TestSetAlgebraWrongType(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "SINTER", setup: [][]string{{"SADD", "set", "a"}, {"SET", "key", "v"}}, args: []string{"SINTER", "set", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "SINTER after missing key", setup: [][]string{{"SET", "key", "v"}}, args: []string{"SINTER", "nokey", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "SDIFFSTORE keeps destination", setup: [][]string{{"SADD", "dst", "a"}, {"SET", "key", "v"}}, args: []string{"SDIFFSTORE", "dst", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"SCARD", "dst"}, wantCheck: ":1\r\n"},
+		{name: "SUNIONSTORE overwrites string destination", setup: [][]string{{"SADD", "set", "a"}, {"SET", "dst", "v"}}, args: []string{"SUNIONSTORE", "dst", "set"}, want: ":1\r\n", check: []string{"TYPE", "dst"}, wantCheck: "+set\r\n"},
+	})
+}
+
+func TestSInterCard(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "All", setup: sets, args: []string{"SINTERCARD", "2", "odd", "small"}, want: ":2\r\n"},
+		{name: "Single key", setup: sets, args: []string{"SINTERCARD", "1", "letters"}, want: ":4\r\n"},
+		{name: "LIMIT", setup: sets, args: []string{"SINTERCARD", "2", "odd", "small", "LIMIT", "1"}, want: ":1\r\n"},
+		{name: "LIMIT above cardinality", setup: sets, args: []string{"SINTERCARD", "2", "odd", "small", "LIMIT", "10"}, want: ":2\r\n"},
+		{name: "LIMIT 0 means no limit", setup: sets, args: []string{"SINTERCARD", "1", "odd", "limit", "0"}, want: ":4\r\n"},
+		{name: "Missing key", setup: sets, args: []string{"SINTERCARD", "2", "odd", "nokey"}, want: ":0\r\n"},
+		{name: "Negative LIMIT", setup: sets, args: []string{"SINTERCARD", "1", "odd", "LIMIT", "-1"}, want: "-LIMIT can't be negative\r\n"},
+		{name: "Not integer LIMIT", setup: sets, args: []string{"SINTERCARD", "1", "odd", "LIMIT", "x"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Zero numkeys", args: []string{"SINTERCARD", "0", "odd"}, want: "-numkeys should be greater than 0\r\n"},
+		{name: "Numkeys exceeds keys", args: []string{"SINTERCARD", "3", "odd", "small"}, want: "-syntax error\r\n"},
+		{name: "Extra argument", args: []string{"SINTERCARD", "1", "odd", "small"}, want: "-syntax error\r\n"},
+		{name: "String", setup: [][]string{{"SET", "key", "v"}}, args: []string{"SINTERCARD", "1", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestSRandMember(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "SADD", "set", "a", "b", "c")
+
+	assert.Regexp(t, `^\$1\r\n[abc]\r\n$`, serve(ctx, h, "SRANDMEMBER", "set"))
+	assert.Equal(t, []string{"a", "b", "c"}, parseArray(t, serve(ctx, h, "SRANDMEMBER", "set", "10")))
+
+	// negative count allows repeated members
+	members := parseArray(t, serve(ctx, h, "SRANDMEMBER", "set", "-10"))
+	require.Len(t, members, 10)
+	for _, member := range members {
+		assert.Contains(t, []string{"a", "b", "c"}, member)
+	}
+	assert.Equal(t, ":3\r\n", serve(ctx, h, "SCARD", "set"), "SRANDMEMBER modified set")
+}
+
+func TestSRandMemberInvalidCount(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "SADD", "set", "m")
+
+	var tests = []struct {
+		name  string
+		count string
+		want  string
+	}{
+		{name: "Huge negative", count: "-1125899906842624", want: "-" + ErrValueRange + "\r\n"},
+		{name: "Min int", count: "-9223372036854775808", want: "-" + ErrValueRange + "\r\n"},
+		{name: "Not integer", count: "1.5", want: "-" + ErrInvalidInt + "\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, serve(ctx, h, "SRANDMEMBER", "set", test.count))
+		})
+	}
+}
+
+func TestSMembersRESP3(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "HELLO", "3")
+	serve(ctx, h, "SADD", "set", "1")
+
+	assert.Equal(t, "~1\r\n$1\r\n1\r\n", serve(ctx, h, "SMEMBERS", "set"))
+	assert.Equal(t, "~0\r\n", serve(ctx, h, "SINTER", "set", "nokey"))
+}
@@ -0,0 +1,994 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdXAdd       = "xadd"
+	cmdXRange     = "xrange"
+	cmdXRevRange  = "xrevrange"
+	cmdXLen       = "xlen"
+	cmdXRead      = "xread"
+	cmdXDel       = "xdel"
+	cmdXTrim      = "xtrim"
+	cmdXGroup     = "xgroup"
+	cmdXReadGroup = "xreadgroup"
+	cmdXAck       = "xack"
+	cmdXPending   = "xpending"
+	cmdXClaim     = "xclaim"
+	cmdXAutoClaim = "xautoclaim"
+)
+
+var (
+	ErrInvalidStreamID     = "Invalid stream ID specified as stream command argument"
+	ErrStreamIDTooSmall    = "The ID specified in XADD is equal or smaller than the target stream top item"
+	ErrStreamIDZero        = "The ID specified in XADD must be greater than 0-0"
+	ErrInvalidStartID      = "invalid start ID for the interval"
+	ErrInvalidEndID        = "invalid end ID for the interval"
+	ErrTrimMaxLenNegative  = "The MAXLEN argument must be >= 0."
+	ErrTrimLimitNegative   = "The LIMIT argument must be >= 0."
+	ErrLimitWithoutApprox  = "syntax error, LIMIT cannot be used without the special ~ option"
+	ErrUnbalancedStreams   = "Unbalanced '%s' list of streams: for each stream key an ID or '%s' must be specified."
+	ErrNewIDInXRead        = "The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option."
+	ErrLastIDInXReadGroup  = "The $ ID is meaningless in the context of XREADGROUP"
+	ErrBlockTimeoutInvalid = "timeout is not an integer or out of range"
+	ErrXGroupKeyMissing    = "The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically."
+	ErrBusyGroup           = "BUSYGROUP Consumer Group name already exists"
+	ErrNoGroupForKey       = "NOGROUP No such consumer group '%s' for key name '%s'"
+	ErrNoKeyOrGroup        = "NOGROUP No such key '%s' or consumer group '%s'"
+	ErrNoKeyOrGroupRead    = "NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option"
+	ErrInvalidMinIdle      = "Invalid min-idle-time argument for XCLAIM"
+	ErrCountNotPositive    = "COUNT must be > 0"
+)
+
+var (
+	xgroupHelp = []string{
+		"XGROUP <subcommand> [<arg> [value] [opt] ...]. Subcommands are:",
+		"CREATE <key> <groupname> <id|$> [option]",
+		"    Create a new consumer group. Options are:",
+		"    * MKSTREAM",
+		"      Create the empty stream if it does not exist.",
+		"    * ENTRIESREAD entries_read",
+		"      Set the group's entries_read counter (internal use).",
+		"CREATECONSUMER <key> <groupname> <consumer>",
+		"    Create a new consumer in the specified group.",
+		"DELCONSUMER <key> <groupname> <consumer>",
+		"    Remove the specified consumer.",
+		"DESTROY <key> <groupname>",
+		"    Remove the specified group.",
+		"SETID <key> <groupname> <id|$> [ENTRIESREAD entries_read]",
+		"    Set the current group ID and entries_read counter.",
+		"HELP",
+		"    Print this help.",
+	}
+)
+
+// parseStreamID parses stream ID. Special IDs "-" and "+" are the smallest and the greatest IDs.
+// If sequence number is omitted, seq is used.
+func parseStreamID(arg string, seq uint64) (ds.StreamID, bool) {
+	switch arg {
+	case "-":
+		return ds.StreamID{}, true
+	case "+":
+		return ds.MaxStreamID, true
+	}
+
+	id, seqGiven, ok := ds.ParseStreamID(arg)
+	if !seqGiven {
+		id.Seq = seq
+	}
+	return id, ok
+}
+
+// parseRangeID parses start or end of range of IDs. It's exclusive if prefixed with "(".
+// In case of error, error message is returned.
+func parseRangeID(arg string, start bool) (ds.StreamID, string) {
+	exclusive := strings.HasPrefix(arg, "(")
+	if exclusive {
+		arg = arg[1:]
+	}
+
+	var seq uint64
+	if !start {
+		seq = math.MaxUint64
+	}
+	id, ok := parseStreamID(arg, seq)
+	if !ok {
+		return id, ErrInvalidStreamID
+	}
+	if !exclusive {
+		return id, ""
+	}
+
+	if start {
+		if id, ok = id.Next(); !ok {
+			return id, ErrInvalidStartID
+		}
+		return id, ""
+	}
+	if id, ok = id.Prev(); !ok {
+		return id, ErrInvalidEndID
+	}
+	return id, ""
+}
+
+// parseTrimArgs parses "MAXLEN|MINID [=|~] threshold [LIMIT count]" starting from args[i].
+// It returns index of the next argument. In case of error, error message is returned.
+func parseTrimArgs(args []string, i int) (storage.XTrimOptions, int, string) {
+	var opts storage.XTrimOptions
+	opts.ByMinID = strings.EqualFold(args[i], "minid")
+	i++
+
+	approx := false
+	if i < len(args) && (args[i] == "~" || args[i] == "=") {
+		approx = args[i] == "~"
+		i++
+	}
+	if i >= len(args) {
+		return opts, i, ErrSyntax
+	}
+
+	if opts.ByMinID {
+		id, ok := parseStreamID(args[i], 0)
+		if !ok {
+			return opts, i, ErrInvalidStreamID
+		}
+		opts.MinID = id
+	} else {
+		maxLen, err := strconv.Atoi(args[i])
+		if err != nil {
+			return opts, i, ErrInvalidInt
+		}
+		if maxLen < 0 {
+			return opts, i, ErrTrimMaxLenNegative
+		}
+		opts.MaxLen = maxLen
+	}
+	i++
+
+	if i+1 < len(args) && strings.EqualFold(args[i], "limit") {
+		limit, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			return opts, i, ErrInvalidInt
+		}
+		if limit < 0 {
+			return opts, i, ErrTrimLimitNegative
+		}
+		// trimming is always exact, so LIMIT only caps number of deleted entries
+		if !approx {
+			return opts, i, ErrLimitWithoutApprox
+		}
+		opts.Limit = limit
+		i += 2
+	}
+
+	return opts, i, ""
+}
+
+// encodeStreamEntries encodes entries as array of [ID, [field, value, ...]] pairs.
+// Deleted entries (with nil fields) are encoded with null instead of fields.
+func encodeStreamEntries(c *client, entries []ds.StreamEntry) []byte {
+	elems := make([][]byte, len(entries))
+	for i, entry := range entries {
+		fields := c.encodeNilArray()
+		if entry.Fields != nil {
+			fields = resp.EncodeArray(entry.Fields)
+		}
+		elems[i] = resp.EncodeRawArray([][]byte{resp.EncodeString(entry.ID.String()), fields})
+	}
+
+	return resp.EncodeRawArray(elems)
+}
+
+// encodeStreamIDs encodes IDs as array of strings.
+func encodeStreamIDs(ids []ds.StreamID) []byte {
+	elems := make([][]byte, len(ids))
+	for i, id := range ids {
+		elems[i] = resp.EncodeString(id.String())
+	}
+
+	return resp.EncodeRawArray(elems)
+}
+
+// encodeStreams encodes entries read from several streams. RESP3 clients get map
+// of keys to entries, while RESP2 clients get array of [key, entries] pairs.
+// If there are no entries, null is returned.
+func encodeStreams(c *client, streams []storage.StreamEntries) []byte {
+	if len(streams) == 0 {
+		return c.encodeNilArray()
+	}
+
+	if c.resp3() {
+		pairs := make([][]byte, 0, 2*len(streams))
+		for _, s := range streams {
+			pairs = append(pairs, resp.EncodeString(s.Key), encodeStreamEntries(c, s.Entries))
+		}
+		return c.encodeMap(pairs)
+	}
+
+	elems := make([][]byte, len(streams))
+	for i, s := range streams {
+		elems[i] = resp.EncodeRawArray([][]byte{resp.EncodeString(s.Key), encodeStreamEntries(c, s.Entries)})
+	}
+	return resp.EncodeRawArray(elems)
+}
+
+// xAddHandler appends entry to the stream.
+// Syntax: XADD key [NOMKSTREAM] [MAXLEN|MINID [=|~] threshold [LIMIT count]] *|id field value [field value ...]
+func (h *Handler) xAddHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 5 {
+		return h.wrongNumberOfArgs(ctx, cmdXAdd)
+	}
+
+	var opts storage.XAddOptions
+	i := 2
+loop:
+	for i < len(args) {
+		switch strings.ToLower(args[i]) {
+		case "nomkstream":
+			opts.NoMkStream = true
+			i++
+		case "maxlen", "minid":
+			trim, next, errMsg := parseTrimArgs(args, i)
+			if errMsg != "" {
+				return h.errorReply(ctx, errMsg)
+			}
+			opts.Trim = &trim
+			i = next
+		default:
+			break loop
+		}
+	}
+
+	// ID and at least one field-value pair are required
+	if len(args)-i < 3 || (len(args)-i)%2 == 0 {
+		return h.wrongNumberOfArgs(ctx, cmdXAdd)
+	}
+
+	switch idArg := args[i]; {
+	case idArg == "*":
+		opts.AutoID = true
+	case strings.HasSuffix(idArg, "-*"):
+		ms, err := strconv.ParseUint(strings.TrimSuffix(idArg, "-*"), 10, 64)
+		if err != nil {
+			return h.errorReply(ctx, ErrInvalidStreamID)
+		}
+		opts.ID.Ms, opts.AutoSeq = ms, true
+	default:
+		id, _, ok := ds.ParseStreamID(idArg)
+		if !ok {
+			return h.errorReply(ctx, ErrInvalidStreamID)
+		}
+		if id.IsZero() {
+			return h.errorReply(ctx, ErrStreamIDZero)
+		}
+		opts.ID = id
+	}
+
+	id, err := h.storage.XAdd(args[1], args[i+1:], opts)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrSmallID):
+		return h.errorReply(ctx, ErrStreamIDTooSmall)
+	case errors.Is(err, storage.ErrKeyNotFound):
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNull()
+	}
+
+	log.Info(responseMsg, zap.Stringer("response", id))
+	return resp.EncodeString(id.String())
+}
+
+func (h *Handler) xRangeHandler(ctx context.Context, args []string) []byte {
+	return h.xRangeGeneric(ctx, args, cmdXRange, false)
+}
+
+func (h *Handler) xRevRangeHandler(ctx context.Context, args []string) []byte {
+	return h.xRangeGeneric(ctx, args, cmdXRevRange, true)
+}
+
+// xRangeGeneric implements XRANGE and XREVRANGE.
+// Syntax: XRANGE key start end [COUNT count]
+// Syntax: XREVRANGE key end start [COUNT count]
+func (h *Handler) xRangeGeneric(ctx context.Context, args []string, cmd string, reverse bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 && len(args) != 6 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	startArg, endArg := args[2], args[3]
+	if reverse {
+		startArg, endArg = endArg, startArg
+	}
+	start, errMsg := parseRangeID(startArg, true)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+	end, errMsg := parseRangeID(endArg, false)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	count := 0
+	if len(args) == 6 {
+		if !strings.EqualFold(args[4], "count") {
+			return h.errorReply(ctx, ErrSyntax)
+		}
+		var err error
+		if count, err = strconv.Atoi(args[5]); err != nil {
+			return h.errorReply(ctx, ErrInvalidInt)
+		}
+		// zero count would mean no limit for storage
+		if count <= 0 {
+			log.Info(responseMsg, zap.String("response", nullArray))
+			return resp.EncodeRawArray(nil)
+		}
+	}
+
+	entries, err := h.storage.XRange(args[1], start, end, reverse, count)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(entries)))
+	return encodeStreamEntries(clientFromContext(ctx), entries)
+}
+
+// xLenHandler returns number of entries in the stream.
+// Syntax: XLEN key
+func (h *Handler) xLenHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdXLen)
+	}
+
+	length, err := h.storage.XLen(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// xDelHandler deletes entries from the stream and returns number of deleted ones.
+// Syntax: XDEL key id [id ...]
+func (h *Handler) xDelHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdXDel)
+	}
+
+	ids, ok := parseStreamIDs(args[2:])
+	if !ok {
+		return h.errorReply(ctx, ErrInvalidStreamID)
+	}
+
+	deleted, err := h.storage.XDel(args[1], ids)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", deleted))
+	return resp.EncodeInt(deleted)
+}
+
+// parseStreamIDs parses IDs of stream entries. Omitted sequence numbers are 0.
+func parseStreamIDs(args []string) ([]ds.StreamID, bool) {
+	ids := make([]ds.StreamID, len(args))
+	for i, arg := range args {
+		id, ok := parseStreamID(arg, 0)
+		if !ok {
+			return nil, false
+		}
+		ids[i] = id
+	}
+
+	return ids, true
+}
+
+// xTrimHandler trims the stream and returns number of deleted entries.
+// Syntax: XTRIM key MAXLEN|MINID [=|~] threshold [LIMIT count]
+func (h *Handler) xTrimHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdXTrim)
+	}
+	if !strings.EqualFold(args[2], "maxlen") && !strings.EqualFold(args[2], "minid") {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	opts, next, errMsg := parseTrimArgs(args, 2)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+	if next != len(args) {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	deleted, err := h.storage.XTrim(args[1], opts)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", deleted))
+	return resp.EncodeInt(deleted)
+}
+
+// xreadArgs are arguments of XREAD and XREADGROUP.
+type xreadArgs struct {
+	streams []storage.StreamRead
+	count   int
+	// block is true if BLOCK option is given, timeout is its value.
+	block   bool
+	timeout time.Duration
+	// group, consumer and noAck are used only by XREADGROUP.
+	group, consumer string
+	noAck           bool
+}
+
+// parseXReadArgs parses arguments of XREAD (or XREADGROUP if group is true) following the command name.
+// In case of error, error message is returned.
+func parseXReadArgs(args []string, group bool) (xreadArgs, string) {
+	var xa xreadArgs
+
+	i := 0
+	for ; i < len(args); i++ {
+		opt := strings.ToLower(args[i])
+		if opt == "streams" {
+			break
+		}
+
+		switch {
+		case opt == "count" && i+1 < len(args):
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return xa, ErrInvalidInt
+			}
+			// negative count means no limit just like zero one
+			xa.count = max(count, 0)
+			i++
+		case opt == "block" && i+1 < len(args):
+			ms, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || ms > math.MaxInt64/int64(time.Millisecond) {
+				return xa, ErrBlockTimeoutInvalid
+			}
+			if ms < 0 {
+				return xa, ErrTimeoutNegative
+			}
+			xa.block, xa.timeout = true, time.Duration(ms)*time.Millisecond
+			i++
+		case opt == "group" && group && i+2 < len(args):
+			xa.group, xa.consumer = args[i+1], args[i+2]
+			i += 2
+		case opt == "noack" && group:
+			xa.noAck = true
+		default:
+			return xa, ErrSyntax
+		}
+	}
+	if group && xa.group == "" {
+		return xa, ErrSyntax
+	}
+
+	cmd, special := cmdXRead, "$"
+	if group {
+		cmd, special = cmdXReadGroup, ">"
+	}
+	rest := args[min(i+1, len(args)):]
+	if i == len(args) || len(rest) == 0 || len(rest)%2 != 0 {
+		return xa, fmt.Sprintf(ErrUnbalancedStreams, cmd, special)
+	}
+
+	keys, ids := rest[:len(rest)/2], rest[len(rest)/2:]
+	xa.streams = make([]storage.StreamRead, len(keys))
+	for j, key := range keys {
+		xa.streams[j].Key = key
+
+		switch {
+		case ids[j] == special:
+			xa.streams[j].New = true
+		case ids[j] == ">":
+			return xa, ErrNewIDInXRead
+		case ids[j] == "$":
+			return xa, ErrLastIDInXReadGroup
+		default:
+			id, ok := parseStreamID(ids[j], 0)
+			if !ok {
+				return xa, ErrInvalidStreamID
+			}
+			xa.streams[j].After = id
+		}
+	}
+
+	return xa, ""
+}
+
+// xReadHandler reads entries from streams after given IDs.
+// With BLOCK option, it waits for new entries if there are no ones.
+// Syntax: XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+func (h *Handler) xReadHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdXRead)
+	}
+
+	xa, errMsg := parseXReadArgs(args[1:], false)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	var streams []storage.StreamEntries
+	var err error
+	if xa.block {
+		blockCtx, cancel := h.blockingContext(ctx, xa.timeout)
+		defer cancel()
+		streams, err = h.storage.XReadBlock(blockCtx, xa.streams, xa.count)
+	} else {
+		streams, err = h.storage.XRead(xa.streams, xa.count)
+	}
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(streams)))
+	// timeout is reported as null reply as well as nothing to read
+	return encodeStreams(clientFromContext(ctx), streams)
+}
+
+// xReadGroupHandler reads entries from streams as consumer of consumer group.
+// ID ">" means entries never delivered to the group, other IDs mean reading
+// pending entries of the consumer.
+// Syntax: XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]
+func (h *Handler) xReadGroupHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 7 {
+		return h.wrongNumberOfArgs(ctx, cmdXReadGroup)
+	}
+
+	xa, errMsg := parseXReadArgs(args[1:], true)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	opts := storage.XReadGroupOptions{
+		Group:    xa.group,
+		Consumer: xa.consumer,
+		Count:    xa.count,
+		NoAck:    xa.noAck,
+	}
+	var streams []storage.StreamEntries
+	var err error
+	if xa.block {
+		blockCtx, cancel := h.blockingContext(ctx, xa.timeout)
+		defer cancel()
+		streams, err = h.storage.XReadGroupBlock(blockCtx, xa.streams, opts)
+	} else {
+		streams, err = h.storage.XReadGroup(xa.streams, opts)
+	}
+	var noGroup *storage.NoGroupError
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.As(err, &noGroup):
+		return h.errorReply(ctx, fmt.Sprintf(ErrNoKeyOrGroupRead, noGroup.Key, noGroup.Group))
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(streams)))
+	return encodeStreams(clientFromContext(ctx), streams)
+}
+
+// xGroupHandler executes XGROUP subcommands: CREATE, SETID, DESTROY, CREATECONSUMER, DELCONSUMER and HELP.
+func (h *Handler) xGroupHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	subcmd := ""
+	if len(args) > 1 {
+		subcmd = strings.ToLower(args[1])
+	}
+
+	switch {
+	case (subcmd == "create" && len(args) >= 5) || (subcmd == "setid" && len(args) >= 5):
+		return h.xGroupCreateOrSetID(ctx, args, subcmd == "create")
+
+	case subcmd == "destroy" && len(args) == 4:
+		destroyed, err := h.storage.XGroupDestroy(args[2], args[3])
+		if err != nil {
+			return h.xGroupError(ctx, args, err)
+		}
+
+		result := 0
+		if destroyed {
+			result = 1
+		}
+		log.Info(responseMsg, zap.Int("response", result))
+		return resp.EncodeInt(result)
+
+	case subcmd == "createconsumer" && len(args) == 5:
+		created, err := h.storage.XGroupCreateConsumer(args[2], args[3], args[4])
+		if err != nil {
+			return h.xGroupError(ctx, args, err)
+		}
+
+		result := 0
+		if created {
+			result = 1
+		}
+		log.Info(responseMsg, zap.Int("response", result))
+		return resp.EncodeInt(result)
+
+	case subcmd == "delconsumer" && len(args) == 5:
+		pending, err := h.storage.XGroupDelConsumer(args[2], args[3], args[4])
+		if err != nil {
+			return h.xGroupError(ctx, args, err)
+		}
+
+		log.Info(responseMsg, zap.Int("response", pending))
+		return resp.EncodeInt(pending)
+
+	case subcmd == "help" && len(args) == 2:
+		log.Info(responseMsg, zap.Strings("response", xgroupHelp))
+		elems := make([][]byte, 0, len(xgroupHelp))
+		for _, line := range xgroupHelp {
+			elems = append(elems, resp.EncodeSimpleString(line))
+		}
+		return resp.EncodeRawArray(elems)
+	}
+
+	response := fmt.Sprintf(ErrUnknownSubcmd, subcmd, strings.ToUpper(cmdXGroup))
+	log.Info(responseMsg, zap.String("response", response))
+	return resp.EncodeError(response)
+}
+
+// xGroupCreateOrSetID implements XGROUP CREATE and XGROUP SETID.
+// Syntax: XGROUP CREATE key group id|$ [MKSTREAM] [ENTRIESREAD entries-read]
+// Syntax: XGROUP SETID key group id|$ [ENTRIESREAD entries-read]
+func (h *Handler) xGroupCreateOrSetID(ctx context.Context, args []string, create bool) []byte {
+	log := l.FromContext(ctx)
+
+	mkStream := false
+	for i := 5; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); {
+		case opt == "mkstream" && create:
+			mkStream = true
+		case opt == "entriesread" && i+1 < len(args):
+			// entries-read counter is not tracked, so it's only validated
+			if _, err := strconv.Atoi(args[i+1]); err != nil {
+				return h.errorReply(ctx, ErrInvalidInt)
+			}
+			i++
+		default:
+			return h.errorReply(ctx, ErrSyntax)
+		}
+	}
+
+	fromLast := args[4] == "$"
+	id, ok := parseStreamID(args[4], 0)
+	if !fromLast && !ok {
+		return h.errorReply(ctx, ErrInvalidStreamID)
+	}
+
+	var err error
+	if create {
+		err = h.storage.XGroupCreate(args[2], args[3], id, fromLast, mkStream)
+	} else {
+		err = h.storage.XGroupSetID(args[2], args[3], id, fromLast)
+	}
+	if err != nil {
+		return h.xGroupError(ctx, args, err)
+	}
+
+	log.Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// xGroupError returns error reply for XGROUP subcommand.
+func (h *Handler) xGroupError(ctx context.Context, args []string, err error) []byte {
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return h.errorReply(ctx, ErrXGroupKeyMissing)
+	case errors.Is(err, storage.ErrGroupExists):
+		return h.errorReply(ctx, ErrBusyGroup)
+	default:
+		return h.errorReply(ctx, fmt.Sprintf(ErrNoGroupForKey, args[3], args[2]))
+	}
+}
+
+// xAckHandler acknowledges pending entries of consumer group and returns number of acknowledged ones.
+// Syntax: XACK key group id [id ...]
+func (h *Handler) xAckHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdXAck)
+	}
+
+	ids, ok := parseStreamIDs(args[3:])
+	if !ok {
+		return h.errorReply(ctx, ErrInvalidStreamID)
+	}
+
+	acked, err := h.storage.XAck(args[1], args[2], ids)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", acked))
+	return resp.EncodeInt(acked)
+}
+
+// xPendingHandler returns summary of pending entries of consumer group
+// or, if range is given, the pending entries themselves.
+// Syntax: XPENDING key group [[IDLE min-idle-time] start end count [consumer]]
+func (h *Handler) xPendingHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdXPending)
+	}
+	c := clientFromContext(ctx)
+
+	if len(args) == 3 {
+		summary, err := h.storage.XPendingSummary(args[1], args[2])
+		if err != nil {
+			return h.streamGroupError(ctx, args, err)
+		}
+
+		log.Info(responseMsg, zap.Int("response", summary.Count))
+		if summary.Count == 0 {
+			return resp.EncodeRawArray([][]byte{resp.EncodeInt(0), c.encodeNull(), c.encodeNull(), c.encodeNilArray()})
+		}
+		consumers := make([][]byte, len(summary.Consumers))
+		for i, consumer := range summary.Consumers {
+			consumers[i] = resp.EncodeArray([]string{consumer.Name, strconv.Itoa(consumer.Pending)})
+		}
+		return resp.EncodeRawArray([][]byte{
+			resp.EncodeInt(summary.Count),
+			resp.EncodeString(summary.MinID.String()),
+			resp.EncodeString(summary.MaxID.String()),
+			resp.EncodeRawArray(consumers),
+		})
+	}
+
+	var query storage.XPendingQuery
+	rest := args[3:]
+	if strings.EqualFold(rest[0], "idle") {
+		if len(rest) < 2 {
+			return h.errorReply(ctx, ErrSyntax)
+		}
+		idle, err := strconv.ParseInt(rest[1], 10, 64)
+		if err != nil {
+			return h.errorReply(ctx, ErrInvalidInt)
+		}
+		query.MinIdle = time.Duration(max(idle, 0)) * time.Millisecond
+		rest = rest[2:]
+	}
+	if len(rest) < 3 || len(rest) > 4 {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+
+	var errMsg string
+	if query.Start, errMsg = parseRangeID(rest[0], true); errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+	if query.End, errMsg = parseRangeID(rest[1], false); errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+	count, err := strconv.Atoi(rest[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	if len(rest) == 4 {
+		query.Consumer = rest[3]
+	}
+
+	pending := []ds.PendingEntry{}
+	// non-positive count means empty result, since zero count would mean no limit for storage
+	if count > 0 {
+		query.Count = count
+		if pending, err = h.storage.XPending(args[1], args[2], query); err != nil {
+			return h.streamGroupError(ctx, args, err)
+		}
+	}
+
+	now := time.Now()
+	elems := make([][]byte, len(pending))
+	for i, pe := range pending {
+		elems[i] = resp.EncodeRawArray([][]byte{
+			resp.EncodeString(pe.ID.String()),
+			resp.EncodeString(pe.Consumer),
+			resp.EncodeInt(int(now.Sub(pe.DeliveredAt).Milliseconds())),
+			resp.EncodeInt(pe.Deliveries),
+		})
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(pending)))
+	return resp.EncodeRawArray(elems)
+}
+
+// streamGroupError returns error reply for commands working with consumer group args[2] of stream args[1].
+func (h *Handler) streamGroupError(ctx context.Context, args []string, err error) []byte {
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+	return h.errorReply(ctx, fmt.Sprintf(ErrNoKeyOrGroup, args[1], args[2]))
+}
+
+// parseMinIdle parses min idle time given in milliseconds. Negative time is treated as zero.
+func parseMinIdle(arg string) (time.Duration, bool) {
+	ms, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || ms > math.MaxInt64/int64(time.Millisecond) {
+		return 0, false
+	}
+	return time.Duration(max(ms, 0)) * time.Millisecond, true
+}
+
+// xClaimHandler transfers pending entries idle for at least min-idle-time to consumer
+// and returns claimed entries.
+// Syntax: XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-milliseconds]
+// [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID lastid]
+func (h *Handler) xClaimHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 6 {
+		return h.wrongNumberOfArgs(ctx, cmdXClaim)
+	}
+
+	opts := storage.XClaimOptions{RetryCount: -1}
+	var ok bool
+	if opts.MinIdle, ok = parseMinIdle(args[4]); !ok {
+		return h.errorReply(ctx, ErrInvalidMinIdle)
+	}
+
+	// IDs go until the first argument which is not an ID
+	i := 5
+	ids := []ds.StreamID{}
+	for ; i < len(args); i++ {
+		id, ok := parseStreamID(args[i], 0)
+		if !ok {
+			break
+		}
+		ids = append(ids, id)
+	}
+
+	for ; i < len(args); i++ {
+		opt := strings.ToLower(args[i])
+		switch {
+		case opt == "force":
+			opts.Force = true
+		case opt == "justid":
+			opts.JustID = true
+		case (opt == "idle" || opt == "time" || opt == "retrycount") && i+1 < len(args):
+			num, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return h.errorReply(ctx, ErrInvalidInt)
+			}
+			switch opt {
+			case "idle":
+				opts.DeliveredAt = time.Now().Add(-time.Duration(max(num, 0)) * time.Millisecond)
+			case "time":
+				opts.DeliveredAt = time.UnixMilli(num)
+			default:
+				if num < 0 || num > math.MaxInt32 {
+					return h.errorReply(ctx, ErrInvalidInt)
+				}
+				opts.RetryCount = int(num)
+			}
+			i++
+		case opt == "lastid" && i+1 < len(args):
+			id, ok := parseStreamID(args[i+1], 0)
+			if !ok {
+				return h.errorReply(ctx, ErrInvalidStreamID)
+			}
+			opts.LastID = id
+			i++
+		default:
+			return h.errorReply(ctx, ErrSyntax)
+		}
+	}
+	if len(ids) == 0 {
+		return h.errorReply(ctx, ErrInvalidStreamID)
+	}
+
+	claimed, err := h.storage.XClaim(args[1], args[2], args[3], ids, opts)
+	if err != nil {
+		return h.streamGroupError(ctx, args, err)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(claimed)))
+	if opts.JustID {
+		return encodeStreamIDs(entryIDs(claimed))
+	}
+	return encodeStreamEntries(clientFromContext(ctx), claimed)
+}
+
+// entryIDs returns IDs of entries.
+func entryIDs(entries []ds.StreamEntry) []ds.StreamID {
+	ids := make([]ds.StreamID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+
+	return ids
+}
+
+// xAutoClaimHandler claims pending entries idle for at least min-idle-time scanning them from start.
+// It returns ID to continue scan from, claimed entries and IDs of entries deleted from stream.
+// Syntax: XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID]
+func (h *Handler) xAutoClaimHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 6 {
+		return h.wrongNumberOfArgs(ctx, cmdXAutoClaim)
+	}
+
+	minIdle, ok := parseMinIdle(args[4])
+	if !ok {
+		return h.errorReply(ctx, ErrInvalidMinIdle)
+	}
+	start, errMsg := parseRangeID(args[5], true)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	count, justID := 100, false
+	for i := 6; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); {
+		case opt == "count" && i+1 < len(args):
+			var err error
+			if count, err = strconv.Atoi(args[i+1]); err != nil {
+				return h.errorReply(ctx, ErrInvalidInt)
+			}
+			// up to 10 times more entries than count are scanned
+			if count < 1 || count > math.MaxInt/10 {
+				return h.errorReply(ctx, ErrCountNotPositive)
+			}
+			i++
+		case opt == "justid":
+			justID = true
+		default:
+			return h.errorReply(ctx, ErrSyntax)
+		}
+	}
+
+	next, claimed, deleted, err := h.storage.XAutoClaim(args[1], args[2], args[3], minIdle, start, count, justID)
+	if err != nil {
+		return h.streamGroupError(ctx, args, err)
+	}
+
+	claimedReply := encodeStreamIDs(entryIDs(claimed))
+	if !justID {
+		claimedReply = encodeStreamEntries(clientFromContext(ctx), claimed)
+	}
+
+	log.Info(responseMsg, zap.Stringer("next", next), zap.Int("response", len(claimed)))
+	return resp.EncodeRawArray([][]byte{
+		resp.EncodeString(next.String()),
+		claimedReply,
+		encodeStreamIDs(deleted),
+	})
+}
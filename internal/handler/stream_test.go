@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"nova/pkg/resp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stream is a setup of stream "s" with entries 1-1, 2-1 and 3-1.
+var stream = [][]string{
+	{"XADD", "s", "1-1", "n", "1"},
+	{"XADD", "s", "2-1", "n", "2"},
+	{"XADD", "s", "3-1", "n", "3"},
+}
+
+// streamGroup is a setup of stream "s" with consumer group "g" which delivered 1-1 and 2-1 to alice.
+var streamGroup = slices.Concat(stream, [][]string{
+	{"XGROUP", "CREATE", "s", "g", "0"},
+	{"XREADGROUP", "GROUP", "g", "alice", "COUNT", "2", "STREAMS", "s", ">"},
+})
+
+// entry returns encoded stream entry with ID id and field n set to value.
+func entry(id, value string) string {
+	return "*2\r\n" + string(resp.EncodeString(id)) + bulks("n", value)
+}
+
+// array returns RESP2 array of encoded elements.
+func array(elems ...string) string {
+	return string(resp.EncodeRawArray(toBytes(elems)))
+}
+
+func toBytes(strs []string) [][]byte {
+	elems := make([][]byte, len(strs))
+	for i, str := range strs {
+		elems[i] = []byte(str)
+	}
+	return elems
+}
+
+func TestXAdd(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "Explicit ID", args: []string{"XADD", "s", "5-3", "f", "v"}, want: "$3\r\n5-3\r\n", check: []string{"XLEN", "s"}, wantCheck: ":1\r\n"},
+		{name: "Auto sequence", setup: stream, args: []string{"XADD", "s", "3-*", "n", "4"}, want: "$3\r\n3-2\r\n"},
+		{name: "Auto sequence of new time", setup: stream, args: []string{"XADD", "s", "7-*", "n", "4"}, want: "$3\r\n7-0\r\n"},
+		{name: "Auto ID is greater than last one", setup: [][]string{{"XADD", "s", "99999999999999-5", "f", "v"}}, args: []string{"XADD", "s", "*", "f", "v"}, want: "$16\r\n99999999999999-6\r\n"},
+		{name: "Equal ID", setup: stream, args: []string{"XADD", "s", "3-1", "n", "4"}, want: "-The ID specified in XADD is equal or smaller than the target stream top item\r\n"},
+		{name: "Smaller ID", setup: stream, args: []string{"XADD", "s", "2-5", "n", "4"}, want: "-The ID specified in XADD is equal or smaller than the target stream top item\r\n", check: []string{"XLEN", "s"}, wantCheck: ":3\r\n"},
+		{name: "Zero ID", args: []string{"XADD", "s", "0-0", "f", "v"}, want: "-The ID specified in XADD must be greater than 0-0\r\n"},
+		{name: "Invalid ID", args: []string{"XADD", "s", "1-x", "f", "v"}, want: "-Invalid stream ID specified as stream command argument\r\n"},
+		{name: "Field without value", args: []string{"XADD", "s", "*", "f", "v", "g"}, want: "-Wrong number of arguments for 'xadd' command\r\n"},
+		{name: "NOMKSTREAM", args: []string{"XADD", "s", "NOMKSTREAM", "*", "f", "v"}, want: "$-1\r\n", check: []string{"EXISTS", "s"}, wantCheck: ":0\r\n"},
+		{name: "MAXLEN", setup: stream, args: []string{"XADD", "s", "MAXLEN", "2", "4-1", "n", "4"}, want: "$3\r\n4-1\r\n", check: []string{"XRANGE", "s", "-", "+"}, wantCheck: array(entry("3-1", "3"), entry("4-1", "4"))},
+		{name: "MINID", setup: stream, args: []string{"XADD", "s", "MINID", "=", "3", "4-1", "n", "4"}, want: "$3\r\n4-1\r\n", check: []string{"XLEN", "s"}, wantCheck: ":2\r\n"},
+		{name: "LIMIT without ~", args: []string{"XADD", "s", "MAXLEN", "1", "LIMIT", "1", "*", "f", "v"}, want: "-syntax error, LIMIT cannot be used without the special ~ option\r\n"},
+		{name: "Negative MAXLEN", args: []string{"XADD", "s", "MAXLEN", "-1", "*", "f", "v"}, want: "-The MAXLEN argument must be >= 0.\r\n"},
+		{name: "String", setup: [][]string{{"SET", "key", "v"}}, args: []string{"XADD", "key", "*", "f", "v"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestXRange(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "All", setup: stream, args: []string{"XRANGE", "s", "-", "+"}, want: array(entry("1-1", "1"), entry("2-1", "2"), entry("3-1", "3"))},
+		{name: "Omitted sequence", setup: stream, args: []string{"XRANGE", "s", "2", "3"}, want: array(entry("2-1", "2"), entry("3-1", "3"))},
+		{name: "Exclusive", setup: stream, args: []string{"XRANGE", "s", "(1-1", "(3-1"}, want: array(entry("2-1", "2"))},
+		{name: "COUNT", setup: stream, args: []string{"XRANGE", "s", "-", "+", "COUNT", "1"}, want: array(entry("1-1", "1"))},
+		{name: "Zero COUNT", setup: stream, args: []string{"XRANGE", "s", "-", "+", "COUNT", "0"}, want: "*0\r\n"},
+		{name: "XREVRANGE", setup: stream, args: []string{"XREVRANGE", "s", "+", "2", "COUNT", "5"}, want: array(entry("3-1", "3"), entry("2-1", "2"))},
+		{name: "Missing key", args: []string{"XRANGE", "s", "-", "+"}, want: "*0\r\n"},
+		{name: "Invalid start", args: []string{"XRANGE", "s", "x", "+"}, want: "-Invalid stream ID specified as stream command argument\r\n"},
+
+		{name: "XDEL", setup: stream, args: []string{"XDEL", "s", "2-1", "5-1"}, want: ":1\r\n", check: []string{"XRANGE", "s", "-", "+"}, wantCheck: array(entry("1-1", "1"), entry("3-1", "3"))},
+		// deleting entries doesn't make smaller IDs valid
+		{name: "XDEL last entry", setup: stream, args: []string{"XDEL", "s", "3-1"}, want: ":1\r\n", check: []string{"XADD", "s", "3-1", "n", "3"}, wantCheck: "-The ID specified in XADD is equal or smaller than the target stream top item\r\n"},
+		{name: "XTRIM MAXLEN", setup: stream, args: []string{"XTRIM", "s", "MAXLEN", "1"}, want: ":2\r\n", check: []string{"XRANGE", "s", "-", "+"}, wantCheck: array(entry("3-1", "3"))},
+		{name: "XTRIM MINID", setup: stream, args: []string{"XTRIM", "s", "MINID", "2-1"}, want: ":1\r\n"},
+		{name: "XTRIM approximately with LIMIT", setup: stream, args: []string{"XTRIM", "s", "MAXLEN", "~", "0", "LIMIT", "2"}, want: ":2\r\n", check: []string{"XLEN", "s"}, wantCheck: ":1\r\n"},
+		{name: "XTRIM syntax error", setup: stream, args: []string{"XTRIM", "s", "LEN", "1"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestXRead(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "After ID", setup: stream, args: []string{"XREAD", "STREAMS", "s", "1-1"}, want: array(array(string(resp.EncodeString("s")), array(entry("2-1", "2"), entry("3-1", "3"))))},
+		{name: "COUNT", setup: stream, args: []string{"XREAD", "COUNT", "1", "STREAMS", "s", "0"}, want: array(array(string(resp.EncodeString("s")), array(entry("1-1", "1"))))},
+		{name: "Several streams", setup: slices.Concat(stream, [][]string{{"XADD", "t", "1-1", "n", "1"}}), args: []string{"XREAD", "STREAMS", "t", "s", "nokey", "0", "2-1", "0"}, want: array(
+			array(string(resp.EncodeString("t")), array(entry("1-1", "1"))),
+			array(string(resp.EncodeString("s")), array(entry("3-1", "3"))),
+		)},
+		{name: "Nothing to read", setup: stream, args: []string{"XREAD", "STREAMS", "s", "$"}, want: "*-1\r\n"},
+		{name: "BLOCK timeout", setup: stream, args: []string{"XREAD", "BLOCK", "10", "STREAMS", "s", "3-1"}, want: "*-1\r\n"},
+		{name: "Unbalanced streams", args: []string{"XREAD", "STREAMS", "s", "t", "0"}, want: "-Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.\r\n"},
+		{name: "New ID", args: []string{"XREAD", "STREAMS", "s", ">"}, want: "-The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.\r\n"},
+		{name: "Negative BLOCK", args: []string{"XREAD", "BLOCK", "-1", "STREAMS", "s", "0"}, want: "-timeout is negative\r\n"},
+		{name: "Invalid BLOCK", args: []string{"XREAD", "BLOCK", "x", "STREAMS", "s", "0"}, want: "-timeout is not an integer or out of range\r\n"},
+	})
+}
+
+func TestXReadBlock(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "XADD", "s", "1-1", "n", "1")
+
+	reply := make(chan string, 1)
+	readerCtx := connect(t, h)
+	go func() {
+		reply <- serve(readerCtx, h, "XREAD", "BLOCK", "0", "STREAMS", "s", "$")
+	}()
+	require.Eventually(t, func() bool {
+		return h.storage.BlockedClients() == 1
+	}, time.Second, time.Millisecond)
+
+	serve(ctx, h, "XADD", "s", "2-1", "n", "2")
+	select {
+	case got := <-reply:
+		// entries added before XREAD are not returned for $
+		assert.Equal(t, array(array(string(resp.EncodeString("s")), array(entry("2-1", "2")))), got)
+	case <-time.After(time.Second):
+		t.Fatal("XREAD wasn't unblocked")
+	}
+}
+
+func TestXGroup(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "CREATE", setup: stream, args: []string{"XGROUP", "CREATE", "s", "g", "$"}, want: "+OK\r\n", check: []string{"XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", ">"}, wantCheck: "*-1\r\n"},
+		{name: "CREATE existing group", setup: streamGroup, args: []string{"XGROUP", "CREATE", "s", "g", "0"}, want: "-BUSYGROUP Consumer Group name already exists\r\n"},
+		{name: "CREATE missing key", args: []string{"XGROUP", "CREATE", "s", "g", "0"}, want: "-The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.\r\n"},
+		{name: "CREATE MKSTREAM", args: []string{"XGROUP", "CREATE", "s", "g", "$", "MKSTREAM"}, want: "+OK\r\n", check: []string{"XLEN", "s"}, wantCheck: ":0\r\n"},
+		{name: "SETID", setup: streamGroup, args: []string{"XGROUP", "SETID", "s", "g", "0"}, want: "+OK\r\n", check: []string{"XREADGROUP", "GROUP", "g", "bob", "COUNT", "1", "STREAMS", "s", ">"}, wantCheck: array(array(string(resp.EncodeString("s")), array(entry("1-1", "1"))))},
+		{name: "SETID missing group", setup: stream, args: []string{"XGROUP", "SETID", "s", "g", "0"}, want: "-NOGROUP No such consumer group 'g' for key name 's'\r\n"},
+		{name: "CREATECONSUMER", setup: streamGroup, args: []string{"XGROUP", "CREATECONSUMER", "s", "g", "bob"}, want: ":1\r\n"},
+		{name: "CREATECONSUMER existing", setup: streamGroup, args: []string{"XGROUP", "CREATECONSUMER", "s", "g", "alice"}, want: ":0\r\n"},
+		{name: "DELCONSUMER returns pending count", setup: streamGroup, args: []string{"XGROUP", "DELCONSUMER", "s", "g", "alice"}, want: ":2\r\n", check: []string{"XPENDING", "s", "g"}, wantCheck: "*4\r\n:0\r\n$-1\r\n$-1\r\n*-1\r\n"},
+		{name: "DESTROY", setup: streamGroup, args: []string{"XGROUP", "DESTROY", "s", "g"}, want: ":1\r\n", check: []string{"XGROUP", "DESTROY", "s", "g"}, wantCheck: ":0\r\n"},
+	})
+}
+
+func TestXReadGroup(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "New entries", setup: streamGroup, args: []string{"XREADGROUP", "GROUP", "g", "bob", "STREAMS", "s", ">"}, want: array(array(string(resp.EncodeString("s")), array(entry("3-1", "3"))))},
+		{name: "Nothing new", setup: slices.Concat(streamGroup, [][]string{{"XREADGROUP", "GROUP", "g", "bob", "STREAMS", "s", ">"}}), args: []string{"XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", ">"}, want: "*-1\r\n"},
+		// with ID, consumer gets its own pending entries
+		{name: "Pending history", setup: streamGroup, args: []string{"XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", "0"}, want: array(array(string(resp.EncodeString("s")), array(entry("1-1", "1"), entry("2-1", "2"))))},
+		{name: "Pending history of another consumer", setup: streamGroup, args: []string{"XREADGROUP", "GROUP", "g", "bob", "STREAMS", "s", "0"}, want: array(array(string(resp.EncodeString("s")), "*0\r\n"))},
+		{name: "Deleted pending entry", setup: slices.Concat(streamGroup, [][]string{{"XDEL", "s", "1-1"}}), args: []string{"XREADGROUP", "GROUP", "g", "alice", "COUNT", "1", "STREAMS", "s", "0"}, want: array(array(string(resp.EncodeString("s")), array("*2\r\n$3\r\n1-1\r\n*-1\r\n")))},
+		{name: "NOACK", setup: streamGroup, args: []string{"XREADGROUP", "GROUP", "g", "bob", "NOACK", "STREAMS", "s", ">"}, want: array(array(string(resp.EncodeString("s")), array(entry("3-1", "3")))), check: []string{"XPENDING", "s", "g"}, wantCheck: "*4\r\n:2\r\n$3\r\n1-1\r\n$3\r\n2-1\r\n*1\r\n*2\r\n$5\r\nalice\r\n$1\r\n2\r\n"},
+		{name: "Missing group", setup: stream, args: []string{"XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", ">"}, want: "-NOGROUP No such key 's' or consumer group 'g' in XREADGROUP with GROUP option\r\n"},
+		{name: "Last ID", setup: streamGroup, args: []string{"XREADGROUP", "GROUP", "g", "alice", "STREAMS", "s", "$"}, want: "-The $ ID is meaningless in the context of XREADGROUP\r\n"},
+		{name: "Without GROUP", args: []string{"XREADGROUP", "COUNT", "1", "NOACK", "STREAMS", "s", ">"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestXAckPending(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "XACK", setup: streamGroup, args: []string{"XACK", "s", "g", "1-1", "3-1", "1-1"}, want: ":1\r\n", check: []string{"XPENDING", "s", "g"}, wantCheck: "*4\r\n:1\r\n$3\r\n2-1\r\n$3\r\n2-1\r\n*1\r\n*2\r\n$5\r\nalice\r\n$1\r\n1\r\n"},
+		{name: "XACK missing group", setup: stream, args: []string{"XACK", "s", "g", "1-1"}, want: ":0\r\n"},
+		{name: "XPENDING summary", setup: streamGroup, args: []string{"XPENDING", "s", "g"}, want: "*4\r\n:2\r\n$3\r\n1-1\r\n$3\r\n2-1\r\n*1\r\n*2\r\n$5\r\nalice\r\n$1\r\n2\r\n"},
+		{name: "XPENDING consumer", setup: streamGroup, args: []string{"XPENDING", "s", "g", "-", "+", "10", "bob"}, want: "*0\r\n"},
+		{name: "XPENDING missing group", setup: stream, args: []string{"XPENDING", "s", "g"}, want: "-NOGROUP No such key 's' or consumer group 'g'\r\n"},
+	})
+}
+
+// TestXPendingDetails checks extended form of XPENDING: ID, consumer, idle time and number of deliveries.
+func TestXPendingDetails(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	for _, args := range streamGroup {
+		serve(ctx, h, args...)
+	}
+	// entry is delivered to alice once again
+	serve(ctx, h, "XREADGROUP", "GROUP", "g", "alice", "COUNT", "1", "STREAMS", "s", "0")
+	time.Sleep(20 * time.Millisecond)
+
+	reply := serve(ctx, h, "XPENDING", "s", "g", "IDLE", "10", "-", "+", "10", "alice")
+	assert.Regexp(t, `^\*2\r\n\*4\r\n\$3\r\n1-1\r\n\$5\r\nalice\r\n:\d+\r\n:2\r\n\*4\r\n\$3\r\n2-1\r\n\$5\r\nalice\r\n:\d+\r\n:1\r\n$`, reply)
+	assert.Equal(t, "*0\r\n", serve(ctx, h, "XPENDING", "s", "g", "IDLE", "100000", "-", "+", "10"))
+}
+
+func TestXClaim(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "XCLAIM", setup: streamGroup, args: []string{"XCLAIM", "s", "g", "bob", "0", "1-1", "3-1"}, want: array(entry("1-1", "1")), check: []string{"XPENDING", "s", "g"}, wantCheck: "*4\r\n:2\r\n$3\r\n1-1\r\n$3\r\n2-1\r\n*2\r\n*2\r\n$5\r\nalice\r\n$1\r\n1\r\n*2\r\n$3\r\nbob\r\n$1\r\n1\r\n"},
+		{name: "XCLAIM JUSTID", setup: streamGroup, args: []string{"XCLAIM", "s", "g", "bob", "0", "1-1", "2-1", "JUSTID"}, want: "*2\r\n$3\r\n1-1\r\n$3\r\n2-1\r\n"},
+		{name: "XCLAIM not idle enough", setup: streamGroup, args: []string{"XCLAIM", "s", "g", "bob", "100000", "1-1"}, want: "*0\r\n"},
+		{name: "XCLAIM invalid min idle time", setup: streamGroup, args: []string{"XCLAIM", "s", "g", "bob", "x", "1-1"}, want: "-Invalid min-idle-time argument for XCLAIM\r\n"},
+		{name: "XCLAIM missing group", setup: stream, args: []string{"XCLAIM", "s", "g", "bob", "0", "1-1"}, want: "-NOGROUP No such key 's' or consumer group 'g'\r\n"},
+
+		{name: "XAUTOCLAIM", setup: streamGroup, args: []string{"XAUTOCLAIM", "s", "g", "bob", "0", "0", "COUNT", "1"}, want: "*3\r\n$3\r\n2-1\r\n" + array(entry("1-1", "1")) + "*0\r\n"},
+		{name: "XAUTOCLAIM all", setup: streamGroup, args: []string{"XAUTOCLAIM", "s", "g", "bob", "0", "0", "JUSTID"}, want: "*3\r\n$3\r\n0-0\r\n*2\r\n$3\r\n1-1\r\n$3\r\n2-1\r\n*0\r\n"},
+		{name: "XAUTOCLAIM deleted entry", setup: slices.Concat(streamGroup, [][]string{{"XDEL", "s", "1-1"}}), args: []string{"XAUTOCLAIM", "s", "g", "bob", "0", "0"}, want: "*3\r\n$3\r\n0-0\r\n" + array(entry("2-1", "2")) + "*1\r\n$3\r\n1-1\r\n"},
+		{name: "XAUTOCLAIM zero COUNT", setup: streamGroup, args: []string{"XAUTOCLAIM", "s", "g", "bob", "0", "0", "COUNT", "0"}, want: "-COUNT must be > 0\r\n"},
+	})
+}
+
+func TestStreamRESP3(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "HELLO", "3")
+	serve(ctx, h, "XADD", "s", "1-1", "n", "1")
+
+	assert.Equal(t, "%1\r\n$1\r\ns\r\n"+array(entry("1-1", "1")), serve(ctx, h, "XREAD", "STREAMS", "s", "0"))
+	assert.Equal(t, "_\r\n", serve(ctx, h, "XREAD", "STREAMS", "s", "$"))
+	assert.True(t, strings.HasPrefix(serve(ctx, h, "XRANGE", "s", "-", "+"), "*1\r\n"))
+}
@@ -0,0 +1,432 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nova/internal/storage"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdIncr        = "incr"
+	cmdDecr        = "decr"
+	cmdIncrBy      = "incrby"
+	cmdDecrBy      = "decrby"
+	cmdIncrByFloat = "incrbyfloat"
+	cmdAppend      = "append"
+	cmdStrLen      = "strlen"
+	cmdGetRange    = "getrange"
+	cmdSetRange    = "setrange"
+	cmdGetDel      = "getdel"
+	cmdGetEx       = "getex"
+	cmdGetSet      = "getset"
+	cmdMGet        = "mget"
+	cmdMSet        = "mset"
+	cmdMSetNX      = "msetnx"
+)
+
+var (
+	ErrInvalidFloat  = "value is not a valid float"
+	ErrOverflow      = "increment or decrement would overflow"
+	ErrDecrOverflow  = "decrement would overflow"
+	ErrNaNOrInfinity = "increment would produce NaN or Infinity"
+	ErrOffsetRange   = "offset is out of range"
+	ErrStringTooLong = "string exceeds maximum allowed size (proto-max-bulk-len)"
+)
+
+var (
+	// maxStringLen is the max length of string value, the same as max bulk length.
+	maxStringLen = 512 * 1024 * 1024
+)
+
+// incrHandler increments integer value of the key by one.
+// Syntax: INCR key
+func (h *Handler) incrHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdIncr)
+	}
+
+	return h.incrBy(ctx, args[1], 1)
+}
+
+// decrHandler decrements integer value of the key by one.
+// Syntax: DECR key
+func (h *Handler) decrHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdDecr)
+	}
+
+	return h.incrBy(ctx, args[1], -1)
+}
+
+// incrByHandler increments integer value of the key by given number.
+// Syntax: INCRBY key increment
+func (h *Handler) incrByHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdIncrBy)
+	}
+
+	delta, err := strconv.Atoi(args[2])
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+
+	return h.incrBy(ctx, args[1], delta)
+}
+
+// decrByHandler decrements integer value of the key by given number.
+// Syntax: DECRBY key decrement
+func (h *Handler) decrByHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdDecrBy)
+	}
+
+	delta, err := strconv.Atoi(args[2])
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+	// -MinInt doesn't fit into int
+	if delta == math.MinInt {
+		log.Info(responseMsg, zap.String("response", ErrDecrOverflow))
+		return resp.EncodeError(ErrDecrOverflow)
+	}
+
+	return h.incrBy(ctx, args[1], -delta)
+}
+
+func (h *Handler) incrBy(ctx context.Context, key string, delta int) []byte {
+	log := l.FromContext(ctx)
+
+	result, err := h.storage.IncrBy(key, delta)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	case errors.Is(err, storage.ErrNotInteger):
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	case errors.Is(err, storage.ErrOverflow):
+		log.Info(responseMsg, zap.String("response", ErrOverflow))
+		return resp.EncodeError(ErrOverflow)
+	}
+
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// incrByFloatHandler increments numeric value of the key by given float number.
+// Syntax: INCRBYFLOAT key increment
+func (h *Handler) incrByFloatHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdIncrByFloat)
+	}
+
+	delta, err := strconv.ParseFloat(args[2], 64)
+	if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+		log.Info(responseMsg, zap.String("response", ErrInvalidFloat))
+		return resp.EncodeError(ErrInvalidFloat)
+	}
+
+	result, err := h.storage.IncrByFloat(args[1], delta)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	case errors.Is(err, storage.ErrNotFloat):
+		log.Info(responseMsg, zap.String("response", ErrInvalidFloat))
+		return resp.EncodeError(ErrInvalidFloat)
+	case errors.Is(err, storage.ErrNaNOrInf):
+		log.Info(responseMsg, zap.String("response", ErrNaNOrInfinity))
+		return resp.EncodeError(ErrNaNOrInfinity)
+	}
+
+	log.Info(responseMsg, zap.String("response", result))
+	return resp.EncodeString(result)
+}
+
+// appendHandler appends value to the string.
+// Syntax: APPEND key value
+func (h *Handler) appendHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdAppend)
+	}
+
+	length, err := h.storage.Append(args[1], args[2])
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// strLenHandler returns length of the string.
+// Syntax: STRLEN key
+func (h *Handler) strLenHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdStrLen)
+	}
+
+	length, err := h.storage.StrLen(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// getRangeHandler returns substring of the string.
+// Syntax: GETRANGE key start end
+func (h *Handler) getRangeHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdGetRange)
+	}
+
+	start, err := strconv.Atoi(args[2])
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+	end, err := strconv.Atoi(args[3])
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+
+	value, err := h.storage.GetRange(args[1], start, end)
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.String("response", value))
+	return resp.EncodeString(value)
+}
+
+// setRangeHandler overwrites part of the string starting at offset.
+// Syntax: SETRANGE key offset value
+func (h *Handler) setRangeHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdSetRange)
+	}
+
+	offset, err := strconv.Atoi(args[2])
+	if err != nil {
+		log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+		return resp.EncodeError(ErrInvalidInt)
+	}
+	if offset < 0 {
+		log.Info(responseMsg, zap.String("response", ErrOffsetRange))
+		return resp.EncodeError(ErrOffsetRange)
+	}
+	if offset+len(args[3]) > maxStringLen {
+		log.Info(responseMsg, zap.String("response", ErrStringTooLong))
+		return resp.EncodeError(ErrStringTooLong)
+	}
+
+	length, err := h.storage.SetRange(args[1], offset, args[3])
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", length))
+	return resp.EncodeInt(length)
+}
+
+// getDelHandler returns value of the key and deletes it.
+// Syntax: GETDEL key
+func (h *Handler) getDelHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdGetDel)
+	}
+
+	value, err := h.storage.GetDel(args[1])
+	return h.stringReply(ctx, value, err)
+}
+
+// getExHandler returns value of the key and changes its expiration time.
+// Syntax: GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds |
+// PXAT unix-time-milliseconds | PERSIST]
+func (h *Handler) getExHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdGetEx)
+	}
+
+	option := ""
+	if len(args) > 2 {
+		option = strings.ToLower(args[2])
+	}
+
+	var opts storage.GetExOptions
+	switch {
+	case len(args) == 2:
+	case option == "persist" && len(args) == 3:
+		opts.Persist = true
+	case (option == "ex" || option == "px" || option == "exat" || option == "pxat") && len(args) == 4:
+		num, err := strconv.ParseInt(args[3], 10, 64)
+		if err != nil {
+			log.Info(responseMsg, zap.String("response", ErrInvalidInt))
+			return resp.EncodeError(ErrInvalidInt)
+		}
+
+		unit := time.Second
+		if option == "px" || option == "pxat" {
+			unit = time.Millisecond
+		}
+		expiresAt, ok := expireTime(num, unit, option == "exat" || option == "pxat")
+		if num <= 0 || !ok {
+			response := fmt.Sprintf(ErrInvalidExpire, cmdGetEx)
+			log.Info(responseMsg, zap.String("response", response))
+			return resp.EncodeError(response)
+		}
+		opts.ExpiresAt = expiresAt
+	default:
+		log.Info(responseMsg, zap.String("response", ErrSyntax))
+		return resp.EncodeError(ErrSyntax)
+	}
+
+	value, err := h.storage.GetEx(args[1], opts)
+	return h.stringReply(ctx, value, err)
+}
+
+// getSetHandler sets value of the key and returns the old one.
+// Syntax: GETSET key value
+func (h *Handler) getSetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdGetSet)
+	}
+
+	result, err := h.storage.SetWithOptions(args[1], args[2], storage.SetOptions{Get: true})
+	if errors.Is(err, storage.ErrWrongType) {
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+	if !result.Existed {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNull()
+	}
+
+	log.Info(responseMsg, zap.String("response", result.Old))
+	return resp.EncodeString(result.Old)
+}
+
+// mGetHandler returns values of all given keys.
+// Missing keys and keys holding non-string values are returned as nulls.
+// Syntax: MGET key [key ...]
+func (h *Handler) mGetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdMGet)
+	}
+
+	values, ok := h.storage.MGet(args[1:])
+
+	c := clientFromContext(ctx)
+	elems := make([][]byte, 0, len(values))
+	for i, value := range values {
+		if !ok[i] {
+			elems = append(elems, c.encodeNull())
+			continue
+		}
+		elems = append(elems, resp.EncodeString(value))
+	}
+
+	log.Info(responseMsg, zap.Strings("response", values))
+	return resp.EncodeRawArray(elems)
+}
+
+// mSetHandler atomically sets values of all given keys.
+// Syntax: MSET key value [key value ...]
+func (h *Handler) mSetHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 || len(args)%2 != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdMSet)
+	}
+
+	h.storage.MSet(args[1:])
+
+	log.Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// mSetNXHandler atomically sets values of all given keys only if none of them exists.
+// Syntax: MSETNX key value [key value ...]
+func (h *Handler) mSetNXHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 || len(args)%2 != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdMSetNX)
+	}
+
+	result := 0
+	if h.storage.MSetNX(args[1:]) {
+		result = 1
+	}
+
+	log.Info(responseMsg, zap.Int("response", result))
+	return resp.EncodeInt(result)
+}
+
+// stringReply encodes result of reading string value.
+func (h *Handler) stringReply(ctx context.Context, value string, err error) []byte {
+	log := l.FromContext(ctx)
+
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+		log.Info(responseMsg, zap.String("response", nullString))
+		return clientFromContext(ctx).encodeNull()
+	case errors.Is(err, storage.ErrWrongType):
+		log.Info(responseMsg, zap.String("response", ErrWrongType))
+		return resp.EncodeError(ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.String("response", value))
+	return resp.EncodeString(value)
+}
+
+// wrongNumberOfArgs returns arity error for the command.
+func (h *Handler) wrongNumberOfArgs(ctx context.Context, cmd string) []byte {
+	response := fmt.Sprintf(ErrWrongNumberOfArgs, cmd)
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", response))
+	return resp.EncodeError(response)
+}
+
+// errorReply logs and encodes error reply.
+func (h *Handler) errorReply(ctx context.Context, msg string) []byte {
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", msg))
+	return resp.EncodeError(msg)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncr(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "INCR missing key", args: []string{"INCR", "key"}, want: ":1\r\n", check: []string{"GET", "key"}, wantCheck: "$1\r\n1\r\n"},
+		{name: "INCR", setup: [][]string{{"SET", "key", "10"}}, args: []string{"INCR", "key"}, want: ":11\r\n"},
+		{name: "DECR missing key", args: []string{"DECR", "key"}, want: ":-1\r\n"},
+		{name: "DECR", setup: [][]string{{"SET", "key", "-10"}}, args: []string{"DECR", "key"}, want: ":-11\r\n"},
+		{name: "INCRBY", setup: [][]string{{"SET", "key", "10"}}, args: []string{"INCRBY", "key", "-15"}, want: ":-5\r\n"},
+		{name: "DECRBY", setup: [][]string{{"SET", "key", "10"}}, args: []string{"DECRBY", "key", "15"}, want: ":-5\r\n"},
+		{name: "INCR keeps TTL", setup: [][]string{{"SET", "key", "10", "EX", "100"}}, args: []string{"INCR", "key"}, want: ":11\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+
+		{name: "INCR to max", setup: [][]string{{"SET", "key", "9223372036854775806"}}, args: []string{"INCR", "key"}, want: ":9223372036854775807\r\n"},
+		{name: "INCR overflow", setup: [][]string{{"SET", "key", "9223372036854775807"}}, args: []string{"INCR", "key"}, want: "-increment or decrement would overflow\r\n", check: []string{"GET", "key"}, wantCheck: "$19\r\n9223372036854775807\r\n"},
+		{name: "DECR overflow", setup: [][]string{{"SET", "key", "-9223372036854775808"}}, args: []string{"DECR", "key"}, want: "-increment or decrement would overflow\r\n"},
+		{name: "INCRBY to min", setup: [][]string{{"SET", "key", "-1"}}, args: []string{"INCRBY", "key", "-9223372036854775807"}, want: ":-9223372036854775808\r\n"},
+		{name: "INCRBY overflow", setup: [][]string{{"SET", "key", "-2"}}, args: []string{"INCRBY", "key", "-9223372036854775807"}, want: "-increment or decrement would overflow\r\n"},
+		{name: "DECRBY min int", args: []string{"DECRBY", "key", "-9223372036854775808"}, want: "-decrement would overflow\r\n"},
+		{name: "INCRBY out of range", args: []string{"INCRBY", "key", "9223372036854775808"}, want: "-Value is not an integer or out of range\r\n"},
+
+		{name: "Not an integer", setup: [][]string{{"SET", "key", "1.5"}}, args: []string{"INCR", "key"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Leading zero", setup: [][]string{{"SET", "key", "01"}}, args: []string{"INCR", "key"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Leading space", setup: [][]string{{"SET", "key", " 1"}}, args: []string{"INCR", "key"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Increment not an integer", args: []string{"INCRBY", "key", "1.0"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "Wrong type", setup: [][]string{{"RPUSH", "key", "1"}}, args: []string{"INCR", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{name: "Wrong number of arguments", args: []string{"INCRBY", "key"}, want: "-Wrong number of arguments for 'incrby' command\r\n"},
+	})
+}
+
+func TestIncrByFloat(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "Missing key", args: []string{"INCRBYFLOAT", "key", "1.5"}, want: "$3\r\n1.5\r\n"},
+		{name: "Float value", setup: [][]string{{"SET", "key", "10.50"}}, args: []string{"INCRBYFLOAT", "key", "0.1"}, want: "$4\r\n10.6\r\n", check: []string{"GET", "key"}, wantCheck: "$4\r\n10.6\r\n"},
+		{name: "Integer value", setup: [][]string{{"SET", "key", "10"}}, args: []string{"INCRBYFLOAT", "key", "-0.5"}, want: "$3\r\n9.5\r\n"},
+		{name: "Exponent", setup: [][]string{{"SET", "key", "5.0e3"}}, args: []string{"INCRBYFLOAT", "key", "2.0e2"}, want: "$4\r\n5200\r\n"},
+		{name: "Integer result can be incremented", setup: [][]string{{"SET", "key", "5.5"}, {"INCRBYFLOAT", "key", "0.5"}}, args: []string{"INCR", "key"}, want: ":7\r\n"},
+		{name: "Zero", setup: [][]string{{"SET", "key", "3"}}, args: []string{"INCRBYFLOAT", "key", "-3"}, want: "$1\r\n0\r\n"},
+		{name: "Keeps TTL", setup: [][]string{{"SET", "key", "1", "EX", "100"}}, args: []string{"INCRBYFLOAT", "key", "1"}, want: "$1\r\n2\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+
+		// the same precision as long double of Redis
+		{name: "Decimal fractions", setup: [][]string{{"SET", "key", "0.1"}}, args: []string{"INCRBYFLOAT", "key", "0.2"}, want: "$3\r\n0.3\r\n"},
+		{name: "17 digits after point", setup: [][]string{{"SET", "key", "1"}}, args: []string{"INCRBYFLOAT", "key", "0.00000000000000001"}, want: "$19\r\n1.00000000000000001\r\n"},
+		{name: "Less than 17 digits after point", setup: [][]string{{"SET", "key", "1"}}, args: []string{"INCRBYFLOAT", "key", "1e-20"}, want: "$1\r\n1\r\n"},
+		{name: "Large number without exponent", args: []string{"INCRBYFLOAT", "key", "1e20"}, want: "$21\r\n100000000000000000000\r\n"},
+
+		{name: "Overflow", setup: [][]string{{"SET", "key", "1.7e308"}}, args: []string{"INCRBYFLOAT", "key", "1.7e308"}, want: "-increment would produce NaN or Infinity\r\n", check: []string{"GET", "key"}, wantCheck: "$7\r\n1.7e308\r\n"},
+		{name: "Infinite increment", args: []string{"INCRBYFLOAT", "key", "inf"}, want: "-value is not a valid float\r\n"},
+		{name: "Increment not a float", args: []string{"INCRBYFLOAT", "key", "abc"}, want: "-value is not a valid float\r\n"},
+		{name: "Value not a float", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"INCRBYFLOAT", "key", "1"}, want: "-value is not a valid float\r\n"},
+		{name: "Value with spaces", setup: [][]string{{"SET", "key", "1 "}}, args: []string{"INCRBYFLOAT", "key", "1"}, want: "-value is not a valid float\r\n"},
+		{name: "Wrong type", setup: [][]string{{"RPUSH", "key", "1"}}, args: []string{"INCRBYFLOAT", "key", "1"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestStringRange(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "APPEND missing key", args: []string{"APPEND", "key", "abc"}, want: ":3\r\n", check: []string{"GET", "key"}, wantCheck: "$3\r\nabc\r\n"},
+		{name: "APPEND", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"APPEND", "key", "de"}, want: ":5\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nabcde\r\n"},
+		{name: "APPEND to integer", setup: [][]string{{"SET", "key", "10"}}, args: []string{"APPEND", "key", "5"}, want: ":3\r\n", check: []string{"INCR", "key"}, wantCheck: ":106\r\n"},
+		{name: "APPEND keeps TTL", setup: [][]string{{"SET", "key", "abc", "EX", "100"}}, args: []string{"APPEND", "key", "d"}, want: ":4\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "APPEND wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"APPEND", "key", "b"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+
+		{name: "STRLEN", setup: [][]string{{"SET", "key", "hello"}}, args: []string{"STRLEN", "key"}, want: ":5\r\n"},
+		{name: "STRLEN of integer", setup: [][]string{{"SET", "key", "-123"}}, args: []string{"STRLEN", "key"}, want: ":4\r\n"},
+		{name: "STRLEN missing key", args: []string{"STRLEN", "key"}, want: ":0\r\n"},
+		{name: "STRLEN wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"STRLEN", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+
+		{name: "GETRANGE", setup: [][]string{{"SET", "key", "This is a string"}}, args: []string{"GETRANGE", "key", "0", "3"}, want: "$4\r\nThis\r\n"},
+		{name: "GETRANGE negative", setup: [][]string{{"SET", "key", "This is a string"}}, args: []string{"GETRANGE", "key", "-3", "-1"}, want: "$3\r\ning\r\n"},
+		{name: "GETRANGE whole string", setup: [][]string{{"SET", "key", "This is a string"}}, args: []string{"GETRANGE", "key", "0", "-1"}, want: "$16\r\nThis is a string\r\n"},
+		{name: "GETRANGE end out of range", setup: [][]string{{"SET", "key", "This is a string"}}, args: []string{"GETRANGE", "key", "10", "100"}, want: "$6\r\nstring\r\n"},
+		{name: "GETRANGE start out of range", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"GETRANGE", "key", "5", "10"}, want: "$0\r\n\r\n"},
+		{name: "GETRANGE start after end", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"GETRANGE", "key", "2", "1"}, want: "$0\r\n\r\n"},
+		{name: "GETRANGE negative beyond start", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"GETRANGE", "key", "-100", "0"}, want: "$1\r\na\r\n"},
+		{name: "GETRANGE missing key", args: []string{"GETRANGE", "key", "0", "-1"}, want: "$0\r\n\r\n"},
+		{name: "GETRANGE not an integer", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"GETRANGE", "key", "0", "x"}, want: "-Value is not an integer or out of range\r\n"},
+
+		{name: "SETRANGE", setup: [][]string{{"SET", "key", "Hello World"}}, args: []string{"SETRANGE", "key", "6", "Redis"}, want: ":11\r\n", check: []string{"GET", "key"}, wantCheck: "$11\r\nHello Redis\r\n"},
+		{name: "SETRANGE extends string", setup: [][]string{{"SET", "key", "Hello"}}, args: []string{"SETRANGE", "key", "3", "p me"}, want: ":7\r\n", check: []string{"GET", "key"}, wantCheck: "$7\r\nHelp me\r\n"},
+		{name: "SETRANGE pads with zeros", args: []string{"SETRANGE", "key", "3", "abc"}, want: ":6\r\n", check: []string{"GET", "key"}, wantCheck: "$6\r\n\x00\x00\x00abc\r\n"},
+		{name: "SETRANGE empty value on missing key", args: []string{"SETRANGE", "key", "3", ""}, want: ":0\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "SETRANGE empty value", setup: [][]string{{"SET", "key", "abc"}}, args: []string{"SETRANGE", "key", "10", ""}, want: ":3\r\n", check: []string{"GET", "key"}, wantCheck: "$3\r\nabc\r\n"},
+		{name: "SETRANGE negative offset", args: []string{"SETRANGE", "key", "-1", "abc"}, want: "-offset is out of range\r\n"},
+		{name: "SETRANGE too long", args: []string{"SETRANGE", "key", "536870911", "ab"}, want: "-string exceeds maximum allowed size (proto-max-bulk-len)\r\n"},
+		{name: "SETRANGE wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"SETRANGE", "key", "0", "b"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestGetAndModify(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "GETDEL", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETDEL", "key"}, want: "$5\r\nvalue\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "GETDEL missing key", args: []string{"GETDEL", "key"}, want: "$-1\r\n"},
+		{name: "GETDEL wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"GETDEL", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", check: []string{"LLEN", "key"}, wantCheck: ":1\r\n"},
+
+		{name: "GETEX without options", setup: [][]string{{"SET", "key", "value", "EX", "100"}}, args: []string{"GETEX", "key"}, want: "$5\r\nvalue\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "GETEX EX", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EX", "100"}, want: "$5\r\nvalue\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "GETEX PX", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "px", "100000"}, want: "$5\r\nvalue\r\n", check: []string{"TTL", "key"}, wantCheck: ":100\r\n"},
+		{name: "GETEX EXAT", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EXAT", "32503680000"}, want: "$5\r\nvalue\r\n", check: []string{"EXPIRETIME", "key"}, wantCheck: ":32503680000\r\n"},
+		{name: "GETEX PXAT", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "PXAT", "32503680000123"}, want: "$5\r\nvalue\r\n", check: []string{"PEXPIRETIME", "key"}, wantCheck: ":32503680000123\r\n"},
+		{name: "GETEX PERSIST", setup: [][]string{{"SET", "key", "value", "EX", "100"}}, args: []string{"GETEX", "key", "PERSIST"}, want: "$5\r\nvalue\r\n", check: []string{"TTL", "key"}, wantCheck: ":-1\r\n"},
+		{name: "GETEX missing key", args: []string{"GETEX", "key", "EX", "100"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$-1\r\n"},
+		{name: "GETEX zero TTL", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EX", "0"}, want: "-invalid expire time in 'getex' command\r\n", check: []string{"TTL", "key"}, wantCheck: ":-1\r\n"},
+		{name: "GETEX not an integer", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EX", "x"}, want: "-Value is not an integer or out of range\r\n"},
+		{name: "GETEX EX and PERSIST", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EX", "100", "PERSIST"}, want: "-syntax error\r\n"},
+		{name: "GETEX EX without value", setup: [][]string{{"SET", "key", "value"}}, args: []string{"GETEX", "key", "EX"}, want: "-syntax error\r\n"},
+		{name: "GETEX wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"GETEX", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+
+		{name: "GETSET", setup: [][]string{{"SET", "key", "old", "EX", "100"}}, args: []string{"GETSET", "key", "value"}, want: "$3\r\nold\r\n", check: []string{"TTL", "key"}, wantCheck: ":-1\r\n"},
+		{name: "GETSET missing key", args: []string{"GETSET", "key", "value"}, want: "$-1\r\n", check: []string{"GET", "key"}, wantCheck: "$5\r\nvalue\r\n"},
+		{name: "GETSET wrong type", setup: [][]string{{"RPUSH", "key", "a"}}, args: []string{"GETSET", "key", "value"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestMultiKey(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "MGET", setup: [][]string{{"SET", "a", "1"}, {"SET", "c", "3"}, {"RPUSH", "list", "x"}}, args: []string{"MGET", "a", "b", "c", "list", "a"}, want: "*5\r\n$1\r\n1\r\n$-1\r\n$1\r\n3\r\n$-1\r\n$1\r\n1\r\n"},
+		{name: "MGET wrong number of arguments", args: []string{"MGET"}, want: "-Wrong number of arguments for 'mget' command\r\n"},
+
+		{name: "MSET", setup: [][]string{{"SET", "a", "old", "EX", "100"}, {"RPUSH", "list", "x"}}, args: []string{"MSET", "a", "1", "list", "2"}, want: "+OK\r\n", check: []string{"MGET", "a", "list"}, wantCheck: "*2\r\n$1\r\n1\r\n$1\r\n2\r\n"},
+		{name: "MSET clears TTL", setup: [][]string{{"SET", "a", "old", "EX", "100"}}, args: []string{"MSET", "a", "1"}, want: "+OK\r\n", check: []string{"TTL", "a"}, wantCheck: ":-1\r\n"},
+		{name: "MSET the same key twice", args: []string{"MSET", "a", "1", "a", "2"}, want: "+OK\r\n", check: []string{"GET", "a"}, wantCheck: "$1\r\n2\r\n"},
+		{name: "MSET odd number of arguments", args: []string{"MSET", "a", "1", "b"}, want: "-Wrong number of arguments for 'mset' command\r\n", check: []string{"GET", "a"}, wantCheck: "$-1\r\n"},
+
+		{name: "MSETNX", args: []string{"MSETNX", "a", "1", "b", "2"}, want: ":1\r\n", check: []string{"MGET", "a", "b"}, wantCheck: "*2\r\n$1\r\n1\r\n$1\r\n2\r\n"},
+		{name: "MSETNX existing key", setup: [][]string{{"SET", "b", "old"}}, args: []string{"MSETNX", "a", "1", "b", "2"}, want: ":0\r\n", check: []string{"MGET", "a", "b"}, wantCheck: "*2\r\n$-1\r\n$3\r\nold\r\n"},
+		{name: "MSETNX existing key of other type", setup: [][]string{{"RPUSH", "b", "x"}}, args: []string{"MSETNX", "a", "1", "b", "2"}, want: ":0\r\n", check: []string{"GET", "a"}, wantCheck: "$-1\r\n"},
+		{name: "MSETNX the same key twice", args: []string{"MSETNX", "a", "1", "a", "2"}, want: ":1\r\n", check: []string{"GET", "a"}, wantCheck: "$1\r\n2\r\n"},
+		{name: "MSETNX odd number of arguments", args: []string{"MSETNX", "a"}, want: "-Wrong number of arguments for 'msetnx' command\r\n"},
+	})
+}
+
+// TestMSetAtomic checks that MGET never sees keys set by the same MSET partially.
+func TestMSetAtomic(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	writerCtx := connect(t, h)
+	serve(ctx, h, "MSET", "a", "0", "b", "0")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 1000 {
+			value := strconv.Itoa(i)
+			serve(writerCtx, h, "MSET", "a", value, "b", value)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		reply := serve(ctx, h, "MGET", "a", "b")
+		values := strings.Split(reply, "\r\n")
+		require.Len(t, values, 6, reply)
+		require.Equal(t, values[2], values[4], "MGET saw partial MSET")
+	}
+}
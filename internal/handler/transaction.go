@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdMulti   = "multi"
+	cmdExec    = "exec"
+	cmdDiscard = "discard"
+	cmdWatch   = "watch"
+	cmdUnwatch = "unwatch"
+)
+
+var (
+	ErrMultiNested         = "MULTI calls can not be nested"
+	ErrExecWithoutMulti    = "EXEC without MULTI"
+	ErrDiscardWithoutMulti = "DISCARD without MULTI"
+	ErrWatchInMulti        = "WATCH inside MULTI is not allowed"
+	ErrNotAllowedInMulti   = "Command not allowed inside a transaction"
+	ErrExecAbort           = "EXECABORT Transaction discarded because of previous errors."
+)
+
+// txControlCmds are executed right away inside transaction instead of being queued.
+var txControlCmds = map[string]struct{}{
+	cmdMulti:   {},
+	cmdExec:    {},
+	cmdDiscard: {},
+	cmdWatch:   {},
+}
+
+// notQueuedCmds can't be queued, since their replies are pushed separately
+// and don't fit into the reply of EXEC. Transaction is aborted instead.
+var notQueuedCmds = map[string]struct{}{
+	cmdSubscribe:    {},
+	cmdUnsubscribe:  {},
+	cmdPSubscribe:   {},
+	cmdPUnsubscribe: {},
+	cmdSSubscribe:   {},
+	cmdSUnsubscribe: {},
+}
+
+// transaction is a queue of commands started with MULTI.
+type transaction struct {
+	queue [][]string
+	// aborted is set if command can't be queued, transaction is discarded on EXEC then
+	aborted bool
+}
+
+// multiHandler starts transaction. Following commands are queued until EXEC or DISCARD.
+// Syntax: MULTI
+func (h *Handler) multiHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdMulti)
+	}
+
+	c := clientFromContext(ctx)
+	if c.tx != nil {
+		return h.errorReply(ctx, ErrMultiNested)
+	}
+	c.tx = &transaction{}
+
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// execHandler atomically executes queued commands and returns array of their replies.
+// If any of watched keys was modified, nothing is executed and null array is returned.
+// Blocking commands don't block inside transaction.
+// Syntax: EXEC
+func (h *Handler) execHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdExec)
+	}
+
+	c := clientFromContext(ctx)
+	tx := c.tx
+	if tx == nil {
+		return h.errorReply(ctx, ErrExecWithoutMulti)
+	}
+	// transaction and watches are over regardless of result
+	c.tx = nil
+	defer c.unwatch()
+
+	if tx.aborted {
+		return h.errorReply(ctx, ErrExecAbort)
+	}
+
+	h.execMu.Lock()
+	defer h.execMu.Unlock()
+	// clients blocked on keys are served after all queued commands are executed
+	defer h.storage.ServeBlocked()
+
+	if c.watchedModified() {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNilArray()
+	}
+
+	c.executing = true
+	defer func() { c.executing = false }()
+
+	replies := make([][]byte, len(tx.queue))
+	for i, cmdArgs := range tx.queue {
+		replies[i] = h.call(ctx, cmdArgs)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(replies)))
+	return resp.EncodeRawArray(replies)
+}
+
+// discardHandler discards queued commands and unwatches all keys.
+// Syntax: DISCARD
+func (h *Handler) discardHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdDiscard)
+	}
+
+	c := clientFromContext(ctx)
+	if c.tx == nil {
+		return h.errorReply(ctx, ErrDiscardWithoutMulti)
+	}
+	c.tx = nil
+	c.unwatch()
+
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// watchHandler marks keys to be watched for conditional execution of transaction.
+// Syntax: WATCH key [key ...]
+func (h *Handler) watchHandler(ctx context.Context, args []string) []byte {
+	if len(args) < 2 {
+		return h.wrongNumberOfArgs(ctx, cmdWatch)
+	}
+
+	c := clientFromContext(ctx)
+	if c.tx != nil {
+		return h.errorReply(ctx, ErrWatchInMulti)
+	}
+	c.watches = append(c.watches, h.storage.Watch(args[1:]))
+
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
+
+// unwatchHandler forgets all watched keys.
+// Syntax: UNWATCH
+func (h *Handler) unwatchHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 1 {
+		return h.wrongNumberOfArgs(ctx, cmdUnwatch)
+	}
+
+	clientFromContext(ctx).unwatch()
+
+	l.FromContext(ctx).Info(responseMsg, zap.String("response", "OK"))
+	return resp.EncodeSimpleString("OK")
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{
+			name:      "EXEC",
+			setup:     [][]string{{"MULTI"}, {"SET", "key", "value"}, {"INCR", "key"}, {"GET", "key"}},
+			args:      []string{"EXEC"},
+			want:      "*3\r\n+OK\r\n-Value is not an integer or out of range\r\n$5\r\nvalue\r\n",
+			check:     []string{"GET", "key"},
+			wantCheck: "$5\r\nvalue\r\n",
+		},
+		{
+			name:  "EXEC empty",
+			setup: [][]string{{"MULTI"}},
+			args:  []string{"EXEC"},
+			want:  "*0\r\n",
+		},
+		{
+			name:  "command is queued",
+			setup: [][]string{{"MULTI"}},
+			args:  []string{"SET", "key", "value"},
+			want:  "+QUEUED\r\n",
+		},
+		{
+			name:      "DISCARD",
+			setup:     [][]string{{"MULTI"}, {"SET", "key", "value"}},
+			args:      []string{"DISCARD"},
+			want:      "+OK\r\n",
+			check:     []string{"GET", "key"},
+			wantCheck: "$-1\r\n",
+		},
+		{
+			name:  "nested MULTI",
+			setup: [][]string{{"MULTI"}},
+			args:  []string{"MULTI"},
+			want:  "-MULTI calls can not be nested\r\n",
+		},
+		{
+			name: "EXEC without MULTI",
+			args: []string{"EXEC"},
+			want: "-EXEC without MULTI\r\n",
+		},
+		{
+			name: "DISCARD without MULTI",
+			args: []string{"DISCARD"},
+			want: "-DISCARD without MULTI\r\n",
+		},
+		{
+			name:  "WATCH inside MULTI",
+			setup: [][]string{{"MULTI"}},
+			args:  []string{"WATCH", "key"},
+			want:  "-WATCH inside MULTI is not allowed\r\n",
+		},
+		{
+			name: "WATCH without keys",
+			args: []string{"WATCH"},
+			want: "-Wrong number of arguments for 'watch' command\r\n",
+		},
+	})
+}
+
+// TestExecServesBlockedAfterwards checks that client blocked on a key
+// doesn't take elements pushed by transaction before it's complete.
+func TestExecServesBlockedAfterwards(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	blockedCtx := connect(t, h)
+
+	popped := make(chan string)
+	go func() {
+		popped <- serve(blockedCtx, h, "BLPOP", "list", "0")
+	}()
+	require.Eventually(t, func() bool {
+		return h.storage.BlockedClients() == 1
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, "+OK\r\n", serve(ctx, h, "MULTI"))
+	assert.Equal(t, "+QUEUED\r\n", serve(ctx, h, "RPUSH", "list", "a"))
+	assert.Equal(t, "+QUEUED\r\n", serve(ctx, h, "LLEN", "list"))
+	assert.Equal(t, "*2\r\n:1\r\n:1\r\n", serve(ctx, h, "EXEC"))
+
+	select {
+	case got := <-popped:
+		assert.Equal(t, "*2\r\n$4\r\nlist\r\n$1\r\na\r\n", got)
+	case <-time.After(time.Second):
+		t.Fatal("blocked client wasn't served")
+	}
+	assert.Equal(t, ":0\r\n", serve(ctx, h, "LLEN", "list"))
+}
+
+// TestBlockingWaitsForExec checks that blocking command takes elements
+// only after transaction holding the exec lock is complete.
+func TestBlockingWaitsForExec(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	assert.Equal(t, ":1\r\n", serve(ctx, h, "RPUSH", "list", "a"))
+
+	// the lock is held exclusively the same way EXEC holds it
+	h.execMu.Lock()
+	popped := make(chan string)
+	go func() {
+		popped <- serve(connect(t, h), h, "BLPOP", "list", "0")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, h.storage.Exists([]string{"list"}), "list was popped while transaction is executed")
+	h.execMu.Unlock()
+
+	select {
+	case got := <-popped:
+		assert.Equal(t, "*2\r\n$4\r\nlist\r\n$1\r\na\r\n", got)
+	case <-time.After(time.Second):
+		t.Fatal("BLPOP didn't pop element after transaction")
+	}
+}
+
+func TestExecAbort(t *testing.T) {
+	var tests = []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "Unknown command", args: []string{"NOSUCHCMD"}, wantErr: "-Unknown command\r\n"},
+		{name: "Wrong number of arguments", args: []string{"SET", "key"}, wantErr: "-Wrong number of arguments for 'set' command\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+
+			assert.Equal(t, "+OK\r\n", serve(ctx, h, "MULTI"))
+			assert.Equal(t, "+QUEUED\r\n", serve(ctx, h, "SET", "key", "value"))
+			assert.Equal(t, test.wantErr, serve(ctx, h, test.args...))
+			assert.Equal(t, "-EXECABORT Transaction discarded because of previous errors.\r\n", serve(ctx, h, "EXEC"))
+
+			// queued commands are not executed
+			assert.Equal(t, "$-1\r\n", serve(ctx, h, "GET", "key"))
+		})
+	}
+}
+
+// TestExecSubscribe checks that subscription commands, whose confirmations
+// are pushed to connected client, are not queued.
+func TestExecSubscribe(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{name: "SUBSCRIBE", args: []string{"SUBSCRIBE", "channel"}},
+		{name: "UNSUBSCRIBE", args: []string{"UNSUBSCRIBE"}},
+		{name: "PSUBSCRIBE", args: []string{"PSUBSCRIBE", "chan*"}},
+		{name: "PUNSUBSCRIBE", args: []string{"PUNSUBSCRIBE", "chan*"}},
+		{name: "SSUBSCRIBE", args: []string{"SSUBSCRIBE", "channel"}},
+		{name: "SUNSUBSCRIBE", args: []string{"SUNSUBSCRIBE"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			ctx, pushed := subscriber(t, h)
+
+			assert.Equal(t, "+OK\r\n", serve(ctx, h, "MULTI"))
+			assert.Equal(t, "+QUEUED\r\n", serve(ctx, h, "SET", "key", "value"))
+			assert.Equal(t, "-Command not allowed inside a transaction\r\n", serve(ctx, h, test.args...))
+			assert.Equal(t, "-EXECABORT Transaction discarded because of previous errors.\r\n", serve(ctx, h, "EXEC"))
+
+			assert.Empty(t, *pushed)
+			assert.Equal(t, "$-1\r\n", serve(ctx, h, "GET", "key"))
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	var tests = []struct {
+		name   string
+		setup  [][]string
+		watch  []string
+		modify []string
+		want   string
+	}{
+		{
+			name:   "key is modified",
+			watch:  []string{"WATCH", "key"},
+			modify: []string{"SET", "key", "other"},
+			want:   "*-1\r\n",
+		},
+		{
+			name:   "key is created",
+			watch:  []string{"WATCH", "key", "another"},
+			modify: []string{"LPUSH", "another", "a"},
+			want:   "*-1\r\n",
+		},
+		{
+			name:   "key is deleted",
+			setup:  [][]string{{"SET", "key", "value"}},
+			watch:  []string{"WATCH", "key"},
+			modify: []string{"DEL", "key"},
+			want:   "*-1\r\n",
+		},
+		{
+			name:   "key is expired",
+			setup:  [][]string{{"SET", "key", "value"}},
+			watch:  []string{"WATCH", "key"},
+			modify: []string{"EXPIRE", "key", "100"},
+			want:   "*-1\r\n",
+		},
+		{
+			name:   "other key is modified",
+			watch:  []string{"WATCH", "key"},
+			modify: []string{"SET", "other", "value"},
+			want:   "*1\r\n+OK\r\n",
+		},
+		{
+			name:   "missing key is deleted",
+			watch:  []string{"WATCH", "key"},
+			modify: []string{"DEL", "key"},
+			want:   "*1\r\n+OK\r\n",
+		},
+		{
+			name:   "UNWATCH",
+			watch:  []string{"UNWATCH"},
+			modify: []string{"SET", "key", "other"},
+			want:   "*1\r\n+OK\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, ctx := newTestHandler(t)
+			for _, args := range test.setup {
+				serve(ctx, h, args...)
+			}
+
+			assert.Equal(t, "+OK\r\n", serve(ctx, h, "WATCH", "key"))
+			assert.Equal(t, "+OK\r\n", serve(ctx, h, test.watch...))
+			serve(connect(t, h), h, test.modify...)
+
+			assert.Equal(t, "+OK\r\n", serve(ctx, h, "MULTI"))
+			assert.Equal(t, "+QUEUED\r\n", serve(ctx, h, "SET", "key", "value"))
+			assert.Equal(t, test.want, serve(ctx, h, "EXEC"))
+		})
+	}
+
+	t.Run("watched keys are cleared after EXEC", func(t *testing.T) {
+		h, ctx := newTestHandler(t)
+
+		serve(ctx, h, "WATCH", "key")
+		serve(ctx, h, "MULTI")
+		serve(ctx, h, "EXEC")
+		serve(connect(t, h), h, "SET", "key", "other")
+
+		serve(ctx, h, "MULTI")
+		serve(ctx, h, "SET", "key", "value")
+		assert.Equal(t, "*1\r\n+OK\r\n", serve(ctx, h, "EXEC"))
+	})
+}
@@ -0,0 +1,611 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var (
+	cmdZAdd        = "zadd"
+	cmdZRem        = "zrem"
+	cmdZScore      = "zscore"
+	cmdZMScore     = "zmscore"
+	cmdZIncrBy     = "zincrby"
+	cmdZCard       = "zcard"
+	cmdZCount      = "zcount"
+	cmdZRank       = "zrank"
+	cmdZRevRank    = "zrevrank"
+	cmdZRange      = "zrange"
+	cmdZRangeStore = "zrangestore"
+	cmdZPopMin     = "zpopmin"
+	cmdZPopMax     = "zpopmax"
+	cmdZUnionStore = "zunionstore"
+	cmdZInterStore = "zinterstore"
+)
+
+var (
+	ErrXXAndNX            = "XX and NX options at the same time are not compatible"
+	ErrGTLTNX             = "GT, LT, and/or NX options at the same time are not compatible"
+	ErrIncrPair           = "INCR option supports a single increment-element pair"
+	ErrScoreNaN           = "resulting score is not a number (NaN)"
+	ErrMinMaxNotFloat     = "min or max is not a float"
+	ErrMinMaxNotLex       = "min or max not valid string range item"
+	ErrLimitWithoutBy     = "syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX"
+	ErrWithScoresWithLex  = "syntax error, WITHSCORES not supported in combination with BYLEX"
+	ErrWeightNotFloat     = "weight value is not a float"
+	ErrAtLeastOneInputKey = "at least 1 input key is needed for '%s' command"
+)
+
+// parseScore parses score of sorted set member. Infinities are allowed.
+func parseScore(arg string) (float64, bool) {
+	score, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false
+	}
+	return score, true
+}
+
+// parseScoreBound parses score bound. It's exclusive if prefixed with "(".
+func parseScoreBound(arg string) (ds.ScoreBound, bool) {
+	var bound ds.ScoreBound
+	if strings.HasPrefix(arg, "(") {
+		bound.Exclusive = true
+		arg = arg[1:]
+	}
+
+	score, ok := parseScore(arg)
+	bound.Value = score
+	return bound, ok
+}
+
+// parseLexBound parses lexicographical bound: "-", "+", "[value" or "(value".
+func parseLexBound(arg string) (ds.LexBound, bool) {
+	switch {
+	case arg == "-":
+		return ds.LexBound{Inf: -1}, true
+	case arg == "+":
+		return ds.LexBound{Inf: 1}, true
+	case strings.HasPrefix(arg, "["):
+		return ds.LexBound{Value: arg[1:]}, true
+	case strings.HasPrefix(arg, "("):
+		return ds.LexBound{Value: arg[1:], Exclusive: true}, true
+	default:
+		return ds.LexBound{}, false
+	}
+}
+
+// zAddHandler adds members to the sorted set or updates their scores.
+// Syntax: ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...]
+func (h *Handler) zAddHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdZAdd)
+	}
+
+	var opts storage.ZAddOptions
+	incr := false
+	i := 2
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			opts.NX = true
+		case "xx":
+			opts.XX = true
+		case "gt":
+			opts.GT = true
+		case "lt":
+			opts.LT = true
+		case "ch":
+			opts.CH = true
+		case "incr":
+			incr = true
+		default:
+			break loop
+		}
+	}
+
+	pairs := args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+	if opts.NX && opts.XX {
+		return h.errorReply(ctx, ErrXXAndNX)
+	}
+	if (opts.GT && opts.LT) || (opts.NX && (opts.GT || opts.LT)) {
+		return h.errorReply(ctx, ErrGTLTNX)
+	}
+	if incr && len(pairs) > 2 {
+		return h.errorReply(ctx, ErrIncrPair)
+	}
+
+	members := make([]ds.ScoredMember, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, ok := parseScore(pairs[j])
+		if !ok {
+			return h.errorReply(ctx, ErrInvalidFloat)
+		}
+		members = append(members, ds.ScoredMember{Member: pairs[j+1], Score: score})
+	}
+
+	if incr {
+		return h.zIncrBy(ctx, args[1], members[0].Member, members[0].Score, opts)
+	}
+
+	count, err := h.storage.ZAdd(args[1], members, opts)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
+
+// zIncrByHandler increments score of the sorted set member.
+// Syntax: ZINCRBY key increment member
+func (h *Handler) zIncrByHandler(ctx context.Context, args []string) []byte {
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdZIncrBy)
+	}
+
+	delta, ok := parseScore(args[2])
+	if !ok {
+		return h.errorReply(ctx, ErrInvalidFloat)
+	}
+
+	return h.zIncrBy(ctx, args[1], args[3], delta, storage.ZAddOptions{})
+}
+
+func (h *Handler) zIncrBy(ctx context.Context, key, member string, delta float64, opts storage.ZAddOptions) []byte {
+	log := l.FromContext(ctx)
+	c := clientFromContext(ctx)
+
+	score, updated, err := h.storage.ZIncrBy(key, member, delta, opts)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrNaN):
+		return h.errorReply(ctx, ErrScoreNaN)
+	case !updated:
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNull()
+	}
+
+	log.Info(responseMsg, zap.Float64("response", score))
+	return c.encodeDouble(score)
+}
+
+// zRemHandler removes members from the sorted set and returns number of removed ones.
+// Syntax: ZREM key member [member ...]
+func (h *Handler) zRemHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdZRem)
+	}
+
+	removed, err := h.storage.ZRem(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", removed))
+	return resp.EncodeInt(removed)
+}
+
+// zScoreHandler returns score of the sorted set member.
+// Syntax: ZSCORE key member
+func (h *Handler) zScoreHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 3 {
+		return h.wrongNumberOfArgs(ctx, cmdZScore)
+	}
+
+	scores, ok, err := h.storage.ZMScore(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	c := clientFromContext(ctx)
+	if !ok[0] {
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNull()
+	}
+
+	log.Info(responseMsg, zap.Float64("response", scores[0]))
+	return c.encodeDouble(scores[0])
+}
+
+// zMScoreHandler returns scores of the sorted set members. Missing members have null scores.
+// Syntax: ZMSCORE key member [member ...]
+func (h *Handler) zMScoreHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 {
+		return h.wrongNumberOfArgs(ctx, cmdZMScore)
+	}
+
+	scores, ok, err := h.storage.ZMScore(args[1], args[2:])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	c := clientFromContext(ctx)
+	elems := make([][]byte, len(scores))
+	for i, score := range scores {
+		if !ok[i] {
+			elems[i] = c.encodeNull()
+			continue
+		}
+		elems[i] = c.encodeDouble(score)
+	}
+
+	log.Info(responseMsg, zap.Float64s("response", scores))
+	return resp.EncodeRawArray(elems)
+}
+
+// zCardHandler returns number of members in the sorted set.
+// Syntax: ZCARD key
+func (h *Handler) zCardHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 2 {
+		return h.wrongNumberOfArgs(ctx, cmdZCard)
+	}
+
+	card, err := h.storage.ZCard(args[1])
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", card))
+	return resp.EncodeInt(card)
+}
+
+// zCountHandler returns number of members with scores in range.
+// Syntax: ZCOUNT key min max
+func (h *Handler) zCountHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) != 4 {
+		return h.wrongNumberOfArgs(ctx, cmdZCount)
+	}
+
+	min, ok := parseScoreBound(args[2])
+	if !ok {
+		return h.errorReply(ctx, ErrMinMaxNotFloat)
+	}
+	max, ok := parseScoreBound(args[3])
+	if !ok {
+		return h.errorReply(ctx, ErrMinMaxNotFloat)
+	}
+
+	count, err := h.storage.ZCount(args[1], min, max)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
+
+func (h *Handler) zRankHandler(ctx context.Context, args []string) []byte {
+	return h.zRankGeneric(ctx, args, cmdZRank, false)
+}
+
+func (h *Handler) zRevRankHandler(ctx context.Context, args []string) []byte {
+	return h.zRankGeneric(ctx, args, cmdZRevRank, true)
+}
+
+// zRankGeneric implements ZRANK and ZREVRANK.
+// Syntax: <cmd> key member [WITHSCORE]
+func (h *Handler) zRankGeneric(ctx context.Context, args []string, cmd string, reverse bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 3 || len(args) > 4 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+	withScore := false
+	if len(args) == 4 {
+		if !strings.EqualFold(args[3], "withscore") {
+			return h.errorReply(ctx, ErrSyntax)
+		}
+		withScore = true
+	}
+
+	c := clientFromContext(ctx)
+	rank, score, err := h.storage.ZRank(args[1], args[2], reverse)
+	switch {
+	case errors.Is(err, storage.ErrWrongType):
+		return h.errorReply(ctx, ErrWrongType)
+	case errors.Is(err, storage.ErrKeyNotFound) && withScore:
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNilArray()
+	case errors.Is(err, storage.ErrKeyNotFound):
+		log.Info(responseMsg, zap.String("response", nullString))
+		return c.encodeNull()
+	}
+
+	log.Info(responseMsg, zap.Int("response", rank))
+	if withScore {
+		return resp.EncodeRawArray([][]byte{resp.EncodeInt(rank), c.encodeDouble(score)})
+	}
+	return resp.EncodeInt(rank)
+}
+
+// zRangeHandler returns members of the sorted set in range.
+// Syntax: ZRANGE key start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
+func (h *Handler) zRangeHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmdZRange)
+	}
+
+	query, withScores, errMsg := parseZRangeArgs(args[2:], true)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	members, err := h.storage.ZRange(args[1], query)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(members)))
+	return encodeScoredMembers(clientFromContext(ctx), members, withScores)
+}
+
+// zRangeStoreHandler stores members of the sorted set in range to another key.
+// Syntax: ZRANGESTORE dst src min max [BYSCORE|BYLEX] [REV] [LIMIT offset count]
+func (h *Handler) zRangeStoreHandler(ctx context.Context, args []string) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 5 {
+		return h.wrongNumberOfArgs(ctx, cmdZRangeStore)
+	}
+
+	query, _, errMsg := parseZRangeArgs(args[3:], false)
+	if errMsg != "" {
+		return h.errorReply(ctx, errMsg)
+	}
+
+	count, err := h.storage.ZRangeStore(args[1], args[2], query)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", count))
+	return resp.EncodeInt(count)
+}
+
+// parseZRangeArgs parses "start stop [BYSCORE|BYLEX] [REV] [LIMIT offset count] [WITHSCORES]".
+// WITHSCORES is allowed only if withScoresAllowed is true.
+// In case of error, error message is returned.
+func parseZRangeArgs(args []string, withScoresAllowed bool) (storage.ZRangeQuery, bool, string) {
+	query := storage.ZRangeQuery{Count: -1}
+	withScores, withLimit := false, false
+
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "byscore":
+			query.By = storage.ZRangeByScore
+		case "bylex":
+			query.By = storage.ZRangeByLex
+		case "rev":
+			query.Rev = true
+		case "withscores":
+			if !withScoresAllowed {
+				return query, false, ErrSyntax
+			}
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return query, false, ErrSyntax
+			}
+			offset, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return query, false, ErrInvalidInt
+			}
+			count, err := strconv.Atoi(args[i+2])
+			if err != nil {
+				return query, false, ErrInvalidInt
+			}
+			query.Offset, query.Count = offset, count
+			withLimit = true
+			i += 2
+		default:
+			return query, false, ErrSyntax
+		}
+	}
+
+	if withLimit && query.By == storage.ZRangeByRank {
+		return query, false, ErrLimitWithoutBy
+	}
+	if withScores && query.By == storage.ZRangeByLex {
+		return query, false, ErrWithScoresWithLex
+	}
+
+	// in reversed ranges by score and lex max goes first
+	minArg, maxArg := args[0], args[1]
+	if query.Rev {
+		minArg, maxArg = maxArg, minArg
+	}
+
+	var ok bool
+	switch query.By {
+	case storage.ZRangeByScore:
+		if query.MinScore, ok = parseScoreBound(minArg); !ok {
+			return query, false, ErrMinMaxNotFloat
+		}
+		if query.MaxScore, ok = parseScoreBound(maxArg); !ok {
+			return query, false, ErrMinMaxNotFloat
+		}
+	case storage.ZRangeByLex:
+		if query.MinLex, ok = parseLexBound(minArg); !ok {
+			return query, false, ErrMinMaxNotLex
+		}
+		if query.MaxLex, ok = parseLexBound(maxArg); !ok {
+			return query, false, ErrMinMaxNotLex
+		}
+	default:
+		var err error
+		if query.Start, err = strconv.Atoi(args[0]); err != nil {
+			return query, false, ErrInvalidInt
+		}
+		if query.Stop, err = strconv.Atoi(args[1]); err != nil {
+			return query, false, ErrInvalidInt
+		}
+	}
+
+	return query, withScores, ""
+}
+
+// encodeScoredMembers encodes members of sorted set.
+// With scores, RESP2 clients get flat array of members and scores,
+// while RESP3 clients get array of member-score pairs.
+func encodeScoredMembers(c *client, members []ds.ScoredMember, withScores bool) []byte {
+	elems := make([][]byte, 0, len(members))
+	for _, m := range members {
+		switch {
+		case !withScores:
+			elems = append(elems, resp.EncodeString(m.Member))
+		case c.resp3():
+			elems = append(elems, resp.EncodeRawArray([][]byte{resp.EncodeString(m.Member), c.encodeDouble(m.Score)}))
+		default:
+			elems = append(elems, resp.EncodeString(m.Member), c.encodeDouble(m.Score))
+		}
+	}
+
+	return resp.EncodeRawArray(elems)
+}
+
+func (h *Handler) zPopMinHandler(ctx context.Context, args []string) []byte {
+	return h.zPopGeneric(ctx, args, cmdZPopMin, false)
+}
+
+func (h *Handler) zPopMaxHandler(ctx context.Context, args []string) []byte {
+	return h.zPopGeneric(ctx, args, cmdZPopMax, true)
+}
+
+// zPopGeneric implements ZPOPMIN and ZPOPMAX.
+// Syntax: <cmd> key [count]
+func (h *Handler) zPopGeneric(ctx context.Context, args []string, cmd string, max bool) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 2 || len(args) > 3 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	count := 1
+	if len(args) == 3 {
+		var err error
+		count, err = strconv.Atoi(args[2])
+		if err != nil || count < 0 {
+			return h.errorReply(ctx, ErrNegativeVal)
+		}
+	}
+
+	popped, err := h.storage.ZPop(args[1], count, max)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", len(popped)))
+	c := clientFromContext(ctx)
+	// single member is returned as flat pair even for RESP3 clients
+	if len(args) == 2 && len(popped) == 1 {
+		return resp.EncodeRawArray([][]byte{resp.EncodeString(popped[0].Member), c.encodeDouble(popped[0].Score)})
+	}
+	return encodeScoredMembers(c, popped, true)
+}
+
+func (h *Handler) zUnionStoreHandler(ctx context.Context, args []string) []byte {
+	return h.zStoreGeneric(ctx, args, cmdZUnionStore, storage.SetOpUnion)
+}
+
+func (h *Handler) zInterStoreHandler(ctx context.Context, args []string) []byte {
+	return h.zStoreGeneric(ctx, args, cmdZInterStore, storage.SetOpInter)
+}
+
+// zStoreGeneric implements ZUNIONSTORE and ZINTERSTORE.
+// Syntax: <cmd> destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM|MIN|MAX]
+func (h *Handler) zStoreGeneric(ctx context.Context, args []string, cmd string, op storage.SetOp) []byte {
+	log := l.FromContext(ctx)
+
+	if len(args) < 4 {
+		return h.wrongNumberOfArgs(ctx, cmd)
+	}
+
+	numKeys, err := strconv.Atoi(args[2])
+	if err != nil {
+		return h.errorReply(ctx, ErrInvalidInt)
+	}
+	if numKeys <= 0 {
+		return h.errorReply(ctx, fmt.Sprintf(ErrAtLeastOneInputKey, cmd))
+	}
+	if numKeys > len(args)-3 {
+		return h.errorReply(ctx, ErrSyntax)
+	}
+	keys := args[3 : numKeys+3]
+
+	weights := make([]float64, numKeys)
+	for i := range weights {
+		weights[i] = 1
+	}
+	agg := storage.AggregateSum
+
+	for i := numKeys + 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "weights":
+			if i+numKeys >= len(args) {
+				return h.errorReply(ctx, ErrSyntax)
+			}
+			for j := range weights {
+				weight, ok := parseScore(args[i+1+j])
+				if !ok {
+					return h.errorReply(ctx, ErrWeightNotFloat)
+				}
+				weights[j] = weight
+			}
+			i += numKeys
+		case "aggregate":
+			if i+1 >= len(args) {
+				return h.errorReply(ctx, ErrSyntax)
+			}
+			switch strings.ToLower(args[i+1]) {
+			case "sum":
+				agg = storage.AggregateSum
+			case "min":
+				agg = storage.AggregateMin
+			case "max":
+				agg = storage.AggregateMax
+			default:
+				return h.errorReply(ctx, ErrSyntax)
+			}
+			i++
+		default:
+			return h.errorReply(ctx, ErrSyntax)
+		}
+	}
+
+	card, err := h.storage.ZStore(op, args[1], keys, weights, agg)
+	if errors.Is(err, storage.ErrWrongType) {
+		return h.errorReply(ctx, ErrWrongType)
+	}
+
+	log.Info(responseMsg, zap.Int("response", card))
+	return resp.EncodeInt(card)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"nova/pkg/resp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// abcd is a setup of sorted set "z" with members a, b, c, d scored 1 to 4.
+var abcd = [][]string{{"ZADD", "z", "1", "a", "2", "b", "3", "c", "4", "d"}}
+
+// bulks returns RESP2 array of bulk strings.
+func bulks(elems ...string) string {
+	return string(resp.EncodeArray(elems))
+}
+
+func TestZAdd(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "New and updated members", setup: abcd, args: []string{"ZADD", "z", "10", "a", "5", "e"}, want: ":1\r\n", check: []string{"ZRANGE", "z", "0", "-1"}, wantCheck: bulks("b", "c", "d", "e", "a")},
+		{name: "CH counts updated members", setup: abcd, args: []string{"ZADD", "z", "CH", "10", "a", "2", "b", "5", "e"}, want: ":2\r\n"},
+		{name: "NX", setup: abcd, args: []string{"ZADD", "z", "NX", "10", "a", "5", "e"}, want: ":1\r\n", check: []string{"ZSCORE", "z", "a"}, wantCheck: "$1\r\n1\r\n"},
+		{name: "XX", setup: abcd, args: []string{"ZADD", "z", "XX", "CH", "10", "a", "5", "e"}, want: ":1\r\n", check: []string{"ZCARD", "z"}, wantCheck: ":4\r\n"},
+		{name: "GT", setup: abcd, args: []string{"ZADD", "z", "GT", "CH", "10", "a", "0", "b"}, want: ":1\r\n", check: []string{"ZMSCORE", "z", "a", "b"}, wantCheck: "*2\r\n$2\r\n10\r\n$1\r\n2\r\n"},
+		{name: "LT", setup: abcd, args: []string{"ZADD", "z", "LT", "CH", "10", "a", "0", "b"}, want: ":1\r\n", check: []string{"ZMSCORE", "z", "a", "b"}, wantCheck: "*2\r\n$1\r\n1\r\n$1\r\n0\r\n"},
+		{name: "GT adds new members", setup: abcd, args: []string{"ZADD", "z", "GT", "0", "e"}, want: ":1\r\n"},
+		{name: "Same score sorted by member", args: []string{"ZADD", "z", "1", "c", "1", "a", "1", "b"}, want: ":3\r\n", check: []string{"ZRANGE", "z", "0", "-1"}, wantCheck: bulks("a", "b", "c")},
+		{name: "Infinite score", args: []string{"ZADD", "z", "-inf", "a", "+inf", "b"}, want: ":2\r\n", check: []string{"ZRANGE", "z", "0", "-1", "WITHSCORES"}, wantCheck: bulks("a", "-inf", "b", "inf")},
+
+		{name: "INCR", setup: abcd, args: []string{"ZADD", "z", "INCR", "1.5", "a"}, want: "$3\r\n2.5\r\n"},
+		{name: "INCR NX existing member", setup: abcd, args: []string{"ZADD", "z", "NX", "INCR", "1", "a"}, want: "$-1\r\n"},
+		{name: "INCR several pairs", args: []string{"ZADD", "z", "INCR", "1", "a", "2", "b"}, want: "-INCR option supports a single increment-element pair\r\n"},
+		{name: "NX and XX", args: []string{"ZADD", "z", "NX", "XX", "1", "a"}, want: "-XX and NX options at the same time are not compatible\r\n"},
+		{name: "GT and LT", args: []string{"ZADD", "z", "GT", "LT", "1", "a"}, want: "-GT, LT, and/or NX options at the same time are not compatible\r\n"},
+		{name: "NX and GT", args: []string{"ZADD", "z", "NX", "GT", "1", "a"}, want: "-GT, LT, and/or NX options at the same time are not compatible\r\n"},
+		{name: "Score without member", args: []string{"ZADD", "z", "1", "a", "2"}, want: "-syntax error\r\n"},
+		{name: "Invalid score", args: []string{"ZADD", "z", "1", "a", "x", "b"}, want: "-value is not a valid float\r\n", check: []string{"EXISTS", "z"}, wantCheck: ":0\r\n"},
+		{name: "NaN score", args: []string{"ZADD", "z", "nan", "a"}, want: "-value is not a valid float\r\n"},
+		{name: "String", setup: [][]string{{"SET", "key", "v"}}, args: []string{"ZADD", "key", "1", "a"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestZScore(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "ZINCRBY", setup: abcd, args: []string{"ZINCRBY", "z", "-0.5", "d"}, want: "$3\r\n3.5\r\n"},
+		{name: "ZINCRBY missing member", args: []string{"ZINCRBY", "z", "2", "a"}, want: "$1\r\n2\r\n"},
+		{name: "ZINCRBY to NaN", setup: [][]string{{"ZADD", "z", "inf", "a"}}, args: []string{"ZINCRBY", "z", "-inf", "a"}, want: "-resulting score is not a number (NaN)\r\n", check: []string{"ZSCORE", "z", "a"}, wantCheck: "$3\r\ninf\r\n"},
+		{name: "ZSCORE missing member", setup: abcd, args: []string{"ZSCORE", "z", "e"}, want: "$-1\r\n"},
+		{name: "ZMSCORE", setup: abcd, args: []string{"ZMSCORE", "z", "b", "e"}, want: "*2\r\n$1\r\n2\r\n$-1\r\n"},
+		{name: "ZREM", setup: abcd, args: []string{"ZREM", "z", "a", "e"}, want: ":1\r\n", check: []string{"ZCARD", "z"}, wantCheck: ":3\r\n"},
+		{name: "ZREM last member deletes key", setup: [][]string{{"ZADD", "z", "1", "a"}}, args: []string{"ZREM", "z", "a"}, want: ":1\r\n", check: []string{"EXISTS", "z"}, wantCheck: ":0\r\n"},
+		{name: "ZCOUNT", setup: abcd, args: []string{"ZCOUNT", "z", "(1", "3"}, want: ":2\r\n"},
+		{name: "ZCOUNT infinite", setup: abcd, args: []string{"ZCOUNT", "z", "-inf", "+inf"}, want: ":4\r\n"},
+		{name: "ZCOUNT invalid bound", setup: abcd, args: []string{"ZCOUNT", "z", "[1", "3"}, want: "-min or max is not a float\r\n"},
+		{name: "ZRANK", setup: abcd, args: []string{"ZRANK", "z", "c"}, want: ":2\r\n"},
+		{name: "ZREVRANK WITHSCORE", setup: abcd, args: []string{"ZREVRANK", "z", "c", "WITHSCORE"}, want: "*2\r\n:1\r\n$1\r\n3\r\n"},
+		{name: "ZRANK missing member", setup: abcd, args: []string{"ZRANK", "z", "e"}, want: "$-1\r\n"},
+		{name: "ZRANK missing member WITHSCORE", setup: abcd, args: []string{"ZRANK", "z", "e", "WITHSCORE"}, want: "*-1\r\n"},
+	})
+}
+
+func TestZRange(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "By rank", setup: abcd, args: []string{"ZRANGE", "z", "1", "-2"}, want: bulks("b", "c")},
+		{name: "By rank out of range", setup: abcd, args: []string{"ZRANGE", "z", "-100", "100"}, want: bulks("a", "b", "c", "d")},
+		{name: "By rank REV", setup: abcd, args: []string{"ZRANGE", "z", "0", "1", "REV", "WITHSCORES"}, want: bulks("d", "4", "c", "3")},
+		{name: "Missing key", args: []string{"ZRANGE", "z", "0", "-1"}, want: "*0\r\n"},
+
+		{name: "BYSCORE", setup: abcd, args: []string{"ZRANGE", "z", "(1", "3", "BYSCORE"}, want: bulks("b", "c")},
+		{name: "BYSCORE infinite", setup: abcd, args: []string{"ZRANGE", "z", "-inf", "+inf", "BYSCORE"}, want: bulks("a", "b", "c", "d")},
+		{name: "BYSCORE REV takes max first", setup: abcd, args: []string{"ZRANGE", "z", "3", "(1", "BYSCORE", "REV"}, want: bulks("c", "b")},
+		{name: "BYSCORE LIMIT", setup: abcd, args: []string{"ZRANGE", "z", "-inf", "+inf", "BYSCORE", "LIMIT", "1", "2"}, want: bulks("b", "c")},
+		{name: "BYSCORE LIMIT negative count", setup: abcd, args: []string{"ZRANGE", "z", "-inf", "+inf", "BYSCORE", "LIMIT", "2", "-1"}, want: bulks("c", "d")},
+		{name: "BYSCORE LIMIT negative offset", setup: abcd, args: []string{"ZRANGE", "z", "-inf", "+inf", "BYSCORE", "LIMIT", "-1", "2"}, want: "*0\r\n"},
+		{name: "BYSCORE invalid bound", setup: abcd, args: []string{"ZRANGE", "z", "a", "1", "BYSCORE"}, want: "-min or max is not a float\r\n"},
+
+		{name: "BYLEX", setup: [][]string{{"ZADD", "z", "0", "a", "0", "b", "0", "c", "0", "d"}}, args: []string{"ZRANGE", "z", "(a", "[c", "BYLEX"}, want: bulks("b", "c")},
+		{name: "BYLEX infinite", setup: [][]string{{"ZADD", "z", "0", "a", "0", "b", "0", "c"}}, args: []string{"ZRANGE", "z", "+", "-", "BYLEX", "REV", "LIMIT", "0", "2"}, want: bulks("c", "b")},
+		{name: "BYLEX LIMIT negative offset", setup: [][]string{{"ZADD", "z", "0", "a", "0", "b"}}, args: []string{"ZRANGE", "z", "-", "+", "BYLEX", "LIMIT", "-1", "1"}, want: "*0\r\n"},
+		{name: "BYLEX invalid bound", setup: abcd, args: []string{"ZRANGE", "z", "a", "+", "BYLEX"}, want: "-min or max not valid string range item\r\n"},
+		{name: "BYLEX WITHSCORES", setup: abcd, args: []string{"ZRANGE", "z", "-", "+", "BYLEX", "WITHSCORES"}, want: "-syntax error, WITHSCORES not supported in combination with BYLEX\r\n"},
+		{name: "LIMIT without BY", setup: abcd, args: []string{"ZRANGE", "z", "0", "-1", "LIMIT", "0", "1"}, want: "-syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX\r\n"},
+		{name: "LIMIT without count", setup: abcd, args: []string{"ZRANGE", "z", "0", "1", "BYSCORE", "LIMIT", "0"}, want: "-syntax error\r\n"},
+
+		{name: "ZRANGESTORE", setup: abcd, args: []string{"ZRANGESTORE", "dst", "z", "2", "+inf", "BYSCORE"}, want: ":3\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("b", "2", "c", "3", "d", "4")},
+		{name: "ZRANGESTORE empty result deletes destination", setup: [][]string{{"ZADD", "dst", "1", "x"}}, args: []string{"ZRANGESTORE", "dst", "z", "0", "-1"}, want: ":0\r\n", check: []string{"EXISTS", "dst"}, wantCheck: ":0\r\n"},
+		{name: "ZRANGESTORE WITHSCORES", setup: abcd, args: []string{"ZRANGESTORE", "dst", "z", "0", "-1", "WITHSCORES"}, want: "-syntax error\r\n"},
+	})
+}
+
+func TestZPop(t *testing.T) {
+	runCommandTests(t, []commandTest{
+		{name: "ZPOPMIN", setup: abcd, args: []string{"ZPOPMIN", "z"}, want: bulks("a", "1")},
+		{name: "ZPOPMAX count", setup: abcd, args: []string{"ZPOPMAX", "z", "2"}, want: bulks("d", "4", "c", "3"), check: []string{"ZCARD", "z"}, wantCheck: ":2\r\n"},
+		{name: "ZPOPMIN whole set", setup: abcd, args: []string{"ZPOPMIN", "z", "10"}, want: bulks("a", "1", "b", "2", "c", "3", "d", "4"), check: []string{"EXISTS", "z"}, wantCheck: ":0\r\n"},
+		{name: "ZPOPMIN missing key", args: []string{"ZPOPMIN", "z"}, want: "*0\r\n"},
+		{name: "ZPOPMIN negative count", setup: abcd, args: []string{"ZPOPMIN", "z", "-1"}, want: "-value is out of range, must be positive\r\n"},
+	})
+}
+
+func TestZStore(t *testing.T) {
+	sets := [][]string{
+		{"ZADD", "z1", "1", "a", "2", "b", "3", "c"},
+		{"ZADD", "z2", "10", "b", "20", "c", "30", "d"},
+		{"SADD", "set", "a", "d"},
+	}
+
+	runCommandTests(t, []commandTest{
+		{name: "ZUNIONSTORE", setup: sets, args: []string{"ZUNIONSTORE", "dst", "2", "z1", "z2"}, want: ":4\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("a", "1", "b", "12", "c", "23", "d", "30")},
+		{name: "ZINTERSTORE", setup: sets, args: []string{"ZINTERSTORE", "dst", "2", "z1", "z2"}, want: ":2\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("b", "12", "c", "23")},
+		{name: "WEIGHTS", setup: sets, args: []string{"ZINTERSTORE", "dst", "2", "z1", "z2", "WEIGHTS", "2", "0.5"}, want: ":2\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("b", "9", "c", "16")},
+		{name: "AGGREGATE MIN", setup: sets, args: []string{"ZUNIONSTORE", "dst", "2", "z1", "z2", "AGGREGATE", "MIN"}, want: ":4\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("a", "1", "b", "2", "c", "3", "d", "30")},
+		{name: "AGGREGATE MAX", setup: sets, args: []string{"ZINTERSTORE", "dst", "2", "z1", "z2", "aggregate", "max"}, want: ":2\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("b", "10", "c", "20")},
+		// members of plain sets have score 1
+		{name: "Set as input", setup: sets, args: []string{"ZINTERSTORE", "dst", "2", "z2", "set"}, want: ":1\r\n", check: []string{"ZRANGE", "dst", "0", "-1", "WITHSCORES"}, wantCheck: bulks("d", "31")},
+		{name: "Missing key", setup: sets, args: []string{"ZINTERSTORE", "z1", "2", "z1", "nokey"}, want: ":0\r\n", check: []string{"EXISTS", "z1"}, wantCheck: ":0\r\n"},
+
+		{name: "Zero numkeys", args: []string{"ZUNIONSTORE", "dst", "0", "z1"}, want: "-at least 1 input key is needed for 'zunionstore' command\r\n"},
+		{name: "Numkeys exceeds keys", args: []string{"ZINTERSTORE", "dst", "3", "z1", "z2"}, want: "-syntax error\r\n"},
+		{name: "Too few weights", setup: sets, args: []string{"ZUNIONSTORE", "dst", "2", "z1", "z2", "WEIGHTS", "1"}, want: "-syntax error\r\n"},
+		{name: "Invalid weight", setup: sets, args: []string{"ZUNIONSTORE", "dst", "2", "z1", "z2", "WEIGHTS", "1", "x"}, want: "-weight value is not a float\r\n"},
+		{name: "Unknown aggregate", setup: sets, args: []string{"ZUNIONSTORE", "dst", "1", "z1", "AGGREGATE", "AVG"}, want: "-syntax error\r\n"},
+		{name: "String input", setup: [][]string{{"SET", "key", "v"}}, args: []string{"ZUNIONSTORE", "dst", "1", "key"}, want: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+	})
+}
+
+func TestZSetRESP3(t *testing.T) {
+	h, ctx := newTestHandler(t)
+	serve(ctx, h, "HELLO", "3")
+	serve(ctx, h, "ZADD", "z", "1", "a", "2.5", "b")
+
+	assert.Equal(t, ",2.5\r\n", serve(ctx, h, "ZSCORE", "z", "b"))
+	assert.Equal(t, "*2\r\n*2\r\n$1\r\na\r\n,1\r\n*2\r\n$1\r\nb\r\n,2.5\r\n", serve(ctx, h, "ZRANGE", "z", "0", "-1", "WITHSCORES"))
+	// single popped member is a flat pair
+	assert.Equal(t, "*2\r\n$1\r\na\r\n,1\r\n", serve(ctx, h, "ZPOPMIN", "z"))
+}
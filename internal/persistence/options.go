@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"nova/internal/config"
+	"slices"
+)
+
+type Option func(*Persistence)
+
+// WithPath sets directory and name of snapshot file.
+func WithPath(dir, filename string) Option {
+	return func(p *Persistence) {
+		p.SetPath(dir, filename)
+	}
+}
+
+// WithRules sets rules of automatic saving.
+func WithRules(rules []config.SaveRule) Option {
+	return func(p *Persistence) {
+		p.SetRules(rules)
+	}
+}
+
+// SetPath changes directory and name of snapshot file.
+// It's applied since the next save, save in progress writes to the old path.
+func (p *Persistence) SetPath(dir, filename string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.dir = dir
+	p.filename = filename
+}
+
+// SetRules changes rules of automatic saving. Empty rules disable it.
+func (p *Persistence) SetRules(rules []config.SaveRule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rules = slices.Clone(rules)
+}
@@ -0,0 +1,302 @@
+// Package persistence saves point-in-time snapshots of storage to disk
+// and loads them on startup.
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"nova/internal/config"
+	"nova/internal/storage"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSaveInProgress = errors.New("background save already in progress")
+	ErrClosed         = errors.New("persistence is shut down")
+)
+
+const (
+	// checkInterval is how often save rules are checked
+	checkInterval = time.Second
+	// retryDelay is how long automatic save waits after failed attempt
+	retryDelay = 5 * time.Second
+)
+
+// Storage is a storage which can be saved to snapshot and restored from it.
+type Storage interface {
+	// Snapshot returns point-in-time copy of data.
+	Snapshot() storage.Snapshot
+	// Changes returns number of modifications made since storage was created.
+	Changes() uint64
+	// Load replaces data with keys read from snapshot.
+	Load(r io.Reader) error
+}
+
+// Status describes state of snapshotting.
+type Status struct {
+	// ChangesSinceSave is number of modifications made after the last successful save.
+	ChangesSinceSave uint64
+	SaveInProgress   bool
+	// LastSave is time of the last successful save (or start time if there were no saves).
+	LastSave time.Time
+	// LastErr is the result of the last save attempt.
+	LastErr error
+}
+
+// Persistence writes snapshots of storage to the file on demand and according to save rules.
+// Only one snapshot is written at a time.
+type Persistence struct {
+	storage Storage
+	log     *zap.Logger
+
+	mu       sync.Mutex
+	dir      string
+	filename string
+	rules    []config.SaveRule
+	saving   bool
+	// lastSaveChanges is storage.Changes at the moment the last saved snapshot was taken
+	lastSaveChanges uint64
+	lastSave        time.Time
+	lastAttempt     time.Time
+	lastErr         error
+	// closed is set on shutdown, background saves are not started after that
+	closed bool
+
+	// background is used to wait for background save to finish
+	background sync.WaitGroup
+}
+
+// New is a constructor for Persistence. By default snapshot is stored in "dump.rdb"
+// of the working directory and there are no save rules.
+func New(storage Storage, log *zap.Logger, opts ...Option) *Persistence {
+	now := time.Now()
+	p := &Persistence{
+		storage:     storage,
+		log:         log,
+		dir:         ".",
+		filename:    "dump.rdb",
+		lastSave:    now,
+		lastAttempt: now,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// path returns path to snapshot file.
+// It MUST BE CALLED with lock held.
+func (p *Persistence) path() string {
+	return filepath.Join(p.dir, p.filename)
+}
+
+// Load loads snapshot file into storage. Missing file is not an error.
+func (p *Persistence) Load() error {
+	p.mu.Lock()
+	path := p.path()
+	p.mu.Unlock()
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		p.log.Info("snapshot file not found, starting with empty storage", zap.String("path", path))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	if err := p.storage.Load(f); err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	p.mu.Lock()
+	p.lastSaveChanges = p.storage.Changes()
+	p.mu.Unlock()
+
+	p.log.Info("snapshot loaded", zap.String("path", path), zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// Save writes snapshot synchronously.
+func (p *Persistence) Save() error {
+	path, err := p.startSave(false)
+	if err != nil {
+		return err
+	}
+
+	snap := p.storage.Snapshot()
+	err = writeSnapshot(path, snap)
+	p.finishSave(path, snap, err)
+	return err
+}
+
+// BGSave takes snapshot and writes it in background goroutine.
+// Storage is locked only while snapshot is taken, not while it's written.
+func (p *Persistence) BGSave() error {
+	path, err := p.startSave(true)
+	if err != nil {
+		return err
+	}
+
+	snap := p.storage.Snapshot()
+	go func() {
+		defer p.background.Done()
+		p.finishSave(path, snap, writeSnapshot(path, snap))
+	}()
+
+	return nil
+}
+
+// startSave marks save as started and returns path to write snapshot to.
+// Background saves are counted, so that Shutdown can wait for them.
+func (p *Persistence) startSave(background bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.saving {
+		return "", ErrSaveInProgress
+	}
+	if background {
+		if p.closed {
+			return "", ErrClosed
+		}
+		p.background.Add(1)
+	}
+	p.saving = true
+
+	return p.path(), nil
+}
+
+func (p *Persistence) finishSave(path string, snap storage.Snapshot, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.saving = false
+	p.lastAttempt = now
+	p.lastErr = err
+	if err != nil {
+		p.log.Error("failed to save snapshot", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	p.lastSave = now
+	p.lastSaveChanges = snap.Changes()
+	p.log.Info("snapshot saved", zap.String("path", path))
+}
+
+// Status returns current state of snapshotting.
+func (p *Persistence) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return Status{
+		ChangesSinceSave: p.storage.Changes() - p.lastSaveChanges,
+		SaveInProgress:   p.saving,
+		LastSave:         p.lastSave,
+		LastErr:          p.lastErr,
+	}
+}
+
+// Run starts background save whenever any of save rules is satisfied
+// until ctx is cancelled.
+func (p *Persistence) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if p.shouldSave(now) {
+				// save may have been started by client or shutdown in the meantime
+				_ = p.BGSave()
+			}
+		}
+	}
+}
+
+// shouldSave reports whether any of save rules is satisfied.
+// After failed attempt save is not retried for retryDelay.
+func (p *Persistence) shouldSave(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.saving || (p.lastErr != nil && now.Sub(p.lastAttempt) < retryDelay) {
+		return false
+	}
+
+	changes := p.storage.Changes() - p.lastSaveChanges
+	elapsed := now.Sub(p.lastSave)
+	for _, rule := range p.rules {
+		if changes >= uint64(rule.Changes) && elapsed > time.Duration(rule.Seconds)*time.Second {
+			return true
+		}
+	}
+	return false
+}
+
+// Shutdown waits for background save to finish and saves snapshot
+// if there are save rules and unsaved changes.
+func (p *Persistence) Shutdown() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.background.Wait()
+
+	p.mu.Lock()
+	needSave := len(p.rules) > 0 && p.storage.Changes() != p.lastSaveChanges
+	p.mu.Unlock()
+
+	if !needSave {
+		return nil
+	}
+	return p.Save()
+}
+
+// writeSnapshot writes snapshot to temporary file and renames it,
+// so that snapshot file is never left partially written.
+func writeSnapshot(path string, snap storage.Snapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "temp-*.rdb")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	// temporary file is created with 0600 permissions
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set snapshot permissions: %w", err)
+	}
+	if err := snap.Encode(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package persistence
+
+import (
+	"errors"
+	"io"
+	"nova/internal/config"
+	"nova/internal/storage"
+	mapstorage "nova/internal/storage/map"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testStorage counts changes and writes their number as a snapshot.
+type testStorage struct {
+	changes uint64
+	loaded  string
+}
+
+type testSnapshot struct {
+	changes uint64
+}
+
+func (s *testStorage) Snapshot() storage.Snapshot { return testSnapshot{changes: s.changes} }
+func (s *testStorage) Changes() uint64            { return s.changes }
+
+func (s *testStorage) Load(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	s.loaded = string(data)
+	return err
+}
+
+func (snap testSnapshot) Changes() uint64 { return snap.changes }
+
+func (snap testSnapshot) Encode(w io.Writer) error {
+	_, err := w.Write([]byte("snapshot"))
+	return err
+}
+
+// slowStorage is a real storage, whose snapshots are encoded only after release is closed.
+type slowStorage struct {
+	*mapstorage.Storage
+	started, release chan struct{}
+}
+
+type slowSnapshot struct {
+	storage.Snapshot
+	s *slowStorage
+}
+
+func (s *slowStorage) Snapshot() storage.Snapshot {
+	return slowSnapshot{Snapshot: s.Storage.Snapshot(), s: s}
+}
+
+func (snap slowSnapshot) Encode(w io.Writer) error {
+	close(snap.s.started)
+	<-snap.s.release
+	return snap.Snapshot.Encode(w)
+}
+
+func newTestPersistence(t *testing.T, rules []config.SaveRule) (*Persistence, *testStorage) {
+	t.Helper()
+
+	s := &testStorage{}
+	p := New(s, zap.NewNop(), WithPath(t.TempDir(), "dump.rdb"), WithRules(rules))
+	return p, s
+}
+
+func TestShouldSave(t *testing.T) {
+	rules := []config.SaveRule{{Seconds: 60, Changes: 10}, {Seconds: 300, Changes: 1}}
+	failed := errors.New("failed")
+
+	var tests = []struct {
+		name        string
+		rules       []config.SaveRule
+		changes     uint64
+		elapsed     time.Duration
+		saving      bool
+		lastErr     error
+		sinceFailed time.Duration
+		want        bool
+	}{
+		{name: "No rules", changes: 100, elapsed: time.Hour, want: false},
+		{name: "No changes", rules: rules, elapsed: time.Hour, want: false},
+		{name: "Too early", rules: rules, changes: 100, elapsed: 60 * time.Second, want: false},
+		{name: "First rule", rules: rules, changes: 10, elapsed: 61 * time.Second, want: true},
+		{name: "Not enough changes", rules: rules, changes: 9, elapsed: 299 * time.Second, want: false},
+		{name: "Second rule", rules: rules, changes: 1, elapsed: 301 * time.Second, want: true},
+		{name: "Save in progress", rules: rules, changes: 100, elapsed: time.Hour, saving: true, want: false},
+		{name: "Retry too early", rules: rules, changes: 100, elapsed: time.Hour, lastErr: failed, sinceFailed: retryDelay - time.Second, want: false},
+		{name: "Retry", rules: rules, changes: 100, elapsed: time.Hour, lastErr: failed, sinceFailed: retryDelay, want: true},
+		{name: "Succeeded recently", rules: rules, changes: 100, elapsed: time.Hour, sinceFailed: time.Second, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, s := newTestPersistence(t, test.rules)
+			now := time.Now()
+
+			s.changes = 5 + test.changes
+			p.lastSaveChanges = 5
+			p.lastSave = now.Add(-test.elapsed)
+			p.lastAttempt = now.Add(-test.sinceFailed)
+			p.lastErr = test.lastErr
+			p.saving = test.saving
+
+			assert.Equal(t, test.want, p.shouldSave(now))
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	p, s := newTestPersistence(t, nil)
+	s.changes = 3
+
+	require.NoError(t, p.Save())
+	data, err := os.ReadFile(filepath.Join(p.dir, p.filename))
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", string(data))
+
+	status := p.Status()
+	assert.Equal(t, uint64(0), status.ChangesSinceSave)
+	assert.NoError(t, status.LastErr)
+
+	s.changes = 5
+	assert.Equal(t, uint64(2), p.Status().ChangesSinceSave)
+
+	require.NoError(t, p.Load())
+	assert.Equal(t, "snapshot", s.loaded)
+	assert.Equal(t, uint64(0), p.Status().ChangesSinceSave)
+}
+
+func TestSaveFailed(t *testing.T) {
+	p, s := newTestPersistence(t, []config.SaveRule{{Seconds: 1, Changes: 1}})
+	s.changes = 1
+	p.SetPath(filepath.Join(t.TempDir(), "missing"), "dump.rdb")
+
+	assert.Error(t, p.Save())
+	status := p.Status()
+	assert.Error(t, status.LastErr)
+	assert.Equal(t, uint64(1), status.ChangesSinceSave)
+	assert.False(t, status.SaveInProgress)
+
+	// save isn't retried right after failed attempt
+	assert.False(t, p.shouldSave(time.Now().Add(time.Second)))
+	assert.True(t, p.shouldSave(time.Now().Add(retryDelay+time.Second)))
+}
+
+func TestLoadMissing(t *testing.T) {
+	p, s := newTestPersistence(t, nil)
+
+	require.NoError(t, p.Load())
+	assert.Equal(t, "", s.loaded)
+}
+
+func TestShutdown(t *testing.T) {
+	var tests = []struct {
+		name     string
+		rules    []config.SaveRule
+		changes  uint64
+		wantFile bool
+	}{
+		{name: "Unsaved changes", rules: []config.SaveRule{{Seconds: 3600, Changes: 1}}, changes: 1, wantFile: true},
+		{name: "No changes", rules: []config.SaveRule{{Seconds: 3600, Changes: 1}}, wantFile: false},
+		{name: "Saving disabled", changes: 1, wantFile: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, s := newTestPersistence(t, test.rules)
+			s.changes = test.changes
+
+			require.NoError(t, p.Shutdown())
+			_, err := os.Stat(filepath.Join(p.dir, p.filename))
+			assert.Equal(t, test.wantFile, err == nil)
+
+			assert.ErrorIs(t, p.BGSave(), ErrClosed)
+		})
+	}
+}
+
+func TestShutdownWaitsForBGSave(t *testing.T) {
+	p, s := newTestPersistence(t, []config.SaveRule{{Seconds: 3600, Changes: 1}})
+	s.changes = 1
+
+	require.NoError(t, p.BGSave())
+	require.NoError(t, p.Shutdown())
+
+	status := p.Status()
+	assert.False(t, status.SaveInProgress)
+	assert.Equal(t, uint64(0), status.ChangesSinceSave)
+}
+
+func TestBGSaveDoesNotBlockWrites(t *testing.T) {
+	s := &slowStorage{
+		Storage: mapstorage.New(t.Context()),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	s.Set("key", "old", 0)
+	p := New(s, zap.NewNop(), WithPath(t.TempDir(), "dump.rdb"))
+
+	require.NoError(t, p.BGSave())
+	<-s.started
+
+	written := make(chan struct{})
+	go func() {
+		s.Set("key", "new", 0)
+		close(written)
+	}()
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("write is blocked by background save")
+	}
+	assert.True(t, p.Status().SaveInProgress)
+
+	close(s.release)
+	require.NoError(t, p.Shutdown())
+
+	// snapshot keeps data at the moment BGSAVE was called
+	f, err := os.Open(p.path())
+	require.NoError(t, err)
+	defer f.Close()
+	loaded := mapstorage.New(t.Context())
+	require.NoError(t, loaded.Load(f))
+	value, err := loaded.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, "old", value)
+}
@@ -0,0 +1,230 @@
+// Package pubsub implements publish/subscribe messaging between clients.
+package pubsub
+
+import (
+	"nova/pkg/glob"
+	"slices"
+	"sync"
+)
+
+// Kind is a kind of delivered message. It depends on a way subscriber is subscribed.
+type Kind string
+
+const (
+	KindMessage      Kind = "message"
+	KindPMessage     Kind = "pmessage"
+	KindShardMessage Kind = "smessage"
+)
+
+// Subscriber receives messages published to channels it's subscribed to.
+type Subscriber interface {
+	// Message delivers message published to channel. Pattern is the pattern matching
+	// the channel for KindPMessage and empty otherwise.
+	// It's called with broker lock held, so it MUST NOT use broker and should return quickly.
+	Message(kind Kind, pattern, channel, message string)
+}
+
+// subscriptions are subscribers of channels (or patterns).
+type subscriptions map[string]map[Subscriber]struct{}
+
+// add subscribes sub to name. It returns false if sub is already subscribed.
+func (s subscriptions) add(name string, sub Subscriber) bool {
+	subs, ok := s[name]
+	if !ok {
+		subs = map[Subscriber]struct{}{}
+		s[name] = subs
+	}
+
+	if _, ok := subs[sub]; ok {
+		return false
+	}
+	subs[sub] = struct{}{}
+	return true
+}
+
+// remove unsubscribes sub from name. It returns false if sub is not subscribed.
+func (s subscriptions) remove(name string, sub Subscriber) bool {
+	subs, ok := s[name]
+	if !ok {
+		return false
+	}
+	if _, ok := subs[sub]; !ok {
+		return false
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(s, name)
+	}
+	return true
+}
+
+// names returns sorted names with subscribers matching pattern. Empty pattern matches all names.
+func (s subscriptions) names(pattern string) []string {
+	names := []string{}
+	for name := range s {
+		if pattern == "" || glob.Match(pattern, name, false) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Broker routes published messages to subscribers.
+// Shard channels are separate from ordinary ones, although there is only one shard.
+//
+// Subscription methods call confirm with their result before any message
+// published after the change is delivered, so subscriber can reply to the change in order.
+type Broker struct {
+	mu sync.RWMutex
+
+	channels      subscriptions
+	patterns      subscriptions
+	shardChannels subscriptions
+}
+
+// NewBroker is a constructor for Broker.
+func NewBroker() *Broker {
+	return &Broker{
+		channels:      subscriptions{},
+		patterns:      subscriptions{},
+		shardChannels: subscriptions{},
+	}
+}
+
+// Subscribe subscribes sub to channel. It returns false if sub is already subscribed.
+func (b *Broker) Subscribe(sub Subscriber, channel string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.channels.add(channel, sub)
+	confirm(ok)
+	return ok
+}
+
+// Unsubscribe unsubscribes sub from channel. It returns false if sub is not subscribed.
+func (b *Broker) Unsubscribe(sub Subscriber, channel string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.channels.remove(channel, sub)
+	confirm(ok)
+	return ok
+}
+
+// PSubscribe subscribes sub to channels matching glob-style pattern.
+// It returns false if sub is already subscribed.
+func (b *Broker) PSubscribe(sub Subscriber, pattern string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.patterns.add(pattern, sub)
+	confirm(ok)
+	return ok
+}
+
+// PUnsubscribe unsubscribes sub from pattern. It returns false if sub is not subscribed.
+func (b *Broker) PUnsubscribe(sub Subscriber, pattern string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.patterns.remove(pattern, sub)
+	confirm(ok)
+	return ok
+}
+
+// SSubscribe subscribes sub to shard channel. It returns false if sub is already subscribed.
+func (b *Broker) SSubscribe(sub Subscriber, channel string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.shardChannels.add(channel, sub)
+	confirm(ok)
+	return ok
+}
+
+// SUnsubscribe unsubscribes sub from shard channel. It returns false if sub is not subscribed.
+func (b *Broker) SUnsubscribe(sub Subscriber, channel string, confirm func(ok bool)) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ok := b.shardChannels.remove(channel, sub)
+	confirm(ok)
+	return ok
+}
+
+// Publish delivers message to subscribers of channel and subscribers of patterns matching it.
+// It returns number of receivers. Subscriber is counted for every matching subscription.
+func (b *Broker) Publish(channel, message string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	receivers := 0
+	for sub := range b.channels[channel] {
+		sub.Message(KindMessage, "", channel, message)
+		receivers++
+	}
+	for pattern, subs := range b.patterns {
+		if !glob.Match(pattern, channel, false) {
+			continue
+		}
+		for sub := range subs {
+			sub.Message(KindPMessage, pattern, channel, message)
+			receivers++
+		}
+	}
+
+	return receivers
+}
+
+// SPublish delivers message to subscribers of shard channel and returns their number.
+func (b *Broker) SPublish(channel, message string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.shardChannels[channel] {
+		sub.Message(KindShardMessage, "", channel, message)
+	}
+
+	return len(b.shardChannels[channel])
+}
+
+// Channels returns sorted active channels (ones with subscribers) matching pattern.
+// Empty pattern matches all channels. Shard channels are returned if shard is true.
+func (b *Broker) Channels(pattern string, shard bool) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if shard {
+		return b.shardChannels.names(pattern)
+	}
+	return b.channels.names(pattern)
+}
+
+// NumSub returns number of subscribers of every channel (or shard channel if shard is true).
+// Pattern subscribers are not counted.
+func (b *Broker) NumSub(channels []string, shard bool) []int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	subs := b.channels
+	if shard {
+		subs = b.shardChannels
+	}
+
+	counts := make([]int, len(channels))
+	for i, channel := range channels {
+		counts[i] = len(subs[channel])
+	}
+	return counts
+}
+
+// NumPat returns number of unique patterns subscribed to.
+func (b *Broker) NumPat() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.patterns)
+}
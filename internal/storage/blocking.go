@@ -0,0 +1,25 @@
+package storage
+
+import "context"
+
+type parkingKey struct{}
+
+// parking holds callbacks run around the time client is parked waiting for keys.
+type parking struct {
+	release, acquire func()
+}
+
+// WithParking returns a copy of ctx making blocking operations call release
+// right before the client is parked and acquire right after it's woken up.
+// It lets caller hold a lock for the non-blocking part of the operation only.
+func WithParking(ctx context.Context, release, acquire func()) context.Context {
+	return context.WithValue(ctx, parkingKey{}, parking{release: release, acquire: acquire})
+}
+
+// Parking returns callbacks set with WithParking. They are no-op if not set.
+func Parking(ctx context.Context) (release, acquire func()) {
+	if p, ok := ctx.Value(parkingKey{}).(parking); ok {
+		return p.release, p.acquire
+	}
+	return func() {}, func() {}
+}
@@ -5,4 +5,28 @@ import "errors"
 var (
 	ErrWrongType   = errors.New("wrong type operation")
 	ErrKeyNotFound = errors.New("key not found")
+	ErrNotInteger  = errors.New("value is not an integer")
+	ErrNotFloat    = errors.New("value is not a valid float")
+	ErrOverflow    = errors.New("increment or decrement would overflow")
+	ErrNaNOrInf    = errors.New("increment would produce NaN or Infinity")
+	ErrSameObject  = errors.New("source and destination objects are the same")
+	ErrOutOfRange  = errors.New("index out of range")
+	ErrNaN         = errors.New("resulting score is not a number (NaN)")
+	ErrSmallID     = errors.New("ID is equal or smaller than the last ID of the stream")
+	ErrNoGroup     = errors.New("no such consumer group")
+	ErrGroupExists = errors.New("consumer group already exists")
 )
+
+// NoGroupError is returned if there is no consumer group Group of stream Key
+// or there is no stream at all. It matches ErrNoGroup.
+type NoGroupError struct {
+	Key, Group string
+}
+
+func (e *NoGroupError) Error() string {
+	return ErrNoGroup.Error() + " " + e.Group + " for key " + e.Key
+}
+
+func (e *NoGroupError) Is(target error) bool {
+	return target == ErrNoGroup
+}
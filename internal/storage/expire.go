@@ -0,0 +1,14 @@
+package storage
+
+// ExpireOptions are conditions of setting expiration time.
+type ExpireOptions struct {
+	// NX sets expiration only when key has no expiry.
+	NX bool
+	// XX sets expiration only when key has an existing expiry.
+	XX bool
+	// GT sets expiration only when new expiry is greater than current one.
+	// Key without expiry is considered to have infinite TTL.
+	GT bool
+	// LT sets expiration only when new expiry is less than current one.
+	LT bool
+}
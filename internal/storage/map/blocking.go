@@ -0,0 +1,198 @@
+package mapstorage
+
+import (
+	"context"
+	"nova/internal/storage"
+	"slices"
+)
+
+// waiter is a client blocked until one of the keys holds a value it waits for.
+type waiter struct {
+	keys []string
+	// serve takes value stored via key. It returns false if there is nothing
+	// to serve waiter with yet. It is called with write lock held.
+	serve func(key string) bool
+	// served is closed after waiter is served
+	served chan struct{}
+	// isServed is guarded by storage lock
+	isServed bool
+}
+
+// block registers waiter for all its keys.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) block(w *waiter) {
+	w.served = make(chan struct{})
+	for _, key := range w.keys {
+		s.waiters[key] = append(s.waiters[key], w)
+	}
+	s.blocked++
+}
+
+// unblock removes waiter from queues of all its keys.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) unblock(w *waiter) {
+	for _, key := range w.keys {
+		queue := slices.DeleteFunc(s.waiters[key], func(other *waiter) bool {
+			return other == w
+		})
+		if len(queue) == 0 {
+			delete(s.waiters, key)
+			continue
+		}
+		s.waiters[key] = queue
+	}
+	s.blocked--
+}
+
+// signalKeyReady marks key as ready, so that clients blocked on it are served by ServeBlocked.
+// It must be called after elements are added to the list or entries to the stream.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) signalKeyReady(key string) {
+	if _, ok := s.waiters[key]; !ok {
+		return
+	}
+	if _, ok := s.readySet[key]; ok {
+		return
+	}
+
+	s.readySet[key] = struct{}{}
+	s.readyKeys = append(s.readyKeys, key)
+	s.hasReady.Store(true)
+}
+
+// ServeBlocked serves clients blocked on ready keys in order of their arrival
+// while there is something to serve them with.
+// It's called once command or transaction is complete, so that blocked clients
+// neither see nor change data in the middle of it.
+func (s *Storage) ServeBlocked() {
+	if !s.hasReady.Load() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// serving a client can make another key ready (e.g. destination of BLMOVE),
+	// so keys are processed until there are none left
+	for len(s.readyKeys) > 0 {
+		key := s.readyKeys[0]
+		s.readyKeys = s.readyKeys[1:]
+		delete(s.readySet, key)
+		s.serveWaiters(key)
+	}
+	s.readyKeys = nil
+	s.hasReady.Store(false)
+}
+
+// serveWaiters serves clients blocked on key.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) serveWaiters(key string) {
+	// queue is copied, since served waiters are removed from it
+	for _, w := range slices.Clone(s.waiters[key]) {
+		// waiter blocked on the same key several times could be already served
+		if w.isServed || !w.serve(key) {
+			continue
+		}
+
+		s.unblock(w)
+		w.isServed = true
+		close(w.served)
+	}
+}
+
+// wait waits until waiter is served or ctx is done.
+// In the latter case waiter is unblocked and ctx error is returned.
+// Parking callbacks of ctx are called around waiting without storage lock held.
+func (s *Storage) wait(ctx context.Context, w *waiter) error {
+	release, acquire := storage.Parking(ctx)
+	release()
+	// deferred first, so it runs after storage lock is released
+	defer acquire()
+
+	select {
+	case <-w.served:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// waiter could be served concurrently with ctx cancellation
+	if w.isServed {
+		return nil
+	}
+	s.unblock(w)
+
+	return ctx.Err()
+}
+
+// BlockedClients returns number of clients blocked on keys.
+func (s *Storage) BlockedClients() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.blocked
+}
+
+// BLMPop pops up to count elements from the first non-empty list among keys.
+// If all lists are empty, it blocks until elements are pushed to any of them or ctx is done.
+// Blocked clients are served in order of their arrival.
+// If ctx is done first, its error is returned.
+func (s *Storage) BLMPop(ctx context.Context, keys []string, left bool, count int) (string, []string, error) {
+	s.mu.Lock()
+
+	key, values, err := s.lmpop(keys, left, count)
+	if err != storage.ErrKeyNotFound {
+		s.mu.Unlock()
+		return key, values, err
+	}
+
+	w := &waiter{keys: keys}
+	w.serve = func(readyKey string) bool {
+		if _, err := s.getList(readyKey); err != nil {
+			return false
+		}
+		key, values, err = s.lmpop([]string{readyKey}, left, count)
+		return true
+	}
+	s.block(w)
+	s.mu.Unlock()
+
+	if err := s.wait(ctx, w); err != nil {
+		return "", nil, err
+	}
+
+	return key, values, err
+}
+
+// BLMove atomically moves element from src list to dst list like LMove does.
+// If there is no src list, it blocks until elements are pushed to it or ctx is done.
+// Blocked clients are served in order of their arrival.
+// If ctx is done first, its error is returned.
+func (s *Storage) BLMove(ctx context.Context, src, dst string, srcLeft, dstLeft bool) (string, error) {
+	s.mu.Lock()
+
+	value, err := s.lmove(src, dst, srcLeft, dstLeft)
+	if err != storage.ErrKeyNotFound {
+		s.mu.Unlock()
+		return value, err
+	}
+
+	w := &waiter{keys: []string{src}}
+	w.serve = func(string) bool {
+		if _, err := s.getList(src); err != nil {
+			return false
+		}
+		value, err = s.lmove(src, dst, srcLeft, dstLeft)
+		return true
+	}
+	s.block(w)
+	s.mu.Unlock()
+
+	if err := s.wait(ctx, w); err != nil {
+		return "", err
+	}
+
+	return value, err
+}
@@ -0,0 +1,49 @@
+package mapstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeBlocked(t *testing.T) {
+	s := New(t.Context())
+
+	moved := make(chan string)
+	go func() {
+		value, err := s.BLMove(context.Background(), "src", "dst", true, false)
+		assert.NoError(t, err)
+		moved <- value
+	}()
+	require.Eventually(t, func() bool { return s.BlockedClients() == 1 }, time.Second, time.Millisecond)
+
+	popped := make(chan []string)
+	go func() {
+		_, values, err := s.BLMPop(context.Background(), []string{"dst"}, true, 1)
+		assert.NoError(t, err)
+		popped <- values
+	}()
+	require.Eventually(t, func() bool { return s.BlockedClients() == 2 }, time.Second, time.Millisecond)
+
+	_, err := s.RPush("src", []string{"a", "b"})
+	require.NoError(t, err)
+
+	// clients are not served until command is complete
+	length, err := s.ListLen("src")
+	require.NoError(t, err)
+	assert.Equal(t, 2, length)
+	assert.Equal(t, 2, s.BlockedClients())
+
+	// element moved to dst serves client blocked on it as well
+	s.ServeBlocked()
+	assert.Equal(t, "a", <-moved)
+	assert.Equal(t, []string{"a"}, <-popped)
+	assert.Equal(t, 0, s.BlockedClients())
+
+	values, err := s.LRange("src", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, values)
+}
@@ -11,12 +11,19 @@ var (
 
 // cleanup is a background worker which deletes expired values every (cleanupInterval) seconds.
 func (s *Storage) cleanup(ctx context.Context) {
+	s.mu.RLock()
 	ticker := time.NewTicker(s.cleanupInterval)
+	s.mu.RUnlock()
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.cleanupReset:
+			s.mu.RLock()
+			ticker.Reset(s.cleanupInterval)
+			s.mu.RUnlock()
 		case <-ticker.C:
 			expiredKeys := []string{}
 
@@ -30,13 +37,31 @@ func (s *Storage) cleanup(ctx context.Context) {
 
 			s.mu.Lock()
 			for _, key := range expiredKeys {
-				delete(s.data, key)
+				// key could be overwritten since it was checked
+				if el, ok := s.data[key]; ok && isExpired(el) {
+					s.remove(key)
+				}
 			}
 			s.mu.Unlock()
 		}
 	}
 }
 
+// SetCleanupInterval changes interval of expired values removal.
+// Background worker is restarted with the new interval.
+func (s *Storage) SetCleanupInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.cleanupInterval = interval
+	s.mu.Unlock()
+
+	// worker could be notified already and not have read new interval yet,
+	// in that case it will read the latest one anyway
+	select {
+	case s.cleanupReset <- struct{}{}:
+	default:
+	}
+}
+
 func isExpired(el item) bool {
 	expiresAt := el.expiresAt
 	return time.Now().After(expiresAt) && !expiresAt.IsZero()
@@ -0,0 +1,74 @@
+package mapstorage
+
+import (
+	"nova/internal/storage"
+	"time"
+)
+
+// Expire sets expiration time of the key if conditions from opts are met.
+// If expiration time is not in the future, key is deleted.
+// It returns false if there is no such key or conditions are not met.
+func (s *Storage) Expire(key string, expiresAt time.Time, opts storage.ExpireOptions) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return false
+	}
+
+	persistent := el.expiresAt.IsZero()
+	switch {
+	case opts.NX && !persistent:
+		return false
+	case opts.XX && persistent:
+		return false
+	// key without expiry has infinite TTL, so it can't be greater
+	case opts.GT && (persistent || !expiresAt.After(el.expiresAt)):
+		return false
+	case opts.LT && !persistent && !expiresAt.Before(el.expiresAt):
+		return false
+	}
+
+	if !expiresAt.After(time.Now()) {
+		s.remove(key)
+		return true
+	}
+
+	el.expiresAt = expiresAt
+	s.put(key, el)
+
+	return true
+}
+
+// Persist removes expiration time of the key.
+// It returns false if there is no such key or it has no expiration time.
+func (s *Storage) Persist(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	if !ok || el.expiresAt.IsZero() {
+		return false
+	}
+
+	el.expiresAt = time.Time{}
+	s.put(key, el)
+
+	return true
+}
+
+// ExpiresAt returns expiration time of the key.
+// Zero time is returned for keys without expiration time.
+// If there is no such key, ErrKeyNotFound is returned.
+func (s *Storage) ExpiresAt(key string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return time.Time{}, storage.ErrKeyNotFound
+	}
+
+	return el.expiresAt, nil
+}
@@ -0,0 +1,358 @@
+package mapstorage
+
+import (
+	"math"
+	"math/rand/v2"
+	"nova/internal/storage"
+	"strconv"
+)
+
+// getHash returns hash stored via key.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getHash(key string) (map[string]string, error) {
+	el, ok := s.lookup(key)
+	if !ok {
+		return nil, storage.ErrKeyNotFound
+	}
+	if el.valueType != ValueTypeHash {
+		return nil, storage.ErrWrongType
+	}
+
+	return el.value.(map[string]string), nil
+}
+
+// hashForWrite returns hash stored via key creating it if needed.
+// Created hash must be filled in by caller, since empty hashes are not stored.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) hashForWrite(key string) (map[string]string, error) {
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		hash = map[string]string{}
+		s.put(key, item{
+			valueType: ValueTypeHash,
+			value:     hash,
+		})
+		return hash, nil
+	}
+
+	return hash, err
+}
+
+// HSet sets fields of the hash given as flat list of field-value pairs.
+// It returns number of added fields. If there is no such hash, it is created.
+func (s *Storage) HSet(key string, pairs []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.hashForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for i := 0; i < len(pairs); i += 2 {
+		if _, ok := hash[pairs[i]]; !ok {
+			added++
+		}
+		hash[pairs[i]] = pairs[i+1]
+	}
+	s.signalModified(key)
+
+	return added, nil
+}
+
+// HSetNX sets field of the hash only if it doesn't exist yet.
+// It returns true if field was set.
+func (s *Storage) HSetNX(key, field, value string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.hashForWrite(key)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := hash[field]; ok {
+		return false, nil
+	}
+	hash[field] = value
+	s.signalModified(key)
+
+	return true, nil
+}
+
+// HGet returns value of the hash field.
+// ErrKeyNotFound is returned both for missing key and missing field.
+func (s *Storage) HGet(key, field string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := hash[field]
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
+// HMGet returns values of the hash fields.
+// ok[i] is false if there is no field fields[i]. Missing hash is treated as empty one.
+func (s *Storage) HMGet(key string, fields []string) (values []string, ok []bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values = make([]string, len(fields))
+	ok = make([]bool, len(fields))
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return values, ok, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, field := range fields {
+		values[i], ok[i] = hash[field]
+	}
+
+	return values, ok, nil
+}
+
+// HDel deletes fields of the hash and returns number of deleted ones.
+// Hash without fields is deleted as well.
+func (s *Storage) HDel(key string, fields []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, field := range fields {
+		if _, ok := hash[field]; ok {
+			delete(hash, field)
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		s.signalModified(key)
+	}
+	// empty hashes are not stored
+	if len(hash) == 0 {
+		s.remove(key)
+	}
+
+	return deleted, nil
+}
+
+// HExists reports whether there is a field in the hash.
+func (s *Storage) HExists(key, field string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := hash[field]
+	return ok, nil
+}
+
+// HLen returns number of fields in the hash. It's 0 for missing keys.
+func (s *Storage) HLen(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(hash), nil
+}
+
+// HGetAll returns all fields of the hash and their values.
+// Missing hash is treated as empty one.
+func (s *Storage) HGetAll(key string) (fields, values []string, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return []string{}, []string{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields = make([]string, 0, len(hash))
+	values = make([]string, 0, len(hash))
+	for field, value := range hash {
+		fields = append(fields, field)
+		values = append(values, value)
+	}
+
+	return fields, values, nil
+}
+
+// HIncrBy adds delta to integer value of the hash field and returns the result.
+// Missing field is considered to be 0.
+func (s *Storage) HIncrBy(key, field string, delta int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.getHash(key)
+	if err != nil && err != storage.ErrKeyNotFound {
+		return 0, err
+	}
+
+	current := 0
+	if value, ok := hash[field]; ok {
+		current, err = strconv.Atoi(value)
+		if err != nil || strconv.Itoa(current) != value {
+			return 0, storage.ErrNotInteger
+		}
+	}
+
+	if (delta > 0 && current > math.MaxInt-delta) || (delta < 0 && current < math.MinInt-delta) {
+		return 0, storage.ErrOverflow
+	}
+	result := current + delta
+
+	// hash is created only after checks, since empty hashes are not stored
+	hash, _ = s.hashForWrite(key)
+	hash[field] = strconv.Itoa(result)
+	s.signalModified(key)
+
+	return result, nil
+}
+
+// HIncrByFloat adds delta to numeric value of the hash field and returns the result as a string.
+// Missing field is considered to be 0.
+func (s *Storage) HIncrByFloat(key, field string, delta float64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash, err := s.getHash(key)
+	if err != nil && err != storage.ErrKeyNotFound {
+		return "", err
+	}
+
+	current := 0.0
+	if value, ok := hash[field]; ok {
+		current, err = parseFloat(value)
+		if err != nil {
+			return "", storage.ErrNotFloat
+		}
+	}
+
+	value, err := addFloat(current, delta)
+	if err != nil {
+		return "", err
+	}
+
+	// hash is created only after checks, since empty hashes are not stored
+	hash, _ = s.hashForWrite(key)
+	hash[field] = value
+	s.signalModified(key)
+
+	return value, nil
+}
+
+// HStrLen returns length of value of the hash field. It's 0 for missing fields.
+func (s *Storage) HStrLen(key, field string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(hash[field]), nil
+}
+
+// HRandField returns random fields of the hash with their values.
+// If count is positive, up to count distinct fields are returned.
+// If count is negative, exactly -count fields are returned and they may repeat.
+// Missing hash is treated as empty one.
+func (s *Storage) HRandField(key string, count int) (fields, values []string, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return []string{}, []string{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	all := make([]string, 0, len(hash))
+	for field := range hash {
+		all = append(all, field)
+	}
+
+	if count >= 0 {
+		rand.Shuffle(len(all), func(i, j int) {
+			all[i], all[j] = all[j], all[i]
+		})
+		fields = all[:min(count, len(all))]
+	} else {
+		// count is not trusted, so result isn't preallocated
+		for range -count {
+			fields = append(fields, all[rand.IntN(len(all))])
+		}
+	}
+
+	values = make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = hash[field]
+	}
+
+	return fields, values, nil
+}
+
+// HScan returns portion of hash fields with their values starting from cursor
+// and cursor to continue from. See Scan for details.
+func (s *Storage) HScan(key string, cursor uint64, count int, pattern string) (uint64, []string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, err := s.getHash(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, []string{}, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	next, fields := scanMap(hash, cursor, count, pattern)
+	pairs := make([]string, 0, 2*len(fields))
+	for _, field := range fields {
+		pairs = append(pairs, field, hash[field])
+	}
+
+	return next, pairs, nil
+}
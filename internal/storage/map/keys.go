@@ -0,0 +1,211 @@
+package mapstorage
+
+import (
+	"maps"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"nova/pkg/glob"
+)
+
+// typeNames are names of value types returned by TYPE command.
+var typeNames = map[ValueType]string{
+	ValueTypeString: "string",
+	ValueTypeInt:    "string",
+	ValueTypeList:   "list",
+	ValueTypeHash:   "hash",
+	ValueTypeSet:    "set",
+	ValueTypeZSet:   "zset",
+	ValueTypeStream: "stream",
+}
+
+// Exists returns number of existing keys among given ones.
+// Key mentioned several times is counted several times.
+func (s *Storage) Exists(keys []string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, key := range keys {
+		if _, ok := s.lookup(key); ok {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Type returns name of the type of value stored via key or "none" if there is no such key.
+func (s *Storage) Type(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return "none"
+	}
+
+	return typeNames[el.valueType]
+}
+
+// Keys returns all keys matching glob-style pattern.
+func (s *Storage) Keys(pattern string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []string{}
+	for key, el := range s.data {
+		if isExpired(el) {
+			continue
+		}
+		if pattern == "*" || glob.Match(pattern, key, false) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// DBSize returns number of keys in storage.
+func (s *Storage) DBSize() int {
+	keys, _ := s.KeyspaceStats()
+	return keys
+}
+
+// RandomKey returns random existing key. It returns false if storage is empty.
+func (s *Storage) RandomKey() (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.data) == 0 {
+		return "", false
+	}
+	// expired keys can't be removed under read lock, so they are skipped
+	for range randomKeyTries {
+		if key := s.index.random(); !isExpired(s.data[key]) {
+			return key, true
+		}
+	}
+
+	// most keys are expired, so any remaining one is returned
+	for key, el := range s.data {
+		if !isExpired(el) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+var (
+	// randomKeyTries is a number of random keys RandomKey checks before looking for any not expired one
+	randomKeyTries = 100
+	// lists longer than lazyFreeThreshold are cleared in background by Unlink
+	lazyFreeThreshold = 64
+	// lazyFree runs free function in background
+	lazyFree = func(free func()) { go free() }
+)
+
+// Rename renames key src to dst carrying its expiration time.
+// Existing dst is overwritten unless nx is true, in which case false is returned.
+// If there is no src key, ErrKeyNotFound is returned.
+func (s *Storage) Rename(src, dst string, nx bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(src)
+	if !ok {
+		return false, storage.ErrKeyNotFound
+	}
+	if src == dst {
+		return !nx, nil
+	}
+	if _, exists := s.lookup(dst); exists && nx {
+		return false, nil
+	}
+
+	s.remove(src)
+	s.put(dst, el)
+	s.signalKeyReady(dst)
+
+	return true, nil
+}
+
+// Copy copies value of src key to dst key together with its expiration time.
+// Existing dst is overwritten only if replace is true, otherwise false is returned.
+func (s *Storage) Copy(src, dst string, replace bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if src == dst {
+		return false, storage.ErrSameObject
+	}
+
+	el, ok := s.lookup(src)
+	if !ok {
+		return false, nil
+	}
+	if _, exists := s.lookup(dst); exists && !replace {
+		return false, nil
+	}
+
+	s.put(dst, cloneItem(el))
+	s.signalKeyReady(dst)
+
+	return true, nil
+}
+
+// cloneItem returns deep copy of item, so that copies can be modified independently.
+func cloneItem(el item) item {
+	switch el.valueType {
+	case ValueTypeList:
+		el.value = el.value.(*ds.LinkedList).Clone()
+	case ValueTypeHash:
+		el.value = maps.Clone(el.value.(map[string]string))
+	case ValueTypeSet:
+		el.value = el.value.(*ds.Set).Clone()
+	case ValueTypeZSet:
+		el.value = el.value.(*ds.SortedSet).Clone()
+	case ValueTypeStream:
+		el.value = el.value.(*ds.Stream).Clone()
+	}
+
+	return el
+}
+
+// Touch returns number of existing keys among given ones.
+func (s *Storage) Touch(keys []string) int {
+	return s.Exists(keys)
+}
+
+// Unlink deletes keys like DeleteMany does, but big values are cleared
+// in background goroutine after keys are removed from storage.
+func (s *Storage) Unlink(keys []string) int {
+	count := 0
+	lists := []*ds.LinkedList{}
+
+	s.mu.Lock()
+	for _, key := range keys {
+		el, ok := s.lookup(key)
+		if !ok {
+			continue
+		}
+
+		if el.valueType == ValueTypeList {
+			if list := el.value.(*ds.LinkedList); list.Len() > lazyFreeThreshold {
+				lists = append(lists, list)
+			}
+		}
+		s.remove(key)
+		count++
+	}
+	s.mu.Unlock()
+
+	if len(lists) > 0 {
+		lazyFree(func() {
+			for _, list := range lists {
+				list.Clear()
+			}
+		})
+	}
+
+	return count
+}
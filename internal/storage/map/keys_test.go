@@ -0,0 +1,118 @@
+package mapstorage
+
+import (
+	ds "nova/pkg/datastructures"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameKeepsTTL(t *testing.T) {
+	var tests = []struct {
+		name string
+		nx   bool
+	}{
+		{name: "Rename", nx: false},
+		{name: "RenameNX", nx: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t.Context())
+			s.Set("src", "value", time.Hour)
+			want, err := s.ExpiresAt("src")
+			require.NoError(t, err)
+
+			renamed, err := s.Rename("src", "dst", test.nx)
+			require.NoError(t, err)
+			assert.True(t, renamed)
+
+			got, err := s.ExpiresAt("dst")
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+			assert.Equal(t, 0, s.Exists([]string{"src"}))
+		})
+	}
+}
+
+func TestCopyIsIndependent(t *testing.T) {
+	s := New(t.Context())
+	_, err := s.RPush("src", []string{"a", "b"})
+	require.NoError(t, err)
+
+	copied, err := s.Copy("src", "dst", false)
+	require.NoError(t, err)
+	require.True(t, copied)
+
+	_, err = s.RPush("dst", []string{"c"})
+	require.NoError(t, err)
+
+	src, err := s.LRange("src", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, src)
+	dst, err := s.LRange("dst", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, dst)
+}
+
+func TestUnlinkFreesInBackground(t *testing.T) {
+	threshold, free := lazyFreeThreshold, lazyFree
+	t.Cleanup(func() { lazyFreeThreshold, lazyFree = threshold, free })
+
+	// free functions are collected to be run after Unlink returns
+	var pending []func()
+	lazyFreeThreshold = 2
+	lazyFree = func(free func()) { pending = append(pending, free) }
+
+	s := New(t.Context())
+	lists := map[string]*ds.LinkedList{}
+	for key, length := range map[string]int{"small": 2, "big": 3} {
+		for i := range length {
+			_, err := s.RPush(key, []string{strconv.Itoa(i)})
+			require.NoError(t, err)
+		}
+		lists[key] = s.data[key].value.(*ds.LinkedList)
+	}
+	s.Set("string", "value", 0)
+
+	assert.Equal(t, 3, s.Unlink([]string{"small", "big", "string", "nokey"}))
+	assert.Equal(t, 0, s.DBSize())
+	// keys are removed right away, but values are not freed yet
+	assert.Equal(t, 3, lists["big"].Len())
+
+	require.Len(t, pending, 1)
+	pending[0]()
+	assert.Equal(t, 0, lists["big"].Len())
+	assert.Equal(t, 2, lists["small"].Len(), "small list is not freed in background")
+}
+
+func TestRandomKey(t *testing.T) {
+	s := New(t.Context())
+	_, ok := s.RandomKey()
+	assert.False(t, ok)
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		s.Set(key, "value", 0)
+	}
+	// removed keys are replaced in the index by the last ones
+	s.DeleteMany([]string{"a", "d"})
+	s.Set("expired", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	const draws = 40000
+	counts := map[string]int{}
+	for range draws {
+		key, ok := s.RandomKey()
+		require.True(t, ok)
+		counts[key]++
+	}
+
+	// every key is expected to be chosen draws/4 times, deviation is a few percent
+	require.Len(t, counts, 4)
+	for key, n := range counts {
+		assert.InDelta(t, draws/4, n, draws/20, key)
+	}
+}
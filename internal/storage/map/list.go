@@ -0,0 +1,286 @@
+package mapstorage
+
+import (
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+)
+
+// removeIfEmpty deletes list via key if it has no elements left, since empty lists are not stored.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) removeIfEmpty(key string, list *ds.LinkedList) {
+	if list.Len() == 0 {
+		s.remove(key)
+	}
+}
+
+// RPop pops last n elements from list via given key.
+func (s *Storage) RPop(key string, n int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		return []string{}, err
+	}
+
+	values := list.PopBackNTimes(n)
+	s.signalModified(key)
+	s.removeIfEmpty(key, list)
+
+	return values, nil
+}
+
+// LIndex returns element of the list on index. Negative index is counted from the tail.
+// ErrKeyNotFound is returned both for missing key and index out of range.
+func (s *Storage) LIndex(key string, index int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		return "", err
+	}
+
+	if index < 0 {
+		index += list.Len()
+	}
+	value, ok := list.Get(index)
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
+// LSet sets value of list element on index. Negative index is counted from the tail.
+func (s *Storage) LSet(key string, index int, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 {
+		index += list.Len()
+	}
+	if !list.Set(index, value) {
+		return storage.ErrOutOfRange
+	}
+	s.signalModified(key)
+
+	return nil
+}
+
+// LInsert inserts value before or after pivot element.
+// It returns length of list after insertion, -1 if pivot is not found and 0 if there is no list.
+func (s *Storage) LInsert(key string, before bool, pivot, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	length := list.Insert(pivot, value, before)
+	if length > 0 {
+		s.signalModified(key)
+	}
+
+	return length, nil
+}
+
+// LRem removes count occurrences of value from the list and returns number of removed elements.
+// See ds.LinkedList.Remove for meaning of count.
+func (s *Storage) LRem(key string, count int, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := list.Remove(count, value)
+	if removed > 0 {
+		s.signalModified(key)
+	}
+	s.removeIfEmpty(key, list)
+
+	return removed, nil
+}
+
+// LTrim leaves only elements in range of indexes [start, stop].
+func (s *Storage) LTrim(key string, start, stop int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	}
+
+	list.Trim(start, stop)
+	s.signalModified(key)
+	s.removeIfEmpty(key, list)
+
+	return nil
+}
+
+// LPos returns indexes of elements equal to element according to opts.
+func (s *Storage) LPos(key, element string, opts storage.LPosOptions) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	positions := []int{}
+
+	list, err := s.getList(key)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return positions, nil
+		}
+		return nil, err
+	}
+
+	reverse := opts.Rank < 0
+	// matches to skip before collecting positions
+	skip := max(opts.Rank, -opts.Rank) - 1
+	compared := 0
+	list.Range(reverse, func(index int, val string) bool {
+		if opts.MaxLen > 0 && compared == opts.MaxLen {
+			return false
+		}
+		compared++
+
+		if val != element {
+			return true
+		}
+		if skip > 0 {
+			skip--
+			return true
+		}
+
+		positions = append(positions, index)
+		return opts.Count == 0 || len(positions) < opts.Count
+	})
+
+	return positions, nil
+}
+
+// PushX pushes values to the head (if left is true) or to the tail of existing list.
+// It returns length of the list after addition or 0 if there is no list.
+func (s *Storage) PushX(key string, values []string, left bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	for _, value := range values {
+		if left {
+			list.PushForward(value)
+		} else {
+			list.PushBack(value)
+		}
+	}
+	s.signalModified(key)
+
+	return list.Len(), nil
+}
+
+// LMove atomically pops element from one end of src list and pushes it to one end of dst list.
+// srcLeft and dstLeft show whether head or tail is used. Missing dst list is created.
+// If there is no src list, ErrKeyNotFound is returned.
+func (s *Storage) LMove(src, dst string, srcLeft, dstLeft bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lmove(src, dst, srcLeft, dstLeft)
+}
+
+// lmove implements LMove. It MUST BE CALLED with write lock held.
+func (s *Storage) lmove(src, dst string, srcLeft, dstLeft bool) (string, error) {
+	srcList, err := s.getList(src)
+	if err != nil {
+		return "", err
+	}
+	// type of destination is checked before anything is popped
+	if el, ok := s.lookup(dst); ok && el.valueType != ValueTypeList {
+		return "", storage.ErrWrongType
+	}
+
+	var value string
+	if srcLeft {
+		value, _ = srcList.PopForward()
+	} else {
+		value, _ = srcList.PopBack()
+	}
+	s.signalModified(src)
+	s.removeIfEmpty(src, srcList)
+
+	dstList, err := s.listForPush(dst)
+	if err != nil {
+		return "", err
+	}
+	if dstLeft {
+		dstList.PushForward(value)
+	} else {
+		dstList.PushBack(value)
+	}
+	s.signalModified(dst)
+	s.signalKeyReady(dst)
+
+	return value, nil
+}
+
+// LMPop pops up to count elements from the first non-empty list among keys.
+// It returns key of that list and popped elements.
+// If all lists are empty, ErrKeyNotFound is returned.
+func (s *Storage) LMPop(keys []string, left bool, count int) (string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lmpop(keys, left, count)
+}
+
+// lmpop implements LMPop. It MUST BE CALLED with write lock held.
+func (s *Storage) lmpop(keys []string, left bool, count int) (string, []string, error) {
+	for _, key := range keys {
+		list, err := s.getList(key)
+		if err == storage.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		var values []string
+		if left {
+			values = list.PopForwardNTimes(count)
+		} else {
+			values = list.PopBackNTimes(count)
+		}
+		s.signalModified(key)
+		s.removeIfEmpty(key, list)
+
+		return key, values, nil
+	}
+
+	return "", nil, storage.ErrKeyNotFound
+}
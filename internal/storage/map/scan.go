@@ -0,0 +1,169 @@
+package mapstorage
+
+import (
+	"cmp"
+	"hash/fnv"
+	"math/rand/v2"
+	"nova/pkg/glob"
+	"slices"
+)
+
+var (
+	// keys are distributed among 2^scanSlotBits slots by hash of the key
+	scanSlotBits = 16
+	scanSlots    = 1 << scanSlotBits
+)
+
+// keyIndex groups keys into fixed number of slots by their hash.
+// Slot of the key never changes, so iteration over slots in order returns
+// every key which exists for the whole iteration, no matter how storage grows.
+// Keys are kept in a slice as well, so that random one can be picked in O(1) time.
+type keyIndex struct {
+	// slots map keys to their positions in keys
+	slots []map[string]int
+	keys  []string
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{
+		slots: make([]map[string]int, scanSlots),
+	}
+}
+
+func slotOf(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() >> (32 - scanSlotBits))
+}
+
+func (idx *keyIndex) add(key string) {
+	slot := slotOf(key)
+	if idx.slots[slot] == nil {
+		idx.slots[slot] = map[string]int{}
+	}
+	idx.slots[slot][key] = len(idx.keys)
+	idx.keys = append(idx.keys, key)
+}
+
+func (idx *keyIndex) remove(key string) {
+	slot := slotOf(key)
+	i := idx.slots[slot][key]
+
+	// the last key takes place of the removed one
+	last := idx.keys[len(idx.keys)-1]
+	idx.keys[i] = last
+	idx.slots[slotOf(last)][last] = i
+	idx.keys = idx.keys[:len(idx.keys)-1]
+
+	delete(idx.slots[slot], key)
+	if len(idx.slots[slot]) == 0 {
+		idx.slots[slot] = nil
+	}
+}
+
+// random returns random key. Index MUST NOT be empty.
+func (idx *keyIndex) random() string {
+	return idx.keys[rand.IntN(len(idx.keys))]
+}
+
+// put stores item via key keeping index up to date.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) put(key string, el item) {
+	if _, ok := s.data[key]; !ok {
+		s.index.add(key)
+	}
+	s.data[key] = el
+	s.signalModified(key)
+}
+
+// remove deletes item via key keeping index up to date.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) remove(key string) {
+	if el, ok := s.data[key]; ok {
+		s.index.remove(key)
+		delete(s.data, key)
+		// expired key is already absent, so its removal changes nothing
+		if !isExpired(el) {
+			s.signalModified(key)
+		}
+	}
+}
+
+// Scan returns portion of keys starting from cursor and cursor to continue from.
+// Returned cursor is 0 when iteration is finished.
+// At least count keys are checked (if there are that many) before filtering by pattern and type.
+// Empty pattern and valueType mean no filtering.
+func (s *Storage) Scan(cursor uint64, count int, pattern, valueType string) (uint64, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []string{}
+	if cursor >= uint64(scanSlots) {
+		return 0, keys
+	}
+
+	slot := int(cursor)
+	checked := 0
+	for ; slot < scanSlots && checked < count; slot++ {
+		for key := range s.index.slots[slot] {
+			checked++
+
+			el := s.data[key]
+			if isExpired(el) {
+				continue
+			}
+			if pattern != "" && !glob.Match(pattern, key, false) {
+				continue
+			}
+			if valueType != "" && typeNames[el.valueType] != valueType {
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if slot == scanSlots {
+		return 0, keys
+	}
+	return uint64(slot), keys
+}
+
+// scanMap iterates over keys of a collection (e.g. fields of a hash) the same way as Scan does.
+// Collections have no index, so keys are grouped into slots on the fly, which takes O(n) time.
+// Collections with no more than count keys are returned at once.
+func scanMap[V any](m map[string]V, cursor uint64, count int, pattern string) (uint64, []string) {
+	type slotKey struct {
+		slot int
+		key  string
+	}
+
+	keys := []string{}
+	if cursor >= uint64(scanSlots) {
+		return 0, keys
+	}
+
+	candidates := make([]slotKey, 0, len(m))
+	for key := range m {
+		if slot := slotOf(key); slot >= int(cursor) {
+			candidates = append(candidates, slotKey{slot, key})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b slotKey) int {
+		return cmp.Compare(a.slot, b.slot)
+	})
+
+	next := uint64(0)
+	for i, c := range candidates {
+		// slots are never split between calls
+		if i >= count && c.slot != candidates[i-1].slot {
+			next = uint64(c.slot)
+			break
+		}
+		if pattern != "" && !glob.Match(pattern, c.key, false) {
+			continue
+		}
+		keys = append(keys, c.key)
+	}
+
+	return next, keys
+}
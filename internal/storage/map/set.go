@@ -0,0 +1,358 @@
+package mapstorage
+
+import (
+	"cmp"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"slices"
+)
+
+// getSet returns set stored via key.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getSet(key string) (*ds.Set, error) {
+	el, ok := s.lookup(key)
+	if !ok {
+		return nil, storage.ErrKeyNotFound
+	}
+	if el.valueType != ValueTypeSet {
+		return nil, storage.ErrWrongType
+	}
+
+	return el.value.(*ds.Set), nil
+}
+
+// setForWrite returns set stored via key creating it if needed.
+// Created set must be filled in by caller, since empty sets are not stored.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) setForWrite(key string) (*ds.Set, error) {
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		set = ds.NewSet()
+		s.put(key, item{
+			valueType: ValueTypeSet,
+			value:     set,
+		})
+		return set, nil
+	}
+
+	return set, err
+}
+
+// removeSetIfEmpty deletes set via key if it has no members left.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) removeSetIfEmpty(key string, set *ds.Set) {
+	if set.Len() == 0 {
+		s.remove(key)
+	}
+}
+
+// SAdd adds members to the set and returns number of added ones.
+// If there is no such set, it is created.
+func (s *Storage) SAdd(key string, members []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, err := s.setForWrite(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, member := range members {
+		if set.Add(member) {
+			added++
+		}
+	}
+	if added > 0 {
+		s.signalModified(key)
+	}
+
+	return added, nil
+}
+
+// SRem removes members from the set and returns number of removed ones.
+func (s *Storage) SRem(key string, members []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if set.Remove(member) {
+			removed++
+		}
+	}
+	if removed > 0 {
+		s.signalModified(key)
+	}
+	s.removeSetIfEmpty(key, set)
+
+	return removed, nil
+}
+
+// SMIsMember reports for every member whether it is in the set.
+// Missing set is treated as empty one.
+func (s *Storage) SMIsMember(key string, members []string) ([]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]bool, len(members))
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, member := range members {
+		result[i] = set.Contains(member)
+	}
+
+	return result, nil
+}
+
+// SMembers returns all members of the set. Missing set is treated as empty one.
+func (s *Storage) SMembers(key string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Members(), nil
+}
+
+// SCard returns number of members in the set. It's 0 for missing keys.
+func (s *Storage) SCard(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return set.Len(), nil
+}
+
+// SPop removes up to count random members from the set and returns them.
+// Missing set is treated as empty one.
+func (s *Storage) SPop(key string, count int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	if count == 1 {
+		member, _ := set.RandomMember()
+		members = []string{member}
+	} else {
+		members = set.RandomMembers(count)
+	}
+	for _, member := range members {
+		set.Remove(member)
+	}
+	if len(members) > 0 {
+		s.signalModified(key)
+	}
+	s.removeSetIfEmpty(key, set)
+
+	return members, nil
+}
+
+// SRandMember returns random members of the set.
+// If count is positive, up to count distinct members are returned.
+// If count is negative, exactly -count members are returned and they may repeat.
+// Missing set is treated as empty one.
+func (s *Storage) SRandMember(key string, count int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set, err := s.getSet(key)
+	if err == storage.ErrKeyNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= 0 {
+		return set.RandomMembers(count), nil
+	}
+
+	// count is not trusted, so result isn't preallocated
+	members := []string{}
+	for range -count {
+		member, _ := set.RandomMember()
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// SMove moves member from src set to dst set. It returns false if there is no such member in src.
+// Missing dst set is created.
+func (s *Storage) SMove(src, dst, member string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srcSet, err := s.getSet(src)
+	if err == storage.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// type of destination is checked before anything is removed
+	if _, err := s.getSet(dst); err == storage.ErrWrongType {
+		return false, err
+	}
+
+	if !srcSet.Contains(member) {
+		return false, nil
+	}
+	if src == dst {
+		return true, nil
+	}
+
+	srcSet.Remove(member)
+	s.signalModified(src)
+	s.removeSetIfEmpty(src, srcSet)
+	dstSet, _ := s.setForWrite(dst)
+	dstSet.Add(member)
+	s.signalModified(dst)
+
+	return true, nil
+}
+
+// SetAlgebra returns result of applying op to sets via keys.
+// For difference, members of the first set which are not in the others are returned.
+// Missing sets are treated as empty ones.
+func (s *Storage) SetAlgebra(op storage.SetOp, keys []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, err := s.setAlgebra(op, keys, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Members(), nil
+}
+
+// SetAlgebraStore stores result of applying op to sets via keys in dst
+// and returns its cardinality. Existing dst is overwritten, empty result deletes it.
+func (s *Storage) SetAlgebraStore(op storage.SetOp, dst string, keys []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.setAlgebra(op, keys, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	s.remove(dst)
+	if result.Len() > 0 {
+		s.put(dst, item{
+			valueType: ValueTypeSet,
+			value:     result,
+		})
+	}
+
+	return result.Len(), nil
+}
+
+// SInterCard returns cardinality of intersection of sets via keys.
+// If limit is positive, computation stops as soon as cardinality reaches limit.
+func (s *Storage) SInterCard(keys []string, limit int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, err := s.setAlgebra(storage.SetOpInter, keys, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Len(), nil
+}
+
+// setAlgebra implements set operations. Limit is used only for intersection.
+// Result is a new set which can be stored or modified.
+// It MUST BE CALLED with lock held.
+func (s *Storage) setAlgebra(op storage.SetOp, keys []string, limit int) (*ds.Set, error) {
+	// every key is checked for type even if result is known to be empty
+	sets := make([]*ds.Set, len(keys))
+	for i, key := range keys {
+		set, err := s.getSet(key)
+		if err == storage.ErrWrongType {
+			return nil, err
+		}
+		if set == nil {
+			set = ds.NewSet()
+		}
+		sets[i] = set
+	}
+
+	result := ds.NewSet()
+	switch op {
+	case storage.SetOpInter:
+		// the smallest set is iterated to check as few members as possible
+		slices.SortFunc(sets, func(a, b *ds.Set) int {
+			return cmp.Compare(a.Len(), b.Len())
+		})
+		sets[0].Range(func(member string) bool {
+			for _, other := range sets[1:] {
+				if !other.Contains(member) {
+					return true
+				}
+			}
+			result.Add(member)
+			return limit <= 0 || result.Len() < limit
+		})
+
+	case storage.SetOpUnion:
+		for _, set := range sets {
+			set.Range(func(member string) bool {
+				result.Add(member)
+				return true
+			})
+		}
+
+	case storage.SetOpDiff:
+		sets[0].Range(func(member string) bool {
+			for _, other := range sets[1:] {
+				if other.Contains(member) {
+					return true
+				}
+			}
+			result.Add(member)
+			return true
+		})
+	}
+
+	return result, nil
+}
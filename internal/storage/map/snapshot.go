@@ -0,0 +1,262 @@
+package mapstorage
+
+import (
+	"errors"
+	"io"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"nova/pkg/rdb"
+	"strconv"
+	"time"
+)
+
+// snapshot implements storage.Snapshot. Items are cloned, so it's not affected
+// by modifications of storage made after it was taken.
+type snapshot struct {
+	data    map[string]item
+	changes uint64
+}
+
+// Snapshot returns copy of all keys which are not expired at the moment.
+// Storage is locked only while items are copied, encoding is done without lock.
+// Copying still takes O(n) time, writers are blocked meanwhile, and memory used
+// by data is doubled until snapshot is released. That's the price of having
+// no copy-on-write: the alternative is holding the lock for the whole encoding.
+func (s *Storage) Snapshot() storage.Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := &snapshot{
+		data:    make(map[string]item, len(s.data)),
+		changes: s.changes.Load(),
+	}
+	for key, el := range s.data {
+		if !isExpired(el) {
+			snap.data[key] = cloneItem(el)
+		}
+	}
+
+	return snap
+}
+
+// Changes returns number of modifications made to storage since it was created.
+func (s *Storage) Changes() uint64 {
+	return s.changes.Load()
+}
+
+// Changes implements storage.Snapshot.
+func (snap *snapshot) Changes() uint64 {
+	return snap.changes
+}
+
+// Encode implements storage.Snapshot.
+func (snap *snapshot) Encode(w io.Writer) error {
+	e := rdb.NewEncoder(w)
+	if err := e.WriteHeader(); err != nil {
+		return err
+	}
+	if err := e.WriteAux("redis-bits", "64"); err != nil {
+		return err
+	}
+	if err := e.WriteAux("ctime", strconv.FormatInt(time.Now().Unix(), 10)); err != nil {
+		return err
+	}
+
+	expires := 0
+	for _, el := range snap.data {
+		if !el.expiresAt.IsZero() {
+			expires++
+		}
+	}
+	if err := e.WriteDB(0, len(snap.data), expires); err != nil {
+		return err
+	}
+
+	for key, el := range snap.data {
+		record := rdb.Record{Key: key, ExpiresAt: el.expiresAt, Value: toRDBValue(el)}
+		if err := e.WriteRecord(record); err != nil {
+			return err
+		}
+	}
+
+	return e.Close()
+}
+
+func toRDBValue(el item) any {
+	switch el.valueType {
+	case ValueTypeString, ValueTypeInt:
+		str, _ := stringValue(el)
+		return str
+	case ValueTypeList:
+		return rdb.List(el.value.(*ds.LinkedList).LRange(0, -1))
+	case ValueTypeHash:
+		return rdb.Hash(el.value.(map[string]string))
+	case ValueTypeSet:
+		return rdb.Set(el.value.(*ds.Set).Members())
+	case ValueTypeZSet:
+		zset := rdb.ZSet{}
+		el.value.(*ds.SortedSet).Range(func(member string, score float64) bool {
+			zset = append(zset, rdb.ZMember{Member: member, Score: score})
+			return true
+		})
+		return zset
+	case ValueTypeStream:
+		return toRDBStream(el.value.(*ds.Stream))
+	default:
+		return nil
+	}
+}
+
+func toRDBStream(stream *ds.Stream) *rdb.Stream {
+	result := &rdb.Stream{LastID: rdb.StreamID(stream.LastID)}
+	for _, entry := range stream.Range(ds.StreamID{}, ds.MaxStreamID, false, 0) {
+		result.Entries = append(result.Entries, rdb.StreamEntry{ID: rdb.StreamID(entry.ID), Fields: entry.Fields})
+	}
+
+	for _, name := range stream.GroupNames() {
+		group := stream.Group(name)
+		g := rdb.StreamGroup{Name: name, LastID: rdb.StreamID(group.LastID)}
+		group.RangePending(ds.StreamID{}, func(pe *ds.PendingEntry) bool {
+			g.Pending = append(g.Pending, rdb.StreamPendingEntry{
+				ID:          rdb.StreamID(pe.ID),
+				Consumer:    pe.Consumer,
+				DeliveredAt: pe.DeliveredAt,
+				Deliveries:  uint64(pe.Deliveries),
+			})
+			return true
+		})
+		for _, c := range group.Consumers() {
+			g.Consumers = append(g.Consumers, rdb.StreamConsumer{Name: c.Name, SeenAt: c.SeenAt, ActiveAt: c.SeenAt})
+		}
+		result.Groups = append(result.Groups, g)
+	}
+
+	return result
+}
+
+// Load replaces storage data with keys read from RDB snapshot.
+// Only keys of database 0 are loaded, expired keys and empty collections are skipped.
+// Storage is not modified if snapshot is malformed.
+func (s *Storage) Load(r io.Reader) error {
+	d := rdb.NewDecoder(r)
+	if err := d.ReadHeader(); err != nil {
+		return err
+	}
+
+	data := map[string]item{}
+	now := time.Now()
+	for {
+		record, db, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if db != 0 || (!record.ExpiresAt.IsZero() && !record.ExpiresAt.After(now)) {
+			continue
+		}
+		if el, ok := fromRDBValue(record.Value, record.ExpiresAt); ok {
+			data[record.Key] = el
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.data {
+		s.remove(key)
+	}
+	for key, el := range data {
+		s.put(key, el)
+	}
+
+	return nil
+}
+
+// fromRDBValue creates item from value read from snapshot.
+// It returns false for empty collections which are not stored.
+func fromRDBValue(value any, expiresAt time.Time) (item, bool) {
+	el := item{expiresAt: expiresAt}
+
+	switch value := value.(type) {
+	case string:
+		return newStringItem(value, expiresAt), true
+	case rdb.List:
+		list := ds.NewLinkedList()
+		for _, v := range value {
+			list.PushBack(v)
+		}
+		el.valueType, el.value = ValueTypeList, list
+	case rdb.Hash:
+		el.valueType, el.value = ValueTypeHash, map[string]string(value)
+	case rdb.Set:
+		set := ds.NewSet()
+		for _, member := range value {
+			set.Add(member)
+		}
+		el.valueType, el.value = ValueTypeSet, set
+	case rdb.ZSet:
+		zset := ds.NewSortedSet()
+		for _, m := range value {
+			zset.Add(m.Member, m.Score)
+		}
+		el.valueType, el.value = ValueTypeZSet, zset
+	case *rdb.Stream:
+		// empty streams are stored, since they keep last ID and consumer groups
+		return item{valueType: ValueTypeStream, value: fromRDBStream(value), expiresAt: expiresAt}, true
+	default:
+		return el, false
+	}
+
+	return el, !isEmptyCollection(el)
+}
+
+func isEmptyCollection(el item) bool {
+	switch el.valueType {
+	case ValueTypeList:
+		return el.value.(*ds.LinkedList).Len() == 0
+	case ValueTypeHash:
+		return len(el.value.(map[string]string)) == 0
+	case ValueTypeSet:
+		return el.value.(*ds.Set).Len() == 0
+	case ValueTypeZSet:
+		return el.value.(*ds.SortedSet).Len() == 0
+	default:
+		return false
+	}
+}
+
+func fromRDBStream(value *rdb.Stream) *ds.Stream {
+	stream := ds.NewStream()
+	for _, entry := range value.Entries {
+		// entries out of order would break the stream, so they're skipped
+		if ds.StreamID(entry.ID).Compare(stream.LastID) <= 0 {
+			continue
+		}
+		stream.Add(ds.StreamID(entry.ID), entry.Fields)
+	}
+	// last ID may belong to deleted entry
+	if last := ds.StreamID(value.LastID); last.Compare(stream.LastID) > 0 {
+		stream.LastID = last
+	}
+
+	for _, g := range value.Groups {
+		stream.CreateGroup(g.Name, ds.StreamID(g.LastID))
+		group := stream.Group(g.Name)
+		for _, c := range g.Consumers {
+			group.AddConsumer(c.Name, c.SeenAt)
+		}
+		for _, pe := range g.Pending {
+			consumer := group.Consumer(pe.Consumer)
+			if consumer == nil {
+				consumer, _ = group.AddConsumer(pe.Consumer, pe.DeliveredAt)
+			}
+			entry := group.AddPending(ds.StreamID(pe.ID), consumer, pe.DeliveredAt)
+			entry.Deliveries = int(pe.Deliveries)
+		}
+	}
+
+	return stream
+}
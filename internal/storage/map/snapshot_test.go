@@ -0,0 +1,150 @@
+package mapstorage
+
+import (
+	"bytes"
+	"math"
+	ds "nova/pkg/datastructures"
+	"nova/pkg/rdb"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestItems returns items of every value type. Times have millisecond precision,
+// since it's the precision of snapshot.
+func newTestItems() map[string]item {
+	expiresAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	list := ds.NewLinkedList()
+	for _, v := range []string{"a", "1", "b"} {
+		list.PushBack(v)
+	}
+
+	intSet, set := ds.NewSet(), ds.NewSet()
+	for _, m := range []string{"1", "-5", "300000"} {
+		intSet.Add(m)
+	}
+	for _, m := range []string{"a", "b", "1"} {
+		set.Add(m)
+	}
+
+	zset := ds.NewSortedSet()
+	zset.Add("a", 1.5)
+	zset.Add("b", math.Inf(-1))
+	zset.Add("c", 2)
+
+	stream := ds.NewStream()
+	for ms := uint64(1); ms <= 150; ms++ {
+		stream.Add(ds.StreamID{Ms: ms, Seq: ms % 3}, []string{"field", "value"})
+	}
+	// last ID is kept after the last entry is deleted
+	stream.Delete(ds.StreamID{Ms: 150})
+	stream.CreateGroup("group", ds.StreamID{Ms: 3, Seq: 0})
+	group := stream.Group("group")
+	alice, _ := group.AddConsumer("alice", now)
+	group.AddConsumer("bob", now.Add(-time.Minute))
+	group.AddPending(ds.StreamID{Ms: 1, Seq: 1}, alice, now)
+	group.AddPending(ds.StreamID{Ms: 3}, alice, now).Deliveries = 3
+	stream.CreateGroup("empty", ds.MaxStreamID)
+
+	emptyStream := ds.NewStream()
+	emptyStream.LastID = ds.StreamID{Ms: 10, Seq: 5}
+
+	return map[string]item{
+		"str":          newStringItem("hello", time.Time{}),
+		"int":          newStringItem("-42", expiresAt),
+		"padded":       newStringItem("007", time.Time{}),
+		"list":         {valueType: ValueTypeList, value: list, expiresAt: expiresAt},
+		"hash":         {valueType: ValueTypeHash, value: map[string]string{"f1": "v1", "f2": "2"}},
+		"intset":       {valueType: ValueTypeSet, value: intSet},
+		"set":          {valueType: ValueTypeSet, value: set, expiresAt: expiresAt},
+		"zset":         {valueType: ValueTypeZSet, value: zset},
+		"stream":       {valueType: ValueTypeStream, value: stream, expiresAt: expiresAt},
+		"empty-stream": {valueType: ValueTypeStream, value: emptyStream},
+	}
+}
+
+// comparableValue converts value of item to the form which can be compared.
+func comparableValue(el item) any {
+	value := toRDBValue(el)
+	if set, ok := value.(rdb.Set); ok {
+		slices.Sort(set)
+	}
+	return value
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	src := New(t.Context())
+	items := newTestItems()
+	for key, el := range items {
+		src.put(key, el)
+	}
+	src.put("expired", newStringItem("value", time.Now().Add(-time.Second)))
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Snapshot().Encode(&buf))
+
+	dst := New(t.Context())
+	dst.Set("other", "value", 0)
+	require.NoError(t, dst.Load(&buf))
+
+	require.Len(t, dst.data, len(items))
+	for key, want := range items {
+		got, ok := dst.data[key]
+		require.True(t, ok, key)
+		assert.Equal(t, want.valueType, got.valueType, key)
+		assert.True(t, want.expiresAt.Equal(got.expiresAt), key)
+		assert.Equal(t, comparableValue(want), comparableValue(got), key)
+	}
+
+	assert.True(t, dst.data["intset"].value.(*ds.Set).IsIntSet())
+	assert.Equal(t, ds.StreamID{Ms: 150}, dst.data["stream"].value.(*ds.Stream).LastID)
+	assert.Equal(t, 2, dst.data["stream"].value.(*ds.Stream).Group("group").Consumer("alice").Pending)
+
+	// loaded keys are reachable via index as well
+	assert.Len(t, dst.Keys("*"), len(items))
+}
+
+func TestSnapshotIsolated(t *testing.T) {
+	s := New(t.Context())
+	_, err := s.RPush("list", []string{"a", "b"})
+	require.NoError(t, err)
+
+	snap := s.Snapshot()
+	_, err = s.RPush("list", []string{"c"})
+	require.NoError(t, err)
+	assert.Equal(t, snap.Changes()+1, s.Changes())
+
+	var buf bytes.Buffer
+	require.NoError(t, snap.Encode(&buf))
+	loaded := New(t.Context())
+	require.NoError(t, loaded.Load(&buf))
+
+	values, err := loaded.LRange("list", 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, values)
+}
+
+func TestLoadMalformed(t *testing.T) {
+	src := New(t.Context())
+	src.Set("key", "value", 0)
+	var buf bytes.Buffer
+	require.NoError(t, src.Snapshot().Encode(&buf))
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xFF
+
+	dst := New(t.Context())
+	dst.Set("other", "value", 0)
+	assert.ErrorIs(t, dst.Load(bytes.NewReader(data)), rdb.ErrChecksum)
+
+	// storage is not modified
+	value, err := dst.Get("other")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+	_, err = dst.Get("key")
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	ds "nova/pkg/datastructures"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +18,10 @@ const (
 	ValueTypeString ValueType = iota
 	ValueTypeInt
 	ValueTypeList
+	ValueTypeHash
+	ValueTypeSet
+	ValueTypeZSet
+	ValueTypeStream
 )
 
 // item represents a value in storage with expiration time.
@@ -29,14 +34,35 @@ type item struct {
 type Storage struct {
 	mu sync.RWMutex
 
-	data            map[string]item
+	data map[string]item
+	// index is used for cursor-based iteration over keys
+	index *keyIndex
+	// waiters are clients blocked on keys in order of arrival
+	waiters map[string][]*waiter
+	blocked int
+	// readyKeys are keys with waiters which got new elements, in order of modification.
+	// They're guarded by write lock, hasReady is set while there are any.
+	readyKeys []string
+	readySet  map[string]struct{}
+	hasReady  atomic.Bool
+	// watches are sets of keys watched by clients for modifications
+	watches map[string]map[*watch]struct{}
+	// changes is number of modifications, it's used to decide when snapshot should be saved
+	changes         atomic.Uint64
 	cleanupInterval time.Duration
+	// cleanupReset notifies cleanup worker that interval was changed
+	cleanupReset chan struct{}
 }
 
 func New(ctx context.Context, opts ...Option) *Storage {
 	storage := &Storage{
 		data:            map[string]item{},
+		index:           newKeyIndex(),
+		waiters:         map[string][]*waiter{},
+		readySet:        map[string]struct{}{},
+		watches:         map[string]map[*watch]struct{}{},
 		cleanupInterval: defaultCleanupInterval,
+		cleanupReset:    make(chan struct{}, 1),
 	}
 
 	for _, opt := range opts {
@@ -58,50 +84,95 @@ func (s *Storage) Set(key, value string, ttl time.Duration) {
 		expiresAt = time.Now().Add(ttl)
 	}
 
+	s.mu.Lock()
+	s.put(key, newStringItem(value, expiresAt))
+	s.mu.Unlock()
+}
+
+// SetWithOptions sets string value according to conditions and modifiers from opts.
+// If Get option is used and existing value is not a string, ErrWrongType is returned
+// and value is not set.
+func (s *Storage) SetWithOptions(key, value string, opts storage.SetOptions) (storage.SetResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result storage.SetResult
+
+	el, ok := s.lookup(key)
+	result.Existed = ok
+	if ok && opts.Get {
+		old, err := stringValue(el)
+		if err != nil {
+			return result, err
+		}
+		result.Old = old
+	}
+
+	if (opts.NX && ok) || (opts.XX && !ok) {
+		return result, nil
+	}
+
+	expiresAt := opts.ExpiresAt
+	if opts.KeepTTL {
+		expiresAt = el.expiresAt
+	}
+	s.put(key, newStringItem(value, expiresAt))
+	result.Set = true
+
+	return result, nil
+}
+
+// newStringItem creates item for string value. Integers are stored as int values.
+// Only canonical representations are converted, so that e.g. "007" or "+7" are kept as is.
+func newStringItem(value string, expiresAt time.Time) item {
 	valType := ValueTypeString
 	var valToSet any = value
-	if num, err := strconv.Atoi(value); err == nil {
+	if num, err := strconv.Atoi(value); err == nil && strconv.Itoa(num) == value {
 		valType = ValueTypeInt
 		valToSet = num
 	}
 
-	s.mu.Lock()
-
-	// add new item
-	s.data[key] = item{
+	return item{
 		valueType: valType,
 		value:     valToSet,
 		expiresAt: expiresAt,
 	}
+}
 
-	s.mu.Unlock()
+// stringValue casts string or int item to string.
+// ErrWrongType is returned for other types.
+func stringValue(el item) (string, error) {
+	switch el.valueType {
+	case ValueTypeString:
+		return el.value.(string), nil
+	case ValueTypeInt:
+		return strconv.Itoa(el.value.(int)), nil
+	default:
+		return "", storage.ErrWrongType
+	}
+}
+
+// lookup returns item via given key. Expired items are treated as absent.
+// It MUST BE CALLED with lock held.
+func (s *Storage) lookup(key string) (item, bool) {
+	el, ok := s.data[key]
+	if !ok || isExpired(el) {
+		return item{}, false
+	}
+	return el, true
 }
 
 // Get returns value via given key. If there is no such value, ErrKeyNotFound is returned.
 func (s *Storage) Get(key string) (string, error) {
 	s.mu.RLock()
-	item, ok := s.data[key]
+	item, ok := s.lookup(key)
 	s.mu.RUnlock()
 
-	// cannot be executed with list type
-	if ok && item.valueType == ValueTypeList {
-		return "", storage.ErrWrongType
-	}
-
-	if !ok || isExpired(item) {
+	if !ok {
 		return "", storage.ErrKeyNotFound
 	}
 
-	// cast to string in different ways according to value type
-	var result string
-	if item.valueType == ValueTypeString {
-		result = item.value.(string)
-	}
-	if item.valueType == ValueTypeInt {
-		result = strconv.Itoa(item.value.(int))
-	}
-
-	return result, nil
+	return stringValue(item)
 }
 
 // DeleteMany deletes all records with specified keys. Returns count of deleted records
@@ -111,8 +182,8 @@ func (s *Storage) DeleteMany(keys []string) int {
 	s.mu.Lock()
 
 	for _, key := range keys {
-		if el, ok := s.data[key]; ok && !isExpired(el) {
-			delete(s.data, key)
+		if _, ok := s.lookup(key); ok {
+			s.remove(key)
 			count++
 		}
 	}
@@ -124,96 +195,79 @@ func (s *Storage) DeleteMany(keys []string) int {
 
 // RPush adds new elements to the end of the list available via given key.
 // It returns length of list after addition. If there is no such list, it is created.
-// If we push elements to non-list item, it becomes of list data type.
+// If there is a value of another type, ErrWrongType is returned.
 func (s *Storage) RPush(key string, values []string) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if el, ok := s.data[key]; !ok {
-		s.data[key] = item{
-			valueType: ValueTypeList,
-			value:     &ds.LinkedList{},
-		}
-	} else {
-		list := ds.NewLinkedList()
-		switch el.valueType {
-		case ValueTypeInt:
-			list.PushBack(strconv.Itoa(el.value.(int)))
-		case ValueTypeString:
-			list.PushBack(el.value.(string))
-		}
-
-		newItem := item{
-			value:     list,
-			valueType: ValueTypeList,
-			expiresAt: el.expiresAt,
-		}
-
-		s.data[key] = newItem
+	list, err := s.listForPush(key)
+	if err != nil {
+		return 0, err
 	}
 
 	var length int
 	for _, value := range values {
-		length = s.data[key].value.(*ds.LinkedList).PushBack(value)
+		length = list.PushBack(value)
 	}
+	s.signalModified(key)
+	s.signalKeyReady(key)
 
 	return length, nil
 }
 
 // LPush adds new elements to the beginning of the list available via given key.
 // It returns length of list after addition. If there is not such list, it is created.
+// If there is a value of another type, ErrWrongType is returned.
 func (s *Storage) LPush(key string, values []string) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if el, ok := s.data[key]; !ok {
-		s.data[key] = item{
-			valueType: ValueTypeList,
-			value:     &ds.LinkedList{},
-		}
-	} else {
-		list := ds.NewLinkedList()
-		switch el.valueType {
-		case ValueTypeInt:
-			list.PushBack(strconv.Itoa(el.value.(int)))
-		case ValueTypeString:
-			list.PushBack(el.value.(string))
-		}
-
-		newItem := item{
-			value:     list,
-			valueType: ValueTypeList,
-			expiresAt: el.expiresAt,
-		}
-
-		s.data[key] = newItem
+	list, err := s.listForPush(key)
+	if err != nil {
+		return 0, err
 	}
 
 	var length int
 	for _, value := range values {
-		length = s.data[key].value.(*ds.LinkedList).PushForward(value)
+		length = list.PushForward(value)
 	}
+	s.signalModified(key)
+	s.signalKeyReady(key)
 
 	return length, nil
 }
 
-// LRange returns node values in range of indexes [start, stop].
-func (s *Storage) LRange(key string, start, stop int) ([]string, error) {
-	s.mu.RLock()
-	defer s.mu.RLock()
-
-	el, ok := s.data[key]
+// listForPush returns list stored via key creating it if needed.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) listForPush(key string) (*ds.LinkedList, error) {
+	el, ok := s.lookup(key)
 	if !ok {
-		return nil, storage.ErrKeyNotFound
+		list := ds.NewLinkedList()
+		s.put(key, item{
+			valueType: ValueTypeList,
+			value:     list,
+		})
+		return list, nil
 	}
-	if ok && !isExpired(el) && el.valueType != ValueTypeList {
+
+	if el.valueType != ValueTypeList {
 		return nil, storage.ErrWrongType
 	}
 
-	list := s.data[key].value.(*ds.LinkedList)
-	values := list.LRange(start, stop)
+	return el.value.(*ds.LinkedList), nil
+}
 
-	return values, nil
+// LRange returns node values in range of indexes [start, stop].
+func (s *Storage) LRange(key string, start, stop int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, err := s.getList(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.LRange(start, stop), nil
 }
 
 // LPop pops first n elements from list via given key.
@@ -221,32 +275,59 @@ func (s *Storage) LPop(key string, n int) ([]string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	el, ok := s.data[key]
-	if !ok {
-		return []string{}, storage.ErrKeyNotFound
-	}
-	if ok && !isExpired(el) && el.valueType != ValueTypeList {
-		return []string{}, storage.ErrWrongType
+	list, err := s.getList(key)
+	if err != nil {
+		return []string{}, err
 	}
 
-	list := s.data[key].value.(*ds.LinkedList)
 	values := list.PopForwardNTimes(n)
+	s.signalModified(key)
+	s.removeIfEmpty(key, list)
 
 	return values, nil
 }
 
 func (s *Storage) ListLen(key string) (int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	el, ok := s.data[key]
+	list, err := s.getList(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return list.Len(), nil
+}
+
+// getList returns list stored via key.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getList(key string) (*ds.LinkedList, error) {
+	el, ok := s.lookup(key)
 	if !ok {
-		return 0, storage.ErrKeyNotFound
+		return nil, storage.ErrKeyNotFound
 	}
-	if ok && !isExpired(el) && el.valueType != ValueTypeList {
-		return 0, storage.ErrWrongType
+	if el.valueType != ValueTypeList {
+		return nil, storage.ErrWrongType
 	}
 
-	list := s.data[key].value.(*ds.LinkedList)
-	return list.Len(), nil
+	return el.value.(*ds.LinkedList), nil
+}
+
+// KeyspaceStats returns number of keys and number of keys with expiration time.
+// Expired but not yet removed keys are not counted.
+func (s *Storage) KeyspaceStats() (keys, expires int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, el := range s.data {
+		if isExpired(el) {
+			continue
+		}
+		keys++
+		if !el.expiresAt.IsZero() {
+			expires++
+		}
+	}
+
+	return keys, expires
 }
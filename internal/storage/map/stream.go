@@ -0,0 +1,706 @@
+package mapstorage
+
+import (
+	"context"
+	"errors"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"time"
+)
+
+// getStream returns stream stored via key.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getStream(key string) (*ds.Stream, error) {
+	el, ok := s.lookup(key)
+	if !ok {
+		return nil, storage.ErrKeyNotFound
+	}
+	if el.valueType != ValueTypeStream {
+		return nil, storage.ErrWrongType
+	}
+
+	return el.value.(*ds.Stream), nil
+}
+
+// streamForWrite returns stream stored via key creating it if needed.
+// Unlike other collections, empty streams are stored, since they keep last ID and consumer groups.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) streamForWrite(key string) (*ds.Stream, error) {
+	stream, err := s.getStream(key)
+	if err != storage.ErrKeyNotFound {
+		return stream, err
+	}
+
+	stream = ds.NewStream()
+	s.put(key, item{
+		valueType: ValueTypeStream,
+		value:     stream,
+	})
+	return stream, nil
+}
+
+// getGroup returns consumer group of the stream stored via key.
+// It returns NoGroupError if there is no such stream or group.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getGroup(key, name string) (*ds.Stream, *ds.ConsumerGroup, error) {
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound {
+		return nil, nil, &storage.NoGroupError{Key: key, Group: name}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	group := stream.Group(name)
+	if group == nil {
+		return nil, nil, &storage.NoGroupError{Key: key, Group: name}
+	}
+	return stream, group, nil
+}
+
+// XAdd adds entry with fields to the stream and returns its ID.
+// If there is no such stream, it is created unless opts.NoMkStream is true.
+// In that case ErrKeyNotFound is returned.
+func (s *Storage) XAdd(key string, fields []string, opts storage.XAddOptions) (ds.StreamID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrWrongType || (err == storage.ErrKeyNotFound && opts.NoMkStream) {
+		return ds.StreamID{}, err
+	}
+	created := err == storage.ErrKeyNotFound
+	if created {
+		// stream is stored only after ID is validated
+		stream = ds.NewStream()
+	}
+
+	id := opts.ID
+	err = nil
+	switch {
+	case opts.AutoID:
+		id, err = s.nextStreamID(stream, uint64(time.Now().UnixMilli()), false)
+	case opts.AutoSeq:
+		id, err = s.nextStreamID(stream, opts.ID.Ms, true)
+	case id.Compare(stream.LastID) <= 0:
+		err = storage.ErrSmallID
+	}
+	if err != nil {
+		return ds.StreamID{}, err
+	}
+
+	if created {
+		s.put(key, item{
+			valueType: ValueTypeStream,
+			value:     stream,
+		})
+	}
+	stream.Add(id, fields)
+	if opts.Trim != nil {
+		trimStream(stream, *opts.Trim)
+	}
+	s.signalModified(key)
+	s.signalKeyReady(key)
+
+	return id, nil
+}
+
+// nextStreamID generates ID of new entry. It MUST BE CALLED with lock held.
+func (s *Storage) nextStreamID(stream *ds.Stream, ms uint64, seqOnly bool) (ds.StreamID, error) {
+	id, ok := stream.NextID(ms, seqOnly)
+	if !ok {
+		return ds.StreamID{}, storage.ErrSmallID
+	}
+	return id, nil
+}
+
+// trimStream trims stream according to opts and returns number of deleted entries.
+func trimStream(stream *ds.Stream, opts storage.XTrimOptions) int {
+	if opts.ByMinID {
+		return stream.TrimMinID(opts.MinID, opts.Limit)
+	}
+	return stream.TrimMaxLen(opts.MaxLen, opts.Limit)
+}
+
+// XLen returns number of entries in the stream. It's 0 for missing keys.
+func (s *Storage) XLen(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return stream.Len(), nil
+}
+
+// XRange returns up to count entries with IDs in range [start, end].
+// If reverse is true, entries are returned in descending order. Zero count means no limit.
+func (s *Storage) XRange(key string, start, end ds.StreamID, reverse bool, count int) ([]ds.StreamEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound {
+		return []ds.StreamEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.Range(start, end, reverse, count), nil
+}
+
+// XDel deletes entries from the stream and returns number of deleted ones.
+func (s *Storage) XDel(key string, ids []ds.StreamID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if stream.Delete(id) {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		s.signalModified(key)
+	}
+
+	return deleted, nil
+}
+
+// XTrim trims the stream according to opts and returns number of deleted entries.
+func (s *Storage) XTrim(key string, opts storage.XTrimOptions) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := trimStream(stream, opts)
+	if deleted > 0 {
+		s.signalModified(key)
+	}
+
+	return deleted, nil
+}
+
+// XRead reads up to count entries from every stream after given IDs.
+// Only streams with entries are returned. Zero count means no limit.
+func (s *Storage) XRead(streams []storage.StreamRead, count int) ([]storage.StreamEntries, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.xread(streams, count)
+}
+
+// xread implements XRead. New reads are resolved to the last IDs of streams,
+// so streams are modified. It MUST BE CALLED with lock held.
+func (s *Storage) xread(streams []storage.StreamRead, count int) ([]storage.StreamEntries, error) {
+	result := []storage.StreamEntries{}
+	for i, sr := range streams {
+		stream, err := s.getStream(sr.Key)
+		if err == storage.ErrKeyNotFound {
+			streams[i].New = false
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if sr.New {
+			streams[i].After, streams[i].New = stream.LastID, false
+			continue
+		}
+
+		start, ok := sr.After.Next()
+		if !ok {
+			continue
+		}
+		if entries := stream.Range(start, ds.MaxStreamID, false, count); len(entries) > 0 {
+			result = append(result, storage.StreamEntries{Key: sr.Key, Entries: entries})
+		}
+	}
+
+	return result, nil
+}
+
+// XReadBlock reads streams like XRead does. If there are no entries to read,
+// it blocks until they are added to any of streams or ctx is done.
+// If ctx is done first, its error is returned.
+func (s *Storage) XReadBlock(ctx context.Context, streams []storage.StreamRead, count int) ([]storage.StreamEntries, error) {
+	s.mu.Lock()
+
+	result, err := s.xread(streams, count)
+	if err != nil || len(result) > 0 {
+		s.mu.Unlock()
+		return result, err
+	}
+
+	keys := make([]string, len(streams))
+	for i, sr := range streams {
+		keys[i] = sr.Key
+	}
+	w := &waiter{keys: keys}
+	w.serve = func(readyKey string) bool {
+		for _, sr := range streams {
+			if sr.Key != readyKey {
+				continue
+			}
+			if _, err := s.getStream(readyKey); err != nil {
+				return false
+			}
+			result, err = s.xread([]storage.StreamRead{sr}, count)
+			return len(result) > 0
+		}
+		return false
+	}
+	s.block(w)
+	s.mu.Unlock()
+
+	if err := s.wait(ctx, w); err != nil {
+		return nil, err
+	}
+
+	return result, err
+}
+
+// XGroupCreate creates consumer group which gets entries after id
+// or after the last entry if fromLast is true. If there is no stream,
+// it's created if mkStream is true, otherwise ErrKeyNotFound is returned.
+func (s *Storage) XGroupCreate(key, group string, id ds.StreamID, fromLast, mkStream bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, err := s.getStream(key)
+	if err == storage.ErrKeyNotFound && mkStream {
+		stream, err = s.streamForWrite(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fromLast {
+		id = stream.LastID
+	}
+	if !stream.CreateGroup(group, id) {
+		return storage.ErrGroupExists
+	}
+	s.signalModified(key)
+
+	return nil
+}
+
+// XGroupSetID sets ID of the last entry delivered to consumer group.
+func (s *Storage) XGroupSetID(key, group string, id ds.StreamID, fromLast bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, cg, err := s.groupOfExisting(key, group)
+	if err != nil {
+		return err
+	}
+
+	if fromLast {
+		id = stream.LastID
+	}
+	cg.LastID = id
+	s.signalModified(key)
+
+	return nil
+}
+
+// XGroupDestroy deletes consumer group. It returns false if there is no such group.
+func (s *Storage) XGroupDestroy(key, group string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, err := s.getStream(key)
+	if err != nil {
+		return false, err
+	}
+
+	destroyed := stream.DestroyGroup(group)
+	if destroyed {
+		s.signalModified(key)
+	}
+
+	return destroyed, nil
+}
+
+// XGroupCreateConsumer creates consumer in consumer group.
+// It returns false if consumer already exists.
+func (s *Storage) XGroupCreateConsumer(key, group, consumer string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, cg, err := s.groupOfExisting(key, group)
+	if err != nil {
+		return false, err
+	}
+
+	_, created := cg.AddConsumer(consumer, time.Now())
+	if created {
+		s.signalModified(key)
+	}
+
+	return created, nil
+}
+
+// XGroupDelConsumer deletes consumer from consumer group
+// and returns number of its pending entries which are deleted as well.
+func (s *Storage) XGroupDelConsumer(key, group, consumer string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, cg, err := s.groupOfExisting(key, group)
+	if err != nil {
+		return 0, err
+	}
+
+	pending, deleted := cg.DeleteConsumer(consumer)
+	if deleted {
+		s.signalModified(key)
+	}
+
+	return pending, nil
+}
+
+// groupOfExisting is like getGroup, but it returns ErrKeyNotFound if there is no stream.
+// It MUST BE CALLED with lock held.
+func (s *Storage) groupOfExisting(key, group string) (*ds.Stream, *ds.ConsumerGroup, error) {
+	if _, err := s.getStream(key); err != nil {
+		return nil, nil, err
+	}
+	return s.getGroup(key, group)
+}
+
+// XReadGroup reads streams as consumer of consumer group. New entries are delivered
+// to the consumer and become pending unless opts.NoAck is true. Otherwise pending entries
+// of the consumer are read, entries deleted from stream have nil fields then.
+// Streams with new entries are returned only if there are such entries.
+// If there is no stream or consumer group, NoGroupError is returned.
+func (s *Storage) XReadGroup(streams []storage.StreamRead, opts storage.XReadGroupOptions) ([]storage.StreamEntries, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.xreadGroup(streams, opts)
+}
+
+// xreadGroup implements XReadGroup. It MUST BE CALLED with write lock held.
+func (s *Storage) xreadGroup(streams []storage.StreamRead, opts storage.XReadGroupOptions) ([]storage.StreamEntries, error) {
+	// groups are checked before anything is delivered
+	for _, sr := range streams {
+		if _, _, err := s.getGroup(sr.Key, opts.Group); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	result := []storage.StreamEntries{}
+	for _, sr := range streams {
+		stream, group, _ := s.getGroup(sr.Key, opts.Group)
+		consumer, created := group.AddConsumer(opts.Consumer, now)
+		// state of consumer group is changed by new consumer or delivery only
+		if created {
+			s.signalModified(sr.Key)
+		}
+
+		if !sr.New {
+			entries := []ds.StreamEntry{}
+			start, ok := sr.After.Next()
+			if !ok {
+				result = append(result, storage.StreamEntries{Key: sr.Key, Entries: entries})
+				continue
+			}
+
+			pending := group.PendingRange(start, ds.MaxStreamID, opts.Count, func(pe *ds.PendingEntry) bool {
+				return pe.Consumer == consumer.Name
+			})
+			for _, pe := range pending {
+				entry, ok := stream.Get(pe.ID)
+				if !ok {
+					entries = append(entries, ds.StreamEntry{ID: pe.ID})
+					continue
+				}
+				pe.DeliveredAt = now
+				pe.Deliveries++
+				entries = append(entries, entry)
+			}
+			if len(pending) > 0 {
+				s.signalModified(sr.Key)
+			}
+			result = append(result, storage.StreamEntries{Key: sr.Key, Entries: entries})
+			continue
+		}
+
+		start, ok := group.LastID.Next()
+		if !ok {
+			continue
+		}
+		entries := stream.Range(start, ds.MaxStreamID, false, opts.Count)
+		for _, entry := range entries {
+			group.LastID = entry.ID
+			if !opts.NoAck {
+				group.AddPending(entry.ID, consumer, now)
+			}
+		}
+		if len(entries) > 0 {
+			s.signalModified(sr.Key)
+			result = append(result, storage.StreamEntries{Key: sr.Key, Entries: entries})
+		}
+	}
+
+	return result, nil
+}
+
+// XReadGroupBlock reads streams like XReadGroup does. If there are no entries to read,
+// it blocks until new entries are added to any of streams or ctx is done.
+// If ctx is done first, its error is returned.
+func (s *Storage) XReadGroupBlock(ctx context.Context, streams []storage.StreamRead, opts storage.XReadGroupOptions) ([]storage.StreamEntries, error) {
+	s.mu.Lock()
+
+	result, err := s.xreadGroup(streams, opts)
+	if err != nil || len(result) > 0 {
+		s.mu.Unlock()
+		return result, err
+	}
+
+	keys := make([]string, len(streams))
+	for i, sr := range streams {
+		keys[i] = sr.Key
+	}
+	w := &waiter{keys: keys}
+	w.serve = func(readyKey string) bool {
+		for _, sr := range streams {
+			if sr.Key != readyKey {
+				continue
+			}
+			result, err = s.xreadGroup([]storage.StreamRead{sr}, opts)
+			// client is unblocked with error if group was destroyed
+			return err != nil || len(result) > 0
+		}
+		return false
+	}
+	s.block(w)
+	s.mu.Unlock()
+
+	if err := s.wait(ctx, w); err != nil {
+		return nil, err
+	}
+
+	return result, err
+}
+
+// XAck acknowledges pending entries of consumer group and returns number of acknowledged ones.
+func (s *Storage) XAck(key, group string, ids []ds.StreamID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, cg, err := s.getGroup(key, group)
+	if errors.Is(err, storage.ErrNoGroup) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	acked := 0
+	for _, id := range ids {
+		if cg.Ack(id) {
+			acked++
+		}
+	}
+	if acked > 0 {
+		s.signalModified(key)
+	}
+
+	return acked, nil
+}
+
+// XPendingSummary returns summary of pending entries of consumer group.
+func (s *Storage) XPendingSummary(key, group string) (storage.XPendingSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, cg, err := s.getGroup(key, group)
+	if err != nil {
+		return storage.XPendingSummary{}, err
+	}
+
+	summary := storage.XPendingSummary{
+		Count:     cg.PendingLen(),
+		Consumers: []storage.XPendingConsumer{},
+	}
+	if summary.Count == 0 {
+		return summary, nil
+	}
+
+	summary.MinID, summary.MaxID, _ = cg.PendingBounds()
+	for _, consumer := range cg.Consumers() {
+		if consumer.Pending > 0 {
+			summary.Consumers = append(summary.Consumers, storage.XPendingConsumer{
+				Name:    consumer.Name,
+				Pending: consumer.Pending,
+			})
+		}
+	}
+
+	return summary, nil
+}
+
+// XPending returns pending entries of consumer group described by query.
+func (s *Storage) XPending(key, group string, query storage.XPendingQuery) ([]ds.PendingEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, cg, err := s.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pending := cg.PendingRange(query.Start, query.End, query.Count, func(pe *ds.PendingEntry) bool {
+		return (query.Consumer == "" || pe.Consumer == query.Consumer) &&
+			now.Sub(pe.DeliveredAt) >= query.MinIdle
+	})
+
+	result := make([]ds.PendingEntry, len(pending))
+	for i, pe := range pending {
+		result[i] = *pe
+	}
+	return result, nil
+}
+
+// XClaim transfers pending entries idle for at least opts.MinIdle to consumer
+// and returns claimed entries. Entries deleted from stream are removed from pending ones.
+// If opts.JustID is true, returned entries have nil fields.
+func (s *Storage) XClaim(key, group, consumer string, ids []ds.StreamID, opts storage.XClaimOptions) ([]ds.StreamEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, cg, err := s.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	s.signalModified(key)
+
+	now := time.Now()
+	if opts.LastID.Compare(cg.LastID) > 0 {
+		cg.LastID = opts.LastID
+	}
+	claimer, _ := cg.AddConsumer(consumer, now)
+
+	claimed := []ds.StreamEntry{}
+	for _, id := range ids {
+		pe := cg.Pending(id)
+		entry, exists := stream.Get(id)
+
+		switch {
+		case pe == nil && (!opts.Force || !exists):
+			continue
+		case pe == nil:
+			pe = cg.AddPending(id, claimer, now)
+			pe.Deliveries = 0
+		case !exists:
+			cg.Ack(id)
+			continue
+		case now.Sub(pe.DeliveredAt) < opts.MinIdle:
+			continue
+		}
+		claimPending(cg, pe, claimer, opts, now)
+
+		if opts.JustID {
+			entry.Fields = nil
+		}
+		claimed = append(claimed, entry)
+	}
+
+	return claimed, nil
+}
+
+// claimPending transfers pending entry to consumer updating its delivery info according to opts.
+func claimPending(cg *ds.ConsumerGroup, pe *ds.PendingEntry, consumer *ds.Consumer, opts storage.XClaimOptions, now time.Time) {
+	cg.Claim(pe, consumer)
+
+	pe.DeliveredAt = now
+	if !opts.DeliveredAt.IsZero() {
+		pe.DeliveredAt = opts.DeliveredAt
+	}
+
+	switch {
+	case opts.RetryCount >= 0:
+		pe.Deliveries = opts.RetryCount
+	case !opts.JustID:
+		pe.Deliveries++
+	}
+}
+
+// XAutoClaim claims pending entries idle for at least minIdle like XClaim does.
+// Pending entries are scanned starting from start until count entries are claimed
+// or 10 times more entries are scanned. It returns ID to continue scan from
+// (0-0 if scan is complete), claimed entries and IDs of entries which were deleted
+// from stream and so removed from pending ones.
+func (s *Storage) XAutoClaim(key, group, consumer string, minIdle time.Duration, start ds.StreamID, count int, justID bool) (ds.StreamID, []ds.StreamEntry, []ds.StreamID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, cg, err := s.getGroup(key, group)
+	if err != nil {
+		return ds.StreamID{}, nil, nil, err
+	}
+
+	s.signalModified(key)
+
+	now := time.Now()
+	claimer, _ := cg.AddConsumer(consumer, now)
+	opts := storage.XClaimOptions{RetryCount: -1, JustID: justID}
+
+	next := ds.StreamID{}
+	attempts := count * 10
+	claimed, deleted := []ds.StreamEntry{}, []ds.StreamID{}
+	cg.RangePending(start, func(pe *ds.PendingEntry) bool {
+		if attempts == 0 || len(claimed) == count {
+			next = pe.ID
+			return false
+		}
+		attempts--
+
+		entry, exists := stream.Get(pe.ID)
+		switch {
+		case !exists:
+			deleted = append(deleted, pe.ID)
+		case now.Sub(pe.DeliveredAt) >= minIdle:
+			claimPending(cg, pe, claimer, opts, now)
+			if justID {
+				entry.Fields = nil
+			}
+			claimed = append(claimed, entry)
+		}
+		return true
+	})
+	// pending entries can't be removed during iteration
+	for _, id := range deleted {
+		cg.Ack(id)
+	}
+
+	return next, claimed, deleted, nil
+}
@@ -0,0 +1,54 @@
+package mapstorage
+
+import (
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXReadGroupModified(t *testing.T) {
+	var tests = []struct {
+		name         string
+		consumer     string
+		read         storage.StreamRead
+		addEntry     bool
+		wantModified bool
+	}{
+		{name: "New entries delivered", consumer: "alice", read: storage.StreamRead{Key: "stream", New: true}, addEntry: true, wantModified: true},
+		{name: "Nothing new", consumer: "bob", read: storage.StreamRead{Key: "stream", New: true}, wantModified: false},
+		{name: "Pending entries delivered again", consumer: "bob", read: storage.StreamRead{Key: "stream"}, wantModified: true},
+		{name: "No pending entries", consumer: "alice", read: storage.StreamRead{Key: "stream"}, wantModified: false},
+		{name: "New consumer", consumer: "carol", read: storage.StreamRead{Key: "stream", New: true}, wantModified: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t.Context())
+			_, err := s.XAdd("stream", []string{"field", "value"}, storage.XAddOptions{ID: ds.StreamID{Ms: 1}})
+			require.NoError(t, err)
+			require.NoError(t, s.XGroupCreate("stream", "group", ds.StreamID{}, false, false))
+			// bob has the only entry pending
+			_, err = s.XReadGroup([]storage.StreamRead{{Key: "stream", New: true}}, storage.XReadGroupOptions{Group: "group", Consumer: "bob"})
+			require.NoError(t, err)
+			if test.addEntry {
+				_, err = s.XAdd("stream", []string{"field", "value"}, storage.XAddOptions{ID: ds.StreamID{Ms: 2}})
+				require.NoError(t, err)
+			}
+			_, err = s.XGroupCreateConsumer("stream", "group", "alice")
+			require.NoError(t, err)
+
+			changes := s.Changes()
+			watch := s.Watch([]string{"stream"})
+			defer watch.Release()
+
+			_, err = s.XReadGroup([]storage.StreamRead{test.read}, storage.XReadGroupOptions{Group: "group", Consumer: test.consumer})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.wantModified, watch.Modified())
+			assert.Equal(t, test.wantModified, s.Changes() > changes)
+		})
+	}
+}
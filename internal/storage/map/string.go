@@ -0,0 +1,320 @@
+package mapstorage
+
+import (
+	"math"
+	"math/big"
+	"nova/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// longDoublePrec is a mantissa precision of x87 long double used by Redis for float increments.
+const longDoublePrec = 64
+
+// IncrBy adds delta to integer value of the key and returns the result.
+// Missing key is considered to be 0. Expiration time is kept.
+func (s *Storage) IncrBy(key string, delta int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	current := 0
+	if ok {
+		switch el.valueType {
+		case ValueTypeInt:
+			current = el.value.(int)
+		case ValueTypeString:
+			// only canonical integers are stored as int, so string can't be incremented
+			return 0, storage.ErrNotInteger
+		default:
+			return 0, storage.ErrWrongType
+		}
+	}
+
+	if (delta > 0 && current > math.MaxInt-delta) || (delta < 0 && current < math.MinInt-delta) {
+		return 0, storage.ErrOverflow
+	}
+	result := current + delta
+
+	s.put(key, item{
+		valueType: ValueTypeInt,
+		value:     result,
+		expiresAt: el.expiresAt,
+	})
+
+	return result, nil
+}
+
+// IncrByFloat adds delta to numeric value of the key and returns the result as a string.
+// Missing key is considered to be 0. Expiration time is kept.
+func (s *Storage) IncrByFloat(key string, delta float64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	current := 0.0
+	if ok {
+		switch el.valueType {
+		case ValueTypeInt:
+			current = float64(el.value.(int))
+		case ValueTypeString:
+			num, err := parseFloat(el.value.(string))
+			if err != nil {
+				return "", storage.ErrNotFloat
+			}
+			current = num
+		default:
+			return "", storage.ErrWrongType
+		}
+	}
+
+	value, err := addFloat(current, delta)
+	if err != nil {
+		return "", err
+	}
+
+	s.put(key, newStringItem(value, el.expiresAt))
+
+	return value, nil
+}
+
+// addFloat adds delta to num the way Redis does it with long double: operands in their
+// shortest decimal form are added with 64-bit mantissa, and the result is formatted
+// with 17 digits after the point without trailing zeros. So 0.1 + 0.2 is 0.3 rather than
+// 0.30000000000000004. ErrNaNOrInf is returned if the result doesn't fit into float64.
+func addFloat(num, delta float64) (string, error) {
+	a, _, _ := big.ParseFloat(strconv.FormatFloat(num, 'g', -1, 64), 10, longDoublePrec, big.ToNearestEven)
+	b, _, _ := big.ParseFloat(strconv.FormatFloat(delta, 'g', -1, 64), 10, longDoublePrec, big.ToNearestEven)
+	sum := a.Add(a, b)
+	if f, _ := sum.Float64(); math.IsInf(f, 0) {
+		return "", storage.ErrNaNOrInf
+	}
+
+	value := strings.TrimRight(sum.Text('f', 17), "0")
+	value = strings.TrimSuffix(value, ".")
+	if value == "-0" {
+		value = "0"
+	}
+	return value, nil
+}
+
+// parseFloat parses finite float number without leading or trailing spaces.
+func parseFloat(str string) (float64, error) {
+	if str == "" || strings.TrimSpace(str) != str {
+		return 0, storage.ErrNotFloat
+	}
+
+	num, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+		return 0, storage.ErrNotFloat
+	}
+
+	return num, nil
+}
+
+// Append appends value to the string stored via key and returns length of the result.
+// If there is no such key, it's created. Integer values are treated as strings.
+func (s *Storage) Append(key, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	current := ""
+	if ok {
+		str, err := stringValue(el)
+		if err != nil {
+			return 0, err
+		}
+		current = str
+	}
+
+	result := current + value
+	s.put(key, newStringItem(result, el.expiresAt))
+
+	return len(result), nil
+}
+
+// StrLen returns length of the string stored via key. It's 0 for missing keys.
+func (s *Storage) StrLen(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return 0, nil
+	}
+
+	str, err := stringValue(el)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(str), nil
+}
+
+// GetRange returns substring in range of indexes [start, end].
+// Negative indexes are counted from the end of the string.
+func (s *Storage) GetRange(key string, start, end int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return "", nil
+	}
+
+	str, err := stringValue(el)
+	if err != nil {
+		return "", err
+	}
+
+	length := len(str)
+	if start < 0 {
+		start = max(start+length, 0)
+	}
+	if end < 0 {
+		end = max(end+length, 0)
+	}
+	end = min(end, length-1)
+	if start > end || length == 0 {
+		return "", nil
+	}
+
+	return str[start : end+1], nil
+}
+
+// SetRange overwrites part of the string starting at offset and returns length of the result.
+// If string is shorter than offset, it's padded with zero bytes.
+// Missing key is created unless value is empty.
+func (s *Storage) SetRange(key string, offset int, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	current := ""
+	if ok {
+		str, err := stringValue(el)
+		if err != nil {
+			return 0, err
+		}
+		current = str
+	}
+
+	if value == "" {
+		return len(current), nil
+	}
+
+	buff := []byte(current)
+	if end := offset + len(value); end > len(buff) {
+		buff = append(buff, make([]byte, end-len(buff))...)
+	}
+	copy(buff[offset:], value)
+
+	s.put(key, newStringItem(string(buff), el.expiresAt))
+
+	return len(buff), nil
+}
+
+// GetDel returns string value of the key and deletes it.
+func (s *Storage) GetDel(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+
+	str, err := stringValue(el)
+	if err != nil {
+		return "", err
+	}
+	s.remove(key)
+
+	return str, nil
+}
+
+// GetEx returns string value of the key and changes its expiration time according to opts.
+// If new expiration time is not in the future, key is deleted.
+func (s *Storage) GetEx(key string, opts storage.GetExOptions) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.lookup(key)
+	if !ok {
+		return "", storage.ErrKeyNotFound
+	}
+
+	str, err := stringValue(el)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case opts.Persist:
+		el.expiresAt = time.Time{}
+		s.put(key, el)
+	case !opts.ExpiresAt.IsZero() && !opts.ExpiresAt.After(time.Now()):
+		s.remove(key)
+	case !opts.ExpiresAt.IsZero():
+		el.expiresAt = opts.ExpiresAt
+		s.put(key, el)
+	}
+
+	return str, nil
+}
+
+// MGet returns string values of keys.
+// For missing keys and keys of other types ok is false at corresponding index.
+func (s *Storage) MGet(keys []string) (values []string, ok []bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values = make([]string, len(keys))
+	ok = make([]bool, len(keys))
+	for i, key := range keys {
+		el, exists := s.lookup(key)
+		if !exists {
+			continue
+		}
+
+		str, err := stringValue(el)
+		if err != nil {
+			continue
+		}
+		values[i], ok[i] = str, true
+	}
+
+	return values, ok
+}
+
+// MSet atomically sets values of keys given as flat list of key-value pairs.
+// Existing values are overwritten and their expiration time is removed.
+func (s *Storage) MSet(pairs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		s.put(pairs[i], newStringItem(pairs[i+1], time.Time{}))
+	}
+}
+
+// MSetNX works like MSet but sets values only if none of the keys exists.
+// It reports whether values were set.
+func (s *Storage) MSetNX(pairs []string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if _, ok := s.lookup(pairs[i]); ok {
+			return false
+		}
+	}
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		s.put(pairs[i], newStringItem(pairs[i+1], time.Time{}))
+	}
+
+	return true
+}
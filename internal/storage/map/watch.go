@@ -0,0 +1,75 @@
+package mapstorage
+
+import (
+	"nova/internal/storage"
+)
+
+// watch is a set of keys watched by a client. It's marked dirty when any of them is modified.
+type watch struct {
+	s *Storage
+	// keys are watched keys mapped to whether key existed at the moment of watching
+	keys map[string]bool
+	// dirty is guarded by storage lock
+	dirty bool
+}
+
+// Watch starts watching keys for modifications.
+func (s *Storage) Watch(keys []string) storage.Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := &watch{s: s, keys: make(map[string]bool, len(keys))}
+	for _, key := range keys {
+		_, ok := s.lookup(key)
+		w.keys[key] = ok
+
+		watches, ok := s.watches[key]
+		if !ok {
+			watches = map[*watch]struct{}{}
+			s.watches[key] = watches
+		}
+		watches[w] = struct{}{}
+	}
+
+	return w
+}
+
+// Modified implements storage.Watch.
+// Expired keys are not removed right away, so they are checked separately.
+func (w *watch) Modified() bool {
+	w.s.mu.RLock()
+	defer w.s.mu.RUnlock()
+
+	if w.dirty {
+		return true
+	}
+	for key, existed := range w.keys {
+		if _, ok := w.s.lookup(key); existed && !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Release implements storage.Watch.
+func (w *watch) Release() {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+
+	for key := range w.keys {
+		delete(w.s.watches[key], w)
+		if len(w.s.watches[key]) == 0 {
+			delete(w.s.watches, key)
+		}
+	}
+}
+
+// signalModified marks watches of the key dirty and counts modification.
+// It must be called after every change of the value stored via key, its expiration time included.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) signalModified(key string) {
+	s.changes.Add(1)
+	for w := range s.watches[key] {
+		w.dirty = true
+	}
+}
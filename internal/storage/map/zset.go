@@ -0,0 +1,441 @@
+package mapstorage
+
+import (
+	"cmp"
+	"math"
+	"nova/internal/storage"
+	ds "nova/pkg/datastructures"
+	"slices"
+)
+
+// getZSet returns sorted set stored via key.
+// It MUST BE CALLED with lock held.
+func (s *Storage) getZSet(key string) (*ds.SortedSet, error) {
+	el, ok := s.lookup(key)
+	if !ok {
+		return nil, storage.ErrKeyNotFound
+	}
+	if el.valueType != ValueTypeZSet {
+		return nil, storage.ErrWrongType
+	}
+
+	return el.value.(*ds.SortedSet), nil
+}
+
+// putZSet stores sorted set via key replacing existing value.
+// Empty sorted sets are not stored, so key is just deleted for them.
+// It MUST BE CALLED with write lock held.
+func (s *Storage) putZSet(key string, zset *ds.SortedSet) {
+	s.remove(key)
+	if zset.Len() > 0 {
+		s.put(key, item{
+			valueType: ValueTypeZSet,
+			value:     zset,
+		})
+	}
+}
+
+// ZAdd adds members to the sorted set or updates their scores according to opts.
+// It returns number of added members (and changed ones if opts.CH is true).
+// If there is no such sorted set, it is created.
+func (s *Storage) ZAdd(key string, members []ds.ScoredMember, opts storage.ZAddOptions) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrWrongType {
+		return 0, err
+	}
+	exists := err == nil
+	if !exists {
+		zset = ds.NewSortedSet()
+	}
+
+	added, changed := 0, 0
+	for _, m := range members {
+		current, ok := zset.Score(m.Member)
+		if !canUpdateScore(ok, current, m.Score, opts) {
+			continue
+		}
+
+		if !ok {
+			added++
+		} else if current != m.Score {
+			changed++
+		}
+		zset.Add(m.Member, m.Score)
+	}
+	if added+changed > 0 {
+		s.signalModified(key)
+	}
+
+	if !exists && zset.Len() > 0 {
+		s.putZSet(key, zset)
+	}
+
+	if opts.CH {
+		return added + changed, nil
+	}
+	return added, nil
+}
+
+// canUpdateScore reports whether score of member can be set according to opts.
+func canUpdateScore(exists bool, current, score float64, opts storage.ZAddOptions) bool {
+	if !exists {
+		return !opts.XX
+	}
+
+	switch {
+	case opts.NX:
+		return false
+	case opts.GT:
+		return score > current
+	case opts.LT:
+		return score < current
+	default:
+		return true
+	}
+}
+
+// ZIncrBy adds delta to score of the member and returns the new score.
+// Missing member is added with score equal to delta.
+// It returns false if score is not updated because of opts.
+func (s *Storage) ZIncrBy(key, member string, delta float64, opts storage.ZAddOptions) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrWrongType {
+		return 0, false, err
+	}
+	exists := err == nil
+	if !exists {
+		zset = ds.NewSortedSet()
+	}
+
+	current, ok := zset.Score(member)
+	score := current + delta
+	if math.IsNaN(score) {
+		return 0, false, storage.ErrNaN
+	}
+	if !canUpdateScore(ok, current, score, opts) {
+		return 0, false, nil
+	}
+
+	zset.Add(member, score)
+	s.signalModified(key)
+	if !exists {
+		s.putZSet(key, zset)
+	}
+
+	return score, true, nil
+}
+
+// ZRem removes members from the sorted set and returns number of removed ones.
+func (s *Storage) ZRem(key string, members []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if zset.Remove(member) {
+			removed++
+		}
+	}
+	if removed > 0 {
+		s.signalModified(key)
+	}
+	if zset.Len() == 0 {
+		s.remove(key)
+	}
+
+	return removed, nil
+}
+
+// ZMScore returns scores of members. ok[i] is false if there is no member members[i].
+// Missing sorted set is treated as empty one.
+func (s *Storage) ZMScore(key string, members []string) (scores []float64, ok []bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores = make([]float64, len(members))
+	ok = make([]bool, len(members))
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return scores, ok, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, member := range members {
+		scores[i], ok[i] = zset.Score(member)
+	}
+
+	return scores, ok, nil
+}
+
+// ZCard returns number of members in the sorted set. It's 0 for missing keys.
+func (s *Storage) ZCard(key string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return zset.Len(), nil
+}
+
+// ZCount returns number of members with scores in range [min, max].
+func (s *Storage) ZCount(key string, min, max ds.ScoreBound) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return zset.CountInScoreRange(min, max), nil
+}
+
+// ZRank returns rank of the member and its score.
+// If there is no such member, ErrKeyNotFound is returned.
+func (s *Storage) ZRank(key, member string, reverse bool) (int, float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	zset, err := s.getZSet(key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rank, ok := zset.Rank(member, reverse)
+	if !ok {
+		return 0, 0, storage.ErrKeyNotFound
+	}
+	score, _ := zset.Score(member)
+
+	return rank, score, nil
+}
+
+// ZRange returns members of the sorted set in range described by query.
+// Missing sorted set is treated as empty one.
+func (s *Storage) ZRange(key string, query storage.ZRangeQuery) ([]ds.ScoredMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.zrange(key, query)
+}
+
+// zrange implements ZRange. It MUST BE CALLED with lock held.
+func (s *Storage) zrange(key string, query storage.ZRangeQuery) ([]ds.ScoredMember, error) {
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return []ds.ScoredMember{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch query.By {
+	case storage.ZRangeByScore:
+		return zset.RangeByScore(query.MinScore, query.MaxScore, query.Rev, query.Offset, query.Count), nil
+	case storage.ZRangeByLex:
+		return zset.RangeByLex(query.MinLex, query.MaxLex, query.Rev, query.Offset, query.Count), nil
+	default:
+		return zset.RangeByRank(query.Start, query.Stop, query.Rev), nil
+	}
+}
+
+// ZRangeStore stores members of src sorted set in range described by query to dst
+// and returns their number. Existing dst is overwritten, empty result deletes it.
+func (s *Storage) ZRangeStore(dst, src string, query storage.ZRangeQuery) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, err := s.zrange(src, query)
+	if err != nil {
+		return 0, err
+	}
+
+	zset := ds.NewSortedSet()
+	for _, m := range members {
+		zset.Add(m.Member, m.Score)
+	}
+	s.putZSet(dst, zset)
+
+	return zset.Len(), nil
+}
+
+// ZPop removes up to count members with the lowest scores (or the highest ones if max is true)
+// and returns them. Missing sorted set is treated as empty one.
+func (s *Storage) ZPop(key string, count int, max bool) ([]ds.ScoredMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zset, err := s.getZSet(key)
+	if err == storage.ErrKeyNotFound {
+		return []ds.ScoredMember{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	popped := zset.Pop(count, max)
+	if len(popped) > 0 {
+		s.signalModified(key)
+	}
+	if zset.Len() == 0 {
+		s.remove(key)
+	}
+
+	return popped, nil
+}
+
+// zstoreInput is a sorted set or a set used as input of ZStore.
+// Members of sets have score 1.
+type zstoreInput struct {
+	zset   *ds.SortedSet
+	set    *ds.Set
+	weight float64
+}
+
+func (in zstoreInput) len() int {
+	switch {
+	case in.zset != nil:
+		return in.zset.Len()
+	case in.set != nil:
+		return in.set.Len()
+	default:
+		return 0
+	}
+}
+
+func (in zstoreInput) score(member string) (float64, bool) {
+	switch {
+	case in.zset != nil:
+		return in.zset.Score(member)
+	case in.set != nil:
+		return 1, in.set.Contains(member)
+	default:
+		return 0, false
+	}
+}
+
+func (in zstoreInput) forEach(fn func(member string, score float64)) {
+	switch {
+	case in.zset != nil:
+		in.zset.Range(func(member string, score float64) bool {
+			fn(member, score)
+			return true
+		})
+	case in.set != nil:
+		in.set.Range(func(member string) bool {
+			fn(member, 1)
+			return true
+		})
+	}
+}
+
+// ZStore stores union or intersection of sorted sets via keys to dst and returns its cardinality.
+// Scores are multiplied by weights and then scores of the same member are combined by agg.
+// Plain sets can be used as input as well, their members have score 1.
+// Existing dst is overwritten, empty result deletes it.
+func (s *Storage) ZStore(op storage.SetOp, dst string, keys []string, weights []float64, agg storage.Aggregate) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inputs := make([]zstoreInput, len(keys))
+	for i, key := range keys {
+		inputs[i].weight = weights[i]
+
+		el, ok := s.lookup(key)
+		if !ok {
+			continue
+		}
+		switch el.valueType {
+		case ValueTypeZSet:
+			inputs[i].zset = el.value.(*ds.SortedSet)
+		case ValueTypeSet:
+			inputs[i].set = el.value.(*ds.Set)
+		default:
+			return 0, storage.ErrWrongType
+		}
+	}
+
+	result := ds.NewSortedSet()
+	switch op {
+	case storage.SetOpInter:
+		// the smallest input is iterated to check as few members as possible
+		slices.SortStableFunc(inputs, func(a, b zstoreInput) int {
+			return cmp.Compare(a.len(), b.len())
+		})
+		inputs[0].forEach(func(member string, score float64) {
+			total := weightedScore(score, inputs[0].weight)
+			for _, other := range inputs[1:] {
+				otherScore, ok := other.score(member)
+				if !ok {
+					return
+				}
+				total = aggregate(agg, total, weightedScore(otherScore, other.weight))
+			}
+			result.Add(member, total)
+		})
+
+	case storage.SetOpUnion:
+		for _, in := range inputs {
+			in.forEach(func(member string, score float64) {
+				weighted := weightedScore(score, in.weight)
+				if current, ok := result.Score(member); ok {
+					weighted = aggregate(agg, current, weighted)
+				}
+				result.Add(member, weighted)
+			})
+		}
+	}
+
+	s.putZSet(dst, result)
+
+	return result.Len(), nil
+}
+
+// weightedScore multiplies score by weight. Undefined result (0 * inf) is treated as 0.
+func weightedScore(score, weight float64) float64 {
+	result := score * weight
+	if math.IsNaN(result) {
+		return 0
+	}
+	return result
+}
+
+// aggregate combines two scores. Undefined sum (inf + -inf) is treated as 0.
+func aggregate(agg storage.Aggregate, a, b float64) float64 {
+	switch agg {
+	case storage.AggregateMin:
+		return math.Min(a, b)
+	case storage.AggregateMax:
+		return math.Max(a, b)
+	default:
+		if sum := a + b; !math.IsNaN(sum) {
+			return sum
+		}
+		return 0
+	}
+}
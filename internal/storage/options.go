@@ -0,0 +1,188 @@
+package storage
+
+import (
+	ds "nova/pkg/datastructures"
+	"time"
+)
+
+// SetOptions are conditions and modifiers of setting a string value.
+type SetOptions struct {
+	// NX sets value only if key doesn't exist.
+	NX bool
+	// XX sets value only if key already exists.
+	XX bool
+	// KeepTTL keeps expiration time of existing key. ExpiresAt is ignored in this case.
+	KeepTTL bool
+	// Get makes previous value to be returned. Previous value must be a string.
+	Get bool
+	// ExpiresAt is expiration time of the new value. Zero time means no expiration.
+	ExpiresAt time.Time
+}
+
+// SetResult is a result of conditional set.
+type SetResult struct {
+	// Set shows whether value was set.
+	Set bool
+	// Existed shows whether key existed before. Old is its value (if requested by Get option).
+	Existed bool
+	Old     string
+}
+
+// GetExOptions are modifiers of expiration time applied by GETEX.
+type GetExOptions struct {
+	// ExpiresAt is a new expiration time. Zero time means it's not changed.
+	ExpiresAt time.Time
+	// Persist removes expiration time.
+	Persist bool
+}
+
+// LPosOptions are options of searching element in list.
+type LPosOptions struct {
+	// Rank is a number of the match to start from. Negative rank means search from the tail.
+	Rank int
+	// Count is a max number of returned positions. 0 means all matches.
+	Count int
+	// MaxLen is a max number of compared elements. 0 means the whole list.
+	MaxLen int
+}
+
+// SetOp is an operation of set algebra.
+type SetOp int
+
+const (
+	SetOpInter SetOp = iota
+	SetOpUnion
+	SetOpDiff
+)
+
+// ZAddOptions are conditions and modifiers of adding members to sorted set.
+type ZAddOptions struct {
+	// NX allows only adding new members, XX allows only updating existing ones.
+	NX, XX bool
+	// GT and LT allow updating score only if new score is greater or less than current one.
+	GT, LT bool
+	// CH makes changed members to be counted along with added ones.
+	CH bool
+}
+
+// ZRangeBy shows how range of sorted set members is specified.
+type ZRangeBy int
+
+const (
+	ZRangeByRank ZRangeBy = iota
+	ZRangeByScore
+	ZRangeByLex
+)
+
+// ZRangeQuery describes range of sorted set members.
+type ZRangeQuery struct {
+	By ZRangeBy
+	// Start and Stop are used for ranges by rank.
+	Start, Stop int
+	// MinScore and MaxScore are used for ranges by score.
+	MinScore, MaxScore ds.ScoreBound
+	// MinLex and MaxLex are used for ranges by lex.
+	MinLex, MaxLex ds.LexBound
+	// Rev means descending order.
+	Rev bool
+	// Offset and Count limit ranges by score and lex. Negative Count means no limit.
+	Offset, Count int
+}
+
+// Aggregate is a way to combine scores of the same member in several sorted sets.
+type Aggregate int
+
+const (
+	AggregateSum Aggregate = iota
+	AggregateMin
+	AggregateMax
+)
+
+// XAddOptions are options of adding entry to stream.
+type XAddOptions struct {
+	// ID is ID of the new entry. It's generated if AutoID is true.
+	// If AutoSeq is true, only its sequence number is generated.
+	ID              ds.StreamID
+	AutoID, AutoSeq bool
+	// NoMkStream forbids creating stream if it doesn't exist.
+	NoMkStream bool
+	// Trim is applied after entry is added if it's not nil.
+	Trim *XTrimOptions
+}
+
+// XTrimOptions describe how stream is trimmed: to MaxLen entries or,
+// if ByMinID is true, to entries with IDs greater than or equal to MinID.
+type XTrimOptions struct {
+	ByMinID bool
+	MaxLen  int
+	MinID   ds.StreamID
+	// Limit is a max number of deleted entries. 0 means no limit.
+	Limit int
+}
+
+// StreamRead is a position to read stream from.
+type StreamRead struct {
+	Key string
+	// After is an ID after which entries are read.
+	After ds.StreamID
+	// New means reading only entries added after the command was called ($ ID of XREAD)
+	// or entries never delivered to consumer group (> ID of XREADGROUP). After is ignored then.
+	New bool
+}
+
+// StreamEntries are entries read from stream stored via key.
+type StreamEntries struct {
+	Key     string
+	Entries []ds.StreamEntry
+}
+
+// XReadGroupOptions are options of reading streams by consumer of consumer group.
+type XReadGroupOptions struct {
+	Group, Consumer string
+	// Count is a max number of entries read from every stream. 0 means no limit.
+	Count int
+	// NoAck makes read entries acknowledged right away, so they don't become pending.
+	NoAck bool
+}
+
+// XPendingQuery describes range of pending entries of consumer group.
+type XPendingQuery struct {
+	Start, End ds.StreamID
+	// Count is a max number of returned entries.
+	Count int
+	// Consumer filters entries by consumer if it's not empty.
+	Consumer string
+	// MinIdle filters entries which were delivered at least MinIdle ago.
+	MinIdle time.Duration
+}
+
+// XPendingSummary describes pending entries of consumer group.
+type XPendingSummary struct {
+	Count        int
+	MinID, MaxID ds.StreamID
+	// Consumers are consumers with pending entries ordered by name.
+	Consumers []XPendingConsumer
+}
+
+// XPendingConsumer is a consumer with its number of pending entries.
+type XPendingConsumer struct {
+	Name    string
+	Pending int
+}
+
+// XClaimOptions are options of claiming pending entries.
+type XClaimOptions struct {
+	// MinIdle makes only entries delivered at least MinIdle ago to be claimed.
+	MinIdle time.Duration
+	// DeliveredAt is a new delivery time of claimed entries. Zero time means now.
+	DeliveredAt time.Time
+	// RetryCount is a new number of deliveries if it's not negative.
+	// Otherwise number of deliveries is incremented unless JustID is true.
+	RetryCount int
+	// Force makes entries which are not pending to be claimed if they exist in stream.
+	Force bool
+	// JustID makes only IDs of claimed entries to be returned.
+	JustID bool
+	// LastID updates last delivered ID of consumer group if it's greater than current one.
+	LastID ds.StreamID
+}
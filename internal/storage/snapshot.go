@@ -0,0 +1,11 @@
+package storage
+
+import "io"
+
+// Snapshot is a point-in-time copy of storage data.
+type Snapshot interface {
+	// Encode writes snapshot in RDB format.
+	Encode(w io.Writer) error
+	// Changes returns number of modifications made to storage before the snapshot was taken.
+	Changes() uint64
+}
@@ -0,0 +1,9 @@
+package storage
+
+// Watch tracks keys watched for optimistic locking of transactions.
+type Watch interface {
+	// Modified reports whether any of watched keys was modified, deleted or expired since it was watched.
+	Modified() bool
+	// Release stops watching keys. Watch can't be used after that.
+	Release()
+}
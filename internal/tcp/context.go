@@ -0,0 +1,83 @@
+package tcp
+
+import (
+	"context"
+	"errors"
+	"nova/pkg/resp"
+	"os"
+	"sync"
+	"time"
+)
+
+// requestContext is a context of a single request.
+// Besides cancellation of its parent (which happens on shutdown),
+// it is cancelled when client closes connection while request is being served.
+//
+// Closed connection can be detected only by reading from it, so connection is watched
+// lazily: only after Done or Err is called, i.e. only by requests which wait for something
+// (e.g. blocking commands). Ordinary requests don't pay for it.
+type requestContext struct {
+	context.Context
+
+	conn   *conn
+	reader *resp.Reader
+
+	once    sync.Once
+	watched context.Context
+	cancel  context.CancelFunc
+	// stopped is closed when watching goroutine exits
+	stopped chan struct{}
+}
+
+func newRequestContext(parent context.Context, conn *conn, reader *resp.Reader) *requestContext {
+	return &requestContext{
+		Context: parent,
+		conn:    conn,
+		reader:  reader,
+	}
+}
+
+func (c *requestContext) Done() <-chan struct{} {
+	c.once.Do(c.watch)
+	return c.watched.Done()
+}
+
+func (c *requestContext) Err() error {
+	c.once.Do(c.watch)
+	return c.watched.Err()
+}
+
+// watch starts goroutine which cancels context if connection is closed.
+func (c *requestContext) watch() {
+	c.watched, c.cancel = context.WithCancel(c.Context)
+	c.stopped = make(chan struct{})
+
+	// request is already read, so there is no need in idle deadline anymore
+	_ = c.conn.SetReadDeadline(time.Time{})
+
+	go func() {
+		defer close(c.stopped)
+
+		// if client sends the next command, connection is obviously alive;
+		// it is left in the buffer and watching stops
+		err := c.reader.WaitData()
+		if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+			c.cancel()
+		}
+	}()
+}
+
+// stop stops watching connection. It must be called when request is served,
+// before anything else is read from connection.
+func (c *requestContext) stop() {
+	started := true
+	c.once.Do(func() { started = false })
+	if !started {
+		return
+	}
+
+	// deadline in the past interrupts pending read
+	_ = c.conn.SetReadDeadline(time.Now())
+	<-c.stopped
+	c.cancel()
+}
@@ -0,0 +1,31 @@
+package tcp
+
+import "time"
+
+type Option func(*Server)
+
+// WithMaxClients limits number of simultaneously connected clients.
+func WithMaxClients(n int) Option {
+	return func(s *Server) {
+		s.SetMaxClients(n)
+	}
+}
+
+// WithIdleTimeout makes server close connections which are idle for longer than timeout.
+// Zero timeout disables it.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.SetIdleTimeout(timeout)
+	}
+}
+
+// SetMaxClients changes limit of simultaneously connected clients.
+// Already connected clients are not disconnected.
+func (s *Server) SetMaxClients(n int) {
+	s.maxClients.Store(int64(n))
+}
+
+// SetIdleTimeout changes idle timeout. It's applied since the next read of every connection.
+func (s *Server) SetIdleTimeout(timeout time.Duration) {
+	s.idleTimeout.Store(int64(timeout))
+}
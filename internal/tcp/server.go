@@ -1,23 +1,50 @@
 package tcp
 
 import (
+	"bufio"
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"net"
 	l "nova/pkg/logger"
+	"nova/pkg/resp"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 var (
-	buffSize = 512
+	writeBuffSize = 16 * 1024
+
+	defaultMaxClients = 10000
+
+	// pushLimit is the maximum size of pushed messages waiting to be written,
+	// client which doesn't read them fast enough is disconnected
+	// (as Redis does with client-output-buffer-limit for pub/sub clients)
+	pushLimit = 32 * 1024 * 1024
+)
+
+var (
+	// ErrServerClosed is returned by ListenAndServe after a call to Shutdown.
+	ErrServerClosed = errors.New("tcp: server closed")
+
+	errMaxClients = "max number of clients reached"
 )
 
+// Handler executes decoded commands and returns encoded responses.
 type Handler interface {
-	Serve(context.Context, []byte) []byte
+	// Connect is called once for every accepted connection.
+	// Returned context carries per-connection state and is a parent for all its requests.
+	// push queues server-initiated message (e.g. pub/sub one) to be written to the connection,
+	// it never blocks, is safe for concurrent use and can be called until Disconnect returns.
+	Connect(ctx context.Context, push func([]byte)) context.Context
+	// Disconnect is called once connection is closed with context returned by Connect.
+	Disconnect(ctx context.Context)
+	// Serve executes a single command.
+	Serve(ctx context.Context, args []string) []byte
 }
 
 type Server struct {
@@ -28,60 +55,282 @@ type Server struct {
 	connCounter    uint64
 	requestCounter uint64
 
+	// listener and conns are guarded by mu as well
+	listener   net.Listener
+	conns      map[*conn]struct{}
+	inShutdown atomic.Bool
+
+	// baseCtx is a parent of all connection contexts, it is cancelled on shutdown
+	// to interrupt requests waiting for something (e.g. blocking commands)
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+
+	// tunables which can be changed while server is running
+	maxClients  atomic.Int64
+	idleTimeout atomic.Int64
+
 	Addr    string
 	Handler Handler
 }
 
-func NewServer(addr string, handler Handler, log *zap.Logger) (*Server, error) {
+// conn is an accepted connection with its state.
+type conn struct {
+	net.Conn
+
+	// idle is true while connection waits for the next command.
+	idle atomic.Bool
+
+	// wmu guards writer, since messages can be pushed concurrently with responses
+	wmu    sync.Mutex
+	writer *bufio.Writer
+
+	// qmu guards queue of pushed messages, they're written by writeLoop
+	// or along with the next response, so that push never waits for the client.
+	// If both locks are needed, wmu is acquired first.
+	qmu         sync.Mutex
+	queue       [][]byte
+	queuedBytes int
+	// ready is signalled when message is queued
+	ready chan struct{}
+	// overflowed is set when connection is closed because of exceeded pushLimit
+	overflowed atomic.Bool
+}
+
+func newConn(netConn net.Conn) *conn {
+	c := &conn{Conn: netConn, ready: make(chan struct{}, 1)}
+	c.writer = bufio.NewWriterSize(c, writeBuffSize)
+	return c
+}
+
+// write buffers data and flushes buffer if flush is true.
+// Pushed messages waiting in queue are written before data.
+func (c *conn) write(data []byte, flush bool) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if err := c.writeQueued(); err != nil {
+		return 0, err
+	}
+	n, err := c.writer.Write(data)
+	if err == nil && flush {
+		err = c.writer.Flush()
+	}
+	return n, err
+}
+
+// push queues server-initiated message to be written right away along with buffered responses.
+// If size of queued messages exceeds pushLimit, connection is closed.
+func (c *conn) push(msg []byte) {
+	c.qmu.Lock()
+	defer c.qmu.Unlock()
+
+	if c.overflowed.Load() {
+		return
+	}
+	if c.queuedBytes+len(msg) > pushLimit {
+		c.overflowed.Store(true)
+		c.Close()
+		return
+	}
+
+	c.queue = append(c.queue, msg)
+	c.queuedBytes += len(msg)
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// writeQueued buffers pushed messages waiting in queue.
+// It MUST BE CALLED with wmu held.
+func (c *conn) writeQueued() error {
+	c.qmu.Lock()
+	queue := c.queue
+	c.queue = nil
+	c.qmu.Unlock()
+
+	size := 0
+	for _, msg := range queue {
+		if _, err := c.writer.Write(msg); err != nil {
+			return err
+		}
+		size += len(msg)
+	}
+
+	// messages are counted until they're written, so that client which doesn't read is detected
+	c.qmu.Lock()
+	c.queuedBytes -= size
+	c.qmu.Unlock()
+	return nil
+}
+
+// writeLoop writes pushed messages as they're queued until done is closed.
+func (c *conn) writeLoop(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-c.ready:
+		}
+
+		c.wmu.Lock()
+		err := c.writeQueued()
+		if err == nil {
+			err = c.writer.Flush()
+		}
+		c.wmu.Unlock()
+
+		if err != nil {
+			c.Close()
+			return
+		}
+	}
+}
+
+func NewServer(addr string, handler Handler, log *zap.Logger, opts ...Option) (*Server, error) {
 	if log == nil {
 		return nil, errors.New("logger cannot be nil")
 	}
 
-	return &Server{
+	srv := &Server{
 		Addr:           addr,
 		Handler:        handler,
 		log:            log,
 		connCounter:    0,
 		requestCounter: 0,
-	}, nil
+		conns:          map[*conn]struct{}{},
+	}
+	srv.baseCtx, srv.cancelBase = context.WithCancel(context.Background())
+	srv.SetMaxClients(defaultMaxClients)
+
+	for _, opt := range opts {
+		opt(srv)
+	}
+
+	return srv, nil
 }
 
-func (s *Server) ListenAndServe() {
+// ListenAndServe listens on the server address and handles incoming connections.
+// It blocks until Shutdown is called and returns ErrServerClosed in that case.
+func (s *Server) ListenAndServe() error {
 	ln, err := net.Listen("tcp", s.Addr)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	s.log.Info("created tcp socket listener", zap.String("address", s.Addr))
 
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
+
+	// Shutdown could be called before listener was saved
+	if s.inShutdown.Load() {
+		ln.Close()
+		return ErrServerClosed
+	}
+
 	s.log.Info("listening for incoming connections")
 	for {
-		conn, err := ln.Accept()
+		netConn, err := ln.Accept()
 		if err != nil {
-			fmt.Printf("failed to accept connection: %v\n", err.Error())
+			if s.inShutdown.Load() {
+				return ErrServerClosed
+			}
+
+			s.log.Error("failed to accept connection", zap.Error(err))
 			continue
 		}
 
-		go s.handleConn(conn)
+		c := newConn(netConn)
+		if !s.trackConn(c, true) {
+			s.log.Warn("rejected connection", zap.String("reason", errMaxClients))
+			_, _ = c.Write(resp.EncodeError(errMaxClients))
+			c.Close()
+			continue
+		}
+		go s.handleConn(c)
 	}
 }
 
-func (s *Server) handleConn(conn net.Conn) {
+// trackConn adds connection to the set of open connections or removes it from there.
+// It returns false if connection cannot be added because of clients limit.
+func (s *Server) trackConn(c *conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.conns, c)
+		return true
+	}
+
+	if int64(len(s.conns)) >= s.maxClients.Load() {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+// setIdleDeadline limits time of waiting for the next command.
+func (c *conn) setIdleDeadline(timeout time.Duration) error {
+	if timeout == 0 {
+		return c.SetReadDeadline(time.Time{})
+	}
+	return c.SetReadDeadline(time.Now().Add(timeout))
+}
+
+func (s *Server) handleConn(conn *conn) {
 	s.mu.Lock()
 	log := s.log.With(zap.Uint64("conn_id", s.connCounter))
 	s.connCounter++
 	s.mu.Unlock()
 
 	log.Info("accepted new connection")
+	stopWriter := make(chan struct{})
+	go conn.writeLoop(stopWriter)
+
+	connCtx := s.Handler.Connect(s.baseCtx, conn.push)
+	defer func() {
+		s.Handler.Disconnect(connCtx)
+		close(stopWriter)
+		conn.Close()
+		s.trackConn(conn, false)
+	}()
+
+	reader := resp.NewReader(conn)
 	for {
-		buff := make([]byte, buffSize)
-		n, err := conn.Read(buff)
+		conn.idle.Store(reader.Buffered() == 0)
+		// server could start shutting down while connection was busy
+		if conn.idle.Load() && s.inShutdown.Load() {
+			break
+		}
+
+		if err := conn.setIdleDeadline(time.Duration(s.idleTimeout.Load())); err != nil {
+			log.Error("failed to set read deadline", zap.Error(err))
+			break
+		}
+
+		args, err := reader.ReadCommand()
+		conn.idle.Store(false)
+		if conn.overflowed.Load() {
+			log.Warn("closing connection", zap.String("reason", "pushed messages limit exceeded"))
+			break
+		}
 		if err != nil {
-			if errors.Is(err, io.EOF) {
+			if errors.Is(err, io.EOF) || (errors.Is(err, net.ErrClosed) && s.inShutdown.Load()) {
+				break
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				log.Info("closing idle connection")
 				break
 			}
 
 			log.Error("failed to read request", zap.Error(err))
-			continue
+			// malformed request leaves the stream in unknown state,
+			// so client is notified and connection is closed (as Redis does)
+			if errors.Is(err, resp.ErrProtocol) {
+				_, _ = conn.write(resp.EncodeError(err.Error()), true)
+			}
+			break
 		}
 
 		s.mu.Lock()
@@ -89,16 +338,44 @@ func (s *Server) handleConn(conn net.Conn) {
 		s.requestCounter++
 		s.mu.Unlock()
 
-		ctx := l.WithLogger(context.Background(), log)
-		resp := s.Handler.Serve(ctx, buff[:n])
+		ctx := newRequestContext(l.WithLogger(connCtx, log), conn, reader)
+		response := s.Handler.Serve(ctx, args)
+		ctx.stop()
 
-		n, err = conn.Write(resp)
+		// responses are buffered and written in order;
+		// while there are pipelined commands left in the read buffer,
+		// their responses are coalesced into one write
+		n, err := conn.write(response, reader.Buffered() == 0)
 		if err != nil {
 			log.Error("failed to send response", zap.Error(err))
-			continue
+			break
 		}
 		log.Info("sent response", zap.Int("bytes", n))
 	}
 
 	log.Info("connection closed")
 }
+
+// ConnectedClients returns number of currently open connections.
+func (s *Server) ConnectedClients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+// TotalConnections returns number of connections accepted since server start.
+func (s *Server) TotalConnections() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connCounter
+}
+
+// TotalCommands returns number of commands processed since server start.
+func (s *Server) TotalCommands() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requestCounter
+}
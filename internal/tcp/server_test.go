@@ -0,0 +1,141 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"nova/pkg/resp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// echoHandler replies with arguments of the command.
+type echoHandler struct{}
+
+func (echoHandler) Connect(ctx context.Context, _ func([]byte)) context.Context {
+	return ctx
+}
+
+func (echoHandler) Disconnect(context.Context) {}
+
+func (echoHandler) Serve(_ context.Context, args []string) []byte {
+	return resp.EncodeArray(args)
+}
+
+// countingConn counts writes to the connection.
+type countingConn struct {
+	net.Conn
+	writes atomic.Int32
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	c.writes.Add(1)
+	return c.Conn.Write(b)
+}
+
+// serveConn serves server side of in-memory connection and returns its client side.
+// Returned channel is closed when connection is handled.
+func serveConn(t *testing.T, s *Server) (net.Conn, *countingConn, <-chan struct{}) {
+	t.Helper()
+
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.SetDeadline(time.Now().Add(time.Second)))
+
+	counting := &countingConn{Conn: server}
+	done := make(chan struct{})
+	c := &conn{Conn: counting}
+	c.writer = bufio.NewWriterSize(c, writeBuffSize)
+	go func() {
+		s.handleConn(c)
+		close(done)
+	}()
+	return client, counting, done
+}
+
+func TestPipelining(t *testing.T) {
+	var tests = []struct {
+		name       string
+		writes     []string
+		want       string
+		wantWrites int32
+	}{
+		{
+			name:       "Single command",
+			writes:     []string{"*1\r\n$4\r\nPING\r\n"},
+			want:       "*1\r\n$4\r\nPING\r\n",
+			wantWrites: 1,
+		},
+		{
+			name:       "Pipelined commands",
+			writes:     []string{"*1\r\n$1\r\na\r\n*2\r\n$1\r\nb\r\n$1\r\nc\r\n*1\r\n$1\r\nd\r\n"},
+			want:       "*1\r\n$1\r\na\r\n*2\r\n$1\r\nb\r\n$1\r\nc\r\n*1\r\n$1\r\nd\r\n",
+			wantWrites: 1,
+		},
+		{
+			name:       "Command split between writes",
+			writes:     []string{"*2\r\n$3\r\nGET\r\n$3\r\nk", "ey\r\n"},
+			want:       "*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n",
+			wantWrites: 1,
+		},
+		{
+			name:       "Inline commands mixed with multibulk",
+			writes:     []string{"SET key \"a b\"\r\n*1\r\n$1\r\na\r\nPING\n"},
+			want:       "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$3\r\na b\r\n*1\r\n$1\r\na\r\n*1\r\n$4\r\nPING\r\n",
+			wantWrites: 1,
+		},
+		{
+			name:       "Commands sent one by one",
+			writes:     []string{"*1\r\n$1\r\na\r\n", "*1\r\n$1\r\nb\r\n"},
+			want:       "*1\r\n$1\r\na\r\n*1\r\n$1\r\nb\r\n",
+			wantWrites: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := NewServer("", echoHandler{}, zap.NewNop())
+			require.NoError(t, err)
+			client, conn, done := serveConn(t, s)
+
+			go func() {
+				for _, w := range test.writes {
+					if _, err := client.Write([]byte(w)); err != nil {
+						return
+					}
+				}
+			}()
+
+			got := make([]byte, len(test.want))
+			_, err = io.ReadFull(client, got)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, string(got))
+
+			client.Close()
+			<-done
+			assert.Equal(t, test.wantWrites, conn.writes.Load())
+		})
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	s, err := NewServer("", echoHandler{}, zap.NewNop())
+	require.NoError(t, err)
+	client, _, done := serveConn(t, s)
+
+	go func() {
+		_, _ = client.Write([]byte("*1\r\n$1\r\na\r\n*x\r\n*1\r\n$1\r\nb\r\n"))
+	}()
+
+	// commands before malformed one are served, then connection is closed
+	got, err := io.ReadAll(client)
+	require.NoError(t, err)
+	assert.Equal(t, "*1\r\n$1\r\na\r\n-Protocol error: invalid multibulk length\r\n", string(got))
+	<-done
+}
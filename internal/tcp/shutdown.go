@@ -0,0 +1,72 @@
+package tcp
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	shutdownPollInterval = 50 * time.Millisecond
+)
+
+// Shutdown gracefully stops the server: it closes the listener,
+// lets in-flight commands finish (blocked ones are interrupted) and closes connections as soon as they become idle.
+// If ctx expires before all connections are closed, remaining ones are closed forcibly
+// and ctx error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.inShutdown.Store(true)
+	s.log.Info("shutting down tcp server")
+	s.cancelBase()
+
+	s.mu.Lock()
+	if s.listener != nil {
+		if err := s.listener.Close(); err != nil {
+			s.log.Error("failed to close listener", zap.Error(err))
+		}
+	}
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.closeIdleConns() {
+			s.log.Info("all connections are closed")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.closeAllConns()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeIdleConns closes connections waiting for the next command.
+// It reports whether there are no open connections left.
+func (s *Server) closeIdleConns() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.idle.Load() {
+			c.Close()
+		}
+	}
+
+	return len(s.conns) == 0
+}
+
+func (s *Server) closeAllConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		c.Close()
+	}
+	s.log.Warn("forcibly closed active connections", zap.Int("count", len(s.conns)))
+}
@@ -0,0 +1,131 @@
+package tcp
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// slowHandler replies with arguments of the command once release is closed.
+type slowHandler struct {
+	echoHandler
+	started chan struct{}
+	release chan struct{}
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (h *slowHandler) Serve(ctx context.Context, args []string) []byte {
+	h.started <- struct{}{}
+	<-h.release
+	return h.echoHandler.Serve(ctx, args)
+}
+
+// listen starts the server on a random port and returns its address
+// and channel receiving result of ListenAndServe.
+func listen(t *testing.T, s *Server) (string, <-chan error) {
+	t.Helper()
+
+	s.Addr = "127.0.0.1:0"
+	result := make(chan error, 1)
+	go func() { result <- s.ListenAndServe() }()
+
+	var addr string
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.listener != nil {
+			addr = s.listener.Addr().String()
+		}
+		return addr != ""
+	}, time.Second, time.Millisecond)
+	return addr, result
+}
+
+// dial connects to the server and waits until connection is accepted.
+func dial(t *testing.T, s *Server, addr string) net.Conn {
+	t.Helper()
+
+	s.mu.Lock()
+	accepted := len(s.conns)
+	s.mu.Unlock()
+
+	c, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	require.NoError(t, c.SetDeadline(time.Now().Add(2*time.Second)))
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.conns) > accepted
+	}, time.Second, time.Millisecond)
+	return c
+}
+
+func TestShutdown(t *testing.T) {
+	h := newSlowHandler()
+	s, err := NewServer("", h, zap.NewNop())
+	require.NoError(t, err)
+	addr, served := listen(t, s)
+
+	idle := dial(t, s, addr)
+	busy := dial(t, s, addr)
+	_, err = busy.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	require.NoError(t, err)
+	<-h.started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+
+	// idle connection is closed right away
+	_, err = idle.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+
+	// in-flight command is finished before connection is closed
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v before command was finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(h.release)
+
+	got, err := io.ReadAll(busy)
+	require.NoError(t, err)
+	assert.Equal(t, "*1\r\n$4\r\nPING\r\n", string(got))
+	assert.NoError(t, <-shutdown)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	h := newSlowHandler()
+	s, err := NewServer("", h, zap.NewNop())
+	require.NoError(t, err)
+	addr, _ := listen(t, s)
+
+	busy := dial(t, s, addr)
+	_, err = busy.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	require.NoError(t, err)
+	<-h.started
+	defer close(h.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+
+	// connection is closed forcibly without reply
+	_, err = busy.Read(make([]byte, 1))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, os.ErrDeadlineExceeded, "connection wasn't closed")
+}
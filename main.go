@@ -2,36 +2,123 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"nova/internal/config"
 	"nova/internal/handler"
+	"nova/internal/persistence"
 	mapstorage "nova/internal/storage/map"
 	"nova/internal/tcp"
 	"nova/pkg/logger"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"go.uber.org/zap"
 )
 
-// TODO: move to configuration module (or package)
-var (
-	addr = "localhost:6379"
-)
-
 func main() {
-	log := logger.Setup()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(2)
+	}
+
+	logLevel := zap.NewAtomicLevel()
+	if err := logLevel.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set log level: %v\n", err)
+		os.Exit(2)
+	}
+	log, err := logger.New(logLevel, cfg.LogFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to init logger: %v\n", err)
+		os.Exit(2)
+	}
+	defer func() { _ = log.Sync() }()
+
+	log.Info("starting nova", zap.String("config_file", cfg.File))
 
-	log.Info("starting nova")
+	// ctx is cancelled on SIGINT or SIGTERM which stops storage background workers
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	log.Info("initializing storage")
-	storage := mapstorage.New(context.Background())
+	storage := mapstorage.New(ctx,
+		mapstorage.WithCleanupInterval(cfg.CleanupInterval),
+	)
+
+	persist := persistence.New(storage, log,
+		persistence.WithPath(cfg.Dir, cfg.DBFilename),
+		persistence.WithRules(cfg.Save),
+	)
+	if err := persist.Load(); err != nil {
+		log.Fatal("failed to load snapshot", zap.Error(err))
+	}
+	go persist.Run(ctx)
+
+	runtimeCfg := config.NewRuntime(cfg)
+	h := handler.NewHandler(storage,
+		handler.WithConfig(runtimeCfg),
+		handler.WithPersistence(persist),
+	)
 
 	srv, err := tcp.NewServer(
-		addr,
-		handler.NewHandler(storage),
+		cfg.Addr(),
+		h,
 		log,
+		tcp.WithMaxClients(cfg.MaxClients),
+		tcp.WithIdleTimeout(cfg.Timeout),
 	)
 	if err != nil {
 		log.Panic("failed to init tcp server", zap.Error(err))
 	}
+	h.SetServer(srv)
+
+	// parameters changed via CONFIG SET are applied to running components
+	runtimeCfg.OnChange("cleanup-interval", func(c *config.Config) {
+		storage.SetCleanupInterval(c.CleanupInterval)
+	})
+	runtimeCfg.OnChange("loglevel", func(c *config.Config) {
+		_ = logLevel.UnmarshalText([]byte(c.LogLevel))
+	})
+	runtimeCfg.OnChange("maxclients", func(c *config.Config) {
+		srv.SetMaxClients(c.MaxClients)
+	})
+	runtimeCfg.OnChange("timeout", func(c *config.Config) {
+		srv.SetIdleTimeout(c.Timeout)
+	})
+	runtimeCfg.OnChange("save", func(c *config.Config) {
+		persist.SetRules(c.Save)
+	})
+	setSnapshotPath := func(c *config.Config) {
+		persist.SetPath(c.Dir, c.DBFilename)
+	}
+	runtimeCfg.OnChange("dir", setSnapshotPath)
+	runtimeCfg.OnChange("dbfilename", setSnapshotPath)
+
+	srvErr := make(chan error, 1)
+	go func() {
+		srvErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-srvErr:
+		log.Fatal("failed to run tcp server", zap.Error(err))
+	case <-ctx.Done():
+	}
+	// second signal terminates the process immediately
+	stop()
+
+	shutdownTimeout := runtimeCfg.Current().ShutdownTimeout
+	log.Info("shutting down nova", zap.Duration("timeout", shutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down tcp server gracefully", zap.Error(err))
+	}
+	if err := persist.Shutdown(); err != nil {
+		log.Error("failed to save snapshot on shutdown", zap.Error(err))
+	}
 
-	// TODO: wrap in MustRun() function
-	srv.ListenAndServe()
+	log.Info("nova stopped")
 }
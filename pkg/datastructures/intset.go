@@ -0,0 +1,76 @@
+package datastructures
+
+import (
+	"slices"
+	"strconv"
+)
+
+// IntSet is a compact set of integers stored as a sorted slice.
+// Lookups take O(log n) time, insertions and deletions take O(n) time,
+// so it suits small sets only.
+type IntSet struct {
+	values []int64
+}
+
+// NewIntSet is a constructor for IntSet.
+func NewIntSet() *IntSet {
+	return &IntSet{
+		values: []int64{},
+	}
+}
+
+// Add adds value to the set. It returns false if value is already there.
+func (is *IntSet) Add(val int64) bool {
+	i, found := slices.BinarySearch(is.values, val)
+	if found {
+		return false
+	}
+
+	is.values = slices.Insert(is.values, i, val)
+	return true
+}
+
+// Remove deletes value from the set. It returns false if there is no such value.
+func (is *IntSet) Remove(val int64) bool {
+	i, found := slices.BinarySearch(is.values, val)
+	if !found {
+		return false
+	}
+
+	is.values = slices.Delete(is.values, i, i+1)
+	return true
+}
+
+// Contains reports whether value is in the set.
+func (is *IntSet) Contains(val int64) bool {
+	_, found := slices.BinarySearch(is.values, val)
+	return found
+}
+
+// Len returns number of values in the set.
+func (is *IntSet) Len() int {
+	return len(is.values)
+}
+
+// Get returns value on index in ascending order.
+// It MUST BE CALLED with valid index.
+func (is *IntSet) Get(index int) int64 {
+	return is.values[index]
+}
+
+// Clone returns copy of the set.
+func (is *IntSet) Clone() *IntSet {
+	return &IntSet{
+		values: slices.Clone(is.values),
+	}
+}
+
+// parseSetInt parses integer in canonical form, so that "+7" or "007"
+// are not treated as integers and are kept as is.
+func parseSetInt(str string) (int64, bool) {
+	num, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || strconv.FormatInt(num, 10) != str {
+		return 0, false
+	}
+	return num, true
+}
@@ -0,0 +1,62 @@
+package datastructures
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntSet(t *testing.T) {
+	is := NewIntSet()
+
+	// values of every width are kept sorted in the same set
+	values := []int64{math.MaxInt64, 5, -70000, math.MinInt64, 1 << 40, -3, 300}
+	for _, v := range values {
+		assert.True(t, is.Add(v))
+	}
+	assert.False(t, is.Add(5))
+	assert.Equal(t, len(values), is.Len())
+
+	want := []int64{math.MinInt64, -70000, -3, 5, 300, 1 << 40, math.MaxInt64}
+	for i, v := range want {
+		assert.Equal(t, v, is.Get(i))
+	}
+
+	assert.True(t, is.Contains(-70000))
+	assert.False(t, is.Contains(4))
+
+	assert.True(t, is.Remove(-3))
+	assert.False(t, is.Remove(-3))
+	assert.False(t, is.Contains(-3))
+	assert.Equal(t, len(values)-1, is.Len())
+
+	clone := is.Clone()
+	clone.Add(7)
+	assert.False(t, is.Contains(7))
+}
+
+func TestParseSetInt(t *testing.T) {
+	var tests = []struct {
+		input string
+		want  int64
+		ok    bool
+	}{
+		{input: "42", want: 42, ok: true},
+		{input: "-9223372036854775808", want: math.MinInt64, ok: true},
+		{input: "9223372036854775808", ok: false},
+		{input: "007", ok: false},
+		{input: "+7", ok: false},
+		{input: "-0", ok: false},
+		{input: "", ok: false},
+		{input: "1.0", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, ok := parseSetInt(test.input)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -257,3 +257,164 @@ func (ll *LinkedList) LRange(start, stop int) []string {
 
 	return result
 }
+
+// Clone returns a copy of the list.
+func (ll *LinkedList) Clone() *LinkedList {
+	clone := NewLinkedList()
+	for curr := ll.head; curr != nil; curr = curr.next {
+		clone.PushBack(curr.val)
+	}
+
+	return clone
+}
+
+// Clear deletes all nodes from the list unlinking them from each other.
+func (ll *LinkedList) Clear() {
+	curr := ll.head
+	for curr != nil {
+		next := curr.next
+		curr.prev, curr.next = nil, nil
+		curr = next
+	}
+
+	ll.head, ll.tail = nil, nil
+	ll.length = 0
+}
+
+// PopBackNTimes pops n values from the tail of the list and returns them.
+// If n > ll.length, all values are returned and list becomes empty.
+func (ll *LinkedList) PopBackNTimes(n int) []string {
+	n = min(n, ll.length)
+	values := make([]string, 0, n)
+
+	for range n {
+		val, _ := ll.PopBack()
+		values = append(values, val)
+	}
+
+	return values
+}
+
+// Set replaces value of the node on index.
+// It returns false if index is not valid.
+func (ll *LinkedList) Set(index int, val string) bool {
+	if index < 0 || index >= ll.length {
+		return false
+	}
+
+	ll.get(index).val = val
+	return true
+}
+
+// Insert inserts new node before or after the first node with pivot value.
+// It returns length of the list after insertion or -1 if there is no pivot.
+func (ll *LinkedList) Insert(pivot, val string, before bool) int {
+	index := 0
+	for curr := ll.head; curr != nil; curr = curr.next {
+		if curr.val == pivot {
+			if !before {
+				index++
+			}
+			return ll.PushAtIndex(index, val)
+		}
+		index++
+	}
+
+	return -1
+}
+
+// Remove deletes nodes with value val and returns number of deleted nodes.
+// If count > 0, first count matching nodes from head are deleted,
+// if count < 0, first |count| matching nodes from tail are deleted,
+// if count == 0, all matching nodes are deleted.
+func (ll *LinkedList) Remove(count int, val string) int {
+	fromTail := count < 0
+	if fromTail {
+		count = -count
+	}
+
+	removed := 0
+	curr := ll.head
+	if fromTail {
+		curr = ll.tail
+	}
+	for curr != nil && (count == 0 || removed < count) {
+		next := curr.next
+		if fromTail {
+			next = curr.prev
+		}
+
+		if curr.val == val {
+			ll.unlink(curr)
+			removed++
+		}
+		curr = next
+	}
+
+	return removed
+}
+
+// unlink deletes given node from the list.
+func (ll *LinkedList) unlink(node *ListNode) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		ll.head = node.next
+	}
+
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		ll.tail = node.prev
+	}
+
+	node.prev, node.next = nil, nil
+	ll.length--
+}
+
+// Trim leaves only nodes from indexes in range [start, stop].
+// Negative indexes are counted from the tail.
+func (ll *LinkedList) Trim(start, stop int) {
+	if start < 0 {
+		start = max(start+ll.length, 0)
+	}
+	if stop < 0 {
+		stop += ll.length
+	}
+	stop = min(stop, ll.length-1)
+
+	if start > stop {
+		ll.Clear()
+		return
+	}
+
+	for range start {
+		ll.PopForward()
+	}
+	for range ll.length - (stop - start + 1) {
+		ll.PopBack()
+	}
+}
+
+// Range calls fn for every node from head to tail (or from tail to head if reverse is true)
+// until fn returns false.
+func (ll *LinkedList) Range(reverse bool, fn func(index int, val string) bool) {
+	if reverse {
+		index := ll.length - 1
+		for curr := ll.tail; curr != nil; curr = curr.prev {
+			if !fn(index, curr.val) {
+				return
+			}
+			index--
+		}
+		return
+	}
+
+	index := 0
+	for curr := ll.head; curr != nil; curr = curr.next {
+		if !fn(index, curr.val) {
+			return
+		}
+		index++
+	}
+}
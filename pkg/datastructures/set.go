@@ -0,0 +1,181 @@
+package datastructures
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+var (
+	// MaxIntSetEntries is the max size of a set which is stored as IntSet.
+	// It's the same as default set-max-intset-entries in Redis.
+	MaxIntSetEntries = 512
+)
+
+// Set is a set of strings.
+// While all members are integers and there are no more than MaxIntSetEntries of them,
+// they are stored compactly in IntSet. Otherwise set is converted to a hash table,
+// and it's never converted back.
+type Set struct {
+	ints *IntSet
+	// members of hash table are kept in a slice as well, so that random one
+	// can be picked in O(1) time; index maps member to its position there
+	members []string
+	index   map[string]int
+}
+
+// NewSet is a constructor for Set.
+func NewSet() *Set {
+	return &Set{
+		ints: NewIntSet(),
+	}
+}
+
+// IsIntSet reports whether set is stored as IntSet.
+func (s *Set) IsIntSet() bool {
+	return s.ints != nil
+}
+
+// convert moves members from IntSet to hash table.
+func (s *Set) convert() {
+	s.members = make([]string, 0, s.ints.Len())
+	s.index = make(map[string]int, s.ints.Len())
+	for i := range s.ints.Len() {
+		s.members = append(s.members, strconv.FormatInt(s.ints.Get(i), 10))
+		s.index[s.members[i]] = i
+	}
+	s.ints = nil
+}
+
+// Add adds member to the set. It returns false if member is already there.
+func (s *Set) Add(member string) bool {
+	if s.ints != nil {
+		num, ok := parseSetInt(member)
+		if ok && (s.ints.Len() < MaxIntSetEntries || s.ints.Contains(num)) {
+			return s.ints.Add(num)
+		}
+		s.convert()
+	}
+
+	if _, ok := s.index[member]; ok {
+		return false
+	}
+	s.index[member] = len(s.members)
+	s.members = append(s.members, member)
+	return true
+}
+
+// Remove deletes member from the set. It returns false if there is no such member.
+func (s *Set) Remove(member string) bool {
+	if s.ints != nil {
+		num, ok := parseSetInt(member)
+		return ok && s.ints.Remove(num)
+	}
+
+	i, ok := s.index[member]
+	if !ok {
+		return false
+	}
+
+	// the last member takes place of the removed one
+	last := s.members[len(s.members)-1]
+	s.members[i] = last
+	s.index[last] = i
+	s.members = s.members[:len(s.members)-1]
+	delete(s.index, member)
+	return true
+}
+
+// Contains reports whether member is in the set.
+func (s *Set) Contains(member string) bool {
+	if s.ints != nil {
+		num, ok := parseSetInt(member)
+		return ok && s.ints.Contains(num)
+	}
+
+	_, ok := s.index[member]
+	return ok
+}
+
+// Len returns number of members in the set.
+func (s *Set) Len() int {
+	if s.ints != nil {
+		return s.ints.Len()
+	}
+	return len(s.members)
+}
+
+// Range calls fn for every member until fn returns false.
+// Members of IntSet are iterated in ascending order, others in no particular order.
+// Set MUST NOT be modified during iteration.
+func (s *Set) Range(fn func(member string) bool) {
+	if s.ints != nil {
+		for i := range s.ints.Len() {
+			if !fn(strconv.FormatInt(s.ints.Get(i), 10)) {
+				return
+			}
+		}
+		return
+	}
+
+	for _, member := range s.members {
+		if !fn(member) {
+			return
+		}
+	}
+}
+
+// Members returns all members of the set.
+func (s *Set) Members() []string {
+	members := make([]string, 0, s.Len())
+	s.Range(func(member string) bool {
+		members = append(members, member)
+		return true
+	})
+
+	return members
+}
+
+// RandomMember returns random member of the set. It returns false if set is empty.
+func (s *Set) RandomMember() (string, bool) {
+	if s.Len() == 0 {
+		return "", false
+	}
+
+	if s.ints != nil {
+		return strconv.FormatInt(s.ints.Get(rand.IntN(s.ints.Len())), 10), true
+	}
+	return s.members[rand.IntN(len(s.members))], true
+}
+
+// RandomMembers returns up to count distinct random members of the set.
+func (s *Set) RandomMembers(count int) []string {
+	if count >= s.Len() {
+		return s.Members()
+	}
+
+	// reservoir sampling makes every member equally likely to be chosen
+	chosen := make([]string, 0, count)
+	seen := 0
+	s.Range(func(member string) bool {
+		if len(chosen) < count {
+			chosen = append(chosen, member)
+		} else if j := rand.IntN(seen + 1); j < count {
+			chosen[j] = member
+		}
+		seen++
+		return true
+	})
+
+	return chosen
+}
+
+// Clone returns copy of the set.
+func (s *Set) Clone() *Set {
+	if s.ints != nil {
+		return &Set{ints: s.ints.Clone()}
+	}
+
+	return &Set{members: slices.Clone(s.members), index: maps.Clone(s.index)}
+}
@@ -0,0 +1,146 @@
+package datastructures
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetConversion(t *testing.T) {
+	var tests = []struct {
+		name    string
+		members []string
+		isInt   bool
+	}{
+		{name: "Integers", members: []string{"3", "-1", "1000000000000"}, isInt: true},
+		{name: "Non-canonical integer", members: []string{"1", "007"}, isInt: false},
+		{name: "String", members: []string{"1", "2", "a"}, isInt: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSet()
+			for _, m := range test.members {
+				assert.True(t, s.Add(m))
+			}
+			assert.Equal(t, test.isInt, s.IsIntSet())
+			assert.ElementsMatch(t, test.members, s.Members())
+			for _, m := range test.members {
+				assert.True(t, s.Contains(m))
+			}
+		})
+	}
+}
+
+func TestSetConversionBySize(t *testing.T) {
+	s := NewSet()
+	for i := range MaxIntSetEntries {
+		s.Add(strconv.Itoa(i))
+	}
+	require.True(t, s.IsIntSet())
+
+	// existing member doesn't make set grow
+	assert.False(t, s.Add("0"))
+	require.True(t, s.IsIntSet())
+
+	assert.True(t, s.Add(strconv.Itoa(MaxIntSetEntries)))
+	assert.False(t, s.IsIntSet())
+	assert.Equal(t, MaxIntSetEntries+1, s.Len())
+	for i := range MaxIntSetEntries + 1 {
+		assert.True(t, s.Contains(strconv.Itoa(i)))
+	}
+
+	// set is never converted back
+	for i := range MaxIntSetEntries {
+		assert.True(t, s.Remove(strconv.Itoa(i)))
+	}
+	assert.False(t, s.IsIntSet())
+	assert.Equal(t, []string{strconv.Itoa(MaxIntSetEntries)}, s.Members())
+}
+
+func TestSetRemove(t *testing.T) {
+	s := NewSet()
+	for _, m := range []string{"a", "b", "c", "d"} {
+		s.Add(m)
+	}
+
+	assert.True(t, s.Remove("b"))
+	assert.False(t, s.Remove("b"))
+	assert.True(t, s.Remove("d"))
+	assert.False(t, s.Contains("b"))
+	assert.False(t, s.Contains("d"))
+	assert.ElementsMatch(t, []string{"a", "c"}, s.Members())
+
+	assert.True(t, s.Add("b"))
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, s.Members())
+
+	clone := s.Clone()
+	clone.Remove("a")
+	assert.True(t, s.Contains("a"))
+}
+
+func TestSetRandomMember(t *testing.T) {
+	_, ok := NewSet().RandomMember()
+	assert.False(t, ok)
+
+	var tests = []struct {
+		name    string
+		members []string
+	}{
+		{name: "IntSet", members: []string{"1", "2", "3", "4"}},
+		{name: "Hash table", members: []string{"a", "b", "c", "d"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSet()
+			for _, m := range test.members {
+				s.Add(m)
+			}
+
+			const draws = 40000
+			counts := map[string]int{}
+			for range draws {
+				m, ok := s.RandomMember()
+				require.True(t, ok)
+				counts[m]++
+			}
+
+			// every member is expected to be chosen draws/4 times, deviation is a few percent
+			require.Len(t, counts, len(test.members))
+			for m, n := range counts {
+				assert.InDelta(t, draws/len(test.members), n, draws/20, m)
+			}
+		})
+	}
+}
+
+func TestSetRandomMembers(t *testing.T) {
+	s := NewSet()
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		s.Add(m)
+	}
+
+	got := s.RandomMembers(3)
+	assert.Len(t, got, 3)
+	for _, m := range got {
+		assert.True(t, s.Contains(m))
+	}
+	assert.ElementsMatch(t, got, uniq(got))
+
+	assert.ElementsMatch(t, s.Members(), s.RandomMembers(10))
+}
+
+func uniq(values []string) []string {
+	seen := map[string]struct{}{}
+	result := []string{}
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,390 @@
+package datastructures
+
+import (
+	"math/rand/v2"
+)
+
+var (
+	skipListMaxLevel = 32
+	// skipListP is a probability of node to have the next level
+	skipListP = 0.25
+)
+
+// ScoredMember is a member of sorted set with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// ScoreBound is a bound of score range.
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+// LexBound is a bound of lexicographical range.
+// Inf is -1 for bound which is less than any string, 1 for bound which is greater
+// than any string and 0 otherwise.
+type LexBound struct {
+	Value     string
+	Exclusive bool
+	Inf       int
+}
+
+type skipListLevel struct {
+	forward *SkipListNode
+	// span is the number of nodes between this node and forward one,
+	// it's used to calculate ranks
+	span int
+}
+
+// SkipListNode represents single node in skip list.
+type SkipListNode struct {
+	ScoredMember
+
+	backward *SkipListNode
+	levels   []skipListLevel
+}
+
+// Next returns the next node or nil if it's the last one.
+func (n *SkipListNode) Next() *SkipListNode {
+	return n.levels[0].forward
+}
+
+// Prev returns the previous node or nil if it's the first one.
+func (n *SkipListNode) Prev() *SkipListNode {
+	return n.backward
+}
+
+// SkipList is a list of members ordered by score and then lexicographically by member.
+// Search, insertion, deletion and access by rank take O(log n) time on average.
+// It's the same structure Redis uses for sorted sets.
+type SkipList struct {
+	head   *SkipListNode
+	tail   *SkipListNode
+	length int
+	level  int
+}
+
+// NewSkipList is a constructor for SkipList.
+func NewSkipList() *SkipList {
+	return &SkipList{
+		head: &SkipListNode{
+			levels: make([]skipListLevel, skipListMaxLevel),
+		},
+		level: 1,
+	}
+}
+
+// Len returns number of nodes in the list.
+func (sl *SkipList) Len() int {
+	return sl.length
+}
+
+// First returns the first node or nil if the list is empty.
+func (sl *SkipList) First() *SkipListNode {
+	return sl.head.levels[0].forward
+}
+
+// Last returns the last node or nil if the list is empty.
+func (sl *SkipList) Last() *SkipListNode {
+	return sl.tail
+}
+
+func randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// less reports whether node goes before given score and member.
+func (n *SkipListNode) less(score float64, member string) bool {
+	return n.Score < score || (n.Score == score && n.Member < member)
+}
+
+// Insert adds new node. Caller MUST make sure that member is not in the list yet.
+func (sl *SkipList) Insert(score float64, member string) *SkipListNode {
+	update := make([]*SkipListNode, skipListMaxLevel)
+	rank := make([]int, skipListMaxLevel)
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = sl.head
+			update[i].levels[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	x = &SkipListNode{
+		ScoredMember: ScoredMember{Member: member, Score: score},
+		levels:       make([]skipListLevel, level),
+	}
+	for i := range level {
+		x.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = x
+
+		x.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	// node is inserted under untouched levels
+	for i := level; i < sl.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	if update[0] != sl.head {
+		x.backward = update[0]
+	}
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+
+	return x
+}
+
+// Delete deletes node with given score and member. It returns false if there is no such node.
+func (sl *SkipList) Delete(score float64, member string) bool {
+	update := make([]*SkipListNode, skipListMaxLevel)
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.less(score, member) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].forward
+	if x == nil || x.Score != score || x.Member != member {
+		return false
+	}
+	sl.deleteNode(x, update)
+
+	return true
+}
+
+func (sl *SkipList) deleteNode(x *SkipListNode, update []*SkipListNode) {
+	for i := range sl.level {
+		if update[i].levels[i].forward == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].forward = x.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+
+	for sl.level > 1 && sl.head.levels[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+}
+
+// Rank returns 0-based rank of node with given score and member.
+// It returns false if there is no such node.
+func (sl *SkipList) Rank(score float64, member string) (int, bool) {
+	rank := 0
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil &&
+			(x.levels[i].forward.less(score, member) || x.levels[i].forward.Member == member) {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+
+		if x != sl.head && x.Member == member {
+			return rank - 1, true
+		}
+	}
+
+	return 0, false
+}
+
+// ByRank returns node with given 0-based rank or nil if rank is out of range.
+func (sl *SkipList) ByRank(rank int) *SkipListNode {
+	if rank < 0 || rank >= sl.length {
+		return nil
+	}
+
+	// ranks are 1-based internally, since head has rank 0
+	target := rank + 1
+	traversed := 0
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= target {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+
+		if traversed == target {
+			return x
+		}
+	}
+
+	return nil
+}
+
+// lessOrEqual reports whether score satisfies bound used as min.
+func (b ScoreBound) lessOrEqual(score float64) bool {
+	if b.Exclusive {
+		return b.Value < score
+	}
+	return b.Value <= score
+}
+
+// greaterOrEqual reports whether score satisfies bound used as max.
+func (b ScoreBound) greaterOrEqual(score float64) bool {
+	if b.Exclusive {
+		return score < b.Value
+	}
+	return score <= b.Value
+}
+
+// isEmptyScoreRange reports whether there can be no scores in range [min, max].
+func isEmptyScoreRange(min, max ScoreBound) bool {
+	return min.Value > max.Value || (min.Value == max.Value && (min.Exclusive || max.Exclusive))
+}
+
+// FirstInScoreRange returns the first node with score in range or nil if there is no such node.
+func (sl *SkipList) FirstInScoreRange(min, max ScoreBound) *SkipListNode {
+	if isEmptyScoreRange(min, max) {
+		return nil
+	}
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && !min.lessOrEqual(x.levels[i].forward.Score) {
+			x = x.levels[i].forward
+		}
+	}
+
+	x = x.levels[0].forward
+	if x == nil || !max.greaterOrEqual(x.Score) {
+		return nil
+	}
+	return x
+}
+
+// LastInScoreRange returns the last node with score in range or nil if there is no such node.
+func (sl *SkipList) LastInScoreRange(min, max ScoreBound) *SkipListNode {
+	if isEmptyScoreRange(min, max) {
+		return nil
+	}
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && max.greaterOrEqual(x.levels[i].forward.Score) {
+			x = x.levels[i].forward
+		}
+	}
+
+	if x == sl.head || !min.lessOrEqual(x.Score) {
+		return nil
+	}
+	return x
+}
+
+// lessOrEqual reports whether member satisfies bound used as min.
+func (b LexBound) lessOrEqual(member string) bool {
+	switch {
+	case b.Inf < 0:
+		return true
+	case b.Inf > 0:
+		return false
+	case b.Exclusive:
+		return b.Value < member
+	default:
+		return b.Value <= member
+	}
+}
+
+// greaterOrEqual reports whether member satisfies bound used as max.
+func (b LexBound) greaterOrEqual(member string) bool {
+	switch {
+	case b.Inf > 0:
+		return true
+	case b.Inf < 0:
+		return false
+	case b.Exclusive:
+		return member < b.Value
+	default:
+		return member <= b.Value
+	}
+}
+
+// isEmptyLexRange reports whether there can be no members in range [min, max].
+func isEmptyLexRange(min, max LexBound) bool {
+	if min.Inf > 0 || max.Inf < 0 {
+		return true
+	}
+	if min.Inf < 0 || max.Inf > 0 {
+		return false
+	}
+	return min.Value > max.Value || (min.Value == max.Value && (min.Exclusive || max.Exclusive))
+}
+
+// FirstInLexRange returns the first node with member in range or nil if there is no such node.
+// Lexicographical ranges make sense only if all members have the same score.
+func (sl *SkipList) FirstInLexRange(min, max LexBound) *SkipListNode {
+	if isEmptyLexRange(min, max) {
+		return nil
+	}
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && !min.lessOrEqual(x.levels[i].forward.Member) {
+			x = x.levels[i].forward
+		}
+	}
+
+	x = x.levels[0].forward
+	if x == nil || !max.greaterOrEqual(x.Member) {
+		return nil
+	}
+	return x
+}
+
+// LastInLexRange returns the last node with member in range or nil if there is no such node.
+// Lexicographical ranges make sense only if all members have the same score.
+func (sl *SkipList) LastInLexRange(min, max LexBound) *SkipListNode {
+	if isEmptyLexRange(min, max) {
+		return nil
+	}
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && max.greaterOrEqual(x.levels[i].forward.Member) {
+			x = x.levels[i].forward
+		}
+	}
+
+	if x == sl.head || !min.lessOrEqual(x.Member) {
+		return nil
+	}
+	return x
+}
@@ -0,0 +1,87 @@
+package datastructures
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkSkipList checks that list holds exactly want members (in ascending order)
+// and that links, ranks and spans are consistent.
+func checkSkipList(t *testing.T, sl *SkipList, want []ScoredMember) {
+	t.Helper()
+
+	require.Equal(t, len(want), sl.Len())
+
+	got := []ScoredMember{}
+	for node := sl.First(); node != nil; node = node.Next() {
+		got = append(got, node.ScoredMember)
+	}
+	require.Equal(t, want, got)
+
+	reversed := []ScoredMember{}
+	for node := sl.Last(); node != nil; node = node.Prev() {
+		reversed = append(reversed, node.ScoredMember)
+	}
+	slices.Reverse(reversed)
+	require.Equal(t, want, reversed)
+
+	for i, m := range want {
+		rank, ok := sl.Rank(m.Score, m.Member)
+		require.True(t, ok, m.Member)
+		require.Equal(t, i, rank, m.Member)
+		require.Equal(t, m, sl.ByRank(i).ScoredMember)
+	}
+	assert.Nil(t, sl.ByRank(-1))
+	assert.Nil(t, sl.ByRank(len(want)))
+}
+
+func sortScoredMembers(members []ScoredMember) {
+	slices.SortFunc(members, func(a, b ScoredMember) int {
+		return cmp.Or(cmp.Compare(a.Score, b.Score), cmp.Compare(a.Member, b.Member))
+	})
+}
+
+func TestSkipList(t *testing.T) {
+	sl := NewSkipList()
+	checkSkipList(t, sl, []ScoredMember{})
+
+	// few distinct scores, so that members with equal scores are ordered by name
+	members := []ScoredMember{}
+	for i := range 1000 {
+		m := ScoredMember{Member: "m" + strconv.Itoa(i), Score: float64(rand.IntN(50))}
+		sl.Insert(m.Score, m.Member)
+		members = append(members, m)
+	}
+	sortScoredMembers(members)
+	checkSkipList(t, sl, members)
+
+	_, ok := sl.Rank(1, "missing")
+	assert.False(t, ok)
+
+	// every other member is deleted, including the first and the last ones
+	kept := []ScoredMember{}
+	for i, m := range members {
+		if i%2 == 0 || i == len(members)-1 {
+			require.True(t, sl.Delete(m.Score, m.Member))
+			continue
+		}
+		kept = append(kept, m)
+	}
+	checkSkipList(t, sl, kept)
+
+	// wrong score doesn't match the member
+	assert.False(t, sl.Delete(kept[0].Score+100, kept[0].Member))
+	assert.False(t, sl.Delete(members[0].Score, members[0].Member))
+
+	for _, m := range kept {
+		require.True(t, sl.Delete(m.Score, m.Member))
+	}
+	checkSkipList(t, sl, []ScoredMember{})
+	assert.Equal(t, 1, sl.level)
+}
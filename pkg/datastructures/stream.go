@@ -0,0 +1,418 @@
+package datastructures
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamID identifies stream entry. It consists of milliseconds time
+// and sequence number for entries added within the same millisecond.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID is greater than or equal to any other ID.
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+// ParseStreamID parses ID in "<ms>-<seq>" or "<ms>" form.
+// It reports whether sequence number was given.
+func ParseStreamID(s string) (id StreamID, seqGiven bool, ok bool) {
+	msPart, seqPart, seqGiven := strings.Cut(s, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, false, false
+	}
+	id.Ms = ms
+
+	if seqGiven {
+		seq, err := strconv.ParseUint(seqPart, 10, 64)
+		if err != nil {
+			return StreamID{}, false, false
+		}
+		id.Seq = seq
+	}
+
+	return id, seqGiven, true
+}
+
+// String returns ID in "<ms>-<seq>" form.
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// Compare returns -1, 0 or 1 if id is less than, equal to or greater than other.
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms < other.Ms:
+		return -1
+	case id.Ms > other.Ms:
+		return 1
+	case id.Seq < other.Seq:
+		return -1
+	case id.Seq > other.Seq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether id is 0-0.
+func (id StreamID) IsZero() bool {
+	return id == StreamID{}
+}
+
+// Next returns the smallest ID greater than id. It returns false if id is MaxStreamID.
+func (id StreamID) Next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{Ms: id.Ms + 1}, true
+	default:
+		return id, false
+	}
+}
+
+// Prev returns the greatest ID less than id. It returns false if id is 0-0.
+func (id StreamID) Prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	default:
+		return id, false
+	}
+}
+
+// Stream is a log of entries with unique increasing IDs which can be consumed by consumer groups.
+type Stream struct {
+	log *StreamLog
+	// LastID is ID of the last added entry. It's kept even if the entry is deleted,
+	// since new entries must have greater IDs.
+	LastID StreamID
+	groups map[string]*ConsumerGroup
+}
+
+// NewStream is a constructor for Stream.
+func NewStream() *Stream {
+	return &Stream{
+		log:    NewStreamLog(),
+		groups: map[string]*ConsumerGroup{},
+	}
+}
+
+// Len returns number of entries in the stream.
+func (s *Stream) Len() int {
+	return s.log.Len()
+}
+
+// NextID returns ID for entry added at ms milliseconds time. If seqOnly is true,
+// only sequence number is generated and ms is used as is. It returns false if there
+// is no valid ID greater than LastID.
+func (s *Stream) NextID(ms uint64, seqOnly bool) (StreamID, bool) {
+	switch {
+	case ms > s.LastID.Ms:
+		return StreamID{Ms: ms}, true
+	case !seqOnly:
+		// clock went backwards, so LastID is incremented
+		return s.LastID.Next()
+	case ms == s.LastID.Ms && s.LastID.Seq < math.MaxUint64:
+		// it's also the case of 0-* for empty stream, since 0-0 is not valid ID
+		return StreamID{Ms: ms, Seq: s.LastID.Seq + 1}, true
+	default:
+		return StreamID{}, false
+	}
+}
+
+// Add appends entry to the stream.
+// Caller MUST make sure that its ID is greater than LastID.
+func (s *Stream) Add(id StreamID, fields []string) {
+	s.log.Append(StreamEntry{ID: id, Fields: fields})
+	s.LastID = id
+}
+
+// Get returns entry with given ID. It returns false if there is no such entry.
+func (s *Stream) Get(id StreamID) (StreamEntry, bool) {
+	return s.log.Get(id)
+}
+
+// Delete deletes entry with given ID. It returns false if there is no such entry.
+func (s *Stream) Delete(id StreamID) bool {
+	return s.log.Delete(id)
+}
+
+// Range returns entries with IDs in range [start, end].
+// See StreamLog.Range for details.
+func (s *Stream) Range(start, end StreamID, reverse bool, count int) []StreamEntry {
+	return s.log.Range(start, end, reverse, count)
+}
+
+// TrimMaxLen deletes the oldest entries until there are no more than maxLen ones.
+// See StreamLog.TrimMaxLen for details.
+func (s *Stream) TrimMaxLen(maxLen, limit int) int {
+	return s.log.TrimMaxLen(maxLen, limit)
+}
+
+// TrimMinID deletes entries with IDs less than minID.
+// See StreamLog.TrimMinID for details.
+func (s *Stream) TrimMinID(minID StreamID, limit int) int {
+	return s.log.TrimMinID(minID, limit)
+}
+
+// Group returns consumer group with given name or nil if there is no such group.
+func (s *Stream) Group(name string) *ConsumerGroup {
+	return s.groups[name]
+}
+
+// GroupNames returns names of consumer groups in ascending order.
+func (s *Stream) GroupNames() []string {
+	return slices.Sorted(maps.Keys(s.groups))
+}
+
+// CreateGroup creates consumer group which will get entries with IDs greater than lastID.
+// It returns false if group with such name already exists.
+func (s *Stream) CreateGroup(name string, lastID StreamID) bool {
+	if _, ok := s.groups[name]; ok {
+		return false
+	}
+
+	s.groups[name] = &ConsumerGroup{
+		LastID:    lastID,
+		pending:   map[StreamID]*PendingEntry{},
+		consumers: map[string]*Consumer{},
+	}
+	return true
+}
+
+// DestroyGroup deletes consumer group. It returns false if there is no such group.
+func (s *Stream) DestroyGroup(name string) bool {
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+
+	delete(s.groups, name)
+	return true
+}
+
+// Clone returns copy of the stream including its consumer groups.
+func (s *Stream) Clone() *Stream {
+	clone := &Stream{
+		log:    s.log.Clone(),
+		LastID: s.LastID,
+		groups: make(map[string]*ConsumerGroup, len(s.groups)),
+	}
+	for name, group := range s.groups {
+		clone.groups[name] = group.clone()
+	}
+
+	return clone
+}
+
+// ConsumerGroup tracks which entries of the stream were delivered to its consumers.
+// Entries are pending until they are acknowledged by consumers.
+type ConsumerGroup struct {
+	// LastID is ID of the last entry delivered to the group
+	LastID StreamID
+
+	pending map[StreamID]*PendingEntry
+	// pendingIDs are IDs of pending entries in ascending order
+	pendingIDs []StreamID
+	consumers  map[string]*Consumer
+}
+
+// PendingEntry is an entry delivered to consumer but not acknowledged yet.
+type PendingEntry struct {
+	ID          StreamID
+	Consumer    string
+	DeliveredAt time.Time
+	Deliveries  int
+}
+
+// Consumer is a member of consumer group.
+type Consumer struct {
+	Name   string
+	SeenAt time.Time
+	// Pending is number of entries pending for the consumer
+	Pending int
+}
+
+// Consumer returns consumer with given name or nil if there is no such consumer.
+func (g *ConsumerGroup) Consumer(name string) *Consumer {
+	return g.consumers[name]
+}
+
+// AddConsumer returns consumer with given name creating it if needed.
+// Consumer is marked as seen at now. It reports whether consumer was created.
+func (g *ConsumerGroup) AddConsumer(name string, now time.Time) (*Consumer, bool) {
+	consumer, ok := g.consumers[name]
+	if !ok {
+		consumer = &Consumer{Name: name}
+		g.consumers[name] = consumer
+	}
+	consumer.SeenAt = now
+
+	return consumer, !ok
+}
+
+// DeleteConsumer deletes consumer with its pending entries and returns their number.
+// It returns false if there is no such consumer.
+func (g *ConsumerGroup) DeleteConsumer(name string) (int, bool) {
+	consumer, ok := g.consumers[name]
+	if !ok {
+		return 0, false
+	}
+
+	g.pendingIDs = slices.DeleteFunc(g.pendingIDs, func(id StreamID) bool {
+		if g.pending[id].Consumer != name {
+			return false
+		}
+		delete(g.pending, id)
+		return true
+	})
+	delete(g.consumers, name)
+
+	return consumer.Pending, true
+}
+
+// Consumers returns consumers ordered by name.
+func (g *ConsumerGroup) Consumers() []*Consumer {
+	consumers := slices.Collect(maps.Values(g.consumers))
+	slices.SortFunc(consumers, func(a, b *Consumer) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return consumers
+}
+
+// PendingLen returns number of pending entries.
+func (g *ConsumerGroup) PendingLen() int {
+	return len(g.pendingIDs)
+}
+
+// Pending returns pending entry with given ID or nil if there is no such entry.
+func (g *ConsumerGroup) Pending(id StreamID) *PendingEntry {
+	return g.pending[id]
+}
+
+// AddPending marks entry as delivered to consumer at now.
+// If entry is already pending, it's transferred to consumer.
+func (g *ConsumerGroup) AddPending(id StreamID, consumer *Consumer, now time.Time) *PendingEntry {
+	if entry, ok := g.pending[id]; ok {
+		g.transfer(entry, consumer)
+		entry.DeliveredAt = now
+		entry.Deliveries++
+		return entry
+	}
+
+	entry := &PendingEntry{
+		ID:          id,
+		Consumer:    consumer.Name,
+		DeliveredAt: now,
+		Deliveries:  1,
+	}
+	g.pending[id] = entry
+	consumer.Pending++
+
+	pos, _ := slices.BinarySearchFunc(g.pendingIDs, id, StreamID.Compare)
+	g.pendingIDs = slices.Insert(g.pendingIDs, pos, id)
+
+	return entry
+}
+
+// Claim transfers pending entry to consumer without changing delivery info.
+func (g *ConsumerGroup) Claim(entry *PendingEntry, consumer *Consumer) {
+	g.transfer(entry, consumer)
+}
+
+func (g *ConsumerGroup) transfer(entry *PendingEntry, consumer *Consumer) {
+	if entry.Consumer == consumer.Name {
+		return
+	}
+	if owner, ok := g.consumers[entry.Consumer]; ok {
+		owner.Pending--
+	}
+	entry.Consumer = consumer.Name
+	consumer.Pending++
+}
+
+// Ack removes entry from pending ones. It returns false if entry is not pending.
+func (g *ConsumerGroup) Ack(id StreamID) bool {
+	entry, ok := g.pending[id]
+	if !ok {
+		return false
+	}
+
+	if owner, ok := g.consumers[entry.Consumer]; ok {
+		owner.Pending--
+	}
+	delete(g.pending, id)
+	pos, _ := slices.BinarySearchFunc(g.pendingIDs, id, StreamID.Compare)
+	g.pendingIDs = slices.Delete(g.pendingIDs, pos, pos+1)
+
+	return true
+}
+
+// PendingRange returns pending entries with IDs in range [start, end] in ascending order
+// for which filter returns true. Nil filter accepts all entries. Zero count means no limit.
+func (g *ConsumerGroup) PendingRange(start, end StreamID, count int, filter func(*PendingEntry) bool) []*PendingEntry {
+	result := []*PendingEntry{}
+	g.RangePending(start, func(entry *PendingEntry) bool {
+		if entry.ID.Compare(end) > 0 || (count > 0 && len(result) == count) {
+			return false
+		}
+		if filter == nil || filter(entry) {
+			result = append(result, entry)
+		}
+		return true
+	})
+
+	return result
+}
+
+// RangePending calls fn for every pending entry with ID greater than or equal to start
+// in ascending order until fn returns false. Pending entries MUST NOT be added or removed
+// during iteration.
+func (g *ConsumerGroup) RangePending(start StreamID, fn func(*PendingEntry) bool) {
+	pos, _ := slices.BinarySearchFunc(g.pendingIDs, start, StreamID.Compare)
+	for _, id := range g.pendingIDs[pos:] {
+		if !fn(g.pending[id]) {
+			return
+		}
+	}
+}
+
+// PendingBounds returns the smallest and the greatest IDs of pending entries.
+// It returns false if there are no pending entries.
+func (g *ConsumerGroup) PendingBounds() (StreamID, StreamID, bool) {
+	if len(g.pendingIDs) == 0 {
+		return StreamID{}, StreamID{}, false
+	}
+	return g.pendingIDs[0], g.pendingIDs[len(g.pendingIDs)-1], true
+}
+
+func (g *ConsumerGroup) clone() *ConsumerGroup {
+	clone := &ConsumerGroup{
+		LastID:     g.LastID,
+		pending:    make(map[StreamID]*PendingEntry, len(g.pending)),
+		pendingIDs: slices.Clone(g.pendingIDs),
+		consumers:  make(map[string]*Consumer, len(g.consumers)),
+	}
+	for id, entry := range g.pending {
+		entryClone := *entry
+		clone.pending[id] = &entryClone
+	}
+	for name, consumer := range g.consumers {
+		consumerClone := *consumer
+		clone.consumers[name] = &consumerClone
+	}
+
+	return clone
+}
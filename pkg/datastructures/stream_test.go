@@ -0,0 +1,156 @@
+package datastructures
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pendingIDs(entries []*PendingEntry) []uint64 {
+	result := []uint64{}
+	for _, e := range entries {
+		result = append(result, e.ID.Ms)
+	}
+	return result
+}
+
+func TestStreamNextID(t *testing.T) {
+	s := NewStream()
+
+	next, ok := s.NextID(0, true)
+	assert.True(t, ok)
+	assert.Equal(t, StreamID{Ms: 0, Seq: 1}, next)
+
+	s.Add(StreamID{Ms: 5, Seq: 3}, []string{"f", "v"})
+
+	var tests = []struct {
+		name    string
+		ms      uint64
+		seqOnly bool
+		want    StreamID
+		ok      bool
+	}{
+		{name: "Later time", ms: 7, want: StreamID{Ms: 7}, ok: true},
+		{name: "Same time", ms: 5, want: StreamID{Ms: 5, Seq: 4}, ok: true},
+		{name: "Clock went backwards", ms: 1, want: StreamID{Ms: 5, Seq: 4}, ok: true},
+		{name: "Explicit time with sequence", ms: 5, seqOnly: true, want: StreamID{Ms: 5, Seq: 4}, ok: true},
+		{name: "Explicit earlier time", ms: 4, seqOnly: true, ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := s.NextID(test.ms, test.seqOnly)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	// last ID is kept after the entry is deleted
+	require.True(t, s.Delete(StreamID{Ms: 5, Seq: 3}))
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, StreamID{Ms: 5, Seq: 3}, s.LastID)
+}
+
+func TestConsumerGroupAckClaim(t *testing.T) {
+	s := NewStream()
+	for ms := uint64(1); ms <= 5; ms++ {
+		s.Add(id(ms), []string{"f", "v"})
+	}
+	require.True(t, s.CreateGroup("group", StreamID{}))
+	assert.False(t, s.CreateGroup("group", StreamID{}))
+	g := s.Group("group")
+
+	start := time.Unix(1000, 0)
+	alice, created := g.AddConsumer("alice", start)
+	require.True(t, created)
+	bob, _ := g.AddConsumer("bob", start)
+
+	// entries are delivered to alice out of order and then to bob
+	for _, ms := range []uint64{3, 1, 2} {
+		g.AddPending(id(ms), alice, start)
+	}
+	g.AddPending(id(4), bob, start)
+	g.LastID = id(4)
+
+	assert.Equal(t, 4, g.PendingLen())
+	assert.Equal(t, 3, alice.Pending)
+	assert.Equal(t, 1, bob.Pending)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, pendingIDs(g.PendingRange(StreamID{}, MaxStreamID, 0, nil)))
+
+	// XCLAIM transfers entry and, unless it's JUSTID, counts delivery
+	later := start.Add(time.Minute)
+	claimed := g.AddPending(id(2), bob, later)
+	assert.Equal(t, "bob", claimed.Consumer)
+	assert.Equal(t, 2, claimed.Deliveries)
+	assert.Equal(t, later, claimed.DeliveredAt)
+
+	g.Claim(g.Pending(id(3)), bob)
+	assert.Equal(t, "bob", g.Pending(id(3)).Consumer)
+	assert.Equal(t, 1, g.Pending(id(3)).Deliveries)
+	assert.Equal(t, start, g.Pending(id(3)).DeliveredAt)
+
+	// claiming by the owner changes nothing
+	g.Claim(g.Pending(id(3)), bob)
+
+	assert.Equal(t, 1, alice.Pending)
+	assert.Equal(t, 3, bob.Pending)
+	assert.Equal(t, []uint64{2, 3, 4}, pendingIDs(g.PendingRange(StreamID{}, MaxStreamID, 0, func(e *PendingEntry) bool {
+		return e.Consumer == "bob"
+	})))
+
+	// XACK
+	assert.True(t, g.Ack(id(1)))
+	assert.False(t, g.Ack(id(1)))
+	assert.False(t, g.Ack(id(5)))
+	assert.True(t, g.Ack(id(3)))
+	assert.Nil(t, g.Pending(id(1)))
+	assert.Equal(t, 0, alice.Pending)
+	assert.Equal(t, 2, bob.Pending)
+	assert.Equal(t, 2, g.PendingLen())
+
+	first, last, ok := g.PendingBounds()
+	assert.True(t, ok)
+	assert.Equal(t, id(2), first)
+	assert.Equal(t, id(4), last)
+	assert.Equal(t, []uint64{4}, pendingIDs(g.PendingRange(id(3), MaxStreamID, 0, nil)))
+	assert.Equal(t, []uint64{2}, pendingIDs(g.PendingRange(StreamID{}, MaxStreamID, 1, nil)))
+
+	// consumers are kept after all their entries are acknowledged
+	assert.Equal(t, []string{"alice", "bob"}, []string{g.Consumers()[0].Name, g.Consumers()[1].Name})
+
+	deleted, ok := g.DeleteConsumer("bob")
+	assert.True(t, ok)
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, 0, g.PendingLen())
+	_, _, ok = g.PendingBounds()
+	assert.False(t, ok)
+	assert.Nil(t, g.Consumer("bob"))
+	_, ok = g.DeleteConsumer("bob")
+	assert.False(t, ok)
+}
+
+func TestStreamClone(t *testing.T) {
+	s := NewStream()
+	s.Add(id(1), []string{"f", "v"})
+	s.Add(id(2), []string{"f", "v"})
+	s.CreateGroup("group", StreamID{})
+	g := s.Group("group")
+	alice, _ := g.AddConsumer("alice", time.Unix(1000, 0))
+	g.AddPending(id(1), alice, time.Unix(1000, 0))
+
+	clone := s.Clone()
+	cg := clone.Group("group")
+	cg.AddPending(id(1), cg.Consumer("alice"), time.Unix(2000, 0))
+	cg.Ack(id(1))
+	cg.Consumer("alice").Pending = 10
+	clone.Delete(id(2))
+	clone.CreateGroup("other", StreamID{})
+
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, []string{"group"}, s.GroupNames())
+	assert.Equal(t, 1, g.PendingLen())
+	assert.Equal(t, 1, g.Pending(id(1)).Deliveries)
+	assert.Equal(t, 1, alice.Pending)
+}
@@ -0,0 +1,225 @@
+package datastructures
+
+import (
+	"slices"
+	"sort"
+)
+
+var (
+	// streamNodeSize is the max number of entries in a single node of StreamLog.
+	// It's the same as default stream-node-max-entries in Redis.
+	streamNodeSize = 100
+)
+
+// StreamEntry is a single entry of stream. Fields holds field names and values one after another.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// streamNode is a chunk of consecutive entries of StreamLog.
+type streamNode struct {
+	entries []StreamEntry
+}
+
+func (n *streamNode) lastID() StreamID {
+	return n.entries[len(n.entries)-1].ID
+}
+
+// StreamLog is an append-only log of entries ordered by ID.
+// Entries are stored in nodes of limited size like in Redis, where nodes
+// are listpacks indexed by radix tree. So appending is O(1), while lookup
+// and deletion take O(log n + node size) time.
+type StreamLog struct {
+	nodes  []*streamNode
+	length int
+}
+
+// NewStreamLog is a constructor for StreamLog.
+func NewStreamLog() *StreamLog {
+	return &StreamLog{}
+}
+
+// Len returns number of entries in the log.
+func (sl *StreamLog) Len() int {
+	return sl.length
+}
+
+// First returns the first entry. It returns false if the log is empty.
+func (sl *StreamLog) First() (StreamEntry, bool) {
+	if sl.length == 0 {
+		return StreamEntry{}, false
+	}
+	return sl.nodes[0].entries[0], true
+}
+
+// Last returns the last entry. It returns false if the log is empty.
+func (sl *StreamLog) Last() (StreamEntry, bool) {
+	if sl.length == 0 {
+		return StreamEntry{}, false
+	}
+	last := sl.nodes[len(sl.nodes)-1]
+	return last.entries[len(last.entries)-1], true
+}
+
+// Append adds entry to the end of the log.
+// Caller MUST make sure that its ID is greater than IDs of all entries in the log.
+func (sl *StreamLog) Append(entry StreamEntry) {
+	if len(sl.nodes) == 0 || len(sl.nodes[len(sl.nodes)-1].entries) >= streamNodeSize {
+		sl.nodes = append(sl.nodes, &streamNode{
+			entries: make([]StreamEntry, 0, streamNodeSize),
+		})
+	}
+
+	last := sl.nodes[len(sl.nodes)-1]
+	last.entries = append(last.entries, entry)
+	sl.length++
+}
+
+// seek returns position of the first entry with ID greater than or equal to id.
+// Position is node index and entry index in node. Node index is len(nodes) if there is no such entry.
+func (sl *StreamLog) seek(id StreamID) (int, int) {
+	node := sort.Search(len(sl.nodes), func(i int) bool {
+		return sl.nodes[i].lastID().Compare(id) >= 0
+	})
+	if node == len(sl.nodes) {
+		return node, 0
+	}
+
+	entries := sl.nodes[node].entries
+	entry := sort.Search(len(entries), func(i int) bool {
+		return entries[i].ID.Compare(id) >= 0
+	})
+	return node, entry
+}
+
+// Get returns entry with given ID. It returns false if there is no such entry.
+func (sl *StreamLog) Get(id StreamID) (StreamEntry, bool) {
+	node, entry := sl.seek(id)
+	if node == len(sl.nodes) || sl.nodes[node].entries[entry].ID != id {
+		return StreamEntry{}, false
+	}
+	return sl.nodes[node].entries[entry], true
+}
+
+// Delete deletes entry with given ID. It returns false if there is no such entry.
+func (sl *StreamLog) Delete(id StreamID) bool {
+	node, entry := sl.seek(id)
+	if node == len(sl.nodes) || sl.nodes[node].entries[entry].ID != id {
+		return false
+	}
+
+	n := sl.nodes[node]
+	n.entries = slices.Delete(n.entries, entry, entry+1)
+	if len(n.entries) == 0 {
+		sl.nodes = slices.Delete(sl.nodes, node, node+1)
+	}
+	sl.length--
+
+	return true
+}
+
+// Range returns entries with IDs in range [start, end] in ascending order
+// (or in descending order if reverse is true). Zero count means no limit.
+func (sl *StreamLog) Range(start, end StreamID, reverse bool, count int) []StreamEntry {
+	result := []StreamEntry{}
+	if start.Compare(end) > 0 {
+		return result
+	}
+
+	if !reverse {
+		for node, entry := sl.seek(start); node < len(sl.nodes); node, entry = node+1, 0 {
+			for _, e := range sl.nodes[node].entries[entry:] {
+				if e.ID.Compare(end) > 0 || (count > 0 && len(result) == count) {
+					return result
+				}
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+
+	// walk backward starting before the first entry after end
+	node, entry := len(sl.nodes), 0
+	if next, ok := end.Next(); ok {
+		node, entry = sl.seek(next)
+	}
+	for count <= 0 || len(result) < count {
+		if entry == 0 {
+			if node == 0 {
+				break
+			}
+			node--
+			entry = len(sl.nodes[node].entries)
+		}
+		entry--
+
+		e := sl.nodes[node].entries[entry]
+		if e.ID.Compare(start) < 0 {
+			break
+		}
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// TrimMaxLen deletes the oldest entries until there are no more than maxLen ones.
+// No more than limit entries are deleted, zero limit means no limit.
+// It returns number of deleted entries.
+func (sl *StreamLog) TrimMaxLen(maxLen, limit int) int {
+	toDelete := max(sl.length-maxLen, 0)
+	if limit > 0 {
+		toDelete = min(toDelete, limit)
+	}
+
+	return sl.trim(toDelete)
+}
+
+// TrimMinID deletes entries with IDs less than minID.
+// No more than limit entries are deleted, zero limit means no limit.
+// It returns number of deleted entries.
+func (sl *StreamLog) TrimMinID(minID StreamID, limit int) int {
+	node, entry := sl.seek(minID)
+	toDelete := entry
+	for _, n := range sl.nodes[:node] {
+		toDelete += len(n.entries)
+	}
+	if limit > 0 {
+		toDelete = min(toDelete, limit)
+	}
+
+	return sl.trim(toDelete)
+}
+
+// trim deletes n the oldest entries and returns n.
+func (sl *StreamLog) trim(n int) int {
+	left := n
+	for left > 0 {
+		first := sl.nodes[0]
+		if len(first.entries) > left {
+			first.entries = slices.Delete(first.entries, 0, left)
+			break
+		}
+		left -= len(first.entries)
+		sl.nodes = slices.Delete(sl.nodes, 0, 1)
+	}
+	sl.length -= n
+
+	return n
+}
+
+// Clone returns copy of the log. Entries are shared, since they are never modified.
+func (sl *StreamLog) Clone() *StreamLog {
+	clone := &StreamLog{
+		nodes:  make([]*streamNode, len(sl.nodes)),
+		length: sl.length,
+	}
+	for i, n := range sl.nodes {
+		clone.nodes[i] = &streamNode{
+			entries: slices.Clone(n.entries),
+		}
+	}
+
+	return clone
+}
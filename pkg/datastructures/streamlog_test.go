@@ -0,0 +1,179 @@
+package datastructures
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStreamLog returns log of n entries with IDs 2-0, 4-0, 6-0 and so on,
+// so that there are no entries with odd IDs. Entries are also returned.
+func newTestStreamLog(n int) (*StreamLog, []StreamEntry) {
+	sl := NewStreamLog()
+	entries := []StreamEntry{}
+	for i := range n {
+		entry := StreamEntry{ID: StreamID{Ms: uint64(2 * (i + 1))}, Fields: []string{"i", "v"}}
+		sl.Append(entry)
+		entries = append(entries, entry)
+	}
+	return sl, entries
+}
+
+func id(ms uint64) StreamID {
+	return StreamID{Ms: ms}
+}
+
+// checkStreamLog checks that log holds exactly want entries.
+func checkStreamLog(t *testing.T, sl *StreamLog, want []StreamEntry) {
+	t.Helper()
+
+	require.Equal(t, len(want), sl.Len())
+	require.Equal(t, want, sl.Range(StreamID{}, MaxStreamID, false, 0))
+
+	reversed := slices.Clone(want)
+	slices.Reverse(reversed)
+	require.Equal(t, reversed, sl.Range(StreamID{}, MaxStreamID, true, 0))
+
+	first, ok := sl.First()
+	require.Equal(t, len(want) > 0, ok)
+	last, _ := sl.Last()
+	if len(want) > 0 {
+		assert.Equal(t, want[0], first)
+		assert.Equal(t, want[len(want)-1], last)
+	}
+
+	total := 0
+	for _, n := range sl.nodes {
+		require.NotEmpty(t, n.entries)
+		total += len(n.entries)
+	}
+	require.Equal(t, len(want), total)
+}
+
+func TestStreamLogSeek(t *testing.T) {
+	// entries 2-0 to 200-0 are in the first node, 202-0 to 400-0 in the second one
+	sl, entries := newTestStreamLog(2*streamNodeSize + 50)
+	require.Len(t, sl.nodes, 3)
+	checkStreamLog(t, sl, entries)
+
+	var tests = []struct {
+		name       string
+		start, end StreamID
+		reverse    bool
+		count      int
+		want       []uint64
+	}{
+		{name: "Across nodes", start: id(196), end: id(206), want: []uint64{196, 198, 200, 202, 204, 206}},
+		{name: "Start between nodes", start: id(201), end: id(205), want: []uint64{202, 204}},
+		{name: "End between nodes", start: id(197), end: id(201), want: []uint64{198, 200}},
+		{name: "Count across nodes", start: id(199), end: MaxStreamID, count: 3, want: []uint64{200, 202, 204}},
+		{name: "Reverse across nodes", start: id(197), end: id(203), reverse: true, want: []uint64{202, 200, 198}},
+		{name: "Reverse end between nodes", start: id(190), end: id(201), reverse: true, count: 2, want: []uint64{200, 198}},
+		{name: "Reverse from the end", start: id(495), end: MaxStreamID, reverse: true, want: []uint64{500, 498, 496}},
+		{name: "Before the first", start: StreamID{}, end: id(1), want: []uint64{}},
+		{name: "After the last", start: id(501), end: MaxStreamID, want: []uint64{}},
+		{name: "Start after end", start: id(10), end: id(8), want: []uint64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []uint64{}
+			for _, e := range sl.Range(test.start, test.end, test.reverse, test.count) {
+				got = append(got, e.ID.Ms)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	for _, ms := range []uint64{2, 200, 202, 400, 402, 500} {
+		entry, ok := sl.Get(id(ms))
+		assert.True(t, ok, ms)
+		assert.Equal(t, id(ms), entry.ID)
+	}
+	for _, ms := range []uint64{0, 1, 201, 401, 501} {
+		_, ok := sl.Get(id(ms))
+		assert.False(t, ok, ms)
+	}
+}
+
+func TestStreamLogDelete(t *testing.T) {
+	sl, entries := newTestStreamLog(2*streamNodeSize + 1)
+	require.Len(t, sl.nodes, 3)
+
+	// the last entry of the first node
+	require.True(t, sl.Delete(id(200)))
+	assert.False(t, sl.Delete(id(200)))
+	assert.False(t, sl.Delete(id(201)))
+	entries = slices.Delete(entries, 99, 100)
+	checkStreamLog(t, sl, entries)
+
+	// the whole second node
+	for ms := uint64(202); ms <= 400; ms += 2 {
+		require.True(t, sl.Delete(id(ms)))
+	}
+	entries = slices.Delete(entries, 99, 199)
+	require.Len(t, sl.nodes, 2)
+	checkStreamLog(t, sl, entries)
+	assert.Equal(t, []StreamEntry{entries[98], entries[99]}, sl.Range(id(198), id(402), false, 0))
+
+	// the only entry of the last node
+	require.True(t, sl.Delete(id(402)))
+	entries = entries[:len(entries)-1]
+	require.Len(t, sl.nodes, 1)
+	checkStreamLog(t, sl, entries)
+
+	// appended entry gets into the node with free space
+	entry := StreamEntry{ID: id(1000), Fields: []string{"f", "v"}}
+	sl.Append(entry)
+	entries = append(entries, entry)
+	checkStreamLog(t, sl, entries)
+
+	for _, e := range entries {
+		require.True(t, sl.Delete(e.ID))
+	}
+	checkStreamLog(t, sl, []StreamEntry{})
+	assert.Empty(t, sl.nodes)
+}
+
+func TestStreamLogTrim(t *testing.T) {
+	size := 2*streamNodeSize + 50
+
+	var tests = []struct {
+		name        string
+		trim        func(sl *StreamLog) int
+		wantDeleted int
+	}{
+		{name: "MaxLen", trim: func(sl *StreamLog) int { return sl.TrimMaxLen(120, 0) }, wantDeleted: size - 120},
+		{name: "MaxLen whole nodes", trim: func(sl *StreamLog) int { return sl.TrimMaxLen(50, 0) }, wantDeleted: size - 50},
+		{name: "MaxLen with limit", trim: func(sl *StreamLog) int { return sl.TrimMaxLen(0, 30) }, wantDeleted: 30},
+		{name: "MaxLen greater than length", trim: func(sl *StreamLog) int { return sl.TrimMaxLen(size+1, 0) }, wantDeleted: 0},
+		{name: "MaxLen zero", trim: func(sl *StreamLog) int { return sl.TrimMaxLen(0, 0) }, wantDeleted: size},
+		{name: "MinID", trim: func(sl *StreamLog) int { return sl.TrimMinID(id(202), 0) }, wantDeleted: 100},
+		{name: "MinID between entries", trim: func(sl *StreamLog) int { return sl.TrimMinID(id(251), 0) }, wantDeleted: 125},
+		{name: "MinID with limit", trim: func(sl *StreamLog) int { return sl.TrimMinID(id(401), 150) }, wantDeleted: 150},
+		{name: "MinID before the first", trim: func(sl *StreamLog) int { return sl.TrimMinID(id(1), 0) }, wantDeleted: 0},
+		{name: "MinID after the last", trim: func(sl *StreamLog) int { return sl.TrimMinID(MaxStreamID, 0) }, wantDeleted: size},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sl, entries := newTestStreamLog(size)
+
+			assert.Equal(t, test.wantDeleted, test.trim(sl))
+			checkStreamLog(t, sl, entries[test.wantDeleted:])
+		})
+	}
+}
+
+func TestStreamLogClone(t *testing.T) {
+	sl, entries := newTestStreamLog(150)
+
+	clone := sl.Clone()
+	require.True(t, clone.Delete(id(2)))
+	clone.TrimMaxLen(10, 0)
+	clone.Append(StreamEntry{ID: id(1000)})
+
+	checkStreamLog(t, sl, entries)
+}
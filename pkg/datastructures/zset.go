@@ -0,0 +1,212 @@
+package datastructures
+
+// SortedSet is a set of members ordered by their scores.
+// Scores are kept in a hash table for O(1) lookups and members are ordered by SkipList.
+type SortedSet struct {
+	scores map[string]float64
+	list   *SkipList
+}
+
+// NewSortedSet is a constructor for SortedSet.
+func NewSortedSet() *SortedSet {
+	return &SortedSet{
+		scores: map[string]float64{},
+		list:   NewSkipList(),
+	}
+}
+
+// Len returns number of members in the set.
+func (zs *SortedSet) Len() int {
+	return len(zs.scores)
+}
+
+// Score returns score of the member. It returns false if there is no such member.
+func (zs *SortedSet) Score(member string) (float64, bool) {
+	score, ok := zs.scores[member]
+	return score, ok
+}
+
+// Add adds member with given score or updates score of existing member.
+// It returns true if member was added.
+func (zs *SortedSet) Add(member string, score float64) bool {
+	old, ok := zs.scores[member]
+	if ok {
+		if old == score {
+			return false
+		}
+		zs.list.Delete(old, member)
+	}
+
+	zs.scores[member] = score
+	zs.list.Insert(score, member)
+
+	return !ok
+}
+
+// Remove deletes member from the set. It returns false if there is no such member.
+func (zs *SortedSet) Remove(member string) bool {
+	score, ok := zs.scores[member]
+	if !ok {
+		return false
+	}
+
+	delete(zs.scores, member)
+	zs.list.Delete(score, member)
+
+	return true
+}
+
+// Rank returns 0-based position of the member in ascending order
+// (or in descending order if reverse is true). It returns false if there is no such member.
+func (zs *SortedSet) Rank(member string, reverse bool) (int, bool) {
+	score, ok := zs.scores[member]
+	if !ok {
+		return 0, false
+	}
+
+	rank, _ := zs.list.Rank(score, member)
+	if reverse {
+		rank = zs.Len() - rank - 1
+	}
+
+	return rank, true
+}
+
+// RangeByRank returns members with ranks in range [start, stop].
+// Negative indexes are counted from the end. If reverse is true,
+// ranks are counted in descending order and members are returned in that order.
+func (zs *SortedSet) RangeByRank(start, stop int, reverse bool) []ScoredMember {
+	length := zs.Len()
+	if start < 0 {
+		start = max(start+length, 0)
+	}
+	if stop < 0 {
+		stop += length
+	}
+	stop = min(stop, length-1)
+
+	if start > stop {
+		return []ScoredMember{}
+	}
+
+	rank := start
+	if reverse {
+		rank = length - start - 1
+	}
+	return collect(zs.list.ByRank(rank), reverse, 0, stop-start+1, func(*SkipListNode) bool {
+		return true
+	})
+}
+
+// RangeByScore returns members with scores in range [min, max].
+// If reverse is true, members are returned in descending order.
+// First offset members are skipped and no more than count members are returned.
+// Negative offset makes range empty, negative count means no limit.
+func (zs *SortedSet) RangeByScore(min, max ScoreBound, reverse bool, offset, count int) []ScoredMember {
+	first := zs.list.FirstInScoreRange(min, max)
+	inRange := func(n *SkipListNode) bool {
+		return max.greaterOrEqual(n.Score)
+	}
+	if reverse {
+		first = zs.list.LastInScoreRange(min, max)
+		inRange = func(n *SkipListNode) bool {
+			return min.lessOrEqual(n.Score)
+		}
+	}
+
+	return collect(first, reverse, offset, count, inRange)
+}
+
+// RangeByLex returns members in lexicographical range [min, max].
+// It makes sense only if all members have the same score.
+// Other arguments have the same meaning as for RangeByScore.
+func (zs *SortedSet) RangeByLex(min, max LexBound, reverse bool, offset, count int) []ScoredMember {
+	first := zs.list.FirstInLexRange(min, max)
+	inRange := func(n *SkipListNode) bool {
+		return max.greaterOrEqual(n.Member)
+	}
+	if reverse {
+		first = zs.list.LastInLexRange(min, max)
+		inRange = func(n *SkipListNode) bool {
+			return min.lessOrEqual(n.Member)
+		}
+	}
+
+	return collect(first, reverse, offset, count, inRange)
+}
+
+// collect walks from node forward (or backward if reverse is true) while nodes are in range.
+// It skips offset nodes and collects no more than count ones.
+// Negative offset makes result empty (as in Redis), negative count means no limit.
+func collect(node *SkipListNode, reverse bool, offset, count int, inRange func(*SkipListNode) bool) []ScoredMember {
+	result := []ScoredMember{}
+	if offset < 0 {
+		return result
+	}
+
+	next := (*SkipListNode).Next
+	if reverse {
+		next = (*SkipListNode).Prev
+	}
+
+	for ; node != nil && offset > 0 && inRange(node); node = next(node) {
+		offset--
+	}
+	for ; node != nil && count != 0 && inRange(node); node = next(node) {
+		result = append(result, node.ScoredMember)
+		count--
+	}
+
+	return result
+}
+
+// CountInScoreRange returns number of members with scores in range [min, max].
+func (zs *SortedSet) CountInScoreRange(min, max ScoreBound) int {
+	first := zs.list.FirstInScoreRange(min, max)
+	if first == nil {
+		return 0
+	}
+	last := zs.list.LastInScoreRange(min, max)
+
+	firstRank, _ := zs.list.Rank(first.Score, first.Member)
+	lastRank, _ := zs.list.Rank(last.Score, last.Member)
+
+	return lastRank - firstRank + 1
+}
+
+// Pop removes up to count members with the lowest scores (or the highest ones if max is true)
+// and returns them in order of removal.
+func (zs *SortedSet) Pop(count int, max bool) []ScoredMember {
+	if count <= 0 {
+		return []ScoredMember{}
+	}
+
+	popped := zs.RangeByRank(0, count-1, max)
+
+	for _, m := range popped {
+		zs.Remove(m.Member)
+	}
+
+	return popped
+}
+
+// Range calls fn for every member in ascending order until fn returns false.
+// Set MUST NOT be modified during iteration.
+func (zs *SortedSet) Range(fn func(member string, score float64) bool) {
+	for node := zs.list.First(); node != nil; node = node.Next() {
+		if !fn(node.Member, node.Score) {
+			return
+		}
+	}
+}
+
+// Clone returns copy of the set.
+func (zs *SortedSet) Clone() *SortedSet {
+	clone := NewSortedSet()
+	zs.Range(func(member string, score float64) bool {
+		clone.Add(member, score)
+		return true
+	})
+
+	return clone
+}
@@ -0,0 +1,190 @@
+package datastructures
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSortedSet returns set of members "a" to "f" with scores 1 to 6.
+func newTestSortedSet() *SortedSet {
+	zs := NewSortedSet()
+	for i, m := range []string{"a", "b", "c", "d", "e", "f"} {
+		zs.Add(m, float64(i+1))
+	}
+	return zs
+}
+
+func memberNames(scored []ScoredMember) []string {
+	result := []string{}
+	for _, m := range scored {
+		result = append(result, m.Member)
+	}
+	return result
+}
+
+func TestSortedSetUpdateScore(t *testing.T) {
+	zs := newTestSortedSet()
+
+	assert.False(t, zs.Add("a", 10))
+	assert.False(t, zs.Add("b", 2))
+	assert.True(t, zs.Add("g", 0))
+	assert.Equal(t, 7, zs.Len())
+
+	score, ok := zs.Score("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, score)
+	assert.Equal(t, []string{"g", "b", "c", "d", "e", "f", "a"}, memberNames(zs.RangeByRank(0, -1, false)))
+
+	var tests = []struct {
+		member   string
+		rank     int
+		revRank  int
+		notFound bool
+	}{
+		{member: "g", rank: 0, revRank: 6},
+		{member: "a", rank: 6, revRank: 0},
+		{member: "d", rank: 3, revRank: 3},
+		{member: "missing", notFound: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.member, func(t *testing.T) {
+			rank, ok := zs.Rank(test.member, false)
+			assert.Equal(t, !test.notFound, ok)
+			assert.Equal(t, test.rank, rank)
+
+			rank, ok = zs.Rank(test.member, true)
+			assert.Equal(t, !test.notFound, ok)
+			assert.Equal(t, test.revRank, rank)
+		})
+	}
+}
+
+func TestSortedSetRankAfterRemove(t *testing.T) {
+	zs := newTestSortedSet()
+
+	assert.True(t, zs.Remove("a"))
+	assert.True(t, zs.Remove("d"))
+	assert.False(t, zs.Remove("d"))
+	assert.Equal(t, 4, zs.Len())
+
+	for i, m := range []string{"b", "c", "e", "f"} {
+		rank, ok := zs.Rank(m, false)
+		assert.True(t, ok)
+		assert.Equal(t, i, rank, m)
+	}
+	_, ok := zs.Rank("a", false)
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"e", "c"}, memberNames(zs.RangeByRank(1, 2, true)))
+	assert.Equal(t, 2, zs.CountInScoreRange(ScoreBound{Value: 2}, ScoreBound{Value: 4}))
+}
+
+func TestSortedSetRangeByRank(t *testing.T) {
+	zs := newTestSortedSet()
+
+	var tests = []struct {
+		name        string
+		start, stop int
+		reverse     bool
+		want        []string
+	}{
+		{name: "All", start: 0, stop: -1, want: []string{"a", "b", "c", "d", "e", "f"}},
+		{name: "Negative", start: -2, stop: -1, want: []string{"e", "f"}},
+		{name: "Out of range", start: -100, stop: 100, want: []string{"a", "b", "c", "d", "e", "f"}},
+		{name: "Empty", start: 3, stop: 2, want: []string{}},
+		{name: "Start after end", start: 6, stop: 10, want: []string{}},
+		{name: "Reverse", start: 0, stop: 1, reverse: true, want: []string{"f", "e"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, memberNames(zs.RangeByRank(test.start, test.stop, test.reverse)))
+		})
+	}
+}
+
+func TestSortedSetRangeByScore(t *testing.T) {
+	zs := newTestSortedSet()
+	inf := ScoreBound{Value: math.Inf(1)}
+	negInf := ScoreBound{Value: math.Inf(-1)}
+
+	var tests = []struct {
+		name          string
+		min, max      ScoreBound
+		reverse       bool
+		offset, count int
+		want          []string
+	}{
+		{name: "Inclusive", min: ScoreBound{Value: 2}, max: ScoreBound{Value: 4}, count: -1, want: []string{"b", "c", "d"}},
+		{name: "Exclusive min", min: ScoreBound{Value: 2, Exclusive: true}, max: ScoreBound{Value: 4}, count: -1, want: []string{"c", "d"}},
+		{name: "Exclusive max", min: ScoreBound{Value: 2}, max: ScoreBound{Value: 4, Exclusive: true}, count: -1, want: []string{"b", "c"}},
+		{name: "Exclusive both", min: ScoreBound{Value: 2, Exclusive: true}, max: ScoreBound{Value: 4, Exclusive: true}, count: -1, want: []string{"c"}},
+		{name: "Exclusive equal", min: ScoreBound{Value: 3, Exclusive: true}, max: ScoreBound{Value: 3}, count: -1, want: []string{}},
+		{name: "Between scores", min: ScoreBound{Value: 2.5}, max: ScoreBound{Value: 3.5}, count: -1, want: []string{"c"}},
+		{name: "Infinite", min: negInf, max: inf, count: -1, want: []string{"a", "b", "c", "d", "e", "f"}},
+		{name: "Min above max", min: ScoreBound{Value: 5}, max: ScoreBound{Value: 1}, count: -1, want: []string{}},
+		{name: "Reverse", min: ScoreBound{Value: 2}, max: ScoreBound{Value: 4, Exclusive: true}, reverse: true, count: -1, want: []string{"c", "b"}},
+		{name: "Limit", min: negInf, max: inf, offset: 1, count: 2, want: []string{"b", "c"}},
+		{name: "Limit reverse", min: negInf, max: inf, reverse: true, offset: 1, count: 2, want: []string{"e", "d"}},
+		{name: "Limit zero count", min: negInf, max: inf, count: 0, want: []string{}},
+		{name: "Offset past range", min: ScoreBound{Value: 1}, max: ScoreBound{Value: 2}, offset: 2, count: -1, want: []string{}},
+		{name: "Negative offset", min: negInf, max: inf, offset: -1, count: 2, want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := zs.RangeByScore(test.min, test.max, test.reverse, test.offset, test.count)
+			assert.Equal(t, test.want, memberNames(got))
+		})
+	}
+
+	assert.Equal(t, 3, zs.CountInScoreRange(ScoreBound{Value: 2}, ScoreBound{Value: 4}))
+	assert.Equal(t, 0, zs.CountInScoreRange(ScoreBound{Value: 3, Exclusive: true}, ScoreBound{Value: 3}))
+}
+
+func TestSortedSetRangeByLex(t *testing.T) {
+	zs := NewSortedSet()
+	for _, m := range []string{"a", "b", "c", "d", "e", "f"} {
+		zs.Add(m, 0)
+	}
+	inf := LexBound{Inf: 1}
+	negInf := LexBound{Inf: -1}
+
+	var tests = []struct {
+		name          string
+		min, max      LexBound
+		reverse       bool
+		offset, count int
+		want          []string
+	}{
+		{name: "Inclusive", min: LexBound{Value: "b"}, max: LexBound{Value: "d"}, count: -1, want: []string{"b", "c", "d"}},
+		{name: "Exclusive", min: LexBound{Value: "b", Exclusive: true}, max: LexBound{Value: "d", Exclusive: true}, count: -1, want: []string{"c"}},
+		{name: "Prefix", min: LexBound{Value: "bb"}, max: LexBound{Value: "d"}, count: -1, want: []string{"c", "d"}},
+		{name: "Infinite", min: negInf, max: inf, count: -1, want: []string{"a", "b", "c", "d", "e", "f"}},
+		{name: "Up to", min: negInf, max: LexBound{Value: "c", Exclusive: true}, count: -1, want: []string{"a", "b"}},
+		{name: "Empty", min: inf, max: negInf, count: -1, want: []string{}},
+		{name: "Reverse", min: LexBound{Value: "b"}, max: inf, reverse: true, count: -1, want: []string{"f", "e", "d", "c", "b"}},
+		{name: "Limit reverse", min: negInf, max: LexBound{Value: "e", Exclusive: true}, reverse: true, offset: 1, count: 2, want: []string{"c", "b"}},
+		{name: "Negative offset", min: negInf, max: inf, offset: -3, count: -1, want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := zs.RangeByLex(test.min, test.max, test.reverse, test.offset, test.count)
+			assert.Equal(t, test.want, memberNames(got))
+		})
+	}
+}
+
+func TestSortedSetPop(t *testing.T) {
+	zs := newTestSortedSet()
+
+	assert.Equal(t, []ScoredMember{{Member: "f", Score: 6}, {Member: "e", Score: 5}}, zs.Pop(2, true))
+	assert.Equal(t, []ScoredMember{{Member: "a", Score: 1}}, zs.Pop(1, false))
+	assert.Equal(t, []ScoredMember{}, zs.Pop(0, false))
+	assert.Equal(t, []string{"b", "c", "d"}, memberNames(zs.Pop(10, false)))
+	assert.Equal(t, 0, zs.Len())
+}
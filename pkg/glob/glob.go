@@ -0,0 +1,123 @@
+// Package glob implements glob-style pattern matching used by Redis commands (KEYS, SCAN, CONFIG GET, etc.).
+package glob
+
+import "unicode"
+
+// Match reports whether str matches the pattern. Supported syntax:
+//   - '*' matches any sequence of characters (including empty one);
+//   - '?' matches any single character;
+//   - "[abc]", "[a-z]" match one character from the set or range, "[^abc]" negates the set;
+//   - '\' escapes the next character.
+//
+// If nocase is true, letters are compared case-insensitively.
+func Match(pattern, str string, nocase bool) bool {
+	p, s := 0, 0
+	for p < len(pattern) {
+		switch pattern[p] {
+		case '*':
+			// collapse consecutive stars
+			for p+1 < len(pattern) && pattern[p+1] == '*' {
+				p++
+			}
+			if p+1 == len(pattern) {
+				return true
+			}
+			for ; s <= len(str); s++ {
+				if Match(pattern[p+1:], str[s:], nocase) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if s == len(str) {
+				return false
+			}
+			s++
+
+		case '[':
+			if s == len(str) {
+				return false
+			}
+
+			var matched bool
+			p, matched = matchSet(pattern, p+1, str[s], nocase)
+			if !matched {
+				return false
+			}
+			s++
+
+		case '\\':
+			if p+1 < len(pattern) {
+				p++
+			}
+			fallthrough
+
+		default:
+			if s == len(str) || !equal(pattern[p], str[s], nocase) {
+				return false
+			}
+			s++
+		}
+		p++
+	}
+
+	return s == len(str)
+}
+
+// matchSet checks whether c belongs to the set starting right after '['.
+// It returns position of closing ']' (or last position of pattern if set is not closed)
+// and result of the check.
+func matchSet(pattern string, p int, c byte, nocase bool) (int, bool) {
+	negate := p < len(pattern) && pattern[p] == '^'
+	if negate {
+		p++
+	}
+
+	matched := false
+	for ; p < len(pattern) && pattern[p] != ']'; p++ {
+		switch {
+		case pattern[p] == '\\' && p+1 < len(pattern):
+			p++
+			if equal(pattern[p], c, nocase) {
+				matched = true
+			}
+
+		case p+2 < len(pattern) && pattern[p+1] == '-' && pattern[p+2] != ']':
+			start, end := pattern[p], pattern[p+2]
+			if start > end {
+				start, end = end, start
+			}
+			if nocase {
+				start, end, c = lower(start), lower(end), lower(c)
+			}
+			if start <= c && c <= end {
+				matched = true
+			}
+			p += 2
+
+		default:
+			if equal(pattern[p], c, nocase) {
+				matched = true
+			}
+		}
+	}
+
+	// unclosed set is treated as if it's closed at the end of pattern
+	if p == len(pattern) {
+		p--
+	}
+
+	return p, matched != negate
+}
+
+func equal(a, b byte, nocase bool) bool {
+	if nocase {
+		return lower(a) == lower(b)
+	}
+	return a == b
+}
+
+func lower(c byte) byte {
+	return byte(unicode.ToLower(rune(c)))
+}
@@ -0,0 +1,49 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		str     string
+		nocase  bool
+		want    bool
+	}{
+		{name: "Exact", pattern: "hello", str: "hello", want: true},
+		{name: "Exact mismatch", pattern: "hello", str: "hellO", want: false},
+		{name: "Star matches everything", pattern: "*", str: "anything", want: true},
+		{name: "Star matches empty", pattern: "h*llo", str: "hllo", want: true},
+		{name: "Star in the middle", pattern: "h*llo", str: "heeeello", want: true},
+		{name: "Several stars", pattern: "*a*b**", str: "xxaxxbxx", want: true},
+		{name: "Star mismatch", pattern: "*a*b", str: "xxbxxa", want: false},
+		{name: "Question mark", pattern: "h?llo", str: "hallo", want: true},
+		{name: "Question mark needs char", pattern: "hello?", str: "hello", want: false},
+		{name: "Set", pattern: "h[ae]llo", str: "hello", want: true},
+		{name: "Set mismatch", pattern: "h[ae]llo", str: "hillo", want: false},
+		{name: "Negated set", pattern: "h[^e]llo", str: "hallo", want: true},
+		{name: "Negated set mismatch", pattern: "h[^e]llo", str: "hello", want: false},
+		{name: "Range", pattern: "h[a-c]llo", str: "hbllo", want: true},
+		{name: "Reversed range", pattern: "h[c-a]llo", str: "hbllo", want: true},
+		{name: "Range mismatch", pattern: "h[a-c]llo", str: "hdllo", want: false},
+		{name: "Escaped star", pattern: `h\*llo`, str: "h*llo", want: true},
+		{name: "Escaped star mismatch", pattern: `h\*llo`, str: "hello", want: false},
+		{name: "Escape in set", pattern: `[\]]`, str: "]", want: true},
+		{name: "Nocase", pattern: "MAX*", str: "maxclients", nocase: true, want: true},
+		{name: "Nocase range", pattern: "[A-C]", str: "b", nocase: true, want: true},
+		{name: "Empty pattern", pattern: "", str: "", want: true},
+		{name: "Empty pattern mismatch", pattern: "", str: "a", want: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, Match(test.pattern, test.str, test.nocase))
+		})
+	}
+}
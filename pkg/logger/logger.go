@@ -1,10 +1,31 @@
 package logger
 
-import "go.uber.org/zap"
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
 
 // Setup creates new logger instance.
-// TODO: add more options
 func Setup() *zap.Logger {
 	logger := zap.Must(zap.NewProduction())
 	return logger
 }
+
+// New creates logger with given level and format.
+// Format is either "json" or "console". Level can be changed later via the same AtomicLevel.
+func New(level zap.AtomicLevel, format string) (*zap.Logger, error) {
+	cfg := zap.NewProductionConfig()
+	cfg.Level = level
+
+	switch format {
+	case "json":
+	case "console":
+		cfg.Encoding = "console"
+		cfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+
+	return cfg.Build()
+}
@@ -14,3 +14,18 @@ func TestSetup(t *testing.T) {
 	assert.Equal(t, zap.InfoLevel, got.Level())
 	assert.Equal(t, "json", zap.NewProductionConfig().Encoding)
 }
+
+func TestNew(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.WarnLevel)
+
+	got, err := New(level, "console")
+	assert.NoError(t, err)
+	assert.Equal(t, zap.WarnLevel, got.Level())
+
+	// level is changed on the fly
+	level.SetLevel(zap.DebugLevel)
+	assert.Equal(t, zap.DebugLevel, got.Level())
+
+	_, err = New(level, "xml")
+	assert.Error(t, err)
+}
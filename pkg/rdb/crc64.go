@@ -0,0 +1,34 @@
+package rdb
+
+// crc64 is CRC-64 with Jones polynomial used by Redis to checksum RDB files.
+// Unlike hash/crc64, it has no initial and final inversion.
+type crc64 uint64
+
+// jonesPoly is the reflected Jones polynomial.
+const jonesPoly = 0x95ac9329ac4bc9b5
+
+var crc64Table = makeCRC64Table()
+
+func makeCRC64Table() *[256]uint64 {
+	table := new([256]uint64)
+	for i := range table {
+		crc := uint64(i)
+		for range 8 {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ jonesPoly
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}
+
+func (c crc64) update(p []byte) crc64 {
+	crc := uint64(c)
+	for _, b := range p {
+		crc = crc64Table[byte(crc)^b] ^ crc>>8
+	}
+	return crc64(crc)
+}
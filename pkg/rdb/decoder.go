@@ -0,0 +1,476 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// maxStringLen limits size of a single string, it's the same as in Redis.
+const maxStringLen = 512 * 1024 * 1024
+
+// checksumVersion is the first RDB version with checksum at the end of file.
+const checksumVersion = 5
+
+// Decoder reads RDB file record by record verifying its checksum at the end.
+type Decoder struct {
+	r       *bufio.Reader
+	crc     crc64
+	version int
+	db      int
+}
+
+// NewDecoder is a constructor for Decoder.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// ReadHeader reads magic string and checks RDB version.
+func (d *Decoder) ReadHeader() error {
+	header, err := d.read(len(magic) + 4)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(header, []byte(magic)) {
+		return fmt.Errorf("%w: wrong signature", ErrInvalidFormat)
+	}
+
+	version, err := strconv.Atoi(string(header[len(magic):]))
+	if err != nil || version < 1 {
+		return fmt.Errorf("%w: bad version", ErrInvalidFormat)
+	}
+	if version > maxVersion {
+		return fmt.Errorf("%w: version %d", ErrUnsupported, version)
+	}
+	d.version = version
+
+	return nil
+}
+
+// Next returns the next record and index of its database.
+// After the last record io.EOF is returned if checksum matches and ErrChecksum otherwise.
+func (d *Decoder) Next() (Record, int, error) {
+	var r Record
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return r, 0, err
+		}
+
+		switch Opcode(b) {
+		case OpcodeEOF:
+			return r, 0, d.verifyChecksum()
+		case OpcodeSelectDB:
+			db, err := d.readLength()
+			if err != nil {
+				return r, 0, err
+			}
+			d.db = int(db)
+		case OpcodeResizeDB:
+			// sizes are just hints
+			err = d.skipLengths(2)
+		case OpcodeSlotInfo:
+			err = d.skipLengths(3)
+		case OpcodeAux:
+			if _, err = d.readString(); err == nil {
+				_, err = d.readString()
+			}
+		case OpcodeExpireTimeMs:
+			r.ExpiresAt, err = d.readMillis()
+		case OpcodeExpireTime:
+			var seconds []byte
+			if seconds, err = d.read(4); err == nil {
+				r.ExpiresAt = time.Unix(int64(binary.LittleEndian.Uint32(seconds)), 0)
+			}
+		case OpcodeIdle:
+			// LRU and LFU info is not used
+			_, err = d.readLength()
+		case OpcodeFreq:
+			_, err = d.readByte()
+		case OpcodeFunction2:
+			// functions are not supported, so libraries are skipped
+			_, err = d.readString()
+		case OpcodeModuleAux, OpcodeFunction:
+			return r, 0, fmt.Errorf("%w: opcode 0x%X", ErrUnsupported, b)
+		default:
+			if r.Key, err = d.readString(); err != nil {
+				return r, 0, err
+			}
+			r.Value, err = d.readValue(Type(b))
+			return r, d.db, err
+		}
+		if err != nil {
+			return r, 0, err
+		}
+	}
+}
+
+func (d *Decoder) verifyChecksum() error {
+	if d.version < checksumVersion {
+		return io.EOF
+	}
+
+	expected := d.crc
+	raw, err := d.read(8)
+	if err != nil {
+		return err
+	}
+	// zero checksum means that checksum was disabled while saving
+	checksum := binary.LittleEndian.Uint64(raw)
+	if checksum != 0 && crc64(checksum) != expected {
+		return ErrChecksum
+	}
+	return io.EOF
+}
+
+// readValue reads value encoded as t.
+func (d *Decoder) readValue(t Type) (any, error) {
+	switch t {
+	case TypeString:
+		return d.readString()
+	case TypeList:
+		values, err := d.readStrings()
+		return List(values), err
+	case TypeSet:
+		members, err := d.readStrings()
+		return Set(members), err
+	case TypeHash:
+		pairs, err := d.readStrings(2)
+		if err != nil {
+			return nil, err
+		}
+		return pairsToHash(pairs), nil
+	case TypeZSet, TypeZSet2:
+		return d.readZSet(t)
+	case TypeListZiplist:
+		values, err := d.readPacked(decodeZiplist)
+		return List(values), err
+	case TypeListQuicklist, TypeListQuicklist2:
+		return d.readQuicklist(t)
+	case TypeSetIntset:
+		members, err := d.readPacked(decodeIntset)
+		return Set(members), err
+	case TypeSetListpack:
+		members, err := d.readPacked(decodeListpack)
+		return Set(members), err
+	case TypeHashZiplist, TypeHashListpack:
+		decode := decodeZiplist
+		if t == TypeHashListpack {
+			decode = decodeListpack
+		}
+		pairs, err := d.readPacked(decode)
+		if err != nil {
+			return nil, err
+		}
+		if len(pairs)%2 != 0 {
+			return nil, fmt.Errorf("%w: odd number of hash elements", ErrInvalidFormat)
+		}
+		return pairsToHash(pairs), nil
+	case TypeZSetZiplist, TypeZSetListpack:
+		decode := decodeZiplist
+		if t == TypeZSetListpack {
+			decode = decodeListpack
+		}
+		elems, err := d.readPacked(decode)
+		if err != nil {
+			return nil, err
+		}
+		return packedZSet(elems)
+	case TypeStreamListpacks, TypeStreamListpacks2, TypeStreamListpacks3:
+		return d.readStream(t)
+	default:
+		return nil, fmt.Errorf("%w: value type %d", ErrUnsupported, t)
+	}
+}
+
+func (d *Decoder) readZSet(t Type) (ZSet, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	zset := make(ZSet, 0, min(n, 1024))
+	for range n {
+		var m ZMember
+		if m.Member, err = d.readString(); err != nil {
+			return nil, err
+		}
+		if t == TypeZSet2 {
+			m.Score, err = d.readBinaryDouble()
+		} else {
+			m.Score, err = d.readStringDouble()
+		}
+		if err != nil {
+			return nil, err
+		}
+		zset = append(zset, m)
+	}
+	return zset, nil
+}
+
+// readQuicklist reads list stored as linked list of ziplists or listpacks.
+func (d *Decoder) readQuicklist(t Type) (List, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	list := List{}
+	for range n {
+		container := uint64(quicklistNodePacked)
+		if t == TypeListQuicklist2 {
+			if container, err = d.readLength(); err != nil {
+				return nil, err
+			}
+		}
+
+		data, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		switch {
+		case container == quicklistNodePlain:
+			values = []string{data}
+		case t == TypeListQuicklist2:
+			values, err = decodeListpack([]byte(data))
+		default:
+			values, err = decodeZiplist([]byte(data))
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, values...)
+	}
+	return list, nil
+}
+
+// readPacked reads string with compact encoding of collection and decodes it.
+func (d *Decoder) readPacked(decode func([]byte) ([]string, error)) ([]string, error) {
+	data, err := d.readString()
+	if err != nil {
+		return nil, err
+	}
+	return decode([]byte(data))
+}
+
+// packedZSet builds sorted set from flat list of members and their scores.
+func packedZSet(elems []string) (ZSet, error) {
+	if len(elems)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of sorted set elements", ErrInvalidFormat)
+	}
+
+	zset := make(ZSet, 0, len(elems)/2)
+	for i := 0; i < len(elems); i += 2 {
+		score, err := strconv.ParseFloat(elems[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad score", ErrInvalidFormat)
+		}
+		zset = append(zset, ZMember{Member: elems[i], Score: score})
+	}
+	return zset, nil
+}
+
+func pairsToHash(pairs []string) Hash {
+	hash := make(Hash, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		hash[pairs[i]] = pairs[i+1]
+	}
+	return hash
+}
+
+// read reads exactly n bytes updating checksum.
+func (d *Decoder) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	d.crc = d.crc.update(buf)
+	return buf, nil
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	buf, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// readLength reads length. Special string encodings are not allowed here.
+func (d *Decoder) readLength() (uint64, error) {
+	n, special, err := d.readLengthOrEncoding()
+	if err == nil && special {
+		err = fmt.Errorf("%w: unexpected string encoding", ErrInvalidFormat)
+	}
+	return n, err
+}
+
+// readLengthOrEncoding reads length. If special is true, it's a special
+// string encoding instead.
+func (d *Decoder) readLengthOrEncoding() (n uint64, special bool, err error) {
+	b, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch b >> 6 {
+	case len6Bit:
+		return uint64(b & 0x3F), false, nil
+	case len14Bit:
+		next, err := d.readByte()
+		return uint64(b&0x3F)<<8 | uint64(next), false, err
+	case lenEncVal:
+		return uint64(b & 0x3F), true, nil
+	}
+
+	switch b {
+	case len32Bit:
+		buf, err := d.read(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(buf)), false, nil
+	case len64Bit:
+		buf, err := d.read(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(buf), false, nil
+	default:
+		return 0, false, fmt.Errorf("%w: unknown length encoding 0x%X", ErrInvalidFormat, b)
+	}
+}
+
+func (d *Decoder) skipLengths(n int) error {
+	for range n {
+		if _, err := d.readLength(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readString reads string in any encoding.
+func (d *Decoder) readString() (string, error) {
+	n, special, err := d.readLengthOrEncoding()
+	if err != nil {
+		return "", err
+	}
+
+	if !special {
+		if n > maxStringLen {
+			return "", fmt.Errorf("%w: too long string", ErrInvalidFormat)
+		}
+		buf, err := d.read(int(n))
+		return string(buf), err
+	}
+
+	switch n {
+	case encInt8, encInt16, encInt32:
+		size := 1 << n
+		buf, err := d.read(size)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(signExtend(readUintLE(buf), size*8), 10), nil
+	case encLZF:
+		compressedLen, err := d.readLength()
+		if err != nil {
+			return "", err
+		}
+		length, err := d.readLength()
+		if err != nil {
+			return "", err
+		}
+		if compressedLen > maxStringLen || length > maxStringLen {
+			return "", fmt.Errorf("%w: too long string", ErrInvalidFormat)
+		}
+		compressed, err := d.read(int(compressedLen))
+		if err != nil {
+			return "", err
+		}
+		buf, err := lzfDecompress(compressed, int(length))
+		return string(buf), err
+	default:
+		return "", fmt.Errorf("%w: unknown string encoding %d", ErrInvalidFormat, n)
+	}
+}
+
+// readStrings reads length followed by strings. Length is multiplied by factor if it's given.
+func (d *Decoder) readStrings(factor ...int) ([]string, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range factor {
+		n *= uint64(f)
+	}
+
+	values := make([]string, 0, min(n, 1024))
+	for range n {
+		v, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// readMillis reads unix time in milliseconds stored as 8-byte little-endian integer.
+func (d *Decoder) readMillis() (time.Time, error) {
+	buf, err := d.read(8)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(binary.LittleEndian.Uint64(buf))), nil
+}
+
+func (d *Decoder) readBinaryDouble() (float64, error) {
+	buf, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+}
+
+// readStringDouble reads double stored as string with 1-byte length.
+// Special lengths are used for NaN and infinities.
+func (d *Decoder) readStringDouble() (float64, error) {
+	n, err := d.readByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	buf, err := d.read(int(n))
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(string(buf), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad double", ErrInvalidFormat)
+	}
+	return f, nil
+}
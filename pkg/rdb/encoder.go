@@ -0,0 +1,211 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Record is a key with its value stored in RDB file.
+type Record struct {
+	Key string
+	// ExpiresAt is zero for keys without expiration time.
+	ExpiresAt time.Time
+	// Value is one of string, List, Set, Hash, ZSet and *Stream.
+	Value any
+}
+
+// List is a value of list type.
+type List []string
+
+// Set is a value of set type.
+type Set []string
+
+// Hash is a value of hash type.
+type Hash map[string]string
+
+// ZSet is a value of sorted set type.
+type ZSet []ZMember
+
+// ZMember is a member of sorted set with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// Encoder writes RDB file: header, auxiliary fields, database selector and records.
+// Close MUST BE CALLED after the last record to write checksum.
+// The first write error is kept and returned by all subsequent calls.
+type Encoder struct {
+	w   *bufio.Writer
+	crc crc64
+	err error
+}
+
+// NewEncoder is a constructor for Encoder.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// WriteHeader writes magic string with RDB version.
+func (e *Encoder) WriteHeader() error {
+	e.write([]byte(fmt.Sprintf("%s%04d", magic, Version)))
+	return e.err
+}
+
+// WriteAux writes auxiliary field, e.g. version of the server which created the file.
+func (e *Encoder) WriteAux(key, value string) error {
+	e.writeByte(byte(OpcodeAux))
+	e.writeString(key)
+	e.writeString(value)
+	return e.err
+}
+
+// WriteDB writes selector of database the following records belong to
+// with number of its keys and keys with expiration time.
+func (e *Encoder) WriteDB(index, size, expires int) error {
+	e.writeByte(byte(OpcodeSelectDB))
+	e.writeLength(uint64(index))
+	e.writeByte(byte(OpcodeResizeDB))
+	e.writeLength(uint64(size))
+	e.writeLength(uint64(expires))
+	return e.err
+}
+
+// WriteRecord writes key with its value and expiration time.
+func (e *Encoder) WriteRecord(r Record) error {
+	if !r.ExpiresAt.IsZero() {
+		e.writeByte(byte(OpcodeExpireTimeMs))
+		e.writeMillis(r.ExpiresAt)
+	}
+
+	switch value := r.Value.(type) {
+	case string:
+		e.writeRecordHeader(TypeString, r.Key)
+		e.writeString(value)
+	case List:
+		e.writeRecordHeader(TypeList, r.Key)
+		e.writeStrings(value)
+	case Set:
+		e.writeRecordHeader(TypeSet, r.Key)
+		e.writeStrings(value)
+	case Hash:
+		e.writeRecordHeader(TypeHash, r.Key)
+		e.writeLength(uint64(len(value)))
+		for field, v := range value {
+			e.writeString(field)
+			e.writeString(v)
+		}
+	case ZSet:
+		e.writeRecordHeader(TypeZSet2, r.Key)
+		e.writeLength(uint64(len(value)))
+		for _, m := range value {
+			e.writeString(m.Member)
+			e.writeBinaryDouble(m.Score)
+		}
+	case *Stream:
+		e.writeRecordHeader(TypeStreamListpacks3, r.Key)
+		e.writeStream(value)
+	default:
+		return fmt.Errorf("%w: value of type %T", ErrUnsupported, r.Value)
+	}
+
+	return e.err
+}
+
+// Close writes end of file marker with checksum and flushes buffered data.
+// It doesn't close underlying writer.
+func (e *Encoder) Close() error {
+	e.writeByte(byte(OpcodeEOF))
+	// checksum itself is not checksummed
+	if e.err == nil {
+		e.err = binary.Write(e.w, binary.LittleEndian, uint64(e.crc))
+	}
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+func (e *Encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(p)
+	e.crc = e.crc.update(p)
+}
+
+func (e *Encoder) writeByte(b byte) {
+	e.write([]byte{b})
+}
+
+func (e *Encoder) writeRecordHeader(t Type, key string) {
+	e.writeByte(byte(t))
+	e.writeString(key)
+}
+
+// writeLength writes length using as few bytes as possible.
+func (e *Encoder) writeLength(n uint64) {
+	switch {
+	case n < 1<<6:
+		e.writeByte(byte(n) | len6Bit<<6)
+	case n < 1<<14:
+		e.write([]byte{byte(n>>8) | len14Bit<<6, byte(n)})
+	case n <= math.MaxUint32:
+		e.write(binary.BigEndian.AppendUint32([]byte{len32Bit}, uint32(n)))
+	default:
+		e.write(binary.BigEndian.AppendUint64([]byte{len64Bit}, n))
+	}
+}
+
+// writeString writes string. Integers fitting 32 bits are written in compact integer encoding.
+func (e *Encoder) writeString(s string) {
+	if len(s) <= 11 {
+		if num, ok := parseCanonicalInt(s); ok && num >= math.MinInt32 && num <= math.MaxInt32 {
+			e.writeIntString(num)
+			return
+		}
+	}
+
+	e.writeLength(uint64(len(s)))
+	e.write([]byte(s))
+}
+
+func (e *Encoder) writeIntString(num int64) {
+	const prefix = lenEncVal << 6
+	switch {
+	case num >= math.MinInt8 && num <= math.MaxInt8:
+		e.write([]byte{prefix | encInt8, byte(num)})
+	case num >= math.MinInt16 && num <= math.MaxInt16:
+		e.write(binary.LittleEndian.AppendUint16([]byte{prefix | encInt16}, uint16(num)))
+	default:
+		e.write(binary.LittleEndian.AppendUint32([]byte{prefix | encInt32}, uint32(num)))
+	}
+}
+
+func (e *Encoder) writeStrings(values []string) {
+	e.writeLength(uint64(len(values)))
+	for _, v := range values {
+		e.writeString(v)
+	}
+}
+
+// writeMillis writes unix time in milliseconds as 8-byte little-endian integer.
+func (e *Encoder) writeMillis(t time.Time) {
+	e.write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+}
+
+func (e *Encoder) writeBinaryDouble(f float64) {
+	e.write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+// formatInt is a shortcut used to build listpacks of integers.
+func formatInt[T int | int64 | uint64](num T) string {
+	return strconv.FormatInt(int64(num), 10)
+}
@@ -0,0 +1,227 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// listpack is a compact serialization of a list of strings and integers:
+// <total-bytes uint32> <num-elements uint16> <element> ... <end-byte 0xFF>
+// Every element is <encoding-type><element-data><element-backlen>.
+const (
+	listpackHeaderSize = 6
+	listpackEnd        = 0xFF
+	// listpackUnknownLen is stored as number of elements if there are more of them
+	listpackUnknownLen = 65535
+)
+
+const (
+	lp7BitUint = 0x00 // 0xxxxxxx
+	lp6BitStr  = 0x80 // 10xxxxxx
+	lp13BitInt = 0xC0 // 110xxxxx
+	lp12BitStr = 0xE0 // 1110xxxx
+	lp32BitStr = 0xF0
+	lp16BitInt = 0xF1
+	lp24BitInt = 0xF2
+	lp32BitInt = 0xF3
+	lp64BitInt = 0xF4
+)
+
+// encodeListpack serializes elements to listpack.
+// Elements which are canonical representations of integers are stored as integers.
+func encodeListpack(elems []string) []byte {
+	buf := make([]byte, listpackHeaderSize, listpackHeaderSize+len(elems)*4)
+	for _, elem := range elems {
+		start := len(buf)
+		if num, ok := parseCanonicalInt(elem); ok {
+			buf = appendListpackInt(buf, num)
+		} else {
+			buf = appendListpackString(buf, elem)
+		}
+		buf = appendBacklen(buf, len(buf)-start)
+	}
+	buf = append(buf, listpackEnd)
+
+	binary.LittleEndian.PutUint32(buf, uint32(len(buf)))
+	binary.LittleEndian.PutUint16(buf[4:], uint16(min(len(elems), listpackUnknownLen)))
+	return buf
+}
+
+func appendListpackInt(buf []byte, num int64) []byte {
+	switch {
+	case num >= 0 && num <= 127:
+		return append(buf, byte(num))
+	case num >= -4096 && num <= 4095:
+		v := uint64(num) & (1<<13 - 1)
+		return append(buf, byte(v>>8)|lp13BitInt, byte(v))
+	case num >= -32768 && num <= 32767:
+		return binary.LittleEndian.AppendUint16(append(buf, lp16BitInt), uint16(num))
+	case num >= -8388608 && num <= 8388607:
+		v := uint32(num)
+		return append(buf, lp24BitInt, byte(v), byte(v>>8), byte(v>>16))
+	case num >= -2147483648 && num <= 2147483647:
+		return binary.LittleEndian.AppendUint32(append(buf, lp32BitInt), uint32(num))
+	default:
+		return binary.LittleEndian.AppendUint64(append(buf, lp64BitInt), uint64(num))
+	}
+}
+
+func appendListpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 64:
+		buf = append(buf, byte(n)|lp6BitStr)
+	case n < 4096:
+		buf = append(buf, byte(n>>8)|lp12BitStr, byte(n))
+	default:
+		buf = binary.LittleEndian.AppendUint32(append(buf, lp32BitStr), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendBacklen appends length of element (without backlen itself),
+// it's used to traverse listpack from the end.
+func appendBacklen(buf []byte, n int) []byte {
+	switch {
+	case n <= 127:
+		return append(buf, byte(n))
+	case n < 16383:
+		return append(buf, byte(n>>7), byte(n&127)|128)
+	case n < 2097151:
+		return append(buf, byte(n>>14), byte((n>>7)&127)|128, byte(n&127)|128)
+	case n < 268435455:
+		return append(buf, byte(n>>21), byte((n>>14)&127)|128, byte((n>>7)&127)|128, byte(n&127)|128)
+	default:
+		return append(buf, byte(n>>28), byte((n>>21)&127)|128, byte((n>>14)&127)|128,
+			byte((n>>7)&127)|128, byte(n&127)|128)
+	}
+}
+
+// backlenSize returns number of bytes appendBacklen uses for element of length n.
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// decodeListpack returns elements of listpack. Integers are converted to strings.
+func decodeListpack(lp []byte) ([]string, error) {
+	if len(lp) < listpackHeaderSize+1 || int(binary.LittleEndian.Uint32(lp)) != len(lp) {
+		return nil, fmt.Errorf("%w: bad listpack header", ErrInvalidFormat)
+	}
+
+	elems := []string{}
+	for pos := listpackHeaderSize; ; {
+		if pos >= len(lp) {
+			return nil, fmt.Errorf("%w: listpack without end", ErrInvalidFormat)
+		}
+		if lp[pos] == listpackEnd {
+			return elems, nil
+		}
+
+		elem, size, err := decodeListpackElement(lp[pos:])
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+		pos += size + backlenSize(size)
+	}
+}
+
+// decodeListpackElement decodes element at the beginning of p
+// and returns it with its size (without backlen).
+func decodeListpackElement(p []byte) (string, int, error) {
+	b := p[0]
+
+	var header, strLen int
+	var num int64
+	switch {
+	case b&0x80 == lp7BitUint:
+		return strconv.Itoa(int(b)), 1, nil
+	case b&0xC0 == lp6BitStr:
+		header, strLen = 1, int(b&0x3F)
+	case b&0xE0 == lp13BitInt:
+		if len(p) < 2 {
+			return "", 0, errTruncatedListpack
+		}
+		num = signExtend(uint64(b&0x1F)<<8|uint64(p[1]), 13)
+		return strconv.FormatInt(num, 10), 2, nil
+	case b&0xF0 == lp12BitStr:
+		if len(p) < 2 {
+			return "", 0, errTruncatedListpack
+		}
+		header, strLen = 2, int(b&0x0F)<<8|int(p[1])
+	case b == lp32BitStr:
+		if len(p) < 5 {
+			return "", 0, errTruncatedListpack
+		}
+		header, strLen = 5, int(binary.LittleEndian.Uint32(p[1:]))
+	default:
+		size := listpackIntSize(b)
+		if size == 0 {
+			return "", 0, fmt.Errorf("%w: unknown listpack encoding 0x%X", ErrInvalidFormat, b)
+		}
+		if len(p) < 1+size {
+			return "", 0, errTruncatedListpack
+		}
+		num = signExtend(readUintLE(p[1:1+size]), size*8)
+		return strconv.FormatInt(num, 10), 1 + size, nil
+	}
+
+	if strLen < 0 || len(p) < header+strLen {
+		return "", 0, errTruncatedListpack
+	}
+	return string(p[header : header+strLen]), header + strLen, nil
+}
+
+// listpackIntSize returns size of integer with encoding b or 0 for unknown encodings.
+func listpackIntSize(b byte) int {
+	switch b {
+	case lp16BitInt:
+		return 2
+	case lp24BitInt:
+		return 3
+	case lp32BitInt:
+		return 4
+	case lp64BitInt:
+		return 8
+	default:
+		return 0
+	}
+}
+
+var errTruncatedListpack = fmt.Errorf("%w: truncated listpack", ErrInvalidFormat)
+
+// readUintLE reads little-endian unsigned integer of len(p) bytes.
+func readUintLE(p []byte) uint64 {
+	var v uint64
+	for i := len(p) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(p[i])
+	}
+	return v
+}
+
+// signExtend interprets lower bits of v as signed integer.
+func signExtend(v uint64, bits int) int64 {
+	shift := 64 - bits
+	return int64(v<<shift) >> shift
+}
+
+// parseCanonicalInt parses s if it's a canonical representation of 64-bit integer,
+// so that converting it back gives the same string.
+func parseCanonicalInt(s string) (int64, bool) {
+	num, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || strconv.FormatInt(num, 10) != s {
+		return 0, false
+	}
+	return num, true
+}
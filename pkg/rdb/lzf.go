@@ -0,0 +1,55 @@
+package rdb
+
+import "fmt"
+
+// lzfDecompress decompresses data compressed with LZF into buffer of outLen bytes.
+// Data consists of literal runs (control byte < 32 is run length - 1)
+// and back references (3 high bits are length - 2, unless they are all set
+// and length is in the next byte; the rest is offset - 1 with the following byte).
+func lzfDecompress(in []byte, outLen int) ([]byte, error) {
+	out := make([]byte, 0, outLen)
+
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+
+		if ctrl < 32 {
+			n := ctrl + 1
+			if i+n > len(in) || len(out)+n > outLen {
+				return nil, errBadLZF
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, errBadLZF
+			}
+			n += int(in[i])
+			i++
+		}
+		n += 2
+		if i >= len(in) {
+			return nil, errBadLZF
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 || len(out)+n > outLen {
+			return nil, errBadLZF
+		}
+		// reference may overlap with bytes being copied, so they're copied one by one
+		for j := range n {
+			out = append(out, out[ref+j])
+		}
+	}
+
+	if len(out) != outLen {
+		return nil, errBadLZF
+	}
+	return out, nil
+}
+
+var errBadLZF = fmt.Errorf("%w: corrupted LZF data", ErrInvalidFormat)
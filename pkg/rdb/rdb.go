@@ -0,0 +1,99 @@
+// Package rdb implements encoding and decoding of snapshots in Redis RDB format.
+//
+// Encoder writes values using encodings every Redis version since 7.2 can load.
+// Decoder reads files written by Redis up to 7.4 including compact encodings
+// (listpacks, ziplists, intsets) and LZF-compressed strings.
+// Module values and functions are not supported.
+package rdb
+
+import (
+	"errors"
+)
+
+// Version is the RDB version written by Encoder.
+// It's the first one with the current stream encoding.
+const Version = 11
+
+// maxVersion is the newest RDB version Decoder accepts.
+const maxVersion = 12
+
+// magic is a prefix of every RDB file, it's followed by 4-digit version.
+const magic = "REDIS"
+
+// Opcode is a marker of special record in RDB file.
+type Opcode byte
+
+const (
+	OpcodeSlotInfo     Opcode = 0xF4
+	OpcodeFunction2    Opcode = 0xF5
+	OpcodeFunction     Opcode = 0xF6
+	OpcodeModuleAux    Opcode = 0xF7
+	OpcodeIdle         Opcode = 0xF8
+	OpcodeFreq         Opcode = 0xF9
+	OpcodeAux          Opcode = 0xFA
+	OpcodeResizeDB     Opcode = 0xFB
+	OpcodeExpireTimeMs Opcode = 0xFC
+	OpcodeExpireTime   Opcode = 0xFD
+	OpcodeSelectDB     Opcode = 0xFE
+	OpcodeEOF          Opcode = 0xFF
+)
+
+// Type is a type of value encoding. Opcodes share the same byte, so every record
+// starts with either a type (followed by key and value) or an opcode.
+type Type byte
+
+const (
+	TypeString            Type = 0
+	TypeList              Type = 1
+	TypeSet               Type = 2
+	TypeZSet              Type = 3
+	TypeHash              Type = 4
+	TypeZSet2             Type = 5
+	TypeModule            Type = 6
+	TypeModule2           Type = 7
+	TypeHashZipmap        Type = 9
+	TypeListZiplist       Type = 10
+	TypeSetIntset         Type = 11
+	TypeZSetZiplist       Type = 12
+	TypeHashZiplist       Type = 13
+	TypeListQuicklist     Type = 14
+	TypeStreamListpacks   Type = 15
+	TypeHashListpack      Type = 16
+	TypeZSetListpack      Type = 17
+	TypeListQuicklist2    Type = 18
+	TypeStreamListpacks2  Type = 19
+	TypeSetListpack       Type = 20
+	TypeStreamListpacks3  Type = 21
+	maxSupportedValueType      = TypeStreamListpacks3
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid RDB format")
+	ErrChecksum      = errors.New("RDB checksum mismatch")
+	ErrUnsupported   = errors.New("unsupported RDB feature")
+)
+
+// length encoding is defined by 2 most significant bits of the first byte
+const (
+	len6Bit   = 0
+	len14Bit  = 1
+	len32Or64 = 2
+	lenEncVal = 3
+
+	len32Bit = 0x80
+	len64Bit = 0x81
+)
+
+// special encodings of strings used when the first byte has lenEncVal bits
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+// quicklist containers of TypeListQuicklist2
+const (
+	quicklistNodePlain  = 1
+	quicklistNodePacked = 2
+)
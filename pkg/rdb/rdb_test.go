@@ -0,0 +1,177 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC64(t *testing.T) {
+	assert.Equal(t, crc64(0xe9c6d914c4b8d9ca), crc64(0).update([]byte("123456789")))
+}
+
+func TestListpack(t *testing.T) {
+	elems := []string{
+		"0", "127", "128", "-1", "-4096", "4095", "-32768", "32767",
+		"-8388608", "8388607", "-2147483648", "2147483647",
+		"9223372036854775807", "-9223372036854775808",
+		"", "hello", "007", "1.5", strings.Repeat("a", 63), strings.Repeat("b", 64),
+		strings.Repeat("c", 4095), strings.Repeat("d", 4096), strings.Repeat("e", 20000),
+	}
+
+	got, err := decodeListpack(encodeListpack(elems))
+	require.NoError(t, err)
+	assert.Equal(t, elems, got)
+
+	_, err = decodeListpack(encodeListpack(elems)[:100])
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestRoundTrip(t *testing.T) {
+	expiresAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	stream := &Stream{
+		LastID:       StreamID{Ms: 5, Seq: 1},
+		EntriesAdded: 260,
+		MaxDeletedID: StreamID{Ms: 3},
+		Groups: []StreamGroup{
+			{
+				Name:   "group",
+				LastID: StreamID{Ms: 2},
+				Pending: []StreamPendingEntry{
+					{ID: StreamID{Ms: 1}, Consumer: "alice", DeliveredAt: now, Deliveries: 2},
+					{ID: StreamID{Ms: 2}, Consumer: "bob", DeliveredAt: now, Deliveries: 1},
+				},
+				Consumers: []StreamConsumer{
+					{Name: "alice", SeenAt: now, ActiveAt: now},
+					{Name: "bob", SeenAt: now, ActiveAt: now.Add(-time.Second)},
+					{Name: "carol", SeenAt: now, ActiveAt: now},
+				},
+			},
+		},
+	}
+	for i := range 250 {
+		fields := []string{"name", "alice", "age", "30"}
+		if i%3 == 0 {
+			fields = []string{"other", "field"}
+		}
+		stream.Entries = append(stream.Entries, StreamEntry{ID: StreamID{Ms: uint64(i + 1), Seq: uint64(i % 2)}, Fields: fields})
+	}
+	stream.LastID = stream.Entries[len(stream.Entries)-1].ID
+
+	records := []Record{
+		{Key: "str", Value: "hello"},
+		{Key: "int", Value: "-12345", ExpiresAt: expiresAt},
+		{Key: "big", Value: "12345678901234567890"},
+		{Key: "long", Value: strings.Repeat("x", 100000)},
+		{Key: "list", Value: List{"a", "1", "b"}},
+		{Key: "set", Value: Set{"x", "2"}},
+		{Key: "hash", Value: Hash{"f1": "v1", "f2": "100"}},
+		{Key: "zset", Value: ZSet{{Member: "a", Score: 1.5}, {Member: "b", Score: math.Inf(-1)}}},
+		{Key: "stream", Value: stream},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	require.NoError(t, e.WriteHeader())
+	require.NoError(t, e.WriteAux("redis-ver", "7.2.0"))
+	require.NoError(t, e.WriteDB(0, len(records), 1))
+	for _, r := range records {
+		require.NoError(t, e.WriteRecord(r))
+	}
+	require.NoError(t, e.Close())
+
+	d := NewDecoder(&buf)
+	require.NoError(t, d.ReadHeader())
+	for _, want := range records {
+		got, db, err := d.Next()
+		require.NoError(t, err)
+		assert.Equal(t, 0, db)
+		assert.Equal(t, want, got)
+	}
+	_, _, err := d.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	require.NoError(t, e.WriteHeader())
+	require.NoError(t, e.WriteRecord(Record{Key: "key", Value: "value"}))
+	require.NoError(t, e.Close())
+
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xFF
+
+	d := NewDecoder(bytes.NewReader(data))
+	require.NoError(t, d.ReadHeader())
+	_, _, err := d.Next()
+	require.NoError(t, err)
+	_, _, err = d.Next()
+	assert.ErrorIs(t, err, ErrChecksum)
+}
+
+// TestCompactEncodings checks decoding of encodings Redis uses for small values.
+func TestCompactEncodings(t *testing.T) {
+	str := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	record := func(t Type, key string, value []byte) []byte {
+		return append(append([]byte{byte(t)}, str(key)...), value...)
+	}
+
+	// entries "a", 1, "b", "hello"
+	ziplist := []byte{26, 0, 0, 0, 18, 0, 0, 0, 4, 0,
+		0, 0x01, 'a',
+		3, 0xF2,
+		2, 0x01, 'b',
+		3, 0x05, 'h', 'e', 'l', 'l', 'o',
+		0xFF}
+	// 16-bit integers 1 and -5
+	intset := []byte{2, 0, 0, 0, 2, 0, 0, 0, 1, 0, 0xFB, 0xFF}
+	// "aaaaaaaaaa" as literal "a" and back reference of 9 bytes
+	lzf := []byte{0xC3, 5, 10, 0x00, 'a', 0xE0, 0x00, 0x00}
+
+	var body []byte
+	body = append(body, 0xFE, 0)
+	body = append(body, record(TypeHashZiplist, "hash", str(string(ziplist)))...)
+	body = append(body, record(TypeSetIntset, "set", str(string(intset)))...)
+	body = append(body, record(TypeListQuicklist2, "list",
+		append([]byte{2, quicklistNodePlain}, append(str("plain"),
+			append([]byte{quicklistNodePacked}, str(string(encodeListpack([]string{"1", "two"})))...)...)...))...)
+	body = append(body, record(TypeZSetListpack, "zset", str(string(encodeListpack([]string{"a", "1.5", "b", "2"}))))...)
+	body = append(body, 0xFD, 100, 0, 0, 0)
+	body = append(body, record(TypeString, "lzf", lzf)...)
+	body = append(body, record(TypeString, "int", []byte{0xC1, 0x39, 0x30})...)
+
+	data := append([]byte("REDIS0009"), body...)
+	data = append(data, 0xFF)
+	data = binary.LittleEndian.AppendUint64(data, uint64(crc64(0).update(data)))
+
+	d := NewDecoder(bytes.NewReader(data))
+	require.NoError(t, d.ReadHeader())
+
+	want := []Record{
+		{Key: "hash", Value: Hash{"a": "1", "b": "hello"}},
+		{Key: "set", Value: Set{"1", "-5"}},
+		{Key: "list", Value: List{"plain", "1", "two"}},
+		{Key: "zset", Value: ZSet{{Member: "a", Score: 1.5}, {Member: "b", Score: 2}}},
+		{Key: "lzf", Value: "aaaaaaaaaa", ExpiresAt: time.Unix(100, 0)},
+		{Key: "int", Value: "12345"},
+	}
+	for _, w := range want {
+		got, _, err := d.Next()
+		require.NoError(t, err)
+		assert.Equal(t, w, got)
+	}
+	_, _, err := d.Next()
+	assert.Equal(t, io.EOF, err)
+}
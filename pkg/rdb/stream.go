@@ -0,0 +1,437 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Stream is a value of stream type.
+type Stream struct {
+	// Entries are sorted by ID, fields of every entry are given as flat list of field-value pairs.
+	Entries []StreamEntry
+	LastID  StreamID
+	// MaxDeletedID and EntriesAdded are used by Redis to compute lag of consumer groups.
+	// They're zero if unknown.
+	MaxDeletedID StreamID
+	EntriesAdded uint64
+	Groups       []StreamGroup
+}
+
+// StreamID is an ID of stream entry.
+type StreamID struct {
+	Ms, Seq uint64
+}
+
+// StreamEntry is an entry of stream.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// StreamGroup is a consumer group of stream.
+type StreamGroup struct {
+	Name   string
+	LastID StreamID
+	// Pending are entries delivered but not acknowledged yet sorted by ID.
+	Pending   []StreamPendingEntry
+	Consumers []StreamConsumer
+}
+
+// StreamPendingEntry is an entry delivered to consumer but not acknowledged yet.
+type StreamPendingEntry struct {
+	ID          StreamID
+	Consumer    string
+	DeliveredAt time.Time
+	Deliveries  uint64
+}
+
+// StreamConsumer is a consumer of consumer group.
+type StreamConsumer struct {
+	Name     string
+	SeenAt   time.Time
+	ActiveAt time.Time
+}
+
+// stream entries are stored in listpacks of up to streamNodeMaxEntries entries
+// keyed by ID of their first (master) entry. Every listpack starts with master entry
+// <count> <deleted> <num-fields> <field> ... <0>
+// followed by entries
+// <flags> <ms-diff> <seq-diff> [<num-fields> <field> <value> ... | <value> ...] <lp-count>
+// Field names are omitted if they're the same as of master entry.
+const streamNodeMaxEntries = 100
+
+const (
+	streamItemDeleted    = 1
+	streamItemSameFields = 2
+)
+
+// streamIDSize is a size of ID encoded as 128-bit big-endian integer
+const streamIDSize = 16
+
+func encodeStreamID(id StreamID) []byte {
+	buf := binary.BigEndian.AppendUint64(make([]byte, 0, streamIDSize), id.Ms)
+	return binary.BigEndian.AppendUint64(buf, id.Seq)
+}
+
+func decodeStreamID(p []byte) (StreamID, error) {
+	if len(p) != streamIDSize {
+		return StreamID{}, fmt.Errorf("%w: bad stream ID", ErrInvalidFormat)
+	}
+	return StreamID{Ms: binary.BigEndian.Uint64(p), Seq: binary.BigEndian.Uint64(p[8:])}, nil
+}
+
+func (e *Encoder) writeStream(s *Stream) {
+	nodes := slices.Collect(slices.Chunk(s.Entries, streamNodeMaxEntries))
+	e.writeLength(uint64(len(nodes)))
+	for _, node := range nodes {
+		e.writeString(string(encodeStreamID(node[0].ID)))
+		e.writeString(string(encodeListpack(streamNodeElems(node))))
+	}
+
+	firstID := StreamID{}
+	if len(s.Entries) > 0 {
+		firstID = s.Entries[0].ID
+	}
+	e.writeLength(uint64(len(s.Entries)))
+	e.writeStreamID(s.LastID)
+	e.writeStreamID(firstID)
+	e.writeStreamID(s.MaxDeletedID)
+	e.writeLength(max(s.EntriesAdded, uint64(len(s.Entries))))
+
+	e.writeLength(uint64(len(s.Groups)))
+	for _, g := range s.Groups {
+		e.writeString(g.Name)
+		e.writeStreamID(g.LastID)
+		// number of entries read by group is unknown, it's represented by -1
+		e.writeLength(^uint64(0))
+
+		e.writeLength(uint64(len(g.Pending)))
+		for _, pe := range g.Pending {
+			e.write(encodeStreamID(pe.ID))
+			e.writeMillis(pe.DeliveredAt)
+			e.writeLength(pe.Deliveries)
+		}
+
+		e.writeLength(uint64(len(g.Consumers)))
+		for _, c := range g.Consumers {
+			e.writeString(c.Name)
+			e.writeMillis(c.SeenAt)
+			e.writeMillis(c.ActiveAt)
+
+			var owned [][]byte
+			for _, pe := range g.Pending {
+				if pe.Consumer == c.Name {
+					owned = append(owned, encodeStreamID(pe.ID))
+				}
+			}
+			e.writeLength(uint64(len(owned)))
+			for _, id := range owned {
+				e.write(id)
+			}
+		}
+	}
+}
+
+func (e *Encoder) writeStreamID(id StreamID) {
+	e.writeLength(id.Ms)
+	e.writeLength(id.Seq)
+}
+
+// streamNodeElems returns elements of listpack holding entries.
+func streamNodeElems(entries []StreamEntry) []string {
+	master := entries[0]
+	masterFields := fieldNames(master.Fields)
+
+	elems := []string{formatInt(len(entries)), "0", formatInt(len(masterFields))}
+	elems = append(elems, masterFields...)
+	elems = append(elems, "0")
+
+	for _, entry := range entries {
+		names := fieldNames(entry.Fields)
+		flags := 0
+		if slices.Equal(names, masterFields) {
+			flags |= streamItemSameFields
+		}
+
+		// differences are stored as signed integers, overflow is intended
+		elems = append(elems,
+			formatInt(flags),
+			formatInt(int64(entry.ID.Ms-master.ID.Ms)),
+			formatInt(int64(entry.ID.Seq-master.ID.Seq)),
+		)
+		if flags&streamItemSameFields != 0 {
+			for i := 1; i < len(entry.Fields); i += 2 {
+				elems = append(elems, entry.Fields[i])
+			}
+			elems = append(elems, formatInt(len(names)+3))
+			continue
+		}
+
+		elems = append(elems, formatInt(len(names)))
+		elems = append(elems, entry.Fields...)
+		elems = append(elems, formatInt(2*len(names)+4))
+	}
+
+	return elems
+}
+
+func fieldNames(fields []string) []string {
+	names := make([]string, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		names = append(names, fields[i])
+	}
+	return names
+}
+
+// readStream reads stream encoded as t which is one of stream listpacks types.
+func (d *Decoder) readStream(t Type) (*Stream, error) {
+	s := &Stream{Entries: []StreamEntry{}, Groups: []StreamGroup{}}
+
+	nodes, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for range nodes {
+		key, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		masterID, err := decodeStreamID([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+		lp, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		elems, err := decodeListpack([]byte(lp))
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := parseStreamNode(masterID, elems)
+		if err != nil {
+			return nil, err
+		}
+		s.Entries = append(s.Entries, entries...)
+	}
+
+	// length is known from entries
+	if _, err := d.readLength(); err != nil {
+		return nil, err
+	}
+	if s.LastID, err = d.readStreamID(); err != nil {
+		return nil, err
+	}
+	if t >= TypeStreamListpacks2 {
+		// first ID is known from entries
+		if _, err := d.readStreamID(); err != nil {
+			return nil, err
+		}
+		if s.MaxDeletedID, err = d.readStreamID(); err != nil {
+			return nil, err
+		}
+		if s.EntriesAdded, err = d.readLength(); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for range groups {
+		g, err := d.readStreamGroup(t)
+		if err != nil {
+			return nil, err
+		}
+		s.Groups = append(s.Groups, g)
+	}
+
+	return s, nil
+}
+
+func (d *Decoder) readStreamGroup(t Type) (StreamGroup, error) {
+	var g StreamGroup
+	var err error
+
+	if g.Name, err = d.readString(); err != nil {
+		return g, err
+	}
+	if g.LastID, err = d.readStreamID(); err != nil {
+		return g, err
+	}
+	if t >= TypeStreamListpacks2 {
+		// number of read entries is not used
+		if _, err := d.readLength(); err != nil {
+			return g, err
+		}
+	}
+
+	pending, err := d.readLength()
+	if err != nil {
+		return g, err
+	}
+	g.Pending = []StreamPendingEntry{}
+	index := map[StreamID]int{}
+	for range pending {
+		var pe StreamPendingEntry
+		raw, err := d.read(streamIDSize)
+		if err != nil {
+			return g, err
+		}
+		pe.ID, _ = decodeStreamID(raw)
+		if pe.DeliveredAt, err = d.readMillis(); err != nil {
+			return g, err
+		}
+		if pe.Deliveries, err = d.readLength(); err != nil {
+			return g, err
+		}
+		index[pe.ID] = len(g.Pending)
+		g.Pending = append(g.Pending, pe)
+	}
+
+	consumers, err := d.readLength()
+	if err != nil {
+		return g, err
+	}
+	g.Consumers = []StreamConsumer{}
+	for range consumers {
+		var c StreamConsumer
+		if c.Name, err = d.readString(); err != nil {
+			return g, err
+		}
+		if c.SeenAt, err = d.readMillis(); err != nil {
+			return g, err
+		}
+		c.ActiveAt = c.SeenAt
+		if t >= TypeStreamListpacks3 {
+			if c.ActiveAt, err = d.readMillis(); err != nil {
+				return g, err
+			}
+		}
+
+		owned, err := d.readLength()
+		if err != nil {
+			return g, err
+		}
+		for range owned {
+			raw, err := d.read(streamIDSize)
+			if err != nil {
+				return g, err
+			}
+			id, _ := decodeStreamID(raw)
+			i, ok := index[id]
+			if !ok {
+				return g, fmt.Errorf("%w: consumer pending entry is not in group", ErrInvalidFormat)
+			}
+			g.Pending[i].Consumer = c.Name
+		}
+		g.Consumers = append(g.Consumers, c)
+	}
+
+	return g, nil
+}
+
+func (d *Decoder) readStreamID() (StreamID, error) {
+	ms, err := d.readLength()
+	if err != nil {
+		return StreamID{}, err
+	}
+	seq, err := d.readLength()
+	if err != nil {
+		return StreamID{}, err
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+// parseStreamNode returns entries stored in listpack node. Deleted entries are skipped.
+func parseStreamNode(masterID StreamID, elems []string) ([]StreamEntry, error) {
+	p := &streamNodeParser{elems: elems}
+
+	// number of valid and deleted entries are known from entries themselves
+	p.int()
+	p.int()
+	masterFields := make([]string, p.count(1))
+	for i := range masterFields {
+		masterFields[i] = p.next()
+	}
+	if p.int() != 0 {
+		p.err = true
+	}
+
+	entries := []StreamEntry{}
+	for !p.err && len(p.elems) > 0 {
+		flags := p.int()
+		id := StreamID{
+			Ms:  masterID.Ms + uint64(p.int()),
+			Seq: masterID.Seq + uint64(p.int()),
+		}
+
+		var fields []string
+		if flags&streamItemSameFields != 0 {
+			fields = make([]string, 0, 2*len(masterFields))
+			for _, name := range masterFields {
+				fields = append(fields, name, p.next())
+			}
+		} else {
+			fields = make([]string, p.count(2))
+			for i := range fields {
+				fields[i] = p.next()
+			}
+		}
+		// lp-count is used for backward traversal only
+		p.int()
+
+		if flags&streamItemDeleted == 0 {
+			entries = append(entries, StreamEntry{ID: id, Fields: fields})
+		}
+	}
+
+	if p.err {
+		return nil, fmt.Errorf("%w: bad stream listpack", ErrInvalidFormat)
+	}
+	return entries, nil
+}
+
+// streamNodeParser consumes elements of stream listpack. Once elements are over
+// or integer is malformed, err is set and zero values are returned.
+type streamNodeParser struct {
+	elems []string
+	err   bool
+}
+
+func (p *streamNodeParser) next() string {
+	if len(p.elems) == 0 {
+		p.err = true
+		return ""
+	}
+	elem := p.elems[0]
+	p.elems = p.elems[1:]
+	return elem
+}
+
+func (p *streamNodeParser) int() int64 {
+	if p.err {
+		return 0
+	}
+	num, err := strconv.ParseInt(p.next(), 10, 64)
+	if err != nil {
+		p.err = true
+	}
+	return num
+}
+
+// count reads number of following items which consist of size elements each
+// and returns number of elements.
+func (p *streamNodeParser) count(size int) int {
+	n := p.int()
+	if n < 0 || n > int64(len(p.elems)/size) {
+		p.err = true
+		return 0
+	}
+	return int(n) * size
+}
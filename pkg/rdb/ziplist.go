@@ -0,0 +1,147 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// ziplist is an older compact encoding replaced by listpack. It's only decoded:
+// <zlbytes uint32> <zltail uint32> <zllen uint16> <entry> ... <zlend 0xFF>
+// Every entry is <prevlen><encoding><entry-data>.
+const (
+	ziplistHeaderSize = 10
+	ziplistEnd        = 0xFF
+	// ziplistBigPrevlen marks 4-byte length of the previous entry
+	ziplistBigPrevlen = 0xFE
+)
+
+const (
+	zipStr06B = 0x00 // 00pppppp
+	zipStr14B = 0x40 // 01pppppp qqqqqqqq
+	zipStr32B = 0x80 // 10000000 + 4 bytes
+	zipInt16B = 0xC0
+	zipInt32B = 0xD0
+	zipInt64B = 0xE0
+	zipInt24B = 0xF0
+	zipInt8B  = 0xFE
+	// zipIntImm is 1111xxxx, where xxxx - 1 is a value in range [0, 12]
+	zipIntImmMin = 0xF1
+	zipIntImmMax = 0xFD
+)
+
+// decodeZiplist returns entries of ziplist. Integers are converted to strings.
+func decodeZiplist(zl []byte) ([]string, error) {
+	if len(zl) < ziplistHeaderSize+1 || int(binary.LittleEndian.Uint32(zl)) != len(zl) {
+		return nil, fmt.Errorf("%w: bad ziplist header", ErrInvalidFormat)
+	}
+
+	entries := []string{}
+	for pos := ziplistHeaderSize; ; {
+		if pos >= len(zl) {
+			return nil, errTruncatedZiplist
+		}
+		if zl[pos] == ziplistEnd {
+			return entries, nil
+		}
+
+		if zl[pos] == ziplistBigPrevlen {
+			pos += 5
+		} else {
+			pos++
+		}
+		if pos >= len(zl) {
+			return nil, errTruncatedZiplist
+		}
+
+		entry, size, err := decodeZiplistEntry(zl[pos:])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		pos += size
+	}
+}
+
+// decodeZiplistEntry decodes entry (without prevlen) at the beginning of p
+// and returns it with its size.
+func decodeZiplistEntry(p []byte) (string, int, error) {
+	b := p[0]
+
+	var header, strLen int
+	switch {
+	case b>>6 == zipStr06B>>6:
+		header, strLen = 1, int(b&0x3F)
+	case b>>6 == zipStr14B>>6:
+		if len(p) < 2 {
+			return "", 0, errTruncatedZiplist
+		}
+		header, strLen = 2, int(b&0x3F)<<8|int(p[1])
+	case b == zipStr32B:
+		if len(p) < 5 {
+			return "", 0, errTruncatedZiplist
+		}
+		header, strLen = 5, int(binary.BigEndian.Uint32(p[1:]))
+	case b >= zipIntImmMin && b <= zipIntImmMax:
+		return strconv.Itoa(int(b&0x0F) - 1), 1, nil
+	default:
+		size := ziplistIntSize(b)
+		if size == 0 {
+			return "", 0, fmt.Errorf("%w: unknown ziplist encoding 0x%X", ErrInvalidFormat, b)
+		}
+		if len(p) < 1+size {
+			return "", 0, errTruncatedZiplist
+		}
+		num := signExtend(readUintLE(p[1:1+size]), size*8)
+		return strconv.FormatInt(num, 10), 1 + size, nil
+	}
+
+	if strLen < 0 || len(p) < header+strLen {
+		return "", 0, errTruncatedZiplist
+	}
+	return string(p[header : header+strLen]), header + strLen, nil
+}
+
+// ziplistIntSize returns size of integer with encoding b or 0 for unknown encodings.
+func ziplistIntSize(b byte) int {
+	switch b {
+	case zipInt8B:
+		return 1
+	case zipInt16B:
+		return 2
+	case zipInt24B:
+		return 3
+	case zipInt32B:
+		return 4
+	case zipInt64B:
+		return 8
+	default:
+		return 0
+	}
+}
+
+var errTruncatedZiplist = fmt.Errorf("%w: truncated ziplist", ErrInvalidFormat)
+
+// decodeIntset returns members of intset: <encoding uint32> <length uint32> <contents>,
+// where encoding is a size of every integer in bytes.
+func decodeIntset(is []byte) ([]string, error) {
+	if len(is) < 8 {
+		return nil, fmt.Errorf("%w: bad intset header", ErrInvalidFormat)
+	}
+
+	size := int(binary.LittleEndian.Uint32(is))
+	length := int(binary.LittleEndian.Uint32(is[4:]))
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("%w: unknown intset encoding %d", ErrInvalidFormat, size)
+	}
+	if len(is)-8 != size*length {
+		return nil, fmt.Errorf("%w: bad intset length", ErrInvalidFormat)
+	}
+
+	members := make([]string, length)
+	for i := range members {
+		start := 8 + i*size
+		members[i] = strconv.FormatInt(signExtend(readUintLE(is[start:start+size]), size*8), 10)
+	}
+	return members, nil
+}
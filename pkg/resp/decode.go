@@ -12,7 +12,12 @@ var (
 )
 
 // Decode decodes array of strings from resp protocol.
+// Messages which don't start with '*' are treated as inline commands.
 func Decode(msg []byte) ([]string, error) {
+	if len(msg) == 0 || msg[0] != '*' {
+		return SplitArgs(string(msg))
+	}
+
 	args := strings.Split(string(msg), "\r\n")
 
 	argsCount, err := strconv.Atoi(args[0][1:])
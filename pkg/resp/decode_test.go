@@ -25,6 +25,12 @@ func TestDecode(t *testing.T) {
 			want:  []string{},
 			err:   nil,
 		},
+		{
+			name:  "Inline",
+			input: []byte("SET key \"hello world\"\r\n"),
+			want:  []string{"SET", "key", "hello world"},
+			err:   nil,
+		},
 		{
 			name:  "Invalid array length",
 			input: []byte("*abc\r\n$4\r\njohn\r\n$4\n\rnwick\r\n"),
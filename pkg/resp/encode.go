@@ -3,12 +3,19 @@ package resp
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strconv"
 )
 
 var (
 	NullString = []byte("$-1\r\n")
-	NullArray = []byte("*0\r\n")
+	NullArray  = []byte("*0\r\n")
+	// NilArray is RESP2 null array which is used instead of null reply
+	// in commands returning arrays.
+	NilArray = []byte("*-1\r\n")
+
+	// Null is RESP3 null reply.
+	Null = []byte("_\r\n")
 )
 
 func EncodeSimpleString(str string) []byte {
@@ -44,3 +51,77 @@ func EncodeInt(num int) []byte {
 	res := fmt.Sprintf(":%d\r\n", num)
 	return []byte(res)
 }
+
+// EncodeBool encodes RESP3 boolean.
+func EncodeBool(b bool) []byte {
+	if b {
+		return []byte("#t\r\n")
+	}
+	return []byte("#f\r\n")
+}
+
+// EncodeDouble encodes RESP3 double.
+func EncodeDouble(num float64) []byte {
+	var res string
+	switch {
+	case math.IsInf(num, 1):
+		res = "inf"
+	case math.IsInf(num, -1):
+		res = "-inf"
+	case math.IsNaN(num):
+		res = "nan"
+	default:
+		res = strconv.FormatFloat(num, 'g', -1, 64)
+	}
+
+	return []byte(fmt.Sprintf(",%s\r\n", res))
+}
+
+// EncodeBigNumber encodes RESP3 big number given as a string of digits.
+func EncodeBigNumber(num string) []byte {
+	res := fmt.Sprintf("(%s\r\n", num)
+	return []byte(res)
+}
+
+// EncodeVerbatimString encodes RESP3 verbatim string.
+// Format is exactly three characters, e.g. "txt" or "mkd".
+func EncodeVerbatimString(format, str string) []byte {
+	res := fmt.Sprintf("=%d\r\n%s:%s\r\n", len(format)+1+len(str), format, str)
+	return []byte(res)
+}
+
+// EncodeRawArray encodes array of already encoded elements.
+// Unlike EncodeArray it allows elements of different types.
+func EncodeRawArray(elems [][]byte) []byte {
+	return encodeAggregate('*', len(elems), elems)
+}
+
+// EncodeMap encodes RESP3 map.
+// Pairs are given as already encoded elements in form of key1, value1, key2, value2, ...
+func EncodeMap(pairs [][]byte) []byte {
+	return encodeAggregate('%', len(pairs)/2, pairs)
+}
+
+// EncodeSet encodes RESP3 set of already encoded elements.
+func EncodeSet(elems [][]byte) []byte {
+	return encodeAggregate('~', len(elems), elems)
+}
+
+// EncodePush encodes RESP3 push message of already encoded elements.
+func EncodePush(elems [][]byte) []byte {
+	return encodeAggregate('>', len(elems), elems)
+}
+
+func encodeAggregate(prefix byte, length int, elems [][]byte) []byte {
+	var b bytes.Buffer
+
+	b.WriteByte(prefix)
+	b.WriteString(strconv.Itoa(length))
+	b.WriteString("\r\n")
+
+	for _, elem := range elems {
+		b.Write(elem)
+	}
+
+	return b.Bytes()
+}
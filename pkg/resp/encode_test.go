@@ -1,6 +1,7 @@
 package resp
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -146,3 +147,109 @@ func TestEncodeArray(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeBool(t *testing.T) {
+	assert.Equal(t, []byte("#t\r\n"), EncodeBool(true))
+	assert.Equal(t, []byte("#f\r\n"), EncodeBool(false))
+}
+
+func TestEncodeDouble(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input float64
+		want  []byte
+	}{
+		{
+			name:  "Fractional",
+			input: 3.14,
+			want:  []byte(",3.14\r\n"),
+		},
+		{
+			name:  "Integral",
+			input: 10,
+			want:  []byte(",10\r\n"),
+		},
+		{
+			name:  "Positive infinity",
+			input: math.Inf(1),
+			want:  []byte(",inf\r\n"),
+		},
+		{
+			name:  "Negative infinity",
+			input: math.Inf(-1),
+			want:  []byte(",-inf\r\n"),
+		},
+		{
+			name:  "NaN",
+			input: math.NaN(),
+			want:  []byte(",nan\r\n"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, EncodeDouble(test.input))
+		})
+	}
+}
+
+func TestEncodeBigNumber(t *testing.T) {
+	assert.Equal(t,
+		[]byte("(3492890328409238509324850943850943825024385\r\n"),
+		EncodeBigNumber("3492890328409238509324850943850943825024385"),
+	)
+}
+
+func TestEncodeVerbatimString(t *testing.T) {
+	assert.Equal(t, []byte("=15\r\ntxt:Some string\r\n"), EncodeVerbatimString("txt", "Some string"))
+}
+
+func TestEncodeAggregates(t *testing.T) {
+	var tests = []struct {
+		name   string
+		encode func([][]byte) []byte
+		input  [][]byte
+		want   []byte
+	}{
+		{
+			name:   "Raw array",
+			encode: EncodeRawArray,
+			input:  [][]byte{EncodeInt(1), EncodeString("two"), Null},
+			want:   []byte("*3\r\n:1\r\n$3\r\ntwo\r\n_\r\n"),
+		},
+		{
+			name:   "Map",
+			encode: EncodeMap,
+			input:  [][]byte{EncodeSimpleString("first"), EncodeInt(1), EncodeSimpleString("second"), EncodeInt(2)},
+			want:   []byte("%2\r\n+first\r\n:1\r\n+second\r\n:2\r\n"),
+		},
+		{
+			name:   "Set",
+			encode: EncodeSet,
+			input:  [][]byte{EncodeString("a"), EncodeString("b")},
+			want:   []byte("~2\r\n$1\r\na\r\n$1\r\nb\r\n"),
+		},
+		{
+			name:   "Push",
+			encode: EncodePush,
+			input:  [][]byte{EncodeString("message"), EncodeString("channel"), EncodeString("hi")},
+			want:   []byte(">3\r\n$7\r\nmessage\r\n$7\r\nchannel\r\n$2\r\nhi\r\n"),
+		},
+		{
+			name:   "Empty map",
+			encode: EncodeMap,
+			input:  [][]byte{},
+			want:   []byte("%0\r\n"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.want, test.encode(test.input))
+		})
+	}
+}
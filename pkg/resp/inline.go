@@ -0,0 +1,125 @@
+package resp
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errUnbalancedQuotes = errors.New("unbalanced quotes in request")
+)
+
+// SplitArgs splits inline command (e.g. typed into telnet) into arguments.
+// Arguments are separated by whitespaces and can be quoted the same way as in redis-cli:
+// double quotes support escape sequences (\n, \r, \t, \b, \a, \\, \", \xHH),
+// single quotes support only \' escape.
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+
+	i := 0
+	for {
+		// skip blanks between arguments
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var (
+			arg strings.Builder
+			// inDQ and inSQ show if we are inside double or single quotes
+			inDQ, inSQ bool
+			done       bool
+		)
+		for !done {
+			if i == len(line) {
+				if inDQ || inSQ {
+					return nil, errUnbalancedQuotes
+				}
+				break
+			}
+
+			c := line[i]
+			switch {
+			case inDQ:
+				switch {
+				case c == '\\' && i+3 < len(line) && line[i+1] == 'x' &&
+					isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					arg.WriteByte(hexDigitToInt(line[i+2])<<4 | hexDigitToInt(line[i+3]))
+					i += 3
+				case c == '\\' && i+1 < len(line):
+					i++
+					switch line[i] {
+					case 'n':
+						arg.WriteByte('\n')
+					case 'r':
+						arg.WriteByte('\r')
+					case 't':
+						arg.WriteByte('\t')
+					case 'b':
+						arg.WriteByte('\b')
+					case 'a':
+						arg.WriteByte('\a')
+					default:
+						arg.WriteByte(line[i])
+					}
+				case c == '"':
+					// closing quote must be followed by a space or nothing at all
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(c)
+				}
+			case inSQ:
+				switch {
+				case c == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					arg.WriteByte('\'')
+					i++
+				case c == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg.WriteByte(c)
+				}
+			default:
+				switch {
+				case isSpace(c):
+					done = true
+				case c == '"':
+					inDQ = true
+				case c == '\'':
+					inSQ = true
+				default:
+					arg.WriteByte(c)
+				}
+			}
+			i++
+		}
+
+		args = append(args, arg.String())
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func hexDigitToInt(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,81 @@
+package resp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  []string
+		err   error
+	}{
+		{
+			name:  "OK",
+			input: "SET key value",
+			want:  []string{"SET", "key", "value"},
+			err:   nil,
+		},
+		{
+			name:  "Extra spaces",
+			input: "  GET \t key  ",
+			want:  []string{"GET", "key"},
+			err:   nil,
+		},
+		{
+			name:  "Empty",
+			input: "",
+			want:  []string{},
+			err:   nil,
+		},
+		{
+			name:  "Double quotes",
+			input: `SET key "hello world"`,
+			want:  []string{"SET", "key", "hello world"},
+			err:   nil,
+		},
+		{
+			name:  "Double quotes with escapes",
+			input: `ECHO "a\nb\t\"c\" \x41\x4a"`,
+			want:  []string{"ECHO", "a\nb\t\"c\" AJ"},
+			err:   nil,
+		},
+		{
+			name:  "Single quotes",
+			input: `ECHO 'it\'s \n raw'`,
+			want:  []string{"ECHO", `it's \n raw`},
+			err:   nil,
+		},
+		{
+			name:  "Empty quoted string",
+			input: `SET key ""`,
+			want:  []string{"SET", "key", ""},
+			err:   nil,
+		},
+		{
+			name:  "Unclosed quotes",
+			input: `SET key "value`,
+			want:  nil,
+			err:   errUnbalancedQuotes,
+		},
+		{
+			name:  "Text right after closing quote",
+			input: `SET key "val"ue`,
+			want:  nil,
+			err:   errUnbalancedQuotes,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := SplitArgs(test.input)
+			assert.Equal(t, test.want, result)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	// ErrProtocol is returned by Reader when a client sends malformed request.
+	// Concrete reason is wrapped into it, so errors.Is can be used for checks.
+	ErrProtocol = errors.New("Protocol error")
+
+	errInvalidBulkLength = errors.New("invalid bulk length")
+	errExpectedDollar    = errors.New("expected '$'")
+	errExpectedCRLF      = errors.New("expected CRLF after bulk string")
+	errLineTooLong       = errors.New("too big request line")
+)
+
+var (
+	readBuffSize = 16 * 1024
+
+	// Limits are the same as default ones in Redis.
+	maxLineLen      = 64 * 1024
+	maxMultibulkLen = 1024 * 1024
+	maxBulkLen      = 512 * 1024 * 1024
+)
+
+// Reader reads RESP requests from a byte stream.
+// Unlike Decode it doesn't require the whole request to be in one buffer:
+// it waits for the rest of a frame if it's not fully received yet
+// and keeps the remainder if several requests arrived at once.
+type Reader struct {
+	rd *bufio.Reader
+}
+
+// NewReader is a constructor for Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		rd: bufio.NewReaderSize(r, readBuffSize),
+	}
+}
+
+// Buffered returns the number of bytes that were already received but not parsed yet.
+func (r *Reader) Buffered() int {
+	return r.rd.Buffered()
+}
+
+// WaitData blocks until there is unread data or reading fails. Nothing is consumed.
+// It lets to detect closed connection while no request is being read.
+func (r *Reader) WaitData() error {
+	_, err := r.rd.Peek(1)
+	return err
+}
+
+// ReadCommand reads one complete request and returns its arguments.
+// Empty requests (e.g. "*0\r\n") are skipped.
+// Malformed requests result in error wrapping ErrProtocol.
+func (r *Reader) ReadCommand() ([]string, error) {
+	for {
+		prefix, err := r.rd.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		var args []string
+		switch prefix[0] {
+		case '*':
+			args, err = r.readMultibulk()
+		default:
+			args, err = r.readInline()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) > 0 {
+			return args, nil
+		}
+	}
+}
+
+// readMultibulk reads request in form of "*<count>\r\n" followed by count bulk strings.
+func (r *Reader) readMultibulk() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(string(line[1:]))
+	if err != nil || count > maxMultibulkLen {
+		return nil, protocolError(errInvalidMultibulkLength)
+	}
+	if count <= 0 {
+		return []string{}, nil
+	}
+
+	args := make([]string, 0, count)
+	for range count {
+		arg, err := r.readBulk()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// readInline reads request typed as plain text line, e.g. "SET key value\n".
+func (r *Reader) readInline() ([]string, error) {
+	line, err := r.readRawLine()
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := SplitArgs(string(line))
+	if err != nil {
+		return nil, protocolError(err)
+	}
+
+	return args, nil
+}
+
+// readBulk reads single bulk string in form of "$<length>\r\n<data>\r\n".
+func (r *Reader) readBulk() (string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if line[0] != '$' {
+		return "", protocolError(errExpectedDollar)
+	}
+	length, err := strconv.Atoi(string(line[1:]))
+	if err != nil || length < 0 || length > maxBulkLen {
+		return "", protocolError(errInvalidBulkLength)
+	}
+
+	buff := make([]byte, length+2)
+	if _, err := io.ReadFull(r.rd, buff); err != nil {
+		return "", unexpectedEOF(err)
+	}
+	if buff[length] != '\r' || buff[length+1] != '\n' {
+		return "", protocolError(errExpectedCRLF)
+	}
+
+	return string(buff[:length]), nil
+}
+
+// readLine reads line terminated with "\r\n" and returns it without terminator.
+// Returned line is never empty.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.readRawLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 2 || line[len(line)-1] != '\r' {
+		return nil, protocolError(errInvalidMultibulkFormat)
+	}
+
+	return line[:len(line)-1], nil
+}
+
+// readRawLine reads line terminated with "\n" and returns it without "\n".
+func (r *Reader) readRawLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.rd.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineLen {
+			return nil, protocolError(errLineTooLong)
+		}
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, unexpectedEOF(err)
+		}
+	}
+
+	return line[:len(line)-1], nil
+}
+
+func protocolError(err error) error {
+	return fmt.Errorf("%w: %w", ErrProtocol, err)
+}
+
+// unexpectedEOF converts EOF in the middle of a request to io.ErrUnexpectedEOF,
+// so that only EOF between requests is reported as io.EOF.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
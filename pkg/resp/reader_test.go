@@ -0,0 +1,147 @@
+package resp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaderReadCommand(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  [][]string
+		err   error
+	}{
+		{
+			name:  "OK",
+			input: "*2\r\n$5\r\nhello\r\n$5\r\nworld\r\n",
+			want:  [][]string{{"hello", "world"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Several commands",
+			input: "*1\r\n$4\r\nPING\r\n*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n",
+			want:  [][]string{{"PING"}, {"GET", "key"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Empty multibulk is skipped",
+			input: "*0\r\n*1\r\n$4\r\nPING\r\n",
+			want:  [][]string{{"PING"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Bulk string with CRLF inside",
+			input: "*1\r\n$7\r\nab\r\ncde\r\n",
+			want:  [][]string{{"ab\r\ncde"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Inline commands",
+			input: "PING\r\nSET key \"hello world\"\n\r\nGET key\n",
+			want:  [][]string{{"PING"}, {"SET", "key", "hello world"}, {"GET", "key"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Inline mixed with multibulk",
+			input: "PING\n*1\r\n$4\r\nPING\r\n",
+			want:  [][]string{{"PING"}, {"PING"}},
+			err:   io.EOF,
+		},
+		{
+			name:  "Inline with unbalanced quotes",
+			input: "SET key \"value\n",
+			want:  [][]string{},
+			err:   ErrProtocol,
+		},
+		{
+			name:  "Incomplete command",
+			input: "*2\r\n$5\r\nhello\r\n$5\r\nwor",
+			want:  [][]string{},
+			err:   io.ErrUnexpectedEOF,
+		},
+		{
+			name:  "Invalid multibulk length",
+			input: "*abc\r\n",
+			want:  [][]string{},
+			err:   ErrProtocol,
+		},
+		{
+			name:  "Invalid bulk length",
+			input: "*1\r\n$-5\r\nhello\r\n",
+			want:  [][]string{},
+			err:   ErrProtocol,
+		},
+		{
+			name:  "Missing dollar",
+			input: "*1\r\n:5\r\n",
+			want:  [][]string{},
+			err:   ErrProtocol,
+		},
+		{
+			name:  "Wrong bulk length",
+			input: "*1\r\n$3\r\nhello\r\n",
+			want:  [][]string{},
+			err:   ErrProtocol,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			// reading one byte at a time makes sure that frames split
+			// between several reads are assembled correctly
+			r := NewReader(iotest.OneByteReader(strings.NewReader(test.input)))
+
+			got := [][]string{}
+			var err error
+			for {
+				var args []string
+				args, err = r.ReadCommand()
+				if err != nil {
+					break
+				}
+				got = append(got, args)
+			}
+
+			assert.Equal(t, test.want, got)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestReaderReadCommand_LargeValue(t *testing.T) {
+	value := strings.Repeat("x", 100*1024)
+	input := bytes.Join([][]byte{
+		[]byte("*3\r\n"),
+		EncodeString("SET"),
+		EncodeString("key"),
+		EncodeString(value),
+	}, nil)
+
+	r := NewReader(bytes.NewReader(input))
+	got, err := r.ReadCommand()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SET", "key", value}, got)
+	assert.Equal(t, 0, r.Buffered())
+}
+
+func TestReaderWaitData(t *testing.T) {
+	r := NewReader(strings.NewReader("PING\r\n"))
+
+	assert.NoError(t, r.WaitData())
+	// data is not consumed
+	got, err := r.ReadCommand()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PING"}, got)
+
+	assert.ErrorIs(t, r.WaitData(), io.EOF)
+}